@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ActiveJob is an entry of ListActiveJobs.
+type ActiveJob struct {
+	Type string `json:"type"` // conversion, mixdown, detection, transcription, waveform or download
+	File string `json:"file"` // the file the job writes, or reads for detections and waveforms
+	Name string `json:"name"` // base name of File
+	// Percentage is nil for jobs that don't report progress.
+	Percentage *float64 `json:"percentage,omitempty"`
+	// QueuePosition counts from 1 among the jobs waiting for the same kind of slot (ffmpeg or
+	// waveform); it is 0 once the job runs.
+	QueuePosition int        `json:"queuePosition"`
+	QueuedAt      time.Time  `json:"queuedAt"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"` // nil while queued
+}
+
+// ListActiveJobs returns the jobs that wait for a slot or run, queued ones first in queue
+// order, then running ones by start time. Jobs with a progress tracker (conversions,
+// mixdowns, downloads) also list those that run without a slot.
+func (a *App) ListActiveJobs() []ActiveJob {
+	jobs := activeJobs.list()
+
+	listed := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		listed[job.File] = i
+	}
+	a.progressTracker.Range(func(key, value any) bool {
+		path, ok := key.(string)
+		tracker, isTracker := value.(*ProgressTracker)
+		if !ok || !isTracker {
+			return true
+		}
+		tracker.mu.RLock()
+		pct := tracker.Percentage
+		tracker.mu.RUnlock()
+		if i, ok := listed[path]; ok {
+			if jobs[i].StartedAt != nil {
+				jobs[i].Percentage = &pct
+			}
+			return true
+		}
+		job := ActiveJob{Type: tracker.TaskType, File: path, Name: filepath.Base(path), Percentage: &pct, QueuedAt: tracker.StartedAt}
+		startedAt := tracker.StartedAt
+		job.StartedAt = &startedAt
+		jobs = append(jobs, job)
+		return true
+	})
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		qi, qj := jobs[i].StartedAt == nil, jobs[j].StartedAt == nil
+		if qi != qj {
+			return qi
+		}
+		if qi {
+			return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+		}
+		return jobs[i].StartedAt.Before(*jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// list returns the jobs that wait for or hold a slot.
+func (r *jobRegistry) list() []ActiveJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := make([]ActiveJob, 0, len(r.waiting)+len(r.holding))
+	positions := map[string]int{}
+	for _, job := range r.waiting {
+		positions[job.pool]++
+		jobs = append(jobs, ActiveJob{
+			Type:          job.kind,
+			File:          job.target,
+			Name:          filepath.Base(job.target),
+			QueuePosition: positions[job.pool],
+			QueuedAt:      job.queuedAt,
+		})
+	}
+	for job := range r.holding {
+		startedAt := job.startedAt
+		jobs = append(jobs, ActiveJob{
+			Type:      job.kind,
+			File:      job.target,
+			Name:      filepath.Base(job.target),
+			QueuedAt:  job.queuedAt,
+			StartedAt: &startedAt,
+		})
+	}
+	return jobs
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// An analysis snapshot keeps the silences of every clip of the synced timeline as they are
+// under the current parameters, under a name. Comparing two snapshots of the same timeline
+// shows what one set of parameters cuts that the other doesn't, so editors can pick between
+// candidate settings by more than ear.
+
+const snapshotsFolderName = "snapshots"
+
+// SnapshotStats sums up a snapshot.
+type SnapshotStats struct {
+	Clips          int     `json:"clips"`
+	Cuts           int     `json:"cuts"`
+	SilenceSeconds float64 `json:"silenceSeconds"`
+	ContentSeconds float64 `json:"contentSeconds"`
+}
+
+// SnapshotClip is one clip's silences, in seconds of source audio.
+type SnapshotClip struct {
+	Name     string          `json:"name"`
+	Start    float64         `json:"start"`
+	End      float64         `json:"end"`
+	Adjusted bool            `json:"adjusted"` // manual silence adjustments rather than the detection
+	Silences []SilencePeriod `json:"silences"`
+}
+
+// AnalysisSnapshot is a saved analysis of a timeline.
+type AnalysisSnapshot struct {
+	Name         string                  `json:"name"`
+	ProjectName  string                  `json:"projectName"`
+	TimelineName string                  `json:"timelineName"`
+	CreatedAt    time.Time               `json:"createdAt"`
+	Params       DetectionParams         `json:"params"`
+	Clips        map[string]SnapshotClip `json:"clips"` // by timeline item ID
+	// Missing lists the clips that had not been analysed with their parameters yet.
+	Missing []string      `json:"missing"`
+	Stats   SnapshotStats `json:"stats"`
+}
+
+// ClipComparison shows how two snapshots differ on one clip.
+type ClipComparison struct {
+	ClipID string          `json:"clipId"`
+	Name   string          `json:"name"`
+	CutsA  int             `json:"cutsA"`
+	CutsB  int             `json:"cutsB"`
+	OnlyA  []SilencePeriod `json:"onlyA"` // removed in A but kept in B
+	OnlyB  []SilencePeriod `json:"onlyB"` // removed in B but kept in A
+}
+
+// SnapshotComparison is the result of CompareAnalysisSnapshots.
+type SnapshotComparison struct {
+	A      string        `json:"a"`
+	B      string        `json:"b"`
+	StatsA SnapshotStats `json:"statsA"`
+	StatsB SnapshotStats `json:"statsB"`
+	// ChangedParams names the parameters that differ, e.g. "loudnessThreshold".
+	ChangedParams []string         `json:"changedParams"`
+	Clips         []ClipComparison `json:"clips"` // only clips that differ
+	// OnlyInA and OnlyInB list clips that only one of the snapshots has.
+	OnlyInA []string `json:"onlyInA"`
+	OnlyInB []string `json:"onlyInB"`
+}
+
+func (a *App) getSnapshotsDir(projectName, timelineName string) string {
+	return filepath.Join(a.userResourcesPath, snapshotsFolderName, timelineSessionID(projectName, timelineName))
+}
+
+// syncedTimeline returns the project that was synced last.
+func (a *App) syncedTimeline() (*ProjectDataPayload, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.currentProject == nil {
+		return nil, errors.New("no timeline has been synced yet")
+	}
+	return a.currentProject, nil
+}
+
+// SaveAnalysisSnapshot saves the analysis of the synced timeline under name, replacing a
+// snapshot of the same name.
+func (a *App) SaveAnalysisSnapshot(name string) (*AnalysisSnapshot, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("snapshot name cannot be empty")
+	}
+	project, err := a.syncedTimeline()
+	if err != nil {
+		return nil, err
+	}
+	session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session = &TimelineSession{}
+	}
+
+	snapshot := &AnalysisSnapshot{
+		Name:         name,
+		ProjectName:  project.ProjectName,
+		TimelineName: project.Timeline.Name,
+		CreatedAt:    time.Now(),
+		Params:       a.GetCurrentParams(),
+		Clips:        map[string]SnapshotClip{},
+		Missing:      []string{},
+	}
+	fps := project.Timeline.FPS
+	for i := range project.Timeline.AudioTrackItems {
+		item := &project.Timeline.AudioTrackItems[i]
+		if item.ProcessedFileName == nil || *item.ProcessedFileName == "" || fps <= floatEpsilon {
+			continue
+		}
+		clip := SnapshotClip{Name: item.Name, Start: item.SourceStartFrame / fps, End: item.SourceEndFrame / fps}
+		if adjusted, ok := session.SilenceAdjustments[item.ID]; ok {
+			clip.Adjusted = true
+			clip.Silences = append([]SilencePeriod{}, adjusted...)
+		} else {
+			params := snapshot.Params
+			if override, ok := session.ClipOverrides[item.ID]; ok {
+				params = override
+			}
+			silences, found := a.cachedSilences(*item.ProcessedFileName, clip.Start, clip.End, params)
+			if !found {
+				snapshot.Missing = append(snapshot.Missing, item.ID)
+				continue
+			}
+			clip.Silences = append([]SilencePeriod{}, silences...)
+		}
+		sort.Slice(clip.Silences, func(i, j int) bool { return clip.Silences[i].Start < clip.Silences[j].Start })
+		snapshot.Clips[item.ID] = clip
+
+		snapshot.Stats.Clips++
+		snapshot.Stats.Cuts += len(clip.Silences)
+		silenceSeconds := 0.0
+		for _, s := range clip.Silences {
+			silenceSeconds += s.End - s.Start
+		}
+		snapshot.Stats.SilenceSeconds += silenceSeconds
+		snapshot.Stats.ContentSeconds += clip.End - clip.Start - silenceSeconds
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	path := filepath.Join(a.getSnapshotsDir(snapshot.ProjectName, snapshot.TimelineName), presetSlug(name)+".json")
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot %s: %w", name, err)
+	}
+	if len(snapshot.Missing) > 0 {
+		appLog.Info("Snapshot leaves out clips that had not been analysed yet", "snapshot", name, "missing", len(snapshot.Missing))
+	}
+	a.emit("snapshots:changed", nil)
+	return snapshot, nil
+}
+
+// cachedSilences returns the cached detection of a clip range with exactly these parameters.
+func (a *App) cachedSilences(fileName string, start, end float64, params DetectionParams) ([]SilencePeriod, bool) {
+	key := CacheKey{
+		FilePath:                  fileName,
+		LoudnessThreshold:         params.LoudnessThreshold,
+		MinSilenceDurationSeconds: params.MinSilenceDurationSeconds,
+		PaddingLeftSeconds:        params.PaddingLeftSeconds,
+		PaddingRightSeconds:       params.PaddingRightSeconds,
+		MinContentDuration:        params.MinContent,
+		ClipStartSeconds:          start,
+		ClipEndSeconds:            end,
+	}
+	a.cacheMutex.RLock()
+	defer a.cacheMutex.RUnlock()
+	silences, found := a.silenceCache[key]
+	if found {
+		cacheUsage.touch(key)
+	}
+	return silences, found
+}
+
+// ListAnalysisSnapshots returns the snapshots of the synced timeline, newest first.
+func (a *App) ListAnalysisSnapshots() ([]AnalysisSnapshot, error) {
+	project, err := a.syncedTimeline()
+	if err != nil {
+		return nil, err
+	}
+	dir := a.getSnapshotsDir(project.ProjectName, project.Timeline.Name)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AnalysisSnapshot{}, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	snapshots := []AnalysisSnapshot{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		var snapshot AnalysisSnapshot
+		if err := readJSONWithRecovery(filepath.Join(dir, entry.Name()), &snapshot); err != nil {
+			appLog.Warn("Skipping snapshot", "file", entry.Name(), "err", err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// loadAnalysisSnapshot reads a snapshot of the synced timeline.
+func (a *App) loadAnalysisSnapshot(name string) (*AnalysisSnapshot, error) {
+	project, err := a.syncedTimeline()
+	if err != nil {
+		return nil, err
+	}
+	var snapshot AnalysisSnapshot
+	path := filepath.Join(a.getSnapshotsDir(project.ProjectName, project.Timeline.Name), presetSlug(name)+".json")
+	if err := readJSONWithRecovery(path, &snapshot); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no snapshot named %q for this timeline", name)
+		}
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+	}
+	return &snapshot, nil
+}
+
+// DeleteAnalysisSnapshot removes a snapshot of the synced timeline.
+func (a *App) DeleteAnalysisSnapshot(name string) error {
+	project, err := a.syncedTimeline()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(a.getSnapshotsDir(project.ProjectName, project.Timeline.Name), presetSlug(name)+".json")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete snapshot %s: %w", name, err)
+	}
+	a.emit("snapshots:changed", nil)
+	return nil
+}
+
+// CompareAnalysisSnapshots compares two snapshots of the synced timeline.
+func (a *App) CompareAnalysisSnapshots(nameA, nameB string) (*SnapshotComparison, error) {
+	snapA, err := a.loadAnalysisSnapshot(nameA)
+	if err != nil {
+		return nil, err
+	}
+	snapB, err := a.loadAnalysisSnapshot(nameB)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &SnapshotComparison{
+		A:             snapA.Name,
+		B:             snapB.Name,
+		StatsA:        snapA.Stats,
+		StatsB:        snapB.Stats,
+		ChangedParams: changedParams(snapA.Params, snapB.Params),
+		Clips:         []ClipComparison{},
+		OnlyInA:       []string{},
+		OnlyInB:       []string{},
+	}
+	for id, clipA := range snapA.Clips {
+		clipB, ok := snapB.Clips[id]
+		if !ok {
+			comparison.OnlyInA = append(comparison.OnlyInA, id)
+			continue
+		}
+		diff := ClipComparison{
+			ClipID: id,
+			Name:   clipA.Name,
+			CutsA:  len(clipA.Silences),
+			CutsB:  len(clipB.Silences),
+			OnlyA:  subtractSilences(clipA.Silences, clipB.Silences),
+			OnlyB:  subtractSilences(clipB.Silences, clipA.Silences),
+		}
+		if len(diff.OnlyA) > 0 || len(diff.OnlyB) > 0 || diff.CutsA != diff.CutsB {
+			comparison.Clips = append(comparison.Clips, diff)
+		}
+	}
+	for id := range snapB.Clips {
+		if _, ok := snapA.Clips[id]; !ok {
+			comparison.OnlyInB = append(comparison.OnlyInB, id)
+		}
+	}
+	sort.Slice(comparison.Clips, func(i, j int) bool { return comparison.Clips[i].ClipID < comparison.Clips[j].ClipID })
+	sort.Strings(comparison.OnlyInA)
+	sort.Strings(comparison.OnlyInB)
+	return comparison, nil
+}
+
+// subtractSilences returns the parts of the regions in from that other doesn't cover. Parts
+// shorter than a millisecond are rounding, not a difference.
+func subtractSilences(from, other []SilencePeriod) []SilencePeriod {
+	const minDifference = 0.001
+	result := []SilencePeriod{}
+	for _, region := range from {
+		for _, part := range keepRanges(region.Start, region.End, other) {
+			if part[1]-part[0] >= minDifference {
+				result = append(result, SilencePeriod{Start: part[0], End: part[1]})
+			}
+		}
+	}
+	return result
+}
+
+// changedParams names the detection parameters that differ between two sets.
+func changedParams(a, b DetectionParams) []string {
+	changed := []string{}
+	check := func(name string, differs bool) {
+		if differs {
+			changed = append(changed, name)
+		}
+	}
+	check("loudnessThreshold", a.LoudnessThreshold != b.LoudnessThreshold)
+	check("minSilenceDurationSeconds", a.MinSilenceDurationSeconds != b.MinSilenceDurationSeconds)
+	check("paddingLeftSeconds", a.PaddingLeftSeconds != b.PaddingLeftSeconds)
+	check("paddingRightSeconds", a.PaddingRightSeconds != b.PaddingRightSeconds)
+	check("minContent", a.MinContent != b.MinContent)
+	check("keepSilenceSegments", a.KeepSilenceSegments != b.KeepSilenceSegments)
+	return changed
+}
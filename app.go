@@ -6,22 +6,24 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"math"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	goruntime "runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"github.com/oliwoli/hushcut/internal/i18n"
+	"github.com/oliwoli/hushcut/internal/luahelperlogic"
 )
 
 type FfmpegStatus int
@@ -34,21 +36,35 @@ const (
 )
 
 type App struct {
-	ctx     context.Context
-	isDev   bool
-	testApi bool
+	ctx         context.Context
+	isDev       bool
+	testApi     bool
+	headless    bool // CLI mode: no Wails runtime, no frontend events
+	serve       bool // --serve: headless, with the HTTP server and Python bridge running
+	debugServer bool // --debug-server: expose profiling endpoints in production builds
+	cliProgress *cliProgress
+	events      eventSink
+	tray        *trayIcon
+	jobsPaused  pauseGate
+	quitting    atomic.Bool // set by quit, so closing doesn't just hide the window
 
 	appVersion    string
 	ffmpegVersion string
 	updateInfo    *UpdateResponseV1
 
+	policy *Policy
+
 	licenseMutex     sync.Mutex
+	licenseCheckMu   sync.Mutex // serializes license checks
 	licenseVerifyKey []byte
 	licenseValid     bool
 	licenseOkChan    chan bool
 	machineID        string
+	seatMu           sync.Mutex
+	seat             *seatLease
 
 	silenceCache      map[CacheKey][]SilencePeriod
+	detectionParams   map[string][]CacheKey // by file, one entry per analyzed clip range
 	waveformCache     map[WaveformCacheKey]*PrecomputedWaveformData
 	cacheMutex        sync.RWMutex
 	pythonCmd         *exec.Cmd
@@ -64,13 +80,40 @@ type App struct {
 	ffmpegStatus      FfmpegStatus
 	ffmpegSemaphore   chan struct{}
 	waveformSemaphore chan struct{}
+	detectSemaphore   chan struct{}
+	semaphoreMu       sync.RWMutex
+	settingsMu        sync.Mutex
+	settingsModTime   time.Time
 	progressTracker   sync.Map
 	fileUsage         map[string]time.Time
 	mu                sync.Mutex
+	lastReport        *ProcessingReport
+	currentProject    *ProjectDataPayload
+	currentParams     DetectionParams
+	usageStore        *usageStore
+	usageLoaded       bool
+	fileRefs          *fileRefs
+	recentMu          sync.Mutex
+	sessionMu         sync.Mutex     // serializes read-modify-writes of timeline session files
+	displayFlags      displayOptions // --gpu-policy and --display-backend
+	launch            LaunchContext
+
+	// crash-safe state, see checkpoint.go
+	checkpoint    *checkpointer
+	interruptedMu sync.Mutex
+	interrupted   *InterruptedSession
 
 	// -- HTTP -- //
-	httpClient *http.Client
-	authToken  string
+	httpClient         *http.Client
+	authToken          string
+	pythonTokenMu      sync.RWMutex
+	pythonToken        string // the Python backend's own, see sessionToken.go
+	pendingPythonToken string // offered by a token rotation in progress
+	endpointPrefix     string // random prefix of /msg, /ready and /render_clip
+	serverPort         int    // --serve --port; 0 picks a free port
+	connectivity       connectivityState
+
+	cleanupSettingsChanged chan struct{} // wakes runCleanupScheduler after applySettings
 
 	// --- FFmpeg STATE ---
 	ffmpegMutex     sync.RWMutex
@@ -85,13 +128,15 @@ func NewApp() *App {
 	return &App{
 		licenseOkChan:     make(chan bool, 1),
 		silenceCache:      make(map[CacheKey][]SilencePeriod),
+		detectionParams:   make(map[string][]CacheKey),
 		waveformCache:     make(map[WaveformCacheKey]*PrecomputedWaveformData),
 		pythonReadyChan:   make(chan bool, 1),
 		pythonReady:       false,
 		tmpPath:           "", // Will be initialized in startup
 		pendingTasks:      make(map[string]chan PythonCommandResponse),
-		ffmpegSemaphore:   make(chan struct{}, 8),
-		waveformSemaphore: make(chan struct{}, 3),
+		ffmpegSemaphore:   make(chan struct{}, defaultFfmpegConcurrency),
+		waveformSemaphore: make(chan struct{}, defaultWaveformConcurrency),
+		detectSemaphore:   make(chan struct{}, defaultDetectionConcurrency),
 		progressTracker:   sync.Map{},
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -99,18 +144,17 @@ func NewApp() *App {
 		ffmpegStatus:    StatusUnknown,
 		ffmpegReadyChan: make(chan struct{}),
 
+		cleanupSettingsChanged: make(chan struct{}, 1),
+
 		appVersion:    AppVersion,
 		ffmpegVersion: FfmpegVersion,
 		fileUsage:     make(map[string]time.Time),
+		fileRefs:      newFileRefs(),
 	}
 }
 
-func (a *App) SetWindowAlwaysOnTop(alwaysOnTop bool) {
-	runtime.WindowSetAlwaysOnTop(a.ctx, alwaysOnTop)
-}
-
 func (a *App) OpenURL(url string) {
-	runtime.BrowserOpenURL(a.ctx, url)
+	openURL(a.ctx, url)
 }
 
 type ProgressTracker struct {
@@ -118,10 +162,11 @@ type ProgressTracker struct {
 	Percentage float64
 	Done       chan error
 	TaskType   string
+	StartedAt  time.Time
 }
 
 func (a *App) ResolveBinaryPath(binaryName string) (string, error) {
-	platform := runtime.Environment(a.ctx).Platform
+	platform := goruntime.GOOS
 
 	goExecutablePath, err := os.Executable()
 	if err != nil {
@@ -180,11 +225,9 @@ func (a *App) ResolveBinaryPath(binaryName string) (string, error) {
 // launch python backend and wait for POST /ready on http server endpoint
 func (a *App) LaunchPythonBackend(port int, pythonCommandPort int) error {
 
-	pythonBinaryPath := filepath.Join(a.resourcesPath, "python_backend")
-
-	platform := runtime.Environment(a.ctx).Platform
-	if platform == "windows" {
-		pythonBinaryPath = filepath.Join(a.resourcesPath, "python_backend.exe")
+	pythonBinaryPath := a.pythonBackendPath()
+	if err := verifyPythonBackend(pythonBinaryPath); err != nil {
+		return err
 	}
 
 	cmdArgs := []string{
@@ -201,23 +244,25 @@ func (a *App) LaunchPythonBackend(port int, pythonCommandPort int) error {
 		return err
 	}
 
-	go func() {
+	token := a.rotatePythonToken()
+	go saferun(func() {
 		defer stdin.Close()
-		io.WriteString(stdin, a.authToken)
-	}()
+		io.WriteString(stdin, token+"\n"+a.endpointPrefix+"\n")
+	})
 
 	a.pythonCmd = cmd
 
 	if err := cmd.Start(); err != nil {
 		return err
 	}
-	log.Printf("Go app: Python backend process started (PID: %d, Path: '%s'). Waiting for its HTTP ready signal.\n", cmd.Process.Pid, pythonBinaryPath)
+	childProcesses.track(cmd)
+	backendLog.Info("Python backend process started; waiting for its ready signal", "pid", cmd.Process.Pid, "path", pythonBinaryPath)
 	return nil
 }
 
 func (a *App) GetGoServerPort() int {
 	if !isServerInitialized {
-		log.Println("Wails App: GetAudioServerPort called, but server is not (yet) initialized or failed to start. Returning 0.")
+		appLog.Warn("GetGoServerPort called before the server is initialized; returning 0")
 		return 0
 	}
 	return actualPort
@@ -235,28 +280,32 @@ func getMacCacheTmpDir() string {
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.startLogStream()
+	a.startInternalErrorEvents()
 
-	envInfo := runtime.Environment(ctx)
-	if envInfo.BuildType == "production" {
-		log.Println("|> HushCut v" + a.GetAppVersion() + " - Production Build")
+	// Serve mode has no Wails runtime to ask and runs like a production build.
+	if a.headless || isProductionBuild(ctx) {
+		appLog.Info("HushCut started", "version", a.GetAppVersion(), "build", "production")
 		a.isDev = false
 	} else {
-		log.Println("|> HushCut v" + a.GetAppVersion() + " - Development Build")
+		appLog.Info("HushCut started", "version", a.GetAppVersion(), "build", "development")
 		a.isDev = true
 	}
 
 	goExecutablePath, err_exec := os.Executable()
 	if err_exec != nil {
-		log.Fatalf("Could not get executable path: %v", err_exec)
+		appLog.Error("Could not get executable path", "err", err_exec)
+		os.Exit(1)
 	}
 	goExecutableDir := filepath.Dir(goExecutablePath)
 
-	platform := runtime.Environment(a.ctx).Platform
+	platform := goruntime.GOOS
 	switch platform {
 	case "darwin":
 		configDir, err := os.UserConfigDir()
 		if err != nil {
-			log.Fatalf("failed to get user config dir: %v", err)
+			appLog.Error("Failed to get user config dir", "err", err)
+			os.Exit(1)
 		}
 
 		// Store resources in ~/Library/Application Support/HushCut
@@ -267,10 +316,12 @@ func (a *App) startup(ctx context.Context) {
 		a.tmpPath = getMacCacheTmpDir()
 
 		if err := os.MkdirAll(a.userResourcesPath, 0755); err != nil {
-			log.Fatalf("failed to create resources dir: %v", err)
+			appLog.Error("Failed to create resources dir", "err", err)
+			os.Exit(1)
 		}
 		if err := os.MkdirAll(a.tmpPath, 0755); err != nil {
-			log.Fatalf("failed to create tmp dir: %v", err)
+			appLog.Error("Failed to create tmp dir", "err", err)
+			os.Exit(1)
 		}
 	case "windows":
 		a.resourcesPath = goExecutableDir
@@ -281,12 +332,7 @@ func (a *App) startup(ctx context.Context) {
 		a.resourcesPath = goExecutableDir
 
 		// User settings
-		configHome := os.Getenv("XDG_CONFIG_HOME")
-		if configHome == "" {
-			home, _ := os.UserHomeDir()
-			configHome = filepath.Join(home, ".config")
-		}
-		a.userResourcesPath = filepath.Join(configHome, "HushCut")
+		a.userResourcesPath = linuxConfigDir()
 
 		// Temp / cache files
 		cacheHome := os.Getenv("XDG_CACHE_HOME")
@@ -296,43 +342,39 @@ func (a *App) startup(ctx context.Context) {
 		}
 		a.tmpPath = filepath.Join(cacheHome, "HushCut", "tmp")
 	default:
-		log.Fatalf("Unsupported platform found during path init: %s", platform)
+		appLog.Error("Unsupported platform found during path init", "platform", platform)
+		os.Exit(1)
+	}
+
+	_, err := os.Stat(a.pythonBackendPath())
+	a.launch.BackendBundled = err == nil
+	appLog.Info("Launch context", "mode", a.launch.Mode, "detectedBy", a.launch.DetectedBy)
+
+	a.policy = loadPolicy()
+	if cachePath := settingString(a.policy.Values, "cachePath", ""); cachePath != "" {
+		appLog.Info("Policy sets the cache location", "path", cachePath)
+		a.tmpPath = cachePath
 	}
 
 	// Ensure the directories exist
 	if err := os.MkdirAll(a.userResourcesPath, 0755); err != nil {
-		log.Fatalf("Failed to create resources folder: %v", err)
+		appLog.Error("Failed to create resources folder", "err", err)
+		os.Exit(1)
 	}
 	if err := os.MkdirAll(a.tmpPath, 0755); err != nil {
-		log.Fatalf("Failed to create tmp folder: %v", err)
-	}
-
-	machineID, err := a.getMachineID()
-	if err != nil {
-		log.Println("Could not retrieve machine ID")
-		// alertData := AlertPayload{
-		// 	Title:    "Internal Error (no machine ID)",
-		// 	Message:  "Could not retrieve the machine ID",
-		// 	Severity: "Error",
-		// }
-		// runtime.EventsEmit(a.ctx, "showAlert", alertData)
+		appLog.Error("Failed to create tmp folder", "err", err)
+		os.Exit(1)
 	}
 
-	a.machineID = machineID
-
-	a.licenseValid = a.HasAValidLicense()
-	if !a.licenseValid {
-		runtime.EventsEmit(a.ctx, "license:invalid", nil)
-		log.Println("Wails App: License is invalid or not found.")
+	// The license check may go online. Serve mode refuses to start without a license, so it
+	// waits for the result; the window doesn't and learns it from "license:valid" or
+	// "license:invalid".
+	if a.serve {
+		a.verifyLicenseAtStartup()
+	} else {
+		go saferun(func() { a.verifyLicenseAtStartup() })
 	}
 
-	a.checkForUpdate("v" + a.appVersion)
-
-	a.installLuaScript()
-
-	// Initialize file usage tracking
-	a.loadUsageData()
-
 	var pythonPortArg int
 
 	portStr := os.Getenv("WAILS_PYTHON_PORT")
@@ -340,7 +382,7 @@ func (a *App) startup(ctx context.Context) {
 		port, err := strconv.Atoi(portStr)
 		if err == nil {
 			pythonPortArg = port
-			log.Printf("Wails App: Detected Python port %d from WAILS_PYTHON_PORT environment variable.", port)
+			backendLog.Info("Python port set by environment", "var", "WAILS_PYTHON_PORT", "port", port)
 		}
 	}
 
@@ -369,36 +411,38 @@ func (a *App) startup(ctx context.Context) {
 	}
 
 	if pythonPortArg != 0 {
-		log.Printf("Wails App: Detected --python-port %d. Will attempt to connect to existing Python backend.", pythonPortArg)
+		backendLog.Info("Connecting to an existing Python backend", "port", pythonPortArg)
 		a.pythonCommandPort = pythonPortArg
 	} else {
-		log.Println("Wails App: No --python-port flag detected. Will launch and manage the Python backend.")
+		backendLog.Info("No Python port given; launching and managing the Python backend")
 	}
 
-	log.Println("Wails App: OnStartup called. Offloading backend initialization to a goroutine.")
+	appLog.Info("Startup: offloading backend initialization to a goroutine")
 	// Launch the main initialization logic in a separate goroutine
-	go a.initializeBackendsAndPython()
+	go saferun(func() { a.initializeBackendsAndPython() })
+	go saferun(func() { a.runCleanupScheduler() })
+	go saferun(func() { a.runTokenRotation() })
 	ffmpegBinName := "ffmpeg"
-	if runtime.Environment(a.ctx).Platform == "windows" {
+	if goruntime.GOOS == "windows" {
 		ffmpegBinName = "ffmpeg.exe"
 	}
 	a.ffmpegBinaryPath = filepath.Join(a.userResourcesPath, ffmpegBinName)
 
 	if !binaryExists(a.ffmpegBinaryPath) {
 		// log.Printf("Primary ffmpeg resolution failed or binary not usable (%v). Falling back to system PATH...", err)
-		log.Printf("ffmpeg not found at %s", a.ffmpegBinaryPath)
+		ffmpegLog.Info("ffmpeg not found", "path", a.ffmpegBinaryPath)
 		a.ffmpegStatus = StatusMissing
 		// TODO: figure out how to handle versions (accept locally installed ffmpeg if same minor version?)
 		if pathInSystem, lookupErr := exec.LookPath("ffmpeg"); lookupErr == nil {
 			a.ffmpegBinaryPath = pathInSystem
-			log.Printf("Found ffmpeg in system PATH: %s", a.ffmpegBinaryPath)
+			ffmpegLog.Info("Found ffmpeg in system PATH", "path", a.ffmpegBinaryPath)
 			a.ffmpegStatus = StatusReady
 		} else {
 			//log.Printf("Could not find ffmpeg binary in any known location or system PATH: %v", lookupErr)
-			log.Print("no ffmpeg installation in system PATH")
+			ffmpegLog.Info("No ffmpeg installation in system PATH")
 		}
 
-		platform := runtime.Environment(a.ctx).Platform
+		platform := goruntime.GOOS
 		if platform == "windows" {
 			cmd := exec.Command("cmd", "/c", "where", "ffmpeg")
 			out, err := cmd.Output()
@@ -407,29 +451,43 @@ func (a *App) startup(ctx context.Context) {
 				firstPath := strings.Fields(cleanPath)[0]
 
 				a.ffmpegBinaryPath = firstPath
-				log.Printf("Found and sanitized ffmpeg path: %s", a.ffmpegBinaryPath)
+				ffmpegLog.Info("Found ffmpeg via where", "path", a.ffmpegBinaryPath)
 				a.ffmpegStatus = StatusReady
 			} else {
-				log.Println("ffmpeg could not be detected: ", err)
+				ffmpegLog.Warn("ffmpeg could not be detected", "err", err)
 			}
 		}
 
 	} else {
-		log.Printf("ffmpeg found at %s", a.ffmpegBinaryPath)
+		ffmpegLog.Info("ffmpeg found", "path", a.ffmpegBinaryPath)
 		a.ffmpegStatus = StatusReady
 	}
 
-	runtime.EventsEmit(a.ctx, "ffmpeg:status", a.ffmpegStatus)
+	a.emit("ffmpeg:status", a.ffmpegStatus)
 
-	runtime.WindowSetAlwaysOnTop(a.ctx, true)
+	// Settings may override the ffmpeg path and worker limits; keep them live afterwards.
+	a.loadInitialSettings()
 
-	log.Println("Wails App: OnStartup method finished. UI should proceed to load.")
+	a.restoreWindowState()
+	a.confirmPendingUpdate()
 
+	// Network and disk work the UI doesn't need to render; results arrive as events.
+	go saferun(func() { a.runDeferredStartupTasks() })
+
+	appLog.Info("Startup finished; UI should proceed to load")
+
+}
+
+// emit sends an event to a.events: the frontend in the GUI, the progress display in the CLI.
+func (a *App) emit(eventName string, data ...interface{}) {
+	if a.events != nil {
+		a.events.emit(eventName, data...)
+	}
 }
 
 func (a *App) signalFfmpegReady() {
 	a.ffmpegOnce.Do(func() {
-		log.Println("Signaling that FFmpeg is now ready.")
+		ffmpegLog.Info("Signaling that ffmpeg is now ready")
 		close(a.ffmpegReadyChan)
 	})
 }
@@ -443,73 +501,95 @@ func (a *App) waitForFfmpeg() error {
 		return nil
 	}
 
-	log.Println("Task is waiting for FFmpeg to become available...")
+	ffmpegLog.Info("Task is waiting for ffmpeg to become available")
 	select {
 	case <-a.ffmpegReadyChan:
-		log.Println("FFmpeg is now available. Resuming task.")
+		ffmpegLog.Info("ffmpeg is now available; resuming task")
 		return nil
 	case <-a.ctx.Done():
-		log.Println("Application is shutting down; aborting wait for FFmpeg.")
+		ffmpegLog.Info("Application is shutting down; aborting wait for ffmpeg")
 		return a.ctx.Err()
 	}
 }
 
+// runDeferredStartupTasks installs the Resolve script, validates ffmpeg and checks for
+// updates once the window is up. The update result is delivered via "updateAvailable", the
+// script installation via "luaScript:installed" / "luaScript:error" and ffmpeg problems via
+// "ffmpeg:outdated" / "ffmpeg:corrupt". Crash logs from earlier runs are uploaded if the
+// user opted in.
+func (a *App) runDeferredStartupTasks() {
+	a.installLuaScript()
+	a.checkFfmpegVersion()
+	a.checkForUpdate("v" + a.appVersion)
+	a.uploadCrashReports()
+}
+
 func (a *App) shutdown(ctx context.Context) {
 	a.ctx = ctx
-	log.Println("Wails App: OnShutdown called.")
+	appLog.Info("Shutting down")
+	defer childProcesses.close()
+	defer a.checkpoint.close()
 
 	// Save file usage data and clean up old files
 	a.cleanupOldFiles()
 	a.saveUsageData()
+	if a.usageStore != nil {
+		a.usageStore.Close()
+	}
+	a.releaseSeat()
+	stopTLSServer()
+	if err := luahelperlogic.RemoveDiscovery(os.Getpid()); err != nil {
+		appLog.Warn("Could not remove discovery file", "err", err)
+	}
 
 	// Case 1: The Go app launched the Python process. We own it and can terminate it.
 	if a.pythonCmd != nil && a.pythonCmd.Process != nil {
-		log.Printf("Shutting down Python process with PID %d...", a.pythonCmd.Process.Pid)
+		backendLog.Info("Shutting down Python process", "pid", a.pythonCmd.Process.Pid)
 
 		var terminateErr error
-		if runtime.Environment(a.ctx).Platform == "windows" {
+		if goruntime.GOOS == "windows" {
 			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 			defer cancel()
 			a.sendRequestToPython(ctx, "POST", "/shutdown", map[string]interface{}{})
-			log.Printf("Attempting to kill Python process tree PID %d...", a.pythonCmd.Process.Pid)
+			backendLog.Info("Killing Python process tree", "pid", a.pythonCmd.Process.Pid)
 			killCmd := ExecCommand("taskkill", "/PID", strconv.Itoa(a.pythonCmd.Process.Pid), "/T", "/F")
 			if err := killCmd.Run(); err != nil {
-				log.Printf("taskkill failed: %v", err)
+				backendLog.Warn("taskkill failed", "err", err)
 			}
 			// Wait for Go to reap process handle
 			done := make(chan error)
-			go func() { done <- a.pythonCmd.Wait() }()
+			go saferun(func() { done <- a.pythonCmd.Wait() })
 			select {
 			case err := <-done:
-				log.Printf("Python process exited: %v", err)
+				backendLog.Info("Python process exited", "err", err)
 			case <-time.After(5 * time.Second):
-				log.Println("Process still alive after taskkill.")
+				backendLog.Warn("Python process still alive after taskkill")
 			}
 		} else {
 			terminateErr = a.pythonCmd.Process.Signal(syscall.SIGTERM) // Graceful shutdown on Unix
 		}
 
 		if terminateErr != nil {
-			log.Printf("Failed to terminate Python process: %v", terminateErr)
+			backendLog.Error("Failed to terminate Python process", "err", terminateErr)
 			// send http kill command here as a last resort
 			return
 		}
 
 		// Wait for graceful shutdown
 		done := make(chan error)
-		go func() { done <- a.pythonCmd.Wait() }()
+		go saferun(func() { done <- a.pythonCmd.Wait() })
 
 		select {
 		case err := <-done:
-			log.Printf("Python process exited: %v", err)
+			backendLog.Info("Python process exited", "err", err)
 		case <-time.After(5 * time.Second):
-			log.Println("Python process did not exit gracefully; force killing it.")
+			backendLog.Warn("Python process did not exit gracefully; force killing it")
 			if killErr := a.pythonCmd.Process.Kill(); killErr != nil {
-				log.Printf("Failed to kill Python process: %v", killErr)
+				backendLog.Error("Failed to kill Python process", "err", killErr)
 			}
 		}
 	} else if a.pythonReady {
-		log.Println("Signaling external Python backend to shut down...")
+		backendLog.Info("Signaling external Python backend to shut down")
 
 		// Create a context with a short, 2-second timeout for this specific request.
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -519,172 +599,153 @@ func (a *App) shutdown(ctx context.Context) {
 		_, err := a.sendRequestToPython(shutdownCtx, "POST", "/shutdown", nil)
 		if err != nil {
 			// Log the error, but don't block the shutdown process.
-			log.Printf("Failed to send shutdown signal to Python: %v", err)
+			backendLog.Warn("Failed to send shutdown signal to Python", "err", err)
 		} else {
-			log.Println("Successfully sent shutdown signal to Python backend.")
+			backendLog.Info("Sent shutdown signal to Python backend")
 		}
 	}
 }
 
 func (a *App) initializeBackendsAndPython() {
-	log.Println("Go Routine: Starting backend initialization...")
+	appLog.Info("Starting backend initialization")
+
+	// A crashed earlier session may still have python_backend or ffmpeg running.
+	cleanupOrphanedProcesses()
+
+	// File usage tracking; nothing records usage before the server runs.
+	a.loadUsageData()
+	a.startCheckpointing()
 
 	// Launch Go's HTTP Server
 	if err := a.LaunchHttpServer(); err != nil {
 		errMsg := fmt.Sprintf("CRITICAL ERROR: Failed to launch Go HTTP server: %v", err)
-		log.Println("Go Routine: " + errMsg)
-		runtime.EventsEmit(a.ctx, "app:criticalError", errMsg)
+		appLog.Error(errMsg)
+		a.emit("app:criticalError", errMsg)
 		return
 	}
-	log.Println("Go Routine: Go HTTP server launch sequence initiated.")
-	runtime.EventsEmit(a.ctx, "go:ready", nil)
+	appLog.Info("Go HTTP server launch sequence initiated")
+	a.emit("go:ready", nil)
 
 	goHTTPServerPort := a.GetGoServerPort()
 	if goHTTPServerPort == 0 {
 		errMsg := "CRITICAL ERROR: Failed to get Go HTTP server port."
-		log.Println("Go Routine: " + errMsg)
-		runtime.EventsEmit(a.ctx, "app:criticalError", errMsg)
+		appLog.Error(errMsg)
+		a.emit("app:criticalError", errMsg)
 		return
 	}
 
 	// Determine if Python is already running (dev mode)
 	if a.pythonCommandPort != 0 {
-		log.Printf("Go Routine: Python command server detected on port: %d", a.pythonCommandPort)
+		backendLog.Info("Python command server detected", "port", a.pythonCommandPort)
 		if err := a.registerWithPython(goHTTPServerPort); err != nil {
 			errMsg := fmt.Sprintf("CRITICAL ERROR: Failed to register with Python: %v", err)
-			log.Println("Go Routine: " + errMsg)
-			runtime.EventsEmit(a.ctx, "app:criticalError", errMsg)
+			backendLog.Error(errMsg)
+			var integrityErr *backendIntegrityError
+			if errors.As(err, &integrityErr) {
+				a.emit("app:criticalError", errMsg)
+				a.reportBackendTampered(integrityErr)
+				return
+			}
+			a.emit("app:criticalError", a.backendUnavailableMessage(err.Error()).Text)
+			a.reportBackendUnavailable(err.Error())
 			return
 		}
 		a.pythonReady = true
-		runtime.EventsEmit(a.ctx, "pythonStatusUpdate", map[string]interface{}{"isReady": true})
+		a.emit("pythonStatusUpdate", map[string]interface{}{"isReady": true})
+	} else if a.launch.Mode == launchModeStandalone && !a.launch.BackendBundled {
+		// Nothing to launch and nobody will register: say so instead of waiting.
+		backendLog.Info("Started standalone without a bundled backend; not waiting for one", "path", a.pythonBackendPath())
+		a.reportBackendUnavailable("no bundled backend")
 	} else {
 		// Python is not running, launch it for production
 		pythonCmdPort, err := findFreePort()
 		if err != nil {
 			errMsg := fmt.Sprintf("CRITICAL ERROR: Failed to find free port for Python: %v", err)
-			log.Println("Go Routine: " + errMsg)
-			runtime.EventsEmit(a.ctx, "app:criticalError", errMsg)
+			backendLog.Error(errMsg)
+			a.emit("app:criticalError", errMsg)
 			return
 		}
 		a.pythonCommandPort = pythonCmdPort
 
 		if err := a.LaunchPythonBackend(goHTTPServerPort, a.pythonCommandPort); err != nil {
 			errMsg := fmt.Sprintf("CRITICAL ERROR: Failed to launch Python backend: %v", err)
-			log.Println("Go Routine: " + errMsg)
-			runtime.EventsEmit(a.ctx, "app:criticalError", errMsg)
+			backendLog.Error(errMsg)
+			var integrityErr *backendIntegrityError
+			if errors.As(err, &integrityErr) {
+				a.emit("app:criticalError", errMsg)
+				a.reportBackendTampered(integrityErr)
+				return
+			}
+			a.emit("app:criticalError", a.backendUnavailableMessage(err.Error()).Text)
+			a.reportBackendUnavailable(err.Error())
 			return
 		}
 
 		// Wait for Python's registration signal
 		select {
 		case <-a.pythonReadyChan:
-			log.Println("Go Routine: Python backend has registered successfully.")
+			backendLog.Info("Python backend has registered")
 			a.pythonReady = true
-			runtime.EventsEmit(a.ctx, "pythonStatusUpdate", map[string]interface{}{"isReady": true})
+			a.emit("pythonStatusUpdate", map[string]interface{}{"isReady": true})
 		case <-time.After(30 * time.Second):
-			log.Printf("Go Routine Warning: Timed out waiting for Python registration.")
+			backendLog.Warn("Timed out waiting for Python registration")
 			a.pythonReady = false
+			a.reportBackendUnavailable("no response within 30 seconds")
 		case <-a.ctx.Done():
-			log.Println("Go Routine: Application shutdown requested during Python wait.")
+			backendLog.Info("Shutdown requested while waiting for Python")
 			return
 		}
 	}
-	log.Println("Go Routine: Backend initialization complete.")
+	appLog.Info("Backend initialization complete")
+}
+
+// reportBackendUnavailable tells the frontend that the backend is not coming, with a message
+// that fits the way HushCut was started.
+func (a *App) reportBackendUnavailable(cause string) {
+	a.emitBackendStatus(false, a.backendUnavailableMessage(cause))
 }
 
 func (a *App) registerWithPython(goPort int) error {
 	registrationURL := fmt.Sprintf("http://localhost:%d/register", a.pythonCommandPort)
-	payload := map[string]int{"go_server_port": goPort}
+	payload := map[string]interface{}{"go_server_port": goPort, "endpoint_prefix": a.endpointPrefix}
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal registration payload: %w", err)
 	}
 
 	for i := 0; i < 5; i++ {
-		resp, err := http.Post(registrationURL, "application/json", bytes.NewBuffer(jsonPayload))
+		req, err := http.NewRequest(http.MethodPost, registrationURL, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return fmt.Errorf("failed to create registration request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		// A lua-helper started with HushCut's token rejects requests without it.
+		req.Header.Set("Authorization", "Bearer "+a.backendToken())
+		resp, err := http.DefaultClient.Do(req)
 		if err == nil {
 			defer resp.Body.Close()
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				log.Printf("Successfully registered with Python at %s", registrationURL)
+				backendLog.Info("Registered with Python", "url", registrationURL)
+				// Whatever token the backend was started with, it gets one of its own now.
+				if err := a.RotateBackendToken(); err != nil && !errors.Is(err, errTokenRotationUnsupported) {
+					backendLog.Warn("Could not rotate the backend token after registering", "err", err)
+				}
 				return nil
 			}
 			body, _ := io.ReadAll(resp.Body)
-			log.Printf("Python registration failed with status %d: %s", resp.StatusCode, string(body))
+			backendLog.Warn("Python registration failed", "status", resp.StatusCode, "body", string(body))
 		} else {
-			log.Printf("Attempt %d: Could not connect to Python at %s: %v", i+1, registrationURL, err)
+			backendLog.Warn("Could not connect to Python", "attempt", i+1, "url", registrationURL, "err", err)
 		}
 		time.Sleep(2 * time.Second)
 	}
 	return fmt.Errorf("failed to register with Python after multiple attempts")
 }
 
-// reads settings.json. Creates it with defaults if it doesn't exist.
-func (a *App) GetSettings() (map[string]any, error) {
-	var settingsData map[string]any
-	settingsPath := filepath.Join(a.userResourcesPath, "settings.json")
-
-	fileBytes, err := os.ReadFile(settingsPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist, create it
-			defaultSettings := make(map[string]any)
-			// Add default key-value pairs here if needed
-			defaultSettings["davinciFolderPath"] = ""
-			defaultSettings["cleanupThresholdDays"] = 30
-			defaultSettings["enableCleanup"] = true
-
-			jsonData, marshalErr := json.MarshalIndent(defaultSettings, "", "  ")
-			if marshalErr != nil {
-				return nil, fmt.Errorf("failed to marshal default settings: %w", marshalErr)
-			}
-
-			dir := filepath.Dir(settingsPath)
-			if mkDirErr := os.MkdirAll(dir, 0755); mkDirErr != nil {
-				return nil, fmt.Errorf("failed to create settings directory %s: %w", dir, mkDirErr)
-			}
-
-			if writeErr := os.WriteFile(settingsPath, jsonData, 0644); writeErr != nil {
-				return nil, fmt.Errorf("failed to write default settings file %s: %w", settingsPath, writeErr)
-			}
-			settingsData = defaultSettings
-		} else {
-			// Other error reading file
-			return nil, fmt.Errorf("failed to read settings file %s: %w", settingsPath, err)
-		}
-	} else {
-		// File exists, unmarshal it
-		if unmarshalErr := json.Unmarshal(fileBytes, &settingsData); unmarshalErr != nil {
-			// If JSON is malformed, consider returning default or empty settings instead of erroring out.
-			return nil, fmt.Errorf("failed to unmarshal settings file %s: %w", settingsPath, unmarshalErr)
-		}
-	}
-	return settingsData, nil
-}
-
-// saves the given configuration data to settings.json.
-func (a *App) SaveSettings(settingsData map[string]interface{}) error {
-	jsonData, err := json.MarshalIndent(settingsData, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal settings data for saving: %w", err)
-	}
-	settingsPath := filepath.Join(a.userResourcesPath, "settings.json")
-
-	dir := filepath.Dir(settingsPath)
-	if mkDirErr := os.MkdirAll(dir, 0755); mkDirErr != nil {
-		return fmt.Errorf("failed to create settings directory %s for saving: %w", dir, mkDirErr)
-	}
-
-	if err := os.WriteFile(settingsPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file %s: %w", settingsPath, err)
-	}
-	return nil
-}
-
 func (a *App) SelectDirectory() (string, error) {
 	settings, err := a.GetSettings()
 	if err != nil {
-		log.Printf("Error getting settings for default directory: %v", err)
+		appLog.Warn("Error getting settings for default directory", "err", err)
 	}
 
 	defaultDir := ""
@@ -693,17 +754,21 @@ func (a *App) SelectDirectory() (string, error) {
 		if err == nil && info.IsDir() {
 			defaultDir = davinciPath
 		} else {
-			log.Printf("Davinci folder path from settings is not a valid directory or does not exist: %s", davinciPath)
+			appLog.Warn("Davinci folder path from settings is not a directory", "path", davinciPath)
 		}
 	}
 
-	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		DefaultDirectory: defaultDir,
-	})
+	return openDirectoryDialog(a.ctx, defaultDir)
 }
 
 func (a *App) CloseApp() {
-	runtime.Quit(a.ctx)
+	if a.runsInBackground() {
+		a.hideToBackground()
+		return
+	}
+	// The window is frameless, so this is the usual way out; save geometry while we still can.
+	a.beforeClose(a.ctx)
+	a.quit()
 }
 
 func (a *App) GetPythonReadyStatus() bool {
@@ -723,10 +788,23 @@ func (a *App) GetFfmpegVersion() string {
 }
 
 type ProgressStatus struct {
-	FilePath   string  `json:"filePath"`
-	Percentage float64 `json:"percentage"`
-	Error      string  `json:"error,omitempty"`
-	TaskType   string  `json:"taskType"`
+	FilePath   string      `json:"filePath"`
+	Percentage float64     `json:"percentage"`
+	Error      string      `json:"error,omitempty"`
+	ErrorKey   string      `json:"errorKey,omitempty"` // localizable summary of Error, see internal/i18n
+	Params     i18n.Params `json:"params,omitempty"`
+	TaskType   string      `json:"taskType"`
+}
+
+// progressFailed is the status of a task that failed with err; key names the failure.
+func progressFailed(filePath, taskType, key string, err error) ProgressStatus {
+	return ProgressStatus{
+		FilePath: filePath,
+		Error:    err.Error(),
+		ErrorKey: key,
+		Params:   i18n.Params{"file": filepath.Base(filePath), "detail": err.Error()},
+		TaskType: taskType,
+	}
 }
 
 func (a *App) GetCurrentProgressStatus() map[string]float64 {
@@ -796,7 +874,7 @@ func parseFFmpegStreams(ffmpegOutput string) ([]VideoStream, []AudioStream) {
 			matches := audioRe.FindStringSubmatch(line)
 			if matches == nil {
 				// If our smart regex fails, it's an unknown format. Default to 1 channel.
-				log.Printf("WARNING: Could not parse channel count for line: %s. Defaulting to 1.", line)
+				ffmpegLog.Warn("Could not parse channel count; defaulting to 1", "line", line)
 
 				// Try to at least get the stream index
 				simpleIndexRe := regexp.MustCompile(`Stream #0:(\d+)`)
@@ -840,21 +918,28 @@ func parseFFmpegStreams(ffmpegOutput string) ([]VideoStream, []AudioStream) {
 }
 
 func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceChannel *SourceChannel) error {
-	tracker := &ProgressTracker{Done: make(chan error, 1)}
+	return a.standardizeAudioToWav(inputPath, outputPath, sourceChannel, true)
+}
+
+// standardizeAudioToWav converts one source stream to a mono WAV. With withWaveform the
+// WAV's waveform is generated in the background as well; the preparation graph schedules it
+// as a task of its own instead.
+func (a *App) standardizeAudioToWav(inputPath string, outputPath string, sourceChannel *SourceChannel, withWaveform bool) error {
+	tracker := &ProgressTracker{Done: make(chan error, 1), TaskType: jobConversion, StartedAt: time.Now()}
 	actualTracker, loaded := a.progressTracker.LoadOrStore(outputPath, tracker)
 
 	if loaded {
 		// If another goroutine is already working on this, just wait for its result.
-		log.Printf("StandardizeAudioToWav: Another task is already handling %s. Waiting.", filepath.Base(outputPath))
+		ffmpegLog.Debug("StandardizeAudioToWav: another task is handling the file; waiting", "file", filepath.Base(outputPath))
 		err := <-actualTracker.(*ProgressTracker).Done
-		log.Printf("StandardizeAudioToWav: Wait finished for %s.", filepath.Base(outputPath))
+		ffmpegLog.Debug("StandardizeAudioToWav: wait finished", "file", filepath.Base(outputPath))
 		return err
 	}
 
 	defer func() {
 		close(tracker.Done)
 		a.progressTracker.Delete(outputPath)
-		log.Printf("StandardizeAudioToWav: Cleaned up tracker for %s.", filepath.Base(outputPath))
+		ffmpegLog.Debug("StandardizeAudioToWav: cleaned up tracker", "file", filepath.Base(outputPath))
 	}()
 
 	if err := a.waitForFfmpeg(); err != nil {
@@ -863,20 +948,14 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 	}
 
 	outputFileName := filepath.Base(outputPath)
-	go func() {
-		_, err := a.GetOrGenerateWaveformWithCache(
-			outputFileName,
-			128,
-			"logarithmic",
-			-60.0,
-			0.0,
-			0,
-			math.MaxFloat64,
-		)
-		if err != nil {
-			log.Printf("Error precomputing logarithmic waveform: %v", err)
+	go saferun(func() {
+		if a.headless || !withWaveform {
+			return // nothing displays waveforms, or the caller generates them
 		}
-	}()
+		if err := a.precomputeWaveform(outputFileName); err != nil {
+			waveformLog.Error("Error precomputing logarithmic waveform", "err", err)
+		}
+	})
 
 	if isValidWavFile(outputPath) {
 		tracker.Done <- nil
@@ -884,24 +963,25 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 	}
 
 	// 2. Get Duration for Progress Calculation
-	infoCmd := ExecCommand(a.ffmpegBinaryPath, "-i", inputPath)
+	infoCmd := a.ffmpegCommand("-i", inputPath)
 	var infoOutput bytes.Buffer
 	infoCmd.Stderr = &infoOutput
-	_ = infoCmd.Run() // Ignore error as ffmpeg prints info to stderr even on failure
+	infoStarted := time.Now()
+	infoErr := runTracked(infoCmd) // Ignore error as ffmpeg prints info to stderr even on failure
+	auditFFmpeg("probe", infoCmd, infoStarted, infoErr, infoOutput.String())
 
 	totalDuration, err := parseDuration(infoOutput.String())
 	if err != nil {
-		log.Printf("Could not parse duration for %s, progress will not be available. Error: %v", inputPath, err)
+		ffmpegLog.Warn("Could not parse duration; progress will not be available", "file", inputPath, "err", err)
 		totalDuration = 0
 	}
 	totalDurationUs := float64(totalDuration.Microseconds())
 
 	videoStreams, audioStreams := parseFFmpegStreams(infoOutput.String())
 
-	log.Printf("DEBUG: Detected %d audio streams.", len(audioStreams))
-	log.Printf("DEBUG: Detected %d video streams for file %s", len(videoStreams), inputPath)
+	ffmpegLog.Debug("Detected streams", "file", inputPath, "audio", len(audioStreams), "video", len(videoStreams))
 	for i, as := range audioStreams {
-		log.Printf("  - Stream %d: %d channels", i, as.Channels)
+		ffmpegLog.Debug("Audio stream", "index", i, "channels", as.Channels)
 	}
 
 	streamFound := false
@@ -927,7 +1007,7 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 
 	if sourceChannel != nil {
 		aStream := audioStreams[streamIndexInAudioStreams]
-		log.Printf("Mixing all %d channels from stream %d of '%s'", aStream.Channels, ffmpegStream, filepath.Base(inputPath))
+		ffmpegLog.Info("Mixing all channels of stream", "file", filepath.Base(inputPath), "stream", ffmpegStream, "channels", aStream.Channels)
 
 		panExpr := ""
 		for ch := 0; ch < aStream.Channels; ch++ {
@@ -944,7 +1024,7 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 			"-vn",
 		)
 	} else {
-		log.Printf("Standardizing '%s' to mono", filepath.Base(inputPath))
+		ffmpegLog.Info("Standardizing to mono", "file", filepath.Base(inputPath))
 		args = append(args,
 			"-af", "pan=mono|c0=0.5*FL+0.5*FR",
 			"-vn",
@@ -956,9 +1036,9 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 		"-progress", "pipe:1",
 		outputPath,
 	)
-	log.Printf("FFMPEG FINAL EXTRACT CMD: %s", args)
+	ffmpegLog.Debug("Final extract command", "args", args)
 
-	cmd := ExecCommand(a.ffmpegBinaryPath, args...)
+	cmd := a.ffmpegCommand(args...)
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
@@ -971,20 +1051,28 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 		return err
 	}
 
+	a.checkpoint.jobStarted(StandardizeJob{InputPath: inputPath, OutputPath: outputPath, SourceChannel: sourceChannel})
+	defer a.checkpoint.jobFinished(outputPath)
+
+	started := time.Now()
 	if err := cmd.Start(); err != nil {
+		auditFFmpeg("standardize", cmd, started, err, "")
 		tracker.Done <- err
 		return err
 	}
+	limitStarted(cmd)
+	childProcesses.track(cmd)
+	activeJobs.start(jobConversion, cmd)
 
 	// Emit a 0% event immediately so the UI feels responsive
 	if totalDurationUs > 0 {
-		runtime.EventsEmit(a.ctx, "conversion:progress", ProgressStatus{FilePath: outputPath, Percentage: 0})
+		a.emit("conversion:progress", ProgressStatus{FilePath: outputPath, Percentage: 0})
 	}
 
 	// Goroutine to read and parse progress from stdout
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go func() {
+	go saferun(func() {
 		defer wg.Done()
 		scanner := bufio.NewScanner(stdoutPipe)
 		lastReportedPct := -5.0
@@ -1016,21 +1104,33 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 			tracker.mu.Lock()
 			tracker.Percentage = percentage
 			tracker.mu.Unlock()
-			runtime.EventsEmit(a.ctx, "conversion:progress", ProgressStatus{FilePath: outputPath, Percentage: percentage, TaskType: "conversion"})
+			a.emit("conversion:progress", ProgressStatus{FilePath: outputPath, Percentage: percentage, TaskType: "conversion"})
 			lastReportedPct = percentage
 		}
-	}()
+	})
 
 	var stderrBuf bytes.Buffer
-	go io.Copy(&stderrBuf, stderrPipe) // Silently consume stderr
+	go saferun(func() { io.Copy(&stderrBuf, stderrPipe) }) // Silently consume stderr
 
 	// Wait for completion and signal the result
 	err = cmd.Wait()
+	childProcesses.untrack(cmd)
+	if activeJobs.finish(cmd) {
+		err = errJobCancelled
+	}
 	wg.Wait() // Ensure the progress scanner has finished reading
+	auditFFmpeg("standardize", cmd, started, err, stderrBuf.String())
 
+	if err == errJobCancelled {
+		os.Remove(outputPath)
+		ffmpegLog.Info("Standardization cancelled", "file", filepath.Base(inputPath))
+		tracker.Done <- err
+		return err
+	}
 	if err != nil {
+		os.Remove(outputPath) // never leave a partial WAV in the cache
 		finalErr := fmt.Errorf("ffmpeg standardization failed for %s: %w. Stderr: %s", inputPath, err, stderrBuf.String())
-		runtime.EventsEmit(a.ctx, "conversion:error", ProgressStatus{FilePath: outputPath, Error: finalErr.Error()})
+		a.emit("conversion:error", progressFailed(outputPath, "conversion", "progress.conversionFailed", finalErr))
 		tracker.Done <- finalErr
 		return finalErr
 	}
@@ -1039,7 +1139,7 @@ func (a *App) StandardizeAudioToWav(inputPath string, outputPath string, sourceC
 	tracker.mu.Lock()
 	tracker.Percentage = 100.0
 	tracker.mu.Unlock()
-	runtime.EventsEmit(a.ctx, "conversion:done", ProgressStatus{FilePath: outputPath, Percentage: 100})
+	a.emit("conversion:done", ProgressStatus{FilePath: outputPath, Percentage: 100})
 	tracker.Done <- nil
 
 	// Update file usage timestamp
@@ -1053,7 +1153,7 @@ func (a *App) WaitForFile(path string) error {
 		return nil
 	}
 
-	log.Printf("Waiting for file to be ready: %s", path)
+	ffmpegLog.Debug("Waiting for file to be ready", "path", path)
 
 	tracker, ok := val.(*ProgressTracker)
 	if !ok {
@@ -1074,91 +1174,47 @@ func (a *App) WaitForFile(path string) error {
 	return nil
 }
 
+// ProcessProjectAudio standardizes every audio stream of the project, nested ones included,
+// and mixes down its compound clips once their streams are ready. The clips are then
+// analyzed in the background.
 func (a *App) ProcessProjectAudio(projectData ProjectDataPayload) error {
-	log.Println("Starting to standardize ALL project audio streams (including nested)...")
-
-	// --- Step 1: Collect all unique processing jobs from the entire data structure ---
-	type audioJob struct {
-		SourcePath string
-		Channel    *SourceChannel
+	ffmpegLog.Info("Standardizing all project audio streams, nested ones included")
+	a.setCurrentProject(&projectData)
+	err := a.prepareProjectAudio(a.buildProjectPrep(&projectData, false, DetectionParams{}))
+	if !errors.Is(err, errJobCancelled) {
+		a.startBackgroundAnalysis(&projectData)
 	}
-	// The key is the target output path, which ensures each unique job is only listed once.
-	jobsToProcess := make(map[string]audioJob)
-
-	for _, item := range projectData.Timeline.AudioTrackItems {
-		// Case 1: This is a simple clip (not a compound clip).
-		if item.Type == "" {
-			// Use a guard clause to skip if there's no work to do.
-			if item.ProcessedFileName == nil || *item.ProcessedFileName == "" {
-				continue
-			}
-			targetWavPath := filepath.Join(a.tmpPath, *item.ProcessedFileName)
-			jobsToProcess[targetWavPath] = audioJob{
-				SourcePath: item.SourceFilePath,
-				Channel:    item.SourceChannel,
-			}
-			// Mark the processed file as used
-			a.updateFileUsage(targetWavPath)
-			continue // Move to the next top-level item.
-		}
+	return err
+}
 
-		// Case 2: This is a Compound Clip. Add its nested children to our job list.
-		if item.Type != "" && len(item.NestedClips) > 0 {
-			for _, nestedItem := range item.NestedClips {
-				// Guard clause for nested items.
-				if nestedItem.ProcessedFileName == "" {
-					continue
-				}
-				targetWavPath := filepath.Join(a.tmpPath, nestedItem.ProcessedFileName)
-				jobsToProcess[targetWavPath] = audioJob{
-					SourcePath: nestedItem.SourceFilePath,
-					Channel:    nestedItem.SourceChannel,
-				}
-				// Mark the processed file as used
-				a.updateFileUsage(targetWavPath)
-			}
-		}
-	}
+// setCurrentProject remembers the synced project so endpoints can look up clips by ID.
+func (a *App) setCurrentProject(projectData *ProjectDataPayload) {
+	a.mu.Lock()
+	a.currentProject = projectData
+	a.mu.Unlock()
+	a.rememberProjectFiles(projectData)
+	a.restoreTimelineSession(projectData)
+	a.recordSyncedSession(projectData)
+}
 
-	if len(jobsToProcess) == 0 {
-		log.Println("No audio streams require standardization.")
+// prepareProjectAudio runs the preparation graph and reports the tasks that failed.
+func (a *App) prepareProjectAudio(prep *projectPrep) error {
+	if len(prep.graph.order) == 0 {
+		ffmpegLog.Info("No audio streams require standardization")
 		return nil
 	}
-
-	// --- Step 2: Execute all collected jobs concurrently ---
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(jobsToProcess))
-
-	for targetPath, job := range jobsToProcess {
-		wg.Add(1)
-		// Pass copies of loop variables to the goroutine.
-		go func(target string, currentJob audioJob) {
-			defer wg.Done()
-			a.ffmpegSemaphore <- struct{}{}
-			defer func() { <-a.ffmpegSemaphore }()
-
-			if err := a.StandardizeAudioToWav(currentJob.SourcePath, target, currentJob.Channel); err != nil {
-				log.Printf("Error standardizing stream for %s: %v", currentJob.SourcePath, err)
-				errChan <- err
-			}
-		}(targetPath, job)
-	}
-
-	wg.Wait()
-	close(errChan)
-
-	// --- Step 3: Consolidate and report any errors ---
-	var conversionErrors []string
-	for err := range errChan {
-		conversionErrors = append(conversionErrors, err.Error())
+	err := prep.execute()
+	if errors.Is(err, errJobCancelled) {
+		ffmpegLog.Info("Audio preparation was cancelled")
+		return err
 	}
-	if len(conversionErrors) > 0 {
-		runtime.EventsEmit(a.ctx, "conversionError", conversionErrors)
-		return fmt.Errorf("encountered %d error(s) during audio standardization:\n%s",
-			len(conversionErrors), strings.Join(conversionErrors, "\n"))
+	var failed *prepError
+	if errors.As(err, &failed) {
+		a.emit("conversionError", failed.Errors)
+		return fmt.Errorf("encountered %d error(s) during audio preparation:\n%s",
+			len(failed.Errors), strings.Join(failed.Errors, "\n"))
 	}
-
-	log.Println("All project audio streams standardized successfully.")
+	ffmpegLog.Info("All project audio streams prepared")
 	return nil
 }
 
@@ -1188,14 +1244,8 @@ func (a *App) executeMixdownCommand(fps float64, outputPath string, nestedClips
 		return fmt.Errorf("no valid processed nested clips found for mixdown into %s", filepath.Base(outputPath))
 	}
 
-	log.Printf("Mixdown for '%s' is waiting for %d input file(s) to be ready...", filepath.Base(outputPath), len(uniqueSourceFiles))
-	for _, inputFile := range uniqueSourceFiles {
-		if err := a.WaitForFile(inputFile); err != nil {
-			// If an input file failed to convert, this mixdown cannot proceed.
-			return fmt.Errorf("mixdown dependency '%s' failed: %w", filepath.Base(inputFile), err)
-		}
-	}
-	log.Printf("All inputs for mixdown '%s' are ready. Proceeding.", filepath.Base(outputPath))
+	// The preparation graph only starts a mixdown once all of its inputs are ready.
+	ffmpegLog.Info("Mixing down", "output", filepath.Base(outputPath), "inputs", len(uniqueSourceFiles))
 
 	for i, nc := range nestedClips {
 		if nc.ProcessedFileName == "" {
@@ -1232,69 +1282,66 @@ func (a *App) executeMixdownCommand(fps float64, outputPath string, nestedClips
 		outputPath,
 	)
 
-	cmd := ExecCommand(a.ffmpegBinaryPath, args...)
+	cmd := a.ffmpegCommand(args...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	a.checkpoint.writing(outputPath)
+	defer a.checkpoint.written(outputPath)
+
+	started := time.Now()
+	err := runJob(jobMixdown, cmd)
+	auditFFmpeg("mixdown", cmd, started, err, stderr.String())
+	if errors.Is(err, errJobCancelled) {
+		os.Remove(outputPath)
+		return err
+	}
+	if err != nil {
+		os.Remove(outputPath)
 		return fmt.Errorf("ffmpeg mixdown command failed: %w. Stderr: %s", err, stderr.String())
 	}
 
 	return nil
 }
 
-func (a *App) MixdownCompoundClips(projectData ProjectDataPayload) error {
-	log.Println("Starting mixdown of compound clips...")
-	contentMap := make(map[string]*TimelineItem)
-	for i, item := range projectData.Timeline.AudioTrackItems {
-		if item.Type == "" || len(item.NestedClips) == 0 || item.ProcessedFileName == nil {
-			continue
-		}
-		if _, exists := contentMap[*item.ProcessedFileName]; !exists {
-			contentMap[*item.ProcessedFileName] = &projectData.Timeline.AudioTrackItems[i]
-		}
-	}
-	if len(contentMap) == 0 {
-		log.Println("No compound clips found to mixdown.")
-		return nil
-	}
-
-	for processedName, representativeItem := range contentMap {
-		outputPath := filepath.Join(a.tmpPath, processedName)
-
-		a.updateFileUsage(outputPath)
-
-		a.ExecuteAndTrackMixdown(projectData.Timeline.ProjectFPS, outputPath, representativeItem.NestedClips)
+// startMixdown starts mixing down nestedClips into outputPath unless that is already running,
+// and returns the channel that reports the result.
+func (a *App) startMixdown(fps float64, outputPath string, nestedClips []*NestedAudioTimelineItem) <-chan error {
+	tracker := &ProgressTracker{Done: make(chan error, 1), TaskType: jobMixdown, StartedAt: time.Now()}
+	if actual, loaded := a.progressTracker.LoadOrStore(outputPath, tracker); loaded {
+		return actual.(*ProgressTracker).Done // Job is already running.
 	}
 
-	log.Println("All mixdown jobs have been dispatched.")
-	return nil
-}
-
-func (a *App) ExecuteAndTrackMixdown(fps float64, outputPath string, nestedClips []*NestedAudioTimelineItem) {
-	tracker := &ProgressTracker{Done: make(chan error, 1)}
-	if _, loaded := a.progressTracker.LoadOrStore(outputPath, tracker); loaded {
-		return // Job is already running, exit.
+	// Keep the inputs alive until the mixdown has consumed them.
+	inputs := make([]string, 0, len(nestedClips))
+	for _, nc := range nestedClips {
+		inputs = append(inputs, nc.ProcessedFileName)
 	}
+	releaseInputs := a.acquireFileRefs(inputs...)
 
 	// Launch the actual work in a new goroutine.
-	go func() {
+	go saferun(func() {
 		// This goroutine is the "owner" and is responsible for cleanup and signaling.
 		defer func() {
 			close(tracker.Done)
 			a.progressTracker.Delete(outputPath)
+			releaseInputs()
 		}()
 
 		// Acquire a semaphore slot for the duration of this job
-		a.ffmpegSemaphore <- struct{}{}
-		defer func() { <-a.ffmpegSemaphore }()
+		release, err := a.acquireFfmpegSlot(jobMixdown, outputPath)
+		if err != nil {
+			tracker.Done <- err
+			return
+		}
+		defer release()
 
-		var err error
 		if !isValidWavFile(outputPath) {
 			err = a.executeMixdownCommand(fps, outputPath, nestedClips)
 		}
 
 		// Signal completion (sends nil on success, or the error on failure)
 		tracker.Done <- err
-	}()
+	})
+	return tracker.Done
 }
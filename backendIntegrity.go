@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// The bundled python_backend is checked against the manifest the build wrote next to it
+// (build/manifest/backend.json, see the README there) before it is launched. A backend that
+// was modified, or left behind half-replaced by an interrupted update, is not started: it
+// would talk to this app in a protocol of another version and fail in confusing ways. Builds
+// without a manifest, such as wails dev, skip the check.
+
+//go:embed build/manifest
+var backendManifestFS embed.FS
+
+const backendManifestPath = "build/manifest/backend.json"
+
+type backendManifest struct {
+	Version string `json:"version"`
+	File    string `json:"file"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+}
+
+// backendIntegrityError is returned by verifyPythonBackend when the backend doesn't match.
+type backendIntegrityError struct {
+	path   string
+	reason string
+}
+
+func (e *backendIntegrityError) Error() string {
+	return fmt.Sprintf("%s failed its integrity check: %s", filepath.Base(e.path), e.reason)
+}
+
+// loadBackendManifest returns the embedded manifest, or nil if this build has none.
+func loadBackendManifest() (*backendManifest, error) {
+	data, err := backendManifestFS.ReadFile(backendManifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest backendManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("backend manifest is invalid: %w", err)
+	}
+	if manifest.SHA256 == "" {
+		return nil, errors.New("backend manifest has no checksum")
+	}
+	return &manifest, nil
+}
+
+// verifyPythonBackend checks the backend at path against the embedded manifest.
+func verifyPythonBackend(path string) error {
+	manifest, err := loadBackendManifest()
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		backendLog.Info("No backend manifest in this build; not checking the backend", "file", filepath.Base(path))
+		return nil
+	}
+	if manifest.File != "" && manifest.File != filepath.Base(path) {
+		return &backendIntegrityError{path, fmt.Sprintf("this build was made for %s", manifest.File)}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return &backendIntegrityError{path, err.Error()}
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return &backendIntegrityError{path, err.Error()}
+	}
+	if size != manifest.Size {
+		return &backendIntegrityError{path, fmt.Sprintf("size is %d bytes, expected %d", size, manifest.Size)}
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, manifest.SHA256) {
+		return &backendIntegrityError{path, "checksum does not match"}
+	}
+	backendLog.Info("Backend verified", "file", filepath.Base(path), "version", manifest.Version)
+	return nil
+}
+
+// reportBackendTampered asks the user to reinstall, since nothing else fixes a damaged backend.
+func (a *App) reportBackendTampered(err *backendIntegrityError) {
+	a.showAlert("error", "alert.backendTampered.title", "alert.backendTampered.message", nil, fmt.Sprintf("`%s`", err.Error()))
+	a.emitBackendStatus(false, i18n.New("alert.backendTampered.message", nil))
+}
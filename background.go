@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sync"
+)
+
+// With the "runInBackground" setting, closing the window only hides it: the Python backend,
+// the server and the caches stay warm for the next edit, and the tray icon or Resolve's
+// launch-or-focus bring the window back. Quitting from the tray (or an update restart) ends
+// the app. It needs the tray icon; see startTray.
+
+// pauseGate holds back new ffmpeg and waveform jobs while background jobs are paused. Jobs
+// that already run finish.
+type pauseGate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{} // closed when the gate opens again
+}
+
+// set pauses or resumes and reports whether that changed anything.
+func (g *pauseGate) set(paused bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused == paused {
+		return false
+	}
+	g.paused = paused
+	if paused {
+		g.resume = make(chan struct{})
+	} else {
+		close(g.resume)
+	}
+	return true
+}
+
+func (g *pauseGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// wait blocks while the gate is paused. It returns false if cancel is closed first.
+func (g *pauseGate) wait(cancel <-chan struct{}) bool {
+	g.mu.Lock()
+	paused, resume := g.paused, g.resume
+	g.mu.Unlock()
+	if !paused {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-cancel:
+		return false
+	}
+}
+
+// PauseBackgroundJobs holds back new ffmpeg and waveform jobs and the scheduled cache cleanup
+// until it is called with false. "jobs:paused" reports the new state.
+func (a *App) PauseBackgroundJobs(paused bool) {
+	if !a.jobsPaused.set(paused) {
+		return
+	}
+	if paused {
+		appLog.Info("Background jobs paused")
+	} else {
+		appLog.Info("Background jobs resumed")
+	}
+	a.emit("jobs:paused", paused)
+	a.tray.refresh()
+}
+
+func (a *App) GetBackgroundJobsPaused() bool {
+	return a.jobsPaused.isPaused()
+}
+
+// runsInBackground reports whether closing the window should only hide it.
+func (a *App) runsInBackground() bool {
+	if a.tray == nil {
+		return false
+	}
+	settings, err := a.GetSettings()
+	if err != nil {
+		return false
+	}
+	return settingBool(settings, "runInBackground", false)
+}
+
+// hideToBackground saves the window geometry and hides the window.
+func (a *App) hideToBackground() {
+	if err := a.updateSetting(windowStateSettingsKey, a.GetWindowState()); err != nil {
+		appLog.Warn("Could not save window state", "err", err)
+	}
+	hideWindow(a.ctx)
+	appLog.Info("Window hidden; HushCut keeps running in the background")
+}
+
+// quit ends the app, also when it runs in the background.
+func (a *App) quit() {
+	a.quitting.Store(true)
+	quitApp(a.ctx)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// After a sync, the audio, waveforms and silences of every clip that isn't cached yet are
+// prepared in the background, so a clip the user scrolls to usually has its data ready. Its
+// jobs are low priority: they only take a slot while no other job waits for one, and the
+// files of the visible clips still go first. The "backgroundAnalysis" setting turns it off.
+
+type backgroundAnalyzer struct {
+	mu      sync.Mutex
+	running bool
+	pending *ProjectDataPayload // the timeline to analyze when the current run ends
+}
+
+var backgroundAnalysis backgroundAnalyzer
+
+func (a *App) backgroundAnalysisEnabled() bool {
+	settings, err := a.GetSettings()
+	if err != nil {
+		settings = map[string]any{}
+	}
+	return settingBool(settings, "backgroundAnalysis", true)
+}
+
+// startBackgroundAnalysis analyzes project in the background. A sync during a run queues its
+// timeline for when the run ends, in place of any timeline queued before it.
+func (a *App) startBackgroundAnalysis(project *ProjectDataPayload) {
+	if project == nil || !a.backgroundAnalysisEnabled() {
+		return
+	}
+	b := &backgroundAnalysis
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = project
+	if b.running {
+		return
+	}
+	b.running = true
+	go saferun(func() {
+		done := false
+		defer func() {
+			if !done { // a run panicked; let the next sync start again
+				b.mu.Lock()
+				b.running = false
+				b.mu.Unlock()
+			}
+		}()
+		for {
+			b.mu.Lock()
+			next := b.pending
+			b.pending = nil
+			if next == nil {
+				b.running, done = false, true
+				b.mu.Unlock()
+				return
+			}
+			b.mu.Unlock()
+			a.analyzeInBackground(next)
+		}
+	})
+}
+
+// analyzeInBackground prepares project with the parameters of its timeline session, or the
+// current ones. Without any, the audio is only converted.
+func (a *App) analyzeInBackground(project *ProjectDataPayload) {
+	params, ok := a.backgroundParams(project)
+	prep := a.buildProjectPrep(project, ok, params)
+	prep.background = true
+	if len(prep.graph.order) == 0 {
+		return
+	}
+	var paths []string
+	for _, task := range prep.graph.order {
+		paths = append(paths, task.target)
+	}
+	defer slotQueue.lower(paths)()
+
+	started := time.Now()
+	err := prep.execute()
+	if errors.Is(err, errJobCancelled) {
+		ffmpegLog.Info("Background analysis cancelled", "timeline", project.Timeline.Name)
+		return
+	}
+	ffmpegLog.Info("Background analysis finished", "timeline", project.Timeline.Name,
+		"tasks", len(prep.graph.order), "clips", prep.analysis.Done, "failed", prep.analysis.Failed,
+		"elapsed", time.Since(started).Round(time.Millisecond), "err", err)
+}
+
+// backgroundParams returns the parameters to detect the clips of project with.
+func (a *App) backgroundParams(project *ProjectDataPayload) (DetectionParams, bool) {
+	if session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name); err == nil && session != nil && hasDetectionParams(session.Params) {
+		return session.Params, true
+	}
+	if params := a.GetCurrentParams(); hasDetectionParams(params) {
+		return params, true
+	}
+	return DetectionParams{}, false
+}
+
+func hasDetectionParams(params DetectionParams) bool {
+	return params.LoudnessThreshold != 0 || params.MinSilenceDurationSeconds != 0
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bspatch applies a patch in the classic BSDIFF40 format (as produced by bsdiff 4.x) to old.
+//
+// Layout: 32-byte header ("BSDIFF40", control length, diff length, new size), followed by
+// three bzip2 streams: control tuples, diff bytes and extra bytes.
+func bspatch(old []byte, patch []byte) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != "BSDIFF40" {
+		return nil, errors.New("not a BSDIFF40 patch")
+	}
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || 32+ctrlLen+diffLen > int64(len(patch)) {
+		return nil, errors.New("corrupt patch header")
+	}
+
+	ctrlReader := bzip2.NewReader(bytes.NewReader(patch[32 : 32+ctrlLen]))
+	diffReader := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen : 32+ctrlLen+diffLen]))
+	extraReader := bzip2.NewReader(bytes.NewReader(patch[32+ctrlLen+diffLen:]))
+
+	out := make([]byte, newSize)
+	var oldPos, newPos int64
+	var ctrl [24]byte
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrlReader, ctrl[:]); err != nil {
+			return nil, fmt.Errorf("corrupt patch control data: %w", err)
+		}
+		addLen := offtin(ctrl[0:8])
+		copyLen := offtin(ctrl[8:16])
+		seek := offtin(ctrl[16:24])
+
+		if addLen < 0 || copyLen < 0 || newPos+addLen > newSize {
+			return nil, errors.New("corrupt patch: add block out of range")
+		}
+		// Add diff bytes to the bytes from the old file.
+		if _, err := io.ReadFull(diffReader, out[newPos:newPos+addLen]); err != nil {
+			return nil, fmt.Errorf("corrupt patch diff data: %w", err)
+		}
+		for i := int64(0); i < addLen; i++ {
+			if oldPos+i >= 0 && oldPos+i < int64(len(old)) {
+				out[newPos+i] += old[oldPos+i]
+			}
+		}
+		newPos += addLen
+		oldPos += addLen
+
+		if newPos+copyLen > newSize {
+			return nil, errors.New("corrupt patch: copy block out of range")
+		}
+		// Copy extra bytes verbatim.
+		if _, err := io.ReadFull(extraReader, out[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("corrupt patch extra data: %w", err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+	return out, nil
+}
+
+// offtin decodes bsdiff's sign-magnitude little-endian 64-bit integers.
+func offtin(b []byte) int64 {
+	v := binary.LittleEndian.Uint64(b)
+	if v&(1<<63) != 0 {
+		return -int64(v &^ (1 << 63))
+	}
+	return int64(v)
+}
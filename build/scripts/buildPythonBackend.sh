@@ -49,6 +49,9 @@ mkdir -p "$(dirname "$TARGET_WAILS_BIN_DIR")"
 mv -f "dist/python_backend/$MAIN_FILE_NAME" "$TARGET_WAILS_BIN_DIR"
 chmod +x "$TARGET_WAILS_BIN_DIR"
 
+# Record the backend's checksum for the Go app to embed (see build/manifest/README.md)
+bash build/scripts/writeBackendManifest.sh "$TARGET_WAILS_BIN_DIR"
+
 # If we have a macOS .app bundle, copy directly into Contents/Resources
 MACAPP_DIR="build/bin/HushCut.app"
 if [ -d "$MACAPP_DIR" ]; then
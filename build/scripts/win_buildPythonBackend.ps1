@@ -60,6 +60,17 @@ try {
     $SourceFile = "dist\python_backend\$($MainFileName).exe"
     Move-Item -Path $SourceFile -Destination $TargetWailsBinFile -Force
 
+    # Record the backend's checksum for the Go app to embed (see build\manifest\README.md).
+    Write-Host "Writing backend manifest..."
+    $Version = (Get-Content "package.json" -Raw | ConvertFrom-Json).version
+    $Manifest = [ordered]@{
+        version = $Version
+        file    = "$($MainFileName).exe"
+        sha256  = (Get-FileHash -Algorithm SHA256 $TargetWailsBinFile).Hash.ToLower()
+        size    = (Get-Item $TargetWailsBinFile).Length
+    }
+    $Manifest | ConvertTo-Json | Set-Content -Path "build\manifest\backend.json" -Encoding UTF8
+
     # The macOS .app bundle logic is not applicable on Windows, but is kept here for reference.
     # if ($IsMacOS) { ... }
 
@@ -0,0 +1,31 @@
+#!/bin/bash
+# Records the checksum of the built Python backend for the Go app to embed.
+# Usage: writeBackendManifest.sh <path to python_backend or python_backend.exe>
+set -e
+
+BACKEND="$1"
+if [ ! -f "$BACKEND" ]; then
+    echo "writeBackendManifest: $BACKEND not found" >&2
+    exit 1
+fi
+
+SCRIPT_DIR=$(cd -- "$(dirname -- "${BASH_SOURCE[0]}")" &>/dev/null && pwd)
+PROJECT_ROOT="$SCRIPT_DIR/../.."
+
+if command -v sha256sum >/dev/null 2>&1; then
+    SHA256=$(sha256sum "$BACKEND" | cut -d' ' -f1)
+else
+    SHA256=$(shasum -a 256 "$BACKEND" | cut -d' ' -f1)
+fi
+SIZE=$(wc -c < "$BACKEND" | tr -d ' ')
+VERSION=$(node -p "require('$PROJECT_ROOT/package.json').version")
+
+cat > "$PROJECT_ROOT/build/manifest/backend.json" <<JSON
+{
+  "version": "$VERSION",
+  "file": "$(basename "$BACKEND")",
+  "sha256": "$SHA256",
+  "size": $SIZE
+}
+JSON
+echo "Backend manifest written for $(basename "$BACKEND") ($SHA256)"
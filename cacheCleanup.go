@@ -0,0 +1,471 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	bytesPerGB                  = 1024 * 1024 * 1024
+	defaultCleanupIntervalHours = 6
+)
+
+// CacheEvictionResult is emitted as "cache:evicted" after files were removed to honor the quota.
+type CacheEvictionResult struct {
+	FilesDeleted int   `json:"filesDeleted"`
+	BytesFreed   int64 `json:"bytesFreed"`
+	BytesUsed    int64 `json:"bytesUsed"`
+	QuotaBytes   int64 `json:"quotaBytes"`
+}
+
+type cachedFile struct {
+	path     string
+	size     int64
+	lastUsed time.Time
+}
+
+// listCachedWavs returns every WAV in the tmp folder with its size and last-use time.
+// Files without a usage record fall back to their modification time.
+// Must be called with a.mu held.
+func (a *App) listCachedWavsLocked() []cachedFile {
+	entries, err := os.ReadDir(a.tmpPath)
+	if err != nil {
+		cacheLog.Warn("Could not list cache folder", "dir", a.tmpPath, "err", err)
+		return nil
+	}
+
+	files := make([]cachedFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".wav") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fullPath := filepath.Join(a.tmpPath, entry.Name())
+		lastUsed, ok := a.fileUsage[fullPath]
+		if !ok {
+			lastUsed = info.ModTime()
+		}
+		files = append(files, cachedFile{path: fullPath, size: info.Size(), lastUsed: lastUsed})
+	}
+	return files
+}
+
+// evictToQuotaLocked deletes least-recently-used WAVs until the cache fits into
+// the "maxCacheSizeGB" setting. A value of 0 (the default) means no limit.
+// Must be called with a.mu held.
+func (a *App) evictToQuotaLocked(settings map[string]any) {
+	var maxGB float64
+	switch val := settings["maxCacheSizeGB"].(type) {
+	case float64:
+		maxGB = val
+	case int:
+		maxGB = float64(val)
+	}
+	if maxGB <= 0 {
+		return
+	}
+	quota := int64(maxGB * bytesPerGB)
+
+	files := a.listCachedWavsLocked()
+	var used int64
+	for _, f := range files {
+		used += f.size
+	}
+	if used <= quota {
+		return
+	}
+
+	cacheLog.Info("Cache is above its quota; evicting least recently used files", "usedGB", float64(used)/bytesPerGB, "quotaGB", maxGB)
+	sort.Slice(files, func(i, j int) bool { return files[i].lastUsed.Before(files[j].lastUsed) })
+
+	result := CacheEvictionResult{QuotaBytes: quota}
+	for _, f := range files {
+		if used <= quota {
+			break
+		}
+		if a.isFileBusy(f.path) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			cacheLog.Warn("Error evicting file", "path", f.path, "err", err)
+			continue
+		}
+		delete(a.fileUsage, f.path)
+		used -= f.size
+		result.FilesDeleted++
+		result.BytesFreed += f.size
+	}
+	result.BytesUsed = used
+
+	cacheLog.Info("Quota eviction done", "freedGB", float64(result.BytesFreed)/bytesPerGB, "files", result.FilesDeleted)
+	if a.ctx != nil {
+		a.emit("cache:evicted", result)
+	}
+}
+
+// Scopes accepted by ClearCache.
+const (
+	ClearScopeAll       = "all"
+	ClearScopeOlderThan = "olderThan"
+	ClearScopeProject   = "project"
+	ClearScopeWaveform  = "waveform"
+)
+
+// ClearCacheOptions narrows down what ClearCache removes.
+type ClearCacheOptions struct {
+	Scope         string `json:"scope"`
+	OlderThanDays int    `json:"olderThanDays,omitempty"` // for "olderThan"
+	ProjectName   string `json:"projectName,omitempty"`   // for "project"
+}
+
+// ClearCacheResult reports what a ClearCache call removed.
+type ClearCacheResult struct {
+	Scope           string `json:"scope"`
+	FilesDeleted    int    `json:"filesDeleted"`
+	FilesSkipped    int    `json:"filesSkipped"` // still in use by a running task
+	BytesReclaimed  int64  `json:"bytesReclaimed"`
+	WaveformEntries int    `json:"waveformEntries"`
+	SilenceEntries  int    `json:"silenceEntries"`
+	Transcripts     int    `json:"transcripts"`
+}
+
+// ClearCacheProgress is emitted as "cache:clearProgress" while ClearCache runs.
+type ClearCacheProgress struct {
+	Step       string  `json:"step"`
+	Done       int     `json:"done"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+// rememberProjectFiles stores which project each processed file was created for, its source
+// media and whether it is a compound clip mixdown. ClearCache and GetCacheStats build on this.
+func (a *App) rememberProjectFiles(projectData *ProjectDataPayload) {
+	if a.usageStore == nil {
+		return
+	}
+	annotate := func(fileName string, sourcePath string, mixdown bool) {
+		err := a.usageStore.update(fileName, func(rec *FileRecord) {
+			if rec.Project == "" {
+				rec.Project = projectData.ProjectName
+			}
+			if sourcePath != "" && !mixdown {
+				rec.SourcePath = sourcePath
+			}
+			rec.Mixdown = rec.Mixdown || mixdown
+		})
+		if err != nil {
+			cacheLog.Warn("Usage store: could not annotate file", "file", fileName, "err", err)
+		}
+	}
+
+	for _, item := range projectData.Timeline.AudioTrackItems {
+		if item.ProcessedFileName != nil && *item.ProcessedFileName != "" {
+			annotate(*item.ProcessedFileName, item.SourceFilePath, len(item.NestedClips) > 0)
+		}
+	}
+	compounds := planCompoundMixdowns(projectData.Timeline.AudioTrackItems)
+	for _, nested := range compounds.Sources {
+		annotate(nested.ProcessedFileName, nested.SourceFilePath, false)
+	}
+	for _, mixdown := range compounds.Mixdowns {
+		annotate(mixdown.FileName, "", true)
+	}
+}
+
+func (a *App) emitClearProgress(step string, done int, total int) {
+	pct := 100.0
+	if total > 0 {
+		pct = float64(done) / float64(total) * 100
+	}
+	a.emit("cache:clearProgress", ClearCacheProgress{Step: step, Done: done, Total: total, Percentage: pct})
+}
+
+// dropCacheEntriesForFiles removes in-memory analysis results belonging to deleted files.
+func (a *App) dropCacheEntriesForFiles(fileNames map[string]bool) (waveforms int, silences int) {
+	a.cacheMutex.Lock()
+	defer a.cacheMutex.Unlock()
+	for key := range a.waveformCache {
+		if fileNames[filepath.Base(key.FilePath)] {
+			delete(a.waveformCache, key)
+			cacheUsage.remove(key)
+			waveforms++
+		}
+	}
+	for key := range a.silenceCache {
+		if fileNames[filepath.Base(key.FilePath)] {
+			delete(a.silenceCache, key)
+			cacheUsage.remove(key)
+			silences++
+		}
+	}
+	return waveforms, silences
+}
+
+// ClearCache deletes cached audio and analysis data for the given scope:
+//   - "all":       every converted WAV and all in-memory caches
+//   - "olderThan": WAVs not used within OlderThanDays
+//   - "project":   WAVs first created for ProjectName
+//   - "waveform":  only the in-memory waveform peaks
+func (a *App) ClearCache(opts ClearCacheOptions) (*ClearCacheResult, error) {
+	result := &ClearCacheResult{Scope: opts.Scope}
+
+	if opts.Scope == ClearScopeWaveform {
+		a.emitClearProgress("waveforms", 0, 1)
+		a.cacheMutex.Lock()
+		result.WaveformEntries = len(a.waveformCache)
+		a.waveformCache = make(map[WaveformCacheKey]*PrecomputedWaveformData)
+		cacheUsage.removeWaveforms()
+		a.cacheMutex.Unlock()
+		a.emitClearProgress("waveforms", 1, 1)
+		return result, nil
+	}
+
+	a.mu.Lock()
+	candidates := a.listCachedWavsLocked()
+	a.mu.Unlock()
+
+	var selected []cachedFile
+	switch opts.Scope {
+	case ClearScopeAll:
+		selected = candidates
+	case ClearScopeOlderThan:
+		if opts.OlderThanDays < 0 {
+			return nil, fmt.Errorf("olderThanDays cannot be negative")
+		}
+		cutoff := time.Now().Add(-time.Duration(opts.OlderThanDays) * 24 * time.Hour)
+		for _, f := range candidates {
+			if f.lastUsed.Before(cutoff) {
+				selected = append(selected, f)
+			}
+		}
+	case ClearScopeProject:
+		records := a.fileRecords()
+		if records == nil {
+			return nil, fmt.Errorf("project information is unavailable (usage database not open)")
+		}
+		for _, f := range candidates {
+			if rec, ok := records[filepath.Base(f.path)]; ok && rec.Project == opts.ProjectName {
+				selected = append(selected, f)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown cache scope: '%s'", opts.Scope)
+	}
+
+	deletedNames := make(map[string]bool)
+	for i, f := range selected {
+		if a.isFileBusy(f.path) {
+			result.FilesSkipped++
+			a.emitClearProgress("files", i+1, len(selected))
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			cacheLog.Warn("ClearCache: could not delete file", "path", f.path, "err", err)
+		} else {
+			result.FilesDeleted++
+			result.BytesReclaimed += f.size
+			deletedNames[filepath.Base(f.path)] = true
+		}
+		a.emitClearProgress("files", i+1, len(selected))
+	}
+
+	a.mu.Lock()
+	for name := range deletedNames {
+		delete(a.fileUsage, filepath.Join(a.tmpPath, name))
+	}
+	a.mu.Unlock()
+
+	a.emitClearProgress("memory", 0, 1)
+	if opts.Scope == ClearScopeAll {
+		a.cacheMutex.Lock()
+		result.WaveformEntries = len(a.waveformCache)
+		result.SilenceEntries = len(a.silenceCache)
+		a.waveformCache = make(map[WaveformCacheKey]*PrecomputedWaveformData)
+		a.silenceCache = make(map[CacheKey][]SilencePeriod)
+		cacheUsage.removeAll()
+		a.cacheMutex.Unlock()
+		result.Transcripts = a.dropTranscripts(nil)
+	} else {
+		result.WaveformEntries, result.SilenceEntries = a.dropCacheEntriesForFiles(deletedNames)
+		result.Transcripts = a.dropTranscripts(deletedNames)
+	}
+	a.emitClearProgress("memory", 1, 1)
+
+	a.saveUsageData()
+	cacheLog.Info("Cache cleared", "scope", opts.Scope, "files", result.FilesDeleted, "bytes", result.BytesReclaimed)
+	a.emit("cache:cleared", result)
+	return result, nil
+}
+
+// CacheAgeBucket groups cached files by how long ago they were last used.
+type CacheAgeBucket struct {
+	Label string `json:"label"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// CacheFileStats summarises one kind of file in the cache folder.
+type CacheFileStats struct {
+	Files   int              `json:"files"`
+	Bytes   int64            `json:"bytes"`
+	Buckets []CacheAgeBucket `json:"buckets"`
+}
+
+// CacheMemoryStats summarises one of the in-memory analysis caches.
+type CacheMemoryStats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"` // approximate
+}
+
+// CacheStats is returned by GetCacheStats.
+type CacheStats struct {
+	CachePath  string           `json:"cachePath"`
+	Standard   CacheFileStats   `json:"standard"` // converted source audio
+	Mixdowns   CacheFileStats   `json:"mixdowns"` // compound clip mixdowns
+	Waveforms  CacheMemoryStats `json:"waveforms"`
+	Silences   CacheMemoryStats `json:"silences"`
+	TotalBytes int64            `json:"totalBytes"`
+	// The budget of the in-memory caches, and how many entries were evicted to keep to it.
+	MemoryBudgetBytes int64 `json:"memoryBudgetBytes"`
+	MemoryEvictions   int64 `json:"memoryEvictions"`
+}
+
+var cacheAgeBuckets = []struct {
+	label  string
+	maxAge time.Duration
+}{
+	{"today", 24 * time.Hour},
+	{"thisWeek", 7 * 24 * time.Hour},
+	{"thisMonth", 30 * 24 * time.Hour},
+	{"older", 0},
+}
+
+func newCacheFileStats() CacheFileStats {
+	stats := CacheFileStats{Buckets: make([]CacheAgeBucket, len(cacheAgeBuckets))}
+	for i, b := range cacheAgeBuckets {
+		stats.Buckets[i].Label = b.label
+	}
+	return stats
+}
+
+func (s *CacheFileStats) add(f cachedFile, now time.Time) {
+	s.Files++
+	s.Bytes += f.size
+	age := now.Sub(f.lastUsed)
+	for i, b := range cacheAgeBuckets {
+		if b.maxAge == 0 || age < b.maxAge {
+			s.Buckets[i].Files++
+			s.Buckets[i].Bytes += f.size
+			return
+		}
+	}
+}
+
+// GetCacheStats reports what HushCut is currently storing, so users can decide what to clear.
+func (a *App) GetCacheStats() CacheStats {
+	stats := CacheStats{
+		CachePath: a.tmpPath,
+		Standard:  newCacheFileStats(),
+		Mixdowns:  newCacheFileStats(),
+	}
+	now := time.Now()
+	records := a.fileRecords()
+
+	a.mu.Lock()
+	for _, f := range a.listCachedWavsLocked() {
+		if records[filepath.Base(f.path)].Mixdown {
+			stats.Mixdowns.add(f, now)
+		} else {
+			stats.Standard.add(f, now)
+		}
+	}
+	a.mu.Unlock()
+
+	a.cacheMutex.RLock()
+	stats.Waveforms.Entries = len(a.waveformCache)
+	stats.Silences.Entries = len(a.silenceCache)
+	a.cacheMutex.RUnlock()
+	memory := cacheUsage.usage()
+	stats.Waveforms.Bytes = memory.WaveformBytes
+	stats.Silences.Bytes = memory.SilenceBytes
+	stats.MemoryBudgetBytes = memory.BudgetBytes
+	stats.MemoryEvictions = memory.Evictions
+
+	stats.TotalBytes = stats.Standard.Bytes + stats.Mixdowns.Bytes + stats.Waveforms.Bytes + stats.Silences.Bytes
+	return stats
+}
+
+// isFileBusy reports whether a conversion or mixdown is writing to path, or the file
+// is otherwise referenced (see fileRefs.go).
+func (a *App) isFileBusy(path string) bool {
+	if _, busy := a.progressTracker.Load(path); busy {
+		return true
+	}
+	return a.isFileReferenced(path)
+}
+
+// hasActiveTasks reports whether any conversion, mixdown or download is in progress.
+func (a *App) hasActiveTasks() bool {
+	active := false
+	a.progressTracker.Range(func(_, _ any) bool {
+		active = true
+		return false
+	})
+	return active
+}
+
+// cleanupInterval returns the "cleanupIntervalHours" setting, or the default when it is unset
+// or out of range.
+func (a *App) cleanupInterval() time.Duration {
+	if settings, err := a.GetSettings(); err == nil {
+		hours := settingInt(settings, "cleanupIntervalHours", defaultCleanupIntervalHours)
+		if hours >= 1 && hours <= maxCleanupIntervalHours {
+			return time.Duration(hours) * time.Hour
+		}
+	}
+	return time.Duration(defaultCleanupIntervalHours) * time.Hour
+}
+
+// runCleanupScheduler periodically runs the age and quota cleanup while the app stays open.
+// The interval comes from the "cleanupIntervalHours" setting. When settings are applied the
+// wait is re-timed against the new interval, counted from the previous run, so a shorter
+// interval takes effect without waiting out the old one.
+// A run is postponed while tasks are active so it never competes with processing.
+func (a *App) runCleanupScheduler() {
+	for {
+		since := time.Now()
+		timer := time.NewTimer(a.cleanupInterval())
+	wait:
+		for {
+			select {
+			case <-a.ctx.Done():
+				timer.Stop()
+				return
+			case <-a.cleanupSettingsChanged:
+				timer.Stop()
+				timer.Reset(max(a.cleanupInterval()-time.Since(since), 0))
+			case <-timer.C:
+				break wait
+			}
+		}
+
+		for a.hasActiveTasks() || a.GetBackgroundJobsPaused() {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-time.After(time.Minute):
+			}
+		}
+
+		cacheLog.Info("Scheduled cache cleanup starting")
+		a.cleanupOldFiles()
+		a.saveUsageData()
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// The waveform and silence caches live in memory for the whole session, and an hour-long
+// multi-track timeline analyzed with a few parameter sets adds up. Every entry is accounted
+// with its approximate size and when it was last used; once the caches hold more than the
+// "cacheMemoryBudgetMB" setting, the least recently used entries are evicted. An evicted entry
+// is computed again when it is needed.
+
+const (
+	defaultCacheMemoryBudgetMB = 512
+	minCacheMemoryBudgetMB     = 16
+	maxCacheMemoryBudgetMB     = 64 * 1024
+)
+
+type cacheEntryUsage struct {
+	bytes    int64
+	lastUsed time.Time
+}
+
+type cacheMemory struct {
+	mu            sync.Mutex
+	entries       map[any]*cacheEntryUsage // by WaveformCacheKey or CacheKey
+	waveformBytes int64
+	silenceBytes  int64
+	budget        int64
+	evictions     int64
+}
+
+var cacheUsage = &cacheMemory{
+	entries: map[any]*cacheEntryUsage{},
+	budget:  defaultCacheMemoryBudgetMB << 20,
+}
+
+func waveformDataBytes(data *PrecomputedWaveformData) int64 {
+	if data == nil {
+		return 0
+	}
+	return int64(len(data.Peaks)) * 8
+}
+
+func silencePeriodsBytes(periods []SilencePeriod) int64 {
+	return int64(len(periods)) * 16
+}
+
+func (c *cacheMemory) setBudget(mb int) {
+	if mb < minCacheMemoryBudgetMB {
+		mb = minCacheMemoryBudgetMB
+	}
+	c.mu.Lock()
+	c.budget = int64(mb) << 20
+	c.mu.Unlock()
+}
+
+// add accounts key, replacing what it held before.
+func (c *cacheMemory) add(key any, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+	c.entries[key] = &cacheEntryUsage{bytes: bytes, lastUsed: time.Now()}
+	c.countLocked(key, bytes)
+}
+
+// touch marks key as used now.
+func (c *cacheMemory) touch(key any) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		entry.lastUsed = time.Now()
+	}
+	c.mu.Unlock()
+}
+
+func (c *cacheMemory) remove(key any) {
+	c.mu.Lock()
+	c.removeLocked(key)
+	c.mu.Unlock()
+}
+
+func (c *cacheMemory) removeLocked(key any) {
+	if entry, ok := c.entries[key]; ok {
+		c.countLocked(key, -entry.bytes)
+		delete(c.entries, key)
+	}
+}
+
+func (c *cacheMemory) countLocked(key any, bytes int64) {
+	switch key.(type) {
+	case WaveformCacheKey:
+		c.waveformBytes += bytes
+	case CacheKey:
+		c.silenceBytes += bytes
+	}
+}
+
+// removeWaveforms forgets every waveform entry, for when the waveform cache is replaced.
+func (c *cacheMemory) removeWaveforms() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if _, ok := key.(WaveformCacheKey); ok {
+			c.removeLocked(key)
+		}
+	}
+}
+
+// removeAll forgets every entry, for when both caches are replaced.
+func (c *cacheMemory) removeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[any]*cacheEntryUsage{}
+	c.waveformBytes, c.silenceBytes = 0, 0
+}
+
+// overBudget returns the least recently used entries, other than keep, to evict to get back
+// within the budget, and forgets them.
+func (c *cacheMemory) overBudget(keep any) []any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	excess := c.waveformBytes + c.silenceBytes - c.budget
+	if excess <= 0 {
+		return nil
+	}
+	keys := make([]any, 0, len(c.entries))
+	for key := range c.entries {
+		if key != keep {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].lastUsed.Before(c.entries[keys[j]].lastUsed)
+	})
+	var evict []any
+	for _, key := range keys {
+		if excess <= 0 {
+			break
+		}
+		excess -= c.entries[key].bytes
+		c.removeLocked(key)
+		evict = append(evict, key)
+	}
+	c.evictions += int64(len(evict))
+	return evict
+}
+
+// CacheMemoryUsage is what the in-memory caches hold against their budget.
+type CacheMemoryUsage struct {
+	WaveformBytes int64 `json:"waveformBytes"`
+	SilenceBytes  int64 `json:"silenceBytes"`
+	BudgetBytes   int64 `json:"budgetBytes"`
+	Evictions     int64 `json:"evictions"` // since startup
+}
+
+func (c *cacheMemory) usage() CacheMemoryUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheMemoryUsage{
+		WaveformBytes: c.waveformBytes,
+		SilenceBytes:  c.silenceBytes,
+		BudgetBytes:   c.budget,
+		Evictions:     c.evictions,
+	}
+}
+
+// storeWaveformLocked caches data under key and evicts what no longer fits the budget.
+// a.cacheMutex is held for writing.
+func (a *App) storeWaveformLocked(key WaveformCacheKey, data *PrecomputedWaveformData) {
+	a.waveformCache[key] = data
+	cacheUsage.add(key, waveformDataBytes(data))
+	a.evictCacheLocked(key)
+}
+
+// storeSilencesLocked is storeWaveformLocked for detected silences.
+func (a *App) storeSilencesLocked(key CacheKey, silences []SilencePeriod) {
+	a.silenceCache[key] = silences
+	cacheUsage.add(key, silencePeriodsBytes(silences))
+	a.evictCacheLocked(key)
+}
+
+func (a *App) evictCacheLocked(keep any) {
+	evicted := cacheUsage.overBudget(keep)
+	for _, key := range evicted {
+		switch k := key.(type) {
+		case WaveformCacheKey:
+			delete(a.waveformCache, k)
+		case CacheKey:
+			delete(a.silenceCache, k)
+		}
+	}
+	if len(evicted) > 0 {
+		waveformLog.Debug("Evicted in-memory cache entries over the memory budget", "entries", len(evicted))
+	}
+}
+
+// setCacheMemoryBudget changes the budget and evicts right away what no longer fits.
+func (a *App) setCacheMemoryBudget(mb int) {
+	cacheUsage.setBudget(mb)
+	a.cacheMutex.Lock()
+	a.evictCacheLocked(nil)
+	a.cacheMutex.Unlock()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// CancelAllJobs stops the work a sync started, e.g. by mistake on a huge project: running
+// ffmpeg conversions, mixdowns, detections and transcriptions are killed, and ffmpeg and waveform jobs that
+// wait for a slot give up. Waveform jobs that already run finish; they only read a WAV that
+// is already there. Jobs queued after the cancel run as usual.
+
+const (
+	jobConversion = "conversion"
+	jobMixdown    = "mixdown"
+	jobDetection  = "detection"
+	jobWaveform   = "waveform"
+	// whisper.cpp runs of GetTranscript
+	jobTranscription = "transcription"
+)
+
+// errJobCancelled is returned by jobs that CancelAllJobs stopped.
+var errJobCancelled = errors.New("job cancelled")
+
+// CancelledJobs is sent as "jobs:cancelled".
+type CancelledJobs struct {
+	Conversions    int `json:"conversions"`
+	Mixdowns       int `json:"mixdowns"`
+	Detections     int `json:"detections"`
+	Transcriptions int `json:"transcriptions"`
+	Queued         int `json:"queued"` // ffmpeg and waveform jobs that were waiting for a slot
+}
+
+// slotJob is a job that waits for or holds an ffmpeg or waveform slot.
+type slotJob struct {
+	kind      string
+	target    string
+	pool      string // "ffmpeg", "waveform" or "detection"
+	queuedAt  time.Time
+	startedAt time.Time // zero while queued
+}
+
+type jobRegistry struct {
+	mu        sync.Mutex
+	cancelled chan struct{}        // closed by cancelAll, then replaced for the jobs that follow
+	running   map[*exec.Cmd]string // ffmpeg jobs by kind
+	stopped   map[*exec.Cmd]bool   // killed by cancelAll
+	waiting   []*slotJob           // in the order they were queued
+	holding   map[*slotJob]bool
+}
+
+// activeJobs records the jobs of this process, for CancelAllJobs and ListActiveJobs.
+var activeJobs jobRegistry
+
+// queue registers a job that waits for a slot and returns the channel that is closed if it
+// is cancelled meanwhile. acquired or dequeue must follow.
+func (r *jobRegistry) queue(job *slotJob) <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelled == nil {
+		r.cancelled = make(chan struct{})
+	}
+	job.queuedAt = time.Now()
+	r.waiting = append(r.waiting, job)
+	return r.cancelled
+}
+
+// dequeue forgets a job that gave up waiting.
+func (r *jobRegistry) dequeue(job *slotJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeWaiting(job)
+}
+
+// acquired moves a job from the queue to the slot holders. released must follow.
+func (r *jobRegistry) acquired(job *slotJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeWaiting(job)
+	if r.holding == nil {
+		r.holding = map[*slotJob]bool{}
+	}
+	job.startedAt = time.Now()
+	r.holding[job] = true
+}
+
+func (r *jobRegistry) released(job *slotJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.holding, job)
+}
+
+// removeWaiting takes job out of the queue. r.mu is held.
+func (r *jobRegistry) removeWaiting(job *slotJob) {
+	for i, waiting := range r.waiting {
+		if waiting == job {
+			r.waiting = append(r.waiting[:i], r.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// start records a running ffmpeg job.
+func (r *jobRegistry) start(kind string, cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = map[*exec.Cmd]string{}
+		r.stopped = map[*exec.Cmd]bool{}
+	}
+	r.running[cmd] = kind
+}
+
+// finish forgets a job that has exited and reports whether cancelAll killed it.
+func (r *jobRegistry) finish(cmd *exec.Cmd) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stopped := r.stopped[cmd]
+	delete(r.running, cmd)
+	delete(r.stopped, cmd)
+	return stopped
+}
+
+// cancelAll kills the running jobs and releases the queued ones.
+func (r *jobRegistry) cancelAll() CancelledJobs {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := CancelledJobs{Queued: len(r.waiting)}
+	for cmd, kind := range r.running {
+		if r.stopped[cmd] || cmd.Process == nil {
+			continue
+		}
+		if err := cmd.Process.Kill(); err != nil {
+			appLog.Warn("Could not stop job", "kind", kind, "pid", cmd.Process.Pid, "err", err)
+			continue
+		}
+		r.stopped[cmd] = true
+		switch kind {
+		case jobConversion:
+			result.Conversions++
+		case jobMixdown:
+			result.Mixdowns++
+		case jobDetection:
+			result.Detections++
+		case jobTranscription:
+			result.Transcriptions++
+		}
+	}
+	if r.cancelled != nil {
+		close(r.cancelled)
+		r.cancelled = nil
+	}
+	return result
+}
+
+// runJob is runTracked for an ffmpeg job that CancelAllJobs can stop; it then returns
+// errJobCancelled.
+func runJob(kind string, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	limitStarted(cmd)
+	childProcesses.track(cmd)
+	defer childProcesses.untrack(cmd)
+	activeJobs.start(kind, cmd)
+	err := cmd.Wait()
+	if activeJobs.finish(cmd) {
+		return errJobCancelled
+	}
+	return err
+}
+
+// CancelAllJobs stops running and queued jobs and returns what was stopped, which is also
+// sent as "jobs:cancelled".
+func (a *App) CancelAllJobs() CancelledJobs {
+	result := activeJobs.cancelAll()
+	appLog.Info("Cancelled all jobs", "conversions", result.Conversions, "mixdowns", result.Mixdowns,
+		"detections", result.Detections, "transcriptions", result.Transcriptions, "queued", result.Queued)
+	a.emit("jobs:cancelled", result)
+	return result
+}
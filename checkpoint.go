@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// While the app runs, what is in flight (Python tasks, standardization jobs and the outputs
+// ffmpeg is still writing) is checkpointed to <state dir>/checkpoints/<pid>.json. A clean
+// shutdown removes the file; finding one whose process is gone means that session crashed.
+// The next start then deletes the half-written outputs, so they are never taken for valid
+// cache files, reports what was interrupted and offers to resume the standardization jobs.
+
+const (
+	checkpointsDirName = "checkpoints"
+	checkpointInterval = 2 * time.Second
+)
+
+// CheckpointTask is a Python task (sync, makeFinalTimeline, ...) that was waiting for its result.
+type CheckpointTask struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// StandardizeJob is a conversion of a source file's audio to the WAV in the cache.
+type StandardizeJob struct {
+	InputPath     string         `json:"inputPath"`
+	OutputPath    string         `json:"outputPath"`
+	SourceChannel *SourceChannel `json:"sourceChannel,omitempty"`
+	StartedAt     time.Time      `json:"startedAt"`
+}
+
+// sessionCheckpoint is the content of a checkpoint file.
+type sessionCheckpoint struct {
+	Session trackedProcess            `json:"session"`
+	SavedAt time.Time                 `json:"savedAt"`
+	Tasks   map[string]CheckpointTask `json:"tasks"`
+	Jobs    map[string]StandardizeJob `json:"jobs"`    // by output path
+	Writing map[string]time.Time      `json:"writing"` // outputs that are not complete yet
+}
+
+// InterruptedSession is what earlier sessions left unfinished when they crashed.
+type InterruptedSession struct {
+	LastCheckpoint   time.Time        `json:"lastCheckpoint"`
+	Tasks            []CheckpointTask `json:"tasks"`
+	Jobs             []StandardizeJob `json:"jobs"`
+	DiscardedOutputs []string         `json:"discardedOutputs"`
+}
+
+type checkpointer struct {
+	mu    sync.Mutex
+	path  string
+	state sessionCheckpoint
+	dirty bool
+}
+
+func checkpointsDir() (string, error) {
+	base, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, checkpointsDirName), nil
+}
+
+func newCheckpointer() (*checkpointer, error) {
+	dir, err := checkpointsDir()
+	if err != nil {
+		return nil, err
+	}
+	pid := os.Getpid()
+	identity, err := processIdentity(pid)
+	if err != nil {
+		return nil, err
+	}
+	return &checkpointer{
+		path: filepath.Join(dir, fmt.Sprintf("%d.json", pid)),
+		state: sessionCheckpoint{
+			Session: trackedProcess{PID: pid, Name: "hushcut", Identity: identity},
+			Tasks:   map[string]CheckpointTask{},
+			Jobs:    map[string]StandardizeJob{},
+			Writing: map[string]time.Time{},
+		},
+	}, nil
+}
+
+// A nil *checkpointer (the CLI, or when the state dir is unusable) records nothing.
+
+func (c *checkpointer) taskStarted(id, command string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Tasks[id] = CheckpointTask{ID: id, Command: command, StartedAt: time.Now()}
+	c.dirty = true
+}
+
+func (c *checkpointer) taskFinished(id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.state.Tasks, id)
+	c.dirty = true
+}
+
+// Outputs are saved right away rather than with the next checkpoint: an output that is not
+// recorded before ffmpeg writes to it, or still recorded after it is complete, would
+// survive a crash as a corrupt file or be deleted although it is valid.
+
+// jobStarted records a standardization job whose output ffmpeg is about to write.
+func (c *checkpointer) jobStarted(job StandardizeJob) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	job.StartedAt = time.Now()
+	c.state.Jobs[job.OutputPath] = job
+	c.state.Writing[job.OutputPath] = job.StartedAt
+	c.save()
+}
+
+func (c *checkpointer) jobFinished(outputPath string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.state.Jobs, outputPath)
+	delete(c.state.Writing, outputPath)
+	c.save()
+}
+
+// writing marks an output other than a standardization job, e.g. a mixdown, as incomplete.
+func (c *checkpointer) writing(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Writing[path] = time.Now()
+	c.save()
+}
+
+func (c *checkpointer) written(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.state.Writing, path)
+	c.save()
+}
+
+// run saves the checkpoint every checkpointInterval while something changed, until ctx ends.
+func (c *checkpointer) run(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.dirty {
+				c.save()
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// close removes the checkpoint on a clean shutdown.
+func (c *checkpointer) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		appLog.Warn("Could not remove checkpoint", "path", c.path, "err", err)
+	}
+	c.dirty = false
+}
+
+// save writes the checkpoint atomically. c.mu is held.
+func (c *checkpointer) save() {
+	c.state.SavedAt = time.Now()
+	data, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		appLog.Warn("Could not save checkpoint", "err", err)
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		appLog.Warn("Could not save checkpoint", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		appLog.Warn("Could not save checkpoint", "err", err)
+		return
+	}
+	c.dirty = false
+}
+
+// recoverInterruptedSessions reads the checkpoints of crashed sessions, deletes their
+// half-written outputs and removes the checkpoints. It returns nil if nothing was
+// interrupted. Checkpoints of sessions that still run are left alone.
+func recoverInterruptedSessions() *InterruptedSession {
+	dir, err := checkpointsDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	report := &InterruptedSession{
+		Tasks:            []CheckpointTask{},
+		Jobs:             []StandardizeJob{},
+		DiscardedOutputs: []string{},
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var checkpoint sessionCheckpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			appLog.Warn("Discarding unreadable checkpoint", "path", path, "err", err)
+			os.Remove(path)
+			continue
+		}
+		if checkpoint.Session.isRunning() {
+			continue
+		}
+
+		appLog.Warn("Earlier session did not shut down cleanly", "session", checkpoint.Session.PID, "lastCheckpoint", checkpoint.SavedAt.Format(time.RFC3339))
+		for output := range checkpoint.Writing {
+			if err := os.Remove(output); err == nil {
+				appLog.Info("Deleted incomplete output", "path", output)
+				report.DiscardedOutputs = append(report.DiscardedOutputs, output)
+			} else if !os.IsNotExist(err) {
+				appLog.Warn("Could not delete incomplete output", "path", output, "err", err)
+			}
+		}
+		for _, task := range checkpoint.Tasks {
+			report.Tasks = append(report.Tasks, task)
+		}
+		for _, job := range checkpoint.Jobs {
+			report.Jobs = append(report.Jobs, job)
+		}
+		if checkpoint.SavedAt.After(report.LastCheckpoint) {
+			report.LastCheckpoint = checkpoint.SavedAt
+		}
+		if err := os.Remove(path); err != nil {
+			appLog.Warn("Could not remove checkpoint", "path", path, "err", err)
+		}
+	}
+	if len(report.Tasks) == 0 && len(report.Jobs) == 0 && len(report.DiscardedOutputs) == 0 {
+		return nil
+	}
+	sort.Slice(report.Tasks, func(i, j int) bool { return report.Tasks[i].StartedAt.Before(report.Tasks[j].StartedAt) })
+	sort.Slice(report.Jobs, func(i, j int) bool { return report.Jobs[i].StartedAt.Before(report.Jobs[j].StartedAt) })
+	return report
+}
+
+// startCheckpointing recovers from crashed sessions and starts checkpointing this one. A
+// crash report is kept for GetInterruptedSession and announced as "session:interrupted".
+func (a *App) startCheckpointing() {
+	if report := recoverInterruptedSessions(); report != nil {
+		a.interruptedMu.Lock()
+		a.interrupted = report
+		a.interruptedMu.Unlock()
+		a.emit("session:interrupted", report)
+	}
+	checkpoint, err := newCheckpointer()
+	if err != nil {
+		appLog.Warn("Not checkpointing this session", "err", err)
+		return
+	}
+	a.checkpoint = checkpoint
+	go saferun(func() { checkpoint.run(a.ctx) })
+}
+
+// GetInterruptedSession returns what a crashed earlier session left unfinished, or nil.
+func (a *App) GetInterruptedSession() *InterruptedSession {
+	a.interruptedMu.Lock()
+	defer a.interruptedMu.Unlock()
+	return a.interrupted
+}
+
+// DismissInterruptedSession forgets the crash report without resuming anything.
+func (a *App) DismissInterruptedSession() {
+	a.interruptedMu.Lock()
+	defer a.interruptedMu.Unlock()
+	a.interrupted = nil
+}
+
+// ResumeInterruptedJobs restarts the standardization jobs of the crash report in the
+// background. Progress arrives as the usual "conversion:*" events; the number of jobs started
+// is returned.
+func (a *App) ResumeInterruptedJobs() int {
+	a.interruptedMu.Lock()
+	report := a.interrupted
+	a.interrupted = nil
+	a.interruptedMu.Unlock()
+	if report == nil {
+		return 0
+	}
+
+	started := 0
+	for _, job := range report.Jobs {
+		if _, err := os.Stat(job.InputPath); err != nil {
+			ffmpegLog.Warn("Not resuming interrupted standardization", "input", job.InputPath, "err", err)
+			continue
+		}
+		started++
+		job := job
+		go saferun(func() {
+			release, err := a.acquireFfmpegSlot(jobConversion, job.OutputPath)
+			if err != nil {
+				return
+			}
+			defer release()
+			if err := a.StandardizeAudioToWav(job.InputPath, job.OutputPath, job.SourceChannel); err != nil {
+				ffmpegLog.Error("Resumed standardization failed", "file", job.InputPath, "err", err)
+			}
+		})
+	}
+	ffmpegLog.Info("Resuming interrupted standardization jobs", "jobs", started)
+	return started
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+
+	"github.com/go-audio/wav"
+	"github.com/google/uuid"
+)
+
+// Headless command line mode, run as `hushcut detect <media file> [flags]`. It standardizes
+// the file with ffmpeg, runs the same silence detection as the app and prints the silences
+// without opening a window or talking to Resolve. `hushcut cut` (cliCut.go) goes one step
+// further and renders the media with the silences removed; `hushcut watch` (cliWatch.go) does
+// either for every new file in a folder, and `hushcut peaks` (cliPeaks.go) exports the waveform.
+
+// cliSubcommand returns the subcommand HushCut was started with, or "" for the app.
+func cliSubcommand() string {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "detect", "cut", "watch", "peaks":
+			return os.Args[1]
+		}
+	}
+	return ""
+}
+
+// runCLI runs the subcommand and returns the process exit code (see cliOutput.go).
+func runCLI(subcommand string, args []string) int {
+	r := &cliRun{command: subcommand}
+	defer childProcesses.close()
+	var result interface{}
+	var err error
+	switch subcommand {
+	case "detect":
+		result, err = runDetectCLI(r, args)
+	case "cut":
+		result, err = runCutCLI(r, args)
+	case "watch":
+		result, err = runWatchCLI(r, args)
+	case "peaks":
+		result, err = runPeaksCLI(r, args)
+	default:
+		err = invalidInput("unknown command %q", subcommand)
+	}
+	return r.finish(result, err)
+}
+
+// DetectResult is the JSON output for one media file.
+type DetectResult struct {
+	File     string          `json:"file"`
+	Duration float64         `json:"duration"`
+	Params   DetectionParams `json:"params"`
+	Silences []SilencePeriod `json:"silences"`
+}
+
+// detectOptions are the detect flags besides the detection parameters.
+type detectOptions struct {
+	start   float64
+	end     float64
+	channel int
+}
+
+// headlessFlags are the flags of every subcommand that runs ffmpeg.
+type headlessFlags struct {
+	ffmpegPath *string
+	verbose    *bool
+	quiet      *bool
+}
+
+func addHeadlessFlags(fs *flag.FlagSet) headlessFlags {
+	f := headlessFlags{
+		ffmpegPath: fs.String("ffmpeg", "", "ffmpeg binary to use (default: HushCut's managed ffmpeg, then PATH)"),
+		verbose:    fs.Bool("verbose", false, "log details to stderr; progress is printed as lines instead of bars"),
+		quiet:      fs.Bool("quiet", false, "print no progress, only errors"),
+	}
+	fs.BoolVar(f.verbose, "v", false, "short for -verbose")
+	fs.BoolVar(f.quiet, "q", false, "short for -quiet")
+	return f
+}
+
+// setup quiets logging unless asked for, creates the headless app with its progress display
+// and checks the license. Call it after parsing.
+func (f headlessFlags) setup() (*App, func(), error) {
+	if !*f.verbose && os.Getenv(logLevelEnvVar) == "" {
+		logLevels.set(slog.LevelWarn, nil)
+	}
+	a, cleanup, err := newHeadlessApp(*f.ffmpegPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !a.HasAValidLicense() {
+		cleanup()
+		return nil, nil, &cliError{exitCode: exitLicense, err: errors.New("no valid HushCut license found: activate HushCut in the app first")}
+	}
+	a.cliProgress = newCLIProgress(os.Stderr, *f.quiet, *f.verbose)
+	a.events = a.cliProgress
+	return a, func() {
+		a.cliProgress.close()
+		cleanup()
+	}, nil
+}
+
+// detectFlags are the flags shared by the subcommands that run silence detection.
+type detectFlags struct {
+	headlessFlags
+	params  DetectionParams
+	padding *float64
+	preset  *string
+	opts    detectOptions
+}
+
+func addDetectFlags(fs *flag.FlagSet) *detectFlags {
+	f := &detectFlags{}
+	fs.Float64Var(&f.params.LoudnessThreshold, "threshold", -30, "loudness threshold in dB")
+	fs.Float64Var(&f.params.MinSilenceDurationSeconds, "min-duration", 0.5, "minimum silence duration in seconds")
+	fs.Float64Var(&f.params.PaddingLeftSeconds, "padding-left", 0.05, "padding kept before content in seconds")
+	fs.Float64Var(&f.params.PaddingRightSeconds, "padding-right", 0.05, "padding kept after content in seconds")
+	f.padding = fs.Float64("padding", -1, "sets both -padding-left and -padding-right")
+	fs.Float64Var(&f.params.MinContent, "min-content", 0.1, "minimum content duration in seconds; shorter content is merged into silence")
+	f.preset = fs.String("preset", "", "name of a saved preset or path to a preset file; other flags override its values")
+	fs.Float64Var(&f.opts.start, "start", 0, "only analyze from this time in seconds")
+	fs.Float64Var(&f.opts.end, "end", 0, "only analyze up to this time in seconds (0 = end of file)")
+	fs.IntVar(&f.opts.channel, "channel", 0, "audio channel to analyze; all channels of its stream are mixed")
+	f.headlessFlags = addHeadlessFlags(fs)
+	return f
+}
+
+// resolveParams applies the preset, if any, and then the flags that were given explicitly.
+func (f *detectFlags) resolveParams(fs *flag.FlagSet, a *App) (DetectionParams, error) {
+	params := f.params
+	if *f.preset != "" {
+		p, err := a.loadCLIPreset(*f.preset)
+		if err != nil {
+			return params, &cliError{exitCode: exitInvalidInput, err: err}
+		}
+		params = p.Params
+		// Flags given explicitly win over the preset.
+		fs.Visit(func(fl *flag.Flag) {
+			switch fl.Name {
+			case "threshold":
+				params.LoudnessThreshold = f.params.LoudnessThreshold
+			case "min-duration":
+				params.MinSilenceDurationSeconds = f.params.MinSilenceDurationSeconds
+			case "padding-left":
+				params.PaddingLeftSeconds = f.params.PaddingLeftSeconds
+			case "padding-right":
+				params.PaddingRightSeconds = f.params.PaddingRightSeconds
+			case "min-content":
+				params.MinContent = f.params.MinContent
+			}
+		})
+	}
+	if *f.padding >= 0 {
+		params.PaddingLeftSeconds, params.PaddingRightSeconds = *f.padding, *f.padding
+	}
+	return params, nil
+}
+
+func runDetectCLI(r *cliRun, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("detect", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hushcut detect <media file> [flags]")
+		fmt.Fprintln(fs.Output(), "       hushcut detect -dir <folder> [-recursive] [flags]")
+		fs.PrintDefaults()
+	}
+	df := addDetectFlags(fs)
+	r.addRunFlags(fs)
+	format := fs.String("format", "json", "output format: json or csv")
+	output := fs.String("o", "", "write the result to this file instead of stdout")
+	dir := fs.String("dir", "", "process every media file in this folder")
+	recursive := fs.Bool("recursive", false, "with -dir: include subfolders")
+	outDir := fs.String("out-dir", "", "with -dir: write results here instead of next to each input")
+	jobs := fs.Int("jobs", defaultFfmpegConcurrency, "with -dir: number of files processed in parallel")
+
+	positional, err := r.parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *dir != "" && len(positional) > 0 || *dir == "" && len(positional) != 1 {
+		fs.Usage()
+		return nil, usageError(errors.New("expected one media file or -dir"))
+	}
+	if *format != "json" && *format != "csv" {
+		return nil, invalidInput("unsupported format %q: use json or csv", *format)
+	}
+	if *dir != "" {
+		if info, err := os.Stat(*dir); err != nil || !info.IsDir() {
+			return nil, invalidInput("%s is not a folder", *dir)
+		}
+	} else if err := checkInputFile(positional[0]); err != nil {
+		return nil, err
+	}
+
+	a, cleanup, err := df.setup()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	params, err := df.resolveParams(fs, a)
+	if err != nil {
+		return nil, err
+	}
+
+	if *dir != "" {
+		a.resizeSemaphores(*jobs, defaultWaveformConcurrency, *jobs)
+		summary, err := a.detectFolder(*dir, *recursive, *outDir, *format, params, df.opts)
+		if summary == nil {
+			return nil, err
+		}
+		return summary, err
+	}
+
+	a.cliProgress.setTotal(1)
+	a.cliProgress.start(positional[0], positional[0])
+	result, err := a.detectFile(positional[0], params, df.opts)
+	a.cliProgress.finish(positional[0], err, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// With --json the result is part of the CLIOutput; it only goes elsewhere if -o asks for it.
+	if r.json && *output == "" {
+		return result, nil
+	}
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeDetectResult(out, *format, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadCLIPreset reads a preset file, or a saved preset by name.
+func (a *App) loadCLIPreset(nameOrPath string) (*Preset, error) {
+	if info, err := os.Stat(nameOrPath); err == nil && !info.IsDir() {
+		return readPresetFile(nameOrPath)
+	}
+	return readPresetFile(a.getPresetPath(nameOrPath))
+}
+
+// parseInterspersed parses flags that appear before and after positional arguments, so both
+// `detect -threshold -40 a.mp4` and `detect a.mp4 -threshold -40` work.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newHeadlessApp sets up an App that can run ffmpeg jobs without Wails. Intermediate WAVs go
+// to a temporary folder that the returned cleanup func removes.
+func newHeadlessApp(ffmpegPath string) (*App, func(), error) {
+	a := NewApp()
+	a.headless = true
+	a.ctx = context.Background()
+
+	if ffmpegPath == "" {
+		ffmpegPath = findHeadlessFfmpeg()
+		if ffmpegPath == "" {
+			return nil, nil, &cliError{exitCode: exitFfmpegMissing, err: errors.New("ffmpeg not found: install it, start HushCut once to download it, or pass -ffmpeg")}
+		}
+	} else if !binaryExists(ffmpegPath) {
+		return nil, nil, &cliError{exitCode: exitFfmpegMissing, err: fmt.Errorf("ffmpeg at %s does not run", ffmpegPath)}
+	}
+	a.ffmpegBinaryPath = ffmpegPath
+	a.ffmpegStatus = StatusReady
+	a.signalFfmpegReady()
+
+	// Presets, settings, the device identity and the license live here, as for the app.
+	if configDir, err := os.UserConfigDir(); err == nil {
+		a.userResourcesPath = filepath.Join(configDir, "HushCut")
+		os.MkdirAll(a.userResourcesPath, 0755)
+	}
+	a.policy = loadPolicy()
+	a.licenseVerifyKey = PublicKeyPEM
+	machineID, err := a.getMachineID()
+	if err != nil {
+		licenseLog.Warn("Could not retrieve machine ID", "err", err)
+	}
+	a.machineID = machineID
+
+	tmp, err := os.MkdirTemp("", "hushcut-cli-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create temporary folder: %w", err)
+	}
+	a.tmpPath = tmp
+	return a, func() {
+		a.releaseSeat()
+		os.RemoveAll(tmp)
+	}, nil
+}
+
+// findHeadlessFfmpeg looks for the ffmpeg the app downloads, then for one on PATH.
+func findHeadlessFfmpeg() string {
+	name := "ffmpeg"
+	if goruntime.GOOS == "windows" {
+		name = "ffmpeg.exe"
+	}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		managed := filepath.Join(configDir, "HushCut", name)
+		if binaryExists(managed) {
+			return managed
+		}
+	}
+	if path, err := exec.LookPath("ffmpeg"); err == nil {
+		return path
+	}
+	return ""
+}
+
+// checkInputFile reports a missing or unreadable input as invalid input. Commands call it
+// before setup, so a typo in the path isn't reported as missing ffmpeg or license.
+func checkInputFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return &cliError{exitCode: exitInvalidInput, err: err}
+	}
+	if info.IsDir() {
+		return invalidInput("%s is a folder, not a media file", path)
+	}
+	return nil
+}
+
+// standardizeForCLI converts channel of mediaPath to a WAV in the app's tmp folder. It returns
+// the absolute media path and the WAV's name there; the caller removes the WAV.
+func (a *App) standardizeForCLI(mediaPath string, channel int) (absPath, wavName string, err error) {
+	absPath, err = filepath.Abs(mediaPath)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return "", "", &cliError{exitCode: exitInvalidInput, err: err}
+	}
+
+	wavName = uuid.NewMD5(uuid.Nil, []byte(absPath)).String() + ".wav"
+	wavPath := filepath.Join(a.tmpPath, wavName)
+	a.cliProgress.alias(wavPath, mediaPath)
+	a.cliProgress.alias(wavName, mediaPath) // waveform events name the file relative to tmpPath
+	a.cliProgress.stage(mediaPath, "converting", true)
+	if err := a.StandardizeAudioToWav(absPath, wavPath, &SourceChannel{ChannelIndex: channel}); err != nil {
+		os.Remove(wavPath)
+		return "", "", err
+	}
+	return absPath, wavName, nil
+}
+
+// detectFile standardizes mediaPath into the app's tmp folder and detects its silences.
+func (a *App) detectFile(mediaPath string, params DetectionParams, opts detectOptions) (*DetectResult, error) {
+	absPath, wavName, err := a.standardizeForCLI(mediaPath, opts.channel)
+	if err != nil {
+		return nil, err
+	}
+	wavPath := filepath.Join(a.tmpPath, wavName)
+	defer os.Remove(wavPath)
+	a.cliProgress.stage(mediaPath, "detecting silences", false)
+
+	duration, err := wavDuration(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	end := opts.end
+	if end <= 0 || end > duration {
+		end = duration
+	}
+
+	silences, err := a.DetectSilences(wavName,
+		params.LoudnessThreshold,
+		params.MinSilenceDurationSeconds,
+		params.PaddingLeftSeconds,
+		params.PaddingRightSeconds,
+		params.MinContent,
+		opts.start,
+		end,
+		0,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("silence detection failed for %s: %w", mediaPath, err)
+	}
+	return &DetectResult{File: absPath, Duration: duration, Params: params, Silences: silences}, nil
+}
+
+func wavDuration(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	d, err := wav.NewDecoder(f).Duration()
+	if err != nil {
+		return 0, fmt.Errorf("could not read duration of %s: %w", path, err)
+	}
+	return d.Seconds(), nil
+}
+
+func writeDetectResult(w io.Writer, format string, result *DetectResult) error {
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"start", "end", "duration"})
+		for _, s := range result.Silences {
+			cw.Write([]string{formatSeconds(s.Start), formatSeconds(s.End), formatSeconds(s.End - s.Start)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
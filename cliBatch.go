@@ -0,0 +1,184 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Folder mode of `hushcut detect`: every media file in -dir is processed with the same
+// parameters, in parallel up to -jobs ffmpeg slots. Each input gets a
+// "<file name>.silences.json" (or .csv) next to it or under -out-dir, and a summary of all files
+// is written as hushcut-summary.json.
+
+const detectSummaryFileName = "hushcut-summary.json"
+
+var cliMediaExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".m4a": true, ".aac": true, ".flac": true, ".ogg": true,
+	".opus": true, ".aif": true, ".aiff": true, ".mov": true, ".mp4": true, ".m4v": true,
+	".mkv": true, ".mxf": true, ".avi": true, ".webm": true, ".mts": true, ".m2ts": true,
+}
+
+// DetectSummaryEntry is one file's line in the batch summary.
+type DetectSummaryEntry struct {
+	File           string  `json:"file"`
+	Output         string  `json:"output,omitempty"`
+	Duration       float64 `json:"duration,omitempty"`
+	Silences       int     `json:"silences"`
+	SilenceSeconds float64 `json:"silenceSeconds"`
+	Error          string  `json:"error,omitempty"`
+}
+
+func (e DetectSummaryEntry) err() error {
+	if e.Error == "" {
+		return nil
+	}
+	return errors.New(e.Error)
+}
+
+// DetectSummary is written to hushcut-summary.json after a folder run.
+type DetectSummary struct {
+	Dir       string               `json:"dir"`
+	Params    DetectionParams      `json:"params"`
+	StartedAt time.Time            `json:"startedAt"`
+	Elapsed   float64              `json:"elapsedSeconds"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Files     []DetectSummaryEntry `json:"files"`
+}
+
+// findMediaFiles lists the media files in dir, sorted, skipping hidden files and folders.
+func findMediaFiles(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		hidden := strings.HasPrefix(d.Name(), ".") && path != dir
+		if d.IsDir() {
+			if path != dir && (!recursive || hidden) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hidden && cliMediaExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// detectOutputPath is where the result for input is written.
+func detectOutputPath(dir, outDir, input, format string) string {
+	// Keep the extension so clip.mov and clip.wav don't overwrite each other's results
+	name := filepath.Base(input) + ".silences." + format
+	if outDir == "" {
+		return filepath.Join(filepath.Dir(input), name)
+	}
+	rel, err := filepath.Rel(dir, filepath.Dir(input))
+	if err != nil {
+		rel = ""
+	}
+	return filepath.Join(outDir, rel, name)
+}
+
+// detectFolder processes all media files in dir. The summary is returned even if some files
+// failed; the error then says how many.
+func (a *App) detectFolder(dir string, recursive bool, outDir string, format string, params DetectionParams, opts detectOptions) (*DetectSummary, error) {
+	files, err := findMediaFiles(dir, recursive)
+	if err != nil {
+		return nil, invalidInput("could not read %s: %v", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, invalidInput("no media files found in %s", dir)
+	}
+
+	summary := DetectSummary{Dir: dir, Params: params, StartedAt: time.Now(), Files: make([]DetectSummaryEntry, len(files))}
+	a.cliProgress.setTotal(len(files))
+	var wg sync.WaitGroup
+
+	for i, file := range files {
+		wg.Add(1)
+		go saferun(func() {
+			defer wg.Done()
+			release, err := a.acquireFfmpegSlot(jobDetection, file)
+			if err != nil {
+				summary.Files[i] = DetectSummaryEntry{File: file, Error: err.Error()}
+				return
+			}
+			defer release()
+
+			a.cliProgress.start(file, file)
+			entry := a.detectFolderEntry(dir, outDir, file, format, params, opts)
+			summary.Files[i] = entry
+			a.cliProgress.finish(file, entry.err(), entry.Output)
+		})
+	}
+	wg.Wait()
+
+	for _, entry := range summary.Files {
+		if entry.Error != "" {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+	}
+	summary.Elapsed = time.Since(summary.StartedAt).Seconds()
+
+	summaryDir := cmp.Or(outDir, dir)
+	summaryPath := filepath.Join(summaryDir, detectSummaryFileName)
+	data, _ := json.MarshalIndent(summary, "", "  ")
+	if err := os.MkdirAll(summaryDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write summary: %v\n", err)
+	} else if err := writeFileAtomic(summaryPath, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write summary: %v\n", err)
+	}
+	a.cliProgress.printf("%d of %d files processed, %d failed; summary: %s", summary.Succeeded, len(files), summary.Failed, summaryPath)
+
+	if summary.Failed > 0 {
+		return &summary, fmt.Errorf("%d of %d files failed", summary.Failed, len(files))
+	}
+	return &summary, nil
+}
+
+func (a *App) detectFolderEntry(dir, outDir, file, format string, params DetectionParams, opts detectOptions) DetectSummaryEntry {
+	entry := DetectSummaryEntry{File: file}
+	result, err := a.detectFile(file, params, opts)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Duration = result.Duration
+	entry.Silences = len(result.Silences)
+	for _, s := range result.Silences {
+		entry.SilenceSeconds += s.End - s.Start
+	}
+
+	outPath := detectOutputPath(dir, outDir, file, format)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer f.Close()
+	if err := writeDetectResult(f, format, result); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Output = outPath
+	return entry
+}
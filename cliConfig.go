@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CLI defaults can live in a config file so batch commands don't need a wall of flags. Keys are
+// flag names (out-dir or out_dir, preset, ffmpeg, jobs, threshold, ...; output_dir, ffmpeg_path
+// and concurrency work too) and give the default for every subcommand that has that flag; a
+// [detect], [cut], ... section applies to that subcommand only. Flags on the command line
+// always win.
+//
+//	preset = "Lecture"
+//	ffmpeg = "/opt/ffmpeg/bin/ffmpeg"
+//	jobs = 4
+//
+//	[watch]
+//	out-dir = "~/Recordings/cut"
+//
+// The file is the one given with -config or HUSHCUT_CONFIG, otherwise the first of
+// ./hushcut.toml, ./.hushcutrc, <user config dir>/HushCut/hushcut.toml and ~/.hushcutrc.
+// It is a subset of TOML: strings, numbers and booleans, no arrays or tables beyond sections.
+
+const cliConfigEnvVar = "HUSHCUT_CONFIG"
+
+// cliConfigAliases are config keys that read better in a file than the flag name.
+var cliConfigAliases = map[string]string{
+	"concurrency": "jobs",
+	"output-dir":  "out-dir",
+	"ffmpeg-path": "ffmpeg",
+}
+
+// cliConfig maps section ("" for top level) to key to raw value.
+type cliConfig map[string]map[string]string
+
+// findCLIConfig returns the config file to use, or "" if there is none.
+func findCLIConfig() string {
+	if p := os.Getenv(cliConfigEnvVar); p != "" {
+		return p
+	}
+	candidates := []string{"hushcut.toml", ".hushcutrc"}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(configDir, "HushCut", "hushcut.toml"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".hushcutrc"))
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+func loadCLIConfig(path string) (cliConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := cliConfig{"": {}}
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: malformed section header", path, lineNo)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if cfg[section] == nil {
+				cfg[section] = map[string]string{}
+			}
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNo)
+		}
+		key = strings.ReplaceAll(strings.Trim(strings.TrimSpace(key), `"`), "_", "-")
+		if flagName, ok := cliConfigAliases[key]; ok {
+			key = flagName
+		}
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, lineNo, key, err)
+		}
+		cfg[section][key] = value
+	}
+	return cfg, scanner.Err()
+}
+
+// stripConfigComment removes a # comment that is not inside a string.
+func stripConfigComment(line string) string {
+	var inDouble, inSingle, escaped bool
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inDouble && c == '\\':
+			escaped = true
+		case inDouble:
+			inDouble = c != '"'
+		case inSingle:
+			inSingle = c != '\''
+		case c == '"':
+			inDouble = true
+		case c == '\'':
+			inSingle = true
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", errors.New("missing value")
+	case strings.HasPrefix(raw, `"`):
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "["), strings.HasPrefix(raw, "{"):
+		return "", errors.New("arrays and inline tables are not supported")
+	}
+	// TOML allows 1_000; anything else unquoted (numbers, booleans, bare words) is taken as is.
+	if number := strings.ReplaceAll(raw, "_", ""); number != raw {
+		if _, err := strconv.ParseFloat(number, 64); err == nil {
+			return number, nil
+		}
+	}
+	return raw, nil
+}
+
+// apply sets the flags of fs that were not given on the command line from the config's top
+// level and the command's section.
+func (c cliConfig) apply(fs *flag.FlagSet, command, path string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	values := map[string]string{}
+	for k, v := range c[""] {
+		values[k] = v
+	}
+	for k, v := range c[command] {
+		values[k] = v
+	}
+	for key, value := range values {
+		if explicit[key] || fs.Lookup(key) == nil {
+			continue
+		}
+		if strings.HasPrefix(value, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				value = filepath.Join(home, value[2:])
+			}
+		}
+		if err := fs.Set(key, value); err != nil {
+			return invalidInput("%s: %s: %v", path, key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// `hushcut cut <media file> -o <output>` detects the silences like `hushcut detect` and renders
+// the media without them: every kept range is trimmed out of the input and the pieces are
+// concatenated. Audio outputs (.wav, .mp3, ...) get the audio only; any other container keeps
+// the video too. An existing output is only replaced with -force.
+
+var cliAudioOutputExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".m4a": true, ".aac": true, ".flac": true, ".ogg": true,
+	".opus": true, ".aif": true, ".aiff": true,
+}
+
+// CutResult is the --json result of `hushcut cut`.
+type CutResult struct {
+	File           string          `json:"file"`
+	Output         string          `json:"output"`
+	Start          float64         `json:"start"`
+	End            float64         `json:"end"`
+	KeptSeconds    float64         `json:"keptSeconds"`
+	RemovedSeconds float64         `json:"removedSeconds"`
+	Params         DetectionParams `json:"params"`
+	Silences       []SilencePeriod `json:"silences"`
+}
+
+func runCutCLI(r *cliRun, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("cut", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hushcut cut <media file> -o <output file> [flags]")
+		fmt.Fprintln(fs.Output(), "The output's extension picks the format: .wav, .mp3, ... render audio only, .mp4, .mov, ... keep the video.")
+		fs.PrintDefaults()
+	}
+	df := addDetectFlags(fs)
+	r.addRunFlags(fs)
+	output := fs.String("o", "", "output file (required)")
+	force := fs.Bool("force", false, "overwrite the output file if it exists")
+
+	positional, err := r.parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 || *output == "" {
+		fs.Usage()
+		return nil, usageError(errors.New("expected one media file and -o"))
+	}
+	if sameFile(positional[0], *output) {
+		return nil, invalidInput("the output file must not be the input file")
+	}
+	if err := checkInputFile(positional[0]); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(*output); err == nil && !*force {
+		return nil, invalidInput("%s already exists: pass -force to overwrite it", *output)
+	}
+
+	a, cleanup, err := df.setup()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	params, err := df.resolveParams(fs, a)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cliProgress.setTotal(1)
+	a.cliProgress.start(positional[0], positional[0])
+	result, err := a.cutFile(positional[0], *output, *force, params, df.opts)
+	a.cliProgress.finish(positional[0], err, "")
+	if err != nil {
+		return nil, err
+	}
+	if !r.json {
+		a.cliProgress.printf("removed %d silences, kept %.1fs of %.1fs: %s", len(result.Silences), result.KeptSeconds, result.End-result.Start, *output)
+	}
+	return result, nil
+}
+
+// cutFile detects the silences of inputPath and renders it without them to outputPath, which is
+// only replaced if overwrite is set.
+func (a *App) cutFile(inputPath, outputPath string, overwrite bool, params DetectionParams, opts detectOptions) (*CutResult, error) {
+	detected, err := a.detectFile(inputPath, params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := opts.start, opts.end
+	if end <= 0 || end > detected.Duration {
+		end = detected.Duration
+	}
+	a.cliProgress.alias(outputPath, inputPath)
+	a.cliProgress.stage(inputPath, "rendering", true)
+	kept, err := a.renderCut(detected.File, outputPath, overwrite, start, end, detected.Silences)
+	if err != nil {
+		return nil, err
+	}
+	outPath, _ := filepath.Abs(outputPath)
+	return &CutResult{
+		File:           detected.File,
+		Output:         outPath,
+		Start:          start,
+		End:            end,
+		KeptSeconds:    kept,
+		RemovedSeconds: end - start - kept,
+		Params:         params,
+		Silences:       detected.Silences,
+	}, nil
+}
+
+// renderCut writes inputPath's range [startSec, endSec] minus silences to outputPath and returns
+// the duration that was kept. An existing outputPath is only replaced if overwrite is set.
+func (a *App) renderCut(inputPath, outputPath string, overwrite bool, startSec, endSec float64, silences []SilencePeriod) (float64, error) {
+	outputPath, err := filepath.Abs(outputPath) // ffmpeg runs in a directory of its own
+	if err != nil {
+		return 0, err
+	}
+	ranges := keepRanges(startSec, endSec, silences)
+	kept := keptDuration(startSec, endSec, silences)
+	if kept <= floatEpsilon {
+		return 0, fmt.Errorf("%s is silent from %.2fs to %.2fs; nothing to render", inputPath, startSec, endSec)
+	}
+	if dir := filepath.Dir(outputPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, err
+		}
+	}
+
+	// The graph grows with every silence, past what a command line takes on Windows, so it
+	// goes to ffmpeg as a file.
+	withVideo := !cliAudioOutputExtensions[strings.ToLower(filepath.Ext(outputPath))] && a.hasVideoStream(inputPath)
+	script, err := os.CreateTemp(a.tmpPath, "cut-*.filter")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(script.Name())
+	_, err = script.WriteString(buildCutFilterGraph(ranges, withVideo))
+	if closeErr := script.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	overwriteFlag := "-n"
+	if overwrite {
+		overwriteFlag = "-y"
+	}
+	args := []string{overwriteFlag, "-i", inputPath, "-filter_complex_script", script.Name()}
+	if withVideo {
+		args = append(args, "-map", "[v]")
+	}
+	args = append(args, "-map", "[a]", "-hide_banner", "-loglevel", "error", "-progress", "pipe:1", outputPath)
+
+	ffmpegLog.Info("Rendering cut", "file", inputPath, "output", outputPath, "silencesRemoved", len(silences))
+	cmd := a.ffmpegCommand(args...)
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+	cmd.Stdout = &renderProgress{a: a, outputPath: outputPath, totalUs: kept * 1e6}
+	started := time.Now()
+	err = runTracked(cmd)
+	auditFFmpeg("cut", cmd, started, err, stderr.String())
+	if err != nil {
+		return 0, fmt.Errorf("ffmpeg failed to render %s: %w: %s", outputPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return kept, nil
+}
+
+// buildCutFilterGraph trims every range out of the first audio stream (and the first video
+// stream withVideo) and concatenates the pieces into [a] (and [v]). atrim cuts at exact
+// samples, and each piece's video and audio are joined as one segment, so they stay in sync
+// across any number of cuts.
+func buildCutFilterGraph(ranges [][2]float64, withVideo bool) string {
+	var graph, pads strings.Builder
+	for i, r := range ranges {
+		if withVideo {
+			fmt.Fprintf(&graph, "[0:v:0]trim=start=%.6f:end=%.6f,setpts=PTS-STARTPTS[v%d];\n", r[0], r[1], i)
+			fmt.Fprintf(&pads, "[v%d]", i)
+		}
+		fmt.Fprintf(&graph, "[0:a:0]atrim=start=%.6f:end=%.6f,asetpts=PTS-STARTPTS[a%d];\n", r[0], r[1], i)
+		fmt.Fprintf(&pads, "[a%d]", i)
+	}
+	if withVideo {
+		fmt.Fprintf(&graph, "%sconcat=n=%d:v=1:a=1[v][a]\n", pads.String(), len(ranges))
+	} else {
+		fmt.Fprintf(&graph, "%sconcat=n=%d:v=0:a=1[a]\n", pads.String(), len(ranges))
+	}
+	return graph.String()
+}
+
+// hasVideoStream reports whether ffmpeg finds a video stream in inputPath.
+func (a *App) hasVideoStream(inputPath string) bool {
+	cmd := a.ffmpegCommand("-hide_banner", "-i", inputPath)
+	var info bytes.Buffer
+	cmd.Stderr = &info
+	started := time.Now()
+	err := runTracked(cmd) // fails for want of an output, but lists the streams first
+	auditFFmpeg("probe", cmd, started, err, info.String())
+	videoStreams, _ := parseFFmpegStreams(info.String())
+	return len(videoStreams) > 0
+}
+
+// renderProgress parses ffmpeg's -progress output and emits render:progress events.
+type renderProgress struct {
+	a          *App
+	outputPath string
+	totalUs    float64
+	partial    []byte
+}
+
+func (p *renderProgress) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		line := string(p.partial[:i])
+		p.partial = p.partial[i+1:]
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key != "out_time_us" {
+			continue
+		}
+		us, err := strconv.ParseFloat(value, 64)
+		if err != nil || p.totalUs <= 0 {
+			continue
+		}
+		pct := math.Min(100, us/p.totalUs*100)
+		p.a.emit("render:progress", ProgressStatus{FilePath: p.outputPath, Percentage: pct, TaskType: "render"})
+	}
+}
+
+// keptDuration is how much of [startSec, endSec] is left once silences are removed.
+func keptDuration(startSec, endSec float64, silences []SilencePeriod) float64 {
+	var kept float64
+	for _, r := range keepRanges(startSec, endSec, silences) {
+		kept += r[1] - r[0]
+	}
+	return kept
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
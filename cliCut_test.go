@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeepRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end float64
+		silences   []SilencePeriod
+		want       [][2]float64
+	}{
+		{"no silences", 0, 10, nil, [][2]float64{{0, 10}}},
+		{"silence inside", 0, 10, []SilencePeriod{{Start: 2, End: 3}}, [][2]float64{{0, 2}, {3, 10}}},
+		{"unsorted and overlapping", 0, 10, []SilencePeriod{{Start: 6, End: 8}, {Start: 2, End: 4}, {Start: 3, End: 5}}, [][2]float64{{0, 2}, {5, 6}, {8, 10}}},
+		{"silence at both ends", 0, 10, []SilencePeriod{{Start: 0, End: 1}, {Start: 9, End: 10}}, [][2]float64{{1, 9}}},
+		{"silence past the range", 2, 8, []SilencePeriod{{Start: 0, End: 3}, {Start: 7, End: 12}}, [][2]float64{{3, 7}}},
+		{"all silent", 0, 10, []SilencePeriod{{Start: 0, End: 10}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepRanges(tt.start, tt.end, tt.silences); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keepRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCutFilterGraph(t *testing.T) {
+	ranges := [][2]float64{{0, 1.5}, {2.25, 4}}
+	tests := []struct {
+		name      string
+		withVideo bool
+		want      string
+	}{
+		{"audio only", false, "" +
+			"[0:a:0]atrim=start=0.000000:end=1.500000,asetpts=PTS-STARTPTS[a0];\n" +
+			"[0:a:0]atrim=start=2.250000:end=4.000000,asetpts=PTS-STARTPTS[a1];\n" +
+			"[a0][a1]concat=n=2:v=0:a=1[a]\n"},
+		{"with video", true, "" +
+			"[0:v:0]trim=start=0.000000:end=1.500000,setpts=PTS-STARTPTS[v0];\n" +
+			"[0:a:0]atrim=start=0.000000:end=1.500000,asetpts=PTS-STARTPTS[a0];\n" +
+			"[0:v:0]trim=start=2.250000:end=4.000000,setpts=PTS-STARTPTS[v1];\n" +
+			"[0:a:0]atrim=start=2.250000:end=4.000000,asetpts=PTS-STARTPTS[a1];\n" +
+			"[v0][a0][v1][a1]concat=n=2:v=1:a=1[v][a]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildCutFilterGraph(ranges, tt.withVideo); got != tt.want {
+				t.Errorf("buildCutFilterGraph() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Thousands of silences make a long graph, but one line per piece and no quoting.
+	many := make([][2]float64, 3000)
+	for i := range many {
+		many[i] = [2]float64{float64(2 * i), float64(2*i + 1)}
+	}
+	if got := strings.Count(buildCutFilterGraph(many, true), "\n"); got != 2*len(many)+1 {
+		t.Errorf("graph of %d ranges has %d lines, want %d", len(many), got, 2*len(many)+1)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes of the CLI subcommands. Scripts and CI pipelines rely on them; don't renumber.
+const (
+	exitOK            = 0
+	exitFailed        = 1 // processing failed, e.g. ffmpeg errored or a batch had failures
+	exitInvalidInput  = 2 // bad flags, missing or unreadable input files
+	exitFfmpegMissing = 3
+	exitLicense       = 4
+)
+
+// cliOutputSchemaVersion is bumped whenever a field of CLIOutput or of a result is removed or
+// changes meaning. Adding fields does not bump it.
+const cliOutputSchemaVersion = 1
+
+// CLIOutput is what a subcommand prints to stdout when run with --json: exactly one JSON
+// object, whether the command succeeded or not. Result depends on the command: DetectResult
+// for `detect <file>`, DetectSummary for `detect -dir`, CutResult for `cut`, WatchSummary
+// for `watch` and PeaksResult for `peaks`.
+type CLIOutput struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	Command       string      `json:"command"`
+	OK            bool        `json:"ok"`
+	ExitCode      int         `json:"exitCode"`
+	Error         *CLIError   `json:"error,omitempty"`
+	Result        interface{} `json:"result,omitempty"`
+}
+
+// CLIError describes why a command failed. Code is one of "failed", "invalid_input",
+// "ffmpeg_missing" or "license", matching the exit code.
+type CLIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var cliErrorCodes = map[int]string{
+	exitFailed:        "failed",
+	exitInvalidInput:  "invalid_input",
+	exitFfmpegMissing: "ffmpeg_missing",
+	exitLicense:       "license",
+}
+
+// cliError is an error with the exit code it should produce. Errors of any other type exit
+// with exitFailed.
+type cliError struct {
+	exitCode int
+	err      error
+	reported bool // already printed to stderr, e.g. by the flag package
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func invalidInput(format string, args ...interface{}) error {
+	return &cliError{exitCode: exitInvalidInput, err: fmt.Errorf(format, args...)}
+}
+
+// usageError reports a flag or argument error the flag package has already printed.
+func usageError(err error) error {
+	return &cliError{exitCode: exitInvalidInput, err: err, reported: true}
+}
+
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.exitCode
+	}
+	return exitFailed
+}
+
+// cliRun is the state shared by runCLI and the subcommand it runs.
+type cliRun struct {
+	command    string
+	json       bool
+	configPath string
+}
+
+// addRunFlags adds the flags every subcommand has.
+func (r *cliRun) addRunFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&r.json, "json", false, "print a single JSON object with the result or the error to stdout")
+	fs.StringVar(&r.configPath, "config", "", "config file with flag defaults (default: ./hushcut.toml, ./.hushcutrc, then HushCut's config folder and ~/.hushcutrc)")
+}
+
+// parse parses args and fills in the flags that weren't given from the config file. It returns
+// the positional arguments.
+func (r *cliRun) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	path := r.configPath
+	if path == "" {
+		path = findCLIConfig()
+	}
+	if path == "" {
+		return positional, nil
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return nil, invalidInput("could not read config: %v", err)
+	}
+	if err := cfg.apply(fs, r.command, path); err != nil {
+		return nil, err
+	}
+	return positional, nil
+}
+
+// finish reports the outcome of a subcommand and returns its exit code. In --json mode that is
+// the CLIOutput on stdout; otherwise errors go to stderr and the command has printed its
+// result itself.
+func (r *cliRun) finish(result interface{}, err error) int {
+	code := exitCodeFor(err)
+	if r.json {
+		writeCLIOutput(os.Stdout, r.command, code, result, err)
+		return code
+	}
+	var ce *cliError
+	if err != nil && !(errors.As(err, &ce) && ce.reported) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return code
+}
+
+func writeCLIOutput(w io.Writer, command string, code int, result interface{}, err error) {
+	out := CLIOutput{
+		SchemaVersion: cliOutputSchemaVersion,
+		Command:       command,
+		OK:            code == exitOK,
+		ExitCode:      code,
+		Result:        result,
+	}
+	if err != nil {
+		out.Error = &CLIError{Code: cliErrorCodes[code], Message: err.Error()}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(out)
+}
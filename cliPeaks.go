@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/go-audio/wav"
+)
+
+// `hushcut peaks <media file>` writes the waveform peaks the app displays, so web players and
+// review tools can draw the same waveform. "json" is PeaksResult; "binary" is the audiowaveform
+// .dat format (version 1, 8-bit) that peaks.js and waveform-data.js read.
+
+// PeaksResult is the JSON output of `hushcut peaks`.
+type PeaksResult struct {
+	File            string    `json:"file"`
+	Duration        float64   `json:"duration"`
+	SampleRate      int       `json:"sampleRate"`
+	SamplesPerPixel int       `json:"samplesPerPixel"`
+	Scale           string    `json:"scale"`
+	MinDb           float64   `json:"minDb,omitempty"`
+	Output          string    `json:"output,omitempty"`
+	Peaks           []float64 `json:"peaks,omitempty"` // 0..1, one per samplesPerPixel frames; with --json and -o only in the file
+}
+
+func runPeaksCLI(r *cliRun, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("peaks", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hushcut peaks <media file> [-spp 512] [-format json|binary] [-o file] [flags]")
+		fs.PrintDefaults()
+	}
+	hf := addHeadlessFlags(fs)
+	r.addRunFlags(fs)
+	spp := fs.Int("spp", 512, "samples per pixel: audio frames summarized by each peak")
+	format := fs.String("format", "json", "output format: json, or binary for the audiowaveform .dat format")
+	scale := fs.String("scale", "logarithmic", "peak scale: logarithmic (as shown in HushCut) or linear")
+	minDb := fs.Float64("min-db", -60, "with -scale logarithmic: level shown as silence")
+	channel := fs.Int("channel", 0, "audio channel to use; all channels of its stream are mixed")
+	output := fs.String("o", "", "write the peaks to this file instead of stdout")
+
+	positional, err := r.parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return nil, usageError(errors.New("expected one media file"))
+	}
+	if *format != "json" && *format != "binary" {
+		return nil, invalidInput("unsupported format %q: use json or binary", *format)
+	}
+	if *scale != "logarithmic" && *scale != "linear" {
+		return nil, invalidInput("unsupported scale %q: use logarithmic or linear", *scale)
+	}
+	if *spp < 1 {
+		return nil, invalidInput("-spp must be at least 1")
+	}
+	if *minDb >= 0 {
+		return nil, invalidInput("-min-db must be below 0")
+	}
+	if r.json && *format == "binary" && *output == "" {
+		return nil, invalidInput("binary peaks need -o when --json is used")
+	}
+	if err := checkInputFile(positional[0]); err != nil {
+		return nil, err
+	}
+
+	a, cleanup, err := hf.setup()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	a.cliProgress.setTotal(1)
+	a.cliProgress.start(positional[0], positional[0])
+	result, err := a.peaksFile(positional[0], *spp, *scale, *minDb, *channel)
+	a.cliProgress.finish(positional[0], err, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if r.json && *output == "" {
+		return result, nil
+	}
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		out = f
+	}
+	if *format == "binary" {
+		err = writeAudiowaveformDat(out, result)
+	} else {
+		err = json.NewEncoder(out).Encode(result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.json {
+		// The file has the peaks; keep the CLIOutput small.
+		result.Output, _ = filepath.Abs(*output)
+		result.Peaks = nil
+	}
+	return result, nil
+}
+
+// peaksFile standardizes mediaPath and computes its peaks like the app's waveform view.
+func (a *App) peaksFile(mediaPath string, samplesPerPixel int, scale string, minDb float64, channel int) (*PeaksResult, error) {
+	absPath, wavName, err := a.standardizeForCLI(mediaPath, channel)
+	if err != nil {
+		return nil, err
+	}
+	wavPath := filepath.Join(a.tmpPath, wavName)
+	defer os.Remove(wavPath)
+
+	sampleRate, err := wavSampleRate(wavPath)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cliProgress.stage(mediaPath, "computing peaks", true)
+	var data *PrecomputedWaveformData
+	if scale == "linear" {
+		data, err = a.ProcessWavToLinearPeaks(wavName, samplesPerPixel)
+		minDb = 0
+	} else {
+		data, err = a.ProcessWavToLogarithmicPeaks(wavName, samplesPerPixel, minDb, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not compute peaks for %s: %w", mediaPath, err)
+	}
+	return &PeaksResult{
+		File:            absPath,
+		Duration:        data.Duration,
+		SampleRate:      sampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Scale:           scale,
+		MinDb:           minDb,
+		Peaks:           data.Peaks,
+	}, nil
+}
+
+func wavSampleRate(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	d := wav.NewDecoder(f)
+	d.ReadInfo()
+	if d.Err() != nil || d.SampleRate == 0 {
+		return 0, fmt.Errorf("could not read sample rate of %s", path)
+	}
+	return int(d.SampleRate), nil
+}
+
+// writeAudiowaveformDat writes result as an audiowaveform version 1 .dat file with 8-bit
+// resolution. HushCut's peaks are magnitudes, so each min/max pair is symmetric.
+func writeAudiowaveformDat(w io.Writer, result *PeaksResult) error {
+	const (
+		datVersion = 1
+		datFlags8  = 1 // 8-bit samples
+	)
+	bw := bufio.NewWriter(w)
+	header := []int32{datVersion, datFlags8, int32(result.SampleRate), int32(result.SamplesPerPixel), int32(len(result.Peaks))}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, p := range result.Peaks {
+		v := int8(math.Round(math.Max(0, math.Min(1, p)) * 127))
+		bw.Write([]byte{byte(-v), byte(v)})
+	}
+	return bw.Flush()
+}
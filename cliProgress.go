@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cliProgress shows the progress of CLI jobs on stderr. It is fed by the same events the GUI
+// gets (conversion:progress, waveform:progress, render:progress): it is the App's eventSink in
+// CLI mode. On a terminal it draws a bar per running file and one for the whole run; in
+// logs (not a terminal, or -verbose) it prints a line per stage and per 25%. All methods are
+// no-ops on a nil *cliProgress.
+type cliProgress struct {
+	mu      sync.Mutex
+	out     *os.File
+	tty     bool
+	quiet   bool
+	total   int // files in the run; 0 if open-ended (watch)
+	done    int
+	failed  int
+	files   map[string]*fileProgress // by id, usually the media path
+	order   []string
+	aliases map[string]string // event file path -> id
+	drawn   int               // lines drawn by the last redraw
+	drawnAt time.Time
+}
+
+type fileProgress struct {
+	label    string
+	stage    string
+	pct      float64 // -1 if the stage reports no progress
+	reported float64 // last percentage printed in line mode
+}
+
+const (
+	progressBarWidth    = 24
+	progressRedrawEvery = 100 * time.Millisecond
+)
+
+func newCLIProgress(out *os.File, quiet, verbose bool) *cliProgress {
+	p := &cliProgress{
+		out:     out,
+		quiet:   quiet,
+		files:   map[string]*fileProgress{},
+		aliases: map[string]string{},
+	}
+	if info, err := out.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		p.tty = !verbose && os.Getenv("TERM") != "dumb" && enableVirtualTerminal(out)
+	}
+	return p
+}
+
+// emit receives the app's events; cliProgress is the CLI's eventSink.
+func (p *cliProgress) emit(name string, data ...interface{}) {
+	if p == nil || len(data) == 0 {
+		return
+	}
+	var path string
+	var pct float64
+	switch d := data[0].(type) {
+	case ProgressStatus:
+		path, pct = d.FilePath, d.Percentage
+	case WaveformProgress:
+		path, pct = d.FilePath, d.Percentage
+	default:
+		return
+	}
+	switch name {
+	case "conversion:progress", "waveform:progress", "render:progress":
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.files[p.aliases[path]]
+	if f == nil {
+		return
+	}
+	f.pct = pct
+	p.update(f, false)
+}
+
+func (p *cliProgress) setTotal(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.total = n
+	p.mu.Unlock()
+}
+
+// start begins tracking a file under id.
+func (p *cliProgress) start(id, label string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.files[id]; !ok {
+		p.order = append(p.order, id)
+	}
+	p.files[id] = &fileProgress{label: label, stage: "starting", pct: -1, reported: -1}
+	p.redraw(false)
+}
+
+// alias routes events about path (an intermediate WAV or an output file) to id.
+func (p *cliProgress) alias(path, id string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.aliases[path] = id
+	p.mu.Unlock()
+}
+
+// stage sets what is happening to id; progress restarts from zero if the stage reports it.
+func (p *cliProgress) stage(id, stage string, reportsProgress bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.files[id]
+	if f == nil {
+		return
+	}
+	f.stage, f.pct, f.reported = stage, -1, -1
+	if reportsProgress {
+		f.pct = 0
+	}
+	p.update(f, true)
+}
+
+// finish stops tracking id and prints its outcome, unless it was the only file of the run.
+func (p *cliProgress) finish(id string, err error, detail string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f := p.files[id]
+	if f == nil {
+		return
+	}
+	delete(p.files, id)
+	for i, o := range p.order {
+		if o == id {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+	for path, target := range p.aliases {
+		if target == id {
+			delete(p.aliases, path)
+		}
+	}
+	p.done++
+	if err != nil {
+		p.failed++
+	}
+
+	if p.total == 1 && err == nil {
+		p.redraw(true)
+		return
+	}
+	status := "ok"
+	if detail != "" {
+		status += ": " + detail
+	}
+	if err != nil {
+		status = "failed: " + err.Error()
+	}
+	if err != nil || !p.quiet {
+		p.println(fmt.Sprintf("%s %s: %s", p.counter(), f.label, status))
+	}
+	p.redraw(true)
+}
+
+// printf prints a message line above the bars; -quiet drops it.
+func (p *cliProgress) printf(format string, args ...interface{}) {
+	if p == nil || p.quiet {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.println(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	p.redraw(true)
+}
+
+// close removes the bars.
+func (p *cliProgress) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+func (p *cliProgress) counter() string {
+	if p.total > 0 {
+		return fmt.Sprintf("[%d/%d]", p.done, p.total)
+	}
+	return time.Now().Format("15:04:05")
+}
+
+// update reports a change of f: a redraw on a terminal, a line at stage changes and every 25%
+// otherwise.
+func (p *cliProgress) update(f *fileProgress, stageChanged bool) {
+	if p.quiet {
+		return
+	}
+	if p.tty {
+		p.redraw(stageChanged)
+		return
+	}
+	switch {
+	case stageChanged:
+		fmt.Fprintf(p.out, "%s: %s\n", f.label, f.stage)
+	case f.pct >= 0 && f.pct-f.reported >= 25:
+		f.reported = f.pct
+		fmt.Fprintf(p.out, "%s: %s %.0f%%\n", f.label, f.stage, f.pct)
+	}
+}
+
+// println prints a permanent line above the bars.
+func (p *cliProgress) println(line string) {
+	p.clear()
+	fmt.Fprintln(p.out, line)
+}
+
+func (p *cliProgress) clear() {
+	if p.tty && p.drawn > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.drawn)
+		p.drawn = 0
+	}
+}
+
+func (p *cliProgress) redraw(force bool) {
+	if !p.tty || p.quiet || (!force && time.Since(p.drawnAt) < progressRedrawEvery) {
+		return
+	}
+	p.clear()
+	var b strings.Builder
+	lines := 0
+	for _, id := range p.order {
+		f := p.files[id]
+		label := filepath.Base(f.label)
+		if len(label) > 32 {
+			label = "…" + label[len(label)-31:]
+		}
+		if f.pct >= 0 {
+			fmt.Fprintf(&b, "  %-32s %s %3.0f%% %s\n", label, progressBar(f.pct/100), f.pct, f.stage)
+		} else {
+			fmt.Fprintf(&b, "  %-32s %s\n", label, f.stage)
+		}
+		lines++
+	}
+	if p.total > 1 {
+		fmt.Fprintf(&b, "  %-32s %s %d/%d", "total", progressBar(float64(p.done)/float64(p.total)), p.done, p.total)
+		if p.failed > 0 {
+			fmt.Fprintf(&b, " (%d failed)", p.failed)
+		}
+		b.WriteString("\n")
+		lines++
+	}
+	fmt.Fprint(p.out, b.String())
+	p.drawn = lines
+	p.drawnAt = time.Now()
+}
+
+func progressBar(fraction float64) string {
+	filled := int(fraction*progressBarWidth + 0.5)
+	filled = max(0, min(progressBarWidth, filled))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]"
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// `hushcut watch <folder> -out-dir <folder>` keeps running and processes every recording that
+// appears in the watched folder, either like `detect` (the default) or like `cut`. New files are
+// noticed through fsnotify, but the folder is also scanned every -interval: recorders write files
+// over minutes, so a file is only picked up once its size and modification time have not changed
+// for -settle, which no notification reports, and network shares often send no notifications.
+
+// WatchSummary is the --json result of `hushcut watch`, printed when it is stopped.
+type WatchSummary struct {
+	Dir       string               `json:"dir"`
+	OutDir    string               `json:"outDir"`
+	Mode      string               `json:"mode"`
+	Params    DetectionParams      `json:"params"`
+	StartedAt time.Time            `json:"startedAt"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Files     []DetectSummaryEntry `json:"files"`
+}
+
+// watchedFile is what the poll loop remembers about a file between scans.
+type watchedFile struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+	handled     bool
+}
+
+type watchConfig struct {
+	dir, outDir string
+	recursive   bool
+	mode        string // "detect" or "cut"
+	format      string // detect result format
+	cutExt      string // cut output extension; "" keeps the input's
+	interval    time.Duration
+	settle      time.Duration
+	params      DetectionParams
+	opts        detectOptions
+}
+
+func runWatchCLI(r *cliRun, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hushcut watch <folder> -out-dir <folder> [-mode detect|cut] [flags]")
+		fs.PrintDefaults()
+	}
+	df := addDetectFlags(fs)
+	r.addRunFlags(fs)
+	var cfg watchConfig
+	fs.StringVar(&cfg.outDir, "out-dir", "", "folder the results are written to (required)")
+	fs.BoolVar(&cfg.recursive, "recursive", false, "include subfolders")
+	fs.StringVar(&cfg.mode, "mode", "detect", "what to do with each new file: detect (write its silences) or cut (render it without them)")
+	fs.StringVar(&cfg.format, "format", "json", "with -mode detect: result format, json or csv")
+	fs.StringVar(&cfg.cutExt, "ext", "", "with -mode cut: output extension, e.g. .wav for audio only (default: the input's)")
+	fs.DurationVar(&cfg.interval, "interval", 2*time.Second, "how often the folder is scanned")
+	fs.DurationVar(&cfg.settle, "settle", 5*time.Second, "how long a file must stay unchanged before it is considered finished")
+	existing := fs.Bool("existing", false, "also process files that are already in the folder, unless their result exists")
+	jobs := fs.Int("jobs", 2, "number of files processed in parallel")
+
+	positional, err := r.parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 || cfg.outDir == "" {
+		fs.Usage()
+		return nil, usageError(errors.New("expected one folder and -out-dir"))
+	}
+	cfg.dir = positional[0]
+	if info, err := os.Stat(cfg.dir); err != nil || !info.IsDir() {
+		return nil, invalidInput("%s is not a folder", cfg.dir)
+	}
+	if cfg.mode != "detect" && cfg.mode != "cut" {
+		return nil, invalidInput("unsupported mode %q: use detect or cut", cfg.mode)
+	}
+	if cfg.format != "json" && cfg.format != "csv" {
+		return nil, invalidInput("unsupported format %q: use json or csv", cfg.format)
+	}
+	if cfg.cutExt != "" && !strings.HasPrefix(cfg.cutExt, ".") {
+		cfg.cutExt = "." + cfg.cutExt
+	}
+	if cfg.interval <= 0 || cfg.settle < 0 {
+		return nil, invalidInput("-interval must be positive and -settle must not be negative")
+	}
+	if err := checkWatchOutDir(cfg.dir, cfg.outDir); err != nil {
+		return nil, err
+	}
+	cfg.opts = df.opts
+
+	a, cleanup, err := df.setup()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	if cfg.params, err = df.resolveParams(fs, a); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cfg.outDir, 0755); err != nil {
+		return nil, err
+	}
+	a.resizeSemaphores(*jobs, defaultWaveformConcurrency, *jobs)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.watchFolder(ctx, cfg, *existing), nil
+}
+
+// checkWatchOutDir rejects an output folder that is the watched folder or contains it: every
+// file below the output folder is taken for a result and skipped, so nothing would be processed.
+func checkWatchOutDir(dir, outDir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absOut, absDir); err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+		return invalidInput("-out-dir %s must not be the watched folder or contain it: use a separate folder", outDir)
+	}
+	return nil
+}
+
+// watchFolder scans cfg.dir whenever fsnotify reports a new file and every cfg.interval, until
+// ctx is cancelled, then waits for running jobs.
+func (a *App) watchFolder(ctx context.Context, cfg watchConfig, existing bool) *WatchSummary {
+	summary := &WatchSummary{Dir: cfg.dir, OutDir: cfg.outDir, Mode: cfg.mode, Params: cfg.params, StartedAt: time.Now(), Files: []DetectSummaryEntry{}}
+	var summaryMu sync.Mutex
+	var wg sync.WaitGroup
+
+	outDir, _ := filepath.Abs(cfg.outDir)
+	seen := map[string]*watchedFile{}
+	first := true
+
+	a.cliProgress.printf("watching %s, results go to %s (Ctrl+C to stop)", cfg.dir, cfg.outDir)
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	// Without a watcher the channels stay nil and only the ticker wakes the loop.
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		a.cliProgress.printf("no change notifications (%v); scanning every %s", err, cfg.interval)
+	} else {
+		defer watcher.Close()
+		a.addWatches(watcher, cfg.dir, cfg.recursive, outDir)
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+	for {
+		files, err := findMediaFiles(cfg.dir, cfg.recursive)
+		if err != nil {
+			a.cliProgress.printf("could not scan %s: %v", cfg.dir, err)
+		}
+		now := time.Now()
+		present := map[string]bool{}
+		for _, file := range files {
+			if abs, err := filepath.Abs(file); err == nil && strings.HasPrefix(abs, outDir+string(filepath.Separator)) {
+				continue // our own output
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			present[file] = true
+			w, ok := seen[file]
+			if !ok {
+				w = &watchedFile{size: info.Size(), modTime: info.ModTime(), stableSince: now}
+				// Files from before the start are left alone unless -existing asks for them, and
+				// then only if their result isn't there yet.
+				if first {
+					_, statErr := os.Stat(cfg.outputPath(file))
+					w.handled = !existing || statErr == nil
+				}
+				seen[file] = w
+			}
+			if info.Size() != w.size || !info.ModTime().Equal(w.modTime) {
+				w.size, w.modTime, w.stableSince = info.Size(), info.ModTime(), now
+				w.handled = false // rewritten, e.g. a recording that was resumed
+				continue
+			}
+			if w.handled || w.size == 0 || now.Sub(w.stableSince) < cfg.settle || !readable(file) {
+				continue
+			}
+			w.handled = true
+
+			wg.Add(1)
+			go saferun(func() {
+				defer wg.Done()
+				release, err := a.acquireFfmpegSlot(jobDetection, file)
+				if err != nil {
+					return // only CancelAllJobs fails it, and the CLI has no way to call it
+				}
+				defer release()
+
+				a.cliProgress.start(file, file)
+				entry := a.watchEntry(cfg, file)
+				a.cliProgress.finish(file, entry.err(), entry.Output)
+				summaryMu.Lock()
+				summary.Files = append(summary.Files, entry)
+				if entry.Error != "" {
+					summary.Failed++
+				} else {
+					summary.Succeeded++
+				}
+				summaryMu.Unlock()
+			})
+		}
+		for file := range seen {
+			if !present[file] {
+				delete(seen, file)
+			}
+		}
+		first = false
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				a.cliProgress.printf("stopping; waiting for running jobs")
+				wg.Wait()
+				return summary
+			case <-ticker.C:
+				break wait
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				// Writes are left to the ticker, which times -settle anyway.
+				if !event.Has(fsnotify.Create) {
+					continue
+				}
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && cfg.recursive {
+					a.addWatches(watcher, event.Name, true, outDir)
+				}
+				break wait
+			case err, ok := <-watchErrors:
+				if !ok {
+					watchErrors = nil
+					continue
+				}
+				a.cliProgress.printf("change notifications: %v", err)
+			}
+		}
+	}
+}
+
+// addWatches registers dir, and with recursive its subfolders except the output folder, with
+// watcher. A folder that can't be watched is still covered by the periodic scan.
+func (a *App) addWatches(watcher *fsnotify.Watcher, dir string, recursive bool, outDir string) {
+	add := func(path string) {
+		if err := watcher.Add(path); err != nil {
+			a.cliProgress.printf("no change notifications for %s (%v); scanning every interval", path, err)
+		}
+	}
+	if !recursive {
+		add(dir)
+		return
+	}
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if abs, err := filepath.Abs(path); err == nil && abs == outDir {
+			return filepath.SkipDir
+		}
+		add(path)
+		return nil
+	})
+}
+
+// outputPath is where the result for input goes, mirroring its place below cfg.dir.
+func (cfg watchConfig) outputPath(input string) string {
+	if cfg.mode == "detect" {
+		return detectOutputPath(cfg.dir, cfg.outDir, input, cfg.format)
+	}
+	ext := cfg.cutExt
+	if ext == "" {
+		ext = filepath.Ext(input)
+	}
+	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + ".cut" + ext
+	rel, err := filepath.Rel(cfg.dir, filepath.Dir(input))
+	if err != nil {
+		rel = ""
+	}
+	return filepath.Join(cfg.outDir, rel, name)
+}
+
+func (a *App) watchEntry(cfg watchConfig, file string) DetectSummaryEntry {
+	if cfg.mode == "detect" {
+		return a.detectFolderEntry(cfg.dir, cfg.outDir, file, cfg.format, cfg.params, cfg.opts)
+	}
+	entry := DetectSummaryEntry{File: file}
+	// A rewritten input is cut again, replacing the result of the earlier version.
+	result, err := a.cutFile(file, cfg.outputPath(file), true, cfg.params, cfg.opts)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Output = result.Output
+	entry.Duration = result.End - result.Start
+	entry.Silences = len(result.Silences)
+	entry.SilenceSeconds = result.RemovedSeconds
+	return entry
+}
+
+// readable reports whether file can be opened; on Windows a recorder's exclusive lock makes
+// this fail until it is done.
+func readable(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckWatchOutDir(t *testing.T) {
+	root := t.TempDir()
+	rec := filepath.Join(root, "rec")
+	tests := []struct {
+		name    string
+		outDir  string
+		wantErr bool
+	}{
+		{"same folder", rec, true},
+		{"same folder, other spelling", rec + string(filepath.Separator) + ".", true},
+		{"parent of the watched folder", root, true},
+		{"subfolder of the watched folder", filepath.Join(rec, "out"), false},
+		{"sibling sharing a name prefix", rec + "-out", false},
+		{"unrelated folder", filepath.Join(root, "results"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWatchOutDir(rec, tt.outDir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkWatchOutDir(%q) error = %v, wantErr %v", tt.outDir, err, tt.wantErr)
+			}
+			if err != nil && exitCodeFor(err) != exitInvalidInput {
+				t.Errorf("exit code = %d, want %d", exitCodeFor(err), exitInvalidInput)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Compound clips nest: a compound clip can hold other compound clips, which the backends send
+// as nested items with NestedItems of their own and no source file. Each compound is mixed
+// down to its processed file from the files of its clips, so inner compounds are mixed down
+// before the compounds that contain them and are then used like any other source; the
+// preparation graph (prepGraph.go) runs each mixdown once the files of its clips are ready.
+// The level of a mixdown is how deep it has to wait: 1 for a compound of plain clips, one more
+// than its deepest inner compound otherwise.
+
+// errCompoundCycle reports a compound clip that (through other compounds) contains itself,
+// which could never be mixed down.
+var errCompoundCycle = errors.New("compound clip contains itself")
+
+// compoundMixdown is the mixdown of one compound clip's content.
+type compoundMixdown struct {
+	FileName string
+	Clips    []*NestedAudioTimelineItem
+	Level    int
+	Err      error // set if the compound can't be mixed down
+}
+
+// compoundPlan lists what a timeline's compound clips need, each once.
+type compoundPlan struct {
+	Mixdowns []*compoundMixdown         // by level, inner ones first
+	Sources  []*NestedAudioTimelineItem // the plain clips inside compounds, to standardize
+	Levels   int
+}
+
+// planCompoundMixdowns walks the compound clips of items depth-first.
+func planCompoundMixdowns(items []TimelineItem) *compoundPlan {
+	plan := &compoundPlan{}
+	planned := map[string]*compoundMixdown{}
+	sources := map[string]bool{}
+	onPath := map[string]bool{}
+
+	var visit func(fileName string, clips []*NestedAudioTimelineItem) *compoundMixdown
+	visit = func(fileName string, clips []*NestedAudioTimelineItem) *compoundMixdown {
+		if mixdown, ok := planned[fileName]; ok {
+			return mixdown
+		}
+		onPath[fileName] = true
+		defer delete(onPath, fileName)
+
+		mixdown := &compoundMixdown{FileName: fileName, Clips: clips, Level: 1}
+		for _, clip := range clips {
+			if clip.ProcessedFileName == "" {
+				continue
+			}
+			if len(clip.NestedItems) == 0 {
+				if !sources[clip.ProcessedFileName] {
+					sources[clip.ProcessedFileName] = true
+					plan.Sources = append(plan.Sources, clip)
+				}
+				continue
+			}
+			if onPath[clip.ProcessedFileName] {
+				mixdown.Err = fmt.Errorf("%w: %s contains %s", errCompoundCycle, fileName, clip.ProcessedFileName)
+				continue
+			}
+			inner := visit(clip.ProcessedFileName, clip.NestedItems)
+			mixdown.Level = max(mixdown.Level, inner.Level+1)
+		}
+		planned[fileName] = mixdown
+		plan.Mixdowns = append(plan.Mixdowns, mixdown)
+		plan.Levels = max(plan.Levels, mixdown.Level)
+		return mixdown
+	}
+
+	for _, item := range items {
+		if item.Type == "" || len(item.NestedClips) == 0 || item.ProcessedFileName == nil || *item.ProcessedFileName == "" {
+			continue
+		}
+		visit(*item.ProcessedFileName, item.NestedClips)
+	}
+	sort.SliceStable(plan.Mixdowns, func(i, j int) bool { return plan.Mixdowns[i].Level < plan.Mixdowns[j].Level })
+	return plan
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	bundleManifestName  = "bundle.json"
+	bundleSchemaVersion = 1
+)
+
+// bundleStateEntries are the app-state files and folders (relative to userResourcesPath)
+// that may travel with a config bundle. License data is deliberately never part of it.
+var bundleStateEntries = []string{
+	reportsFolderName,
+	recentSessionsFileName,
+	sessionsFolderName,
+}
+
+// bundleMachineKeys are settings that point at programs on one workstation. An imported value
+// is only kept if it exists on this machine too.
+var bundleMachineKeys = []string{"davinciFolderPath", "ffmpegPath", "whisperPath"}
+
+type ConfigBundleManifest struct {
+	SchemaVersion   int       `json:"schemaVersion"`
+	AppVersion      string    `json:"appVersion"`
+	CreatedAt       time.Time `json:"createdAt"`
+	IncludesAppData bool      `json:"includesAppData"`
+}
+
+func addFileToZip(zw *zip.Writer, srcPath string, nameInZip string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	w, err := zw.Create(filepath.ToSlash(nameInZip))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// addTreeToZip adds a file or a whole directory below userResourcesPath to the archive.
+func (a *App) addTreeToZip(zw *zip.Writer, relPath string) error {
+	root := filepath.Join(a.userResourcesPath, relPath)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(a.userResourcesPath, path)
+		if err != nil {
+			return err
+		}
+		return addFileToZip(zw, path, rel)
+	})
+}
+
+// ExportConfigBundle writes settings, presets and (optionally) app state into a single zip
+// for moving configuration between workstations. If destPath is empty, a save dialog is shown.
+func (a *App) ExportConfigBundle(destPath string, includeAppState bool) (string, error) {
+	if destPath == "" {
+		var err error
+		destPath, err = saveFileDialog(a.ctx, "hushcut-config-"+time.Now().Format("2006-01-02")+".zip",
+			fileFilter{DisplayName: "HushCut Config Bundle", Pattern: "*.zip"})
+		if err != nil || destPath == "" {
+			return "", err
+		}
+	}
+
+	// Make sure a settings file exists so the bundle is never empty.
+	if _, err := a.GetSettings(); err != nil {
+		return "", fmt.Errorf("could not read settings for export: %w", err)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return "", fmt.Errorf("could not create bundle file %s: %w", destPath, err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+
+	manifest := ConfigBundleManifest{
+		SchemaVersion:   bundleSchemaVersion,
+		AppVersion:      a.appVersion,
+		CreatedAt:       time.Now(),
+		IncludesAppData: includeAppState,
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	mw, err := zw.Create(bundleManifestName)
+	if err != nil {
+		return "", fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+	if _, err := mw.Write(manifestData); err != nil {
+		return "", fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+
+	entries := []string{settingsFileName, presetsFolderName}
+	if includeAppState {
+		entries = append(entries, bundleStateEntries...)
+	}
+	for _, entry := range entries {
+		if err := a.addTreeToZip(zw, entry); err != nil {
+			zw.Close()
+			return "", fmt.Errorf("failed to add %s to bundle: %w", entry, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+	appLog.Info("Exported config bundle", "path", destPath)
+	return destPath, nil
+}
+
+// bundleEntryAllowed reports whether a path from an imported bundle may be restored.
+func bundleEntryAllowed(relPath string, allowState bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	if relPath == settingsFileName || strings.HasPrefix(relPath, presetsFolderName+"/") {
+		return true
+	}
+	if !allowState {
+		return false
+	}
+	for _, entry := range bundleStateEntries {
+		if relPath == entry || strings.HasPrefix(relPath, entry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bundleSettingsForMachine adapts imported settings to this workstation. Program paths that
+// don't exist here keep this machine's value, or are left out; keys the policy locks are left
+// out, as SaveSettings keeps the user's own value for them anyway.
+func (a *App) bundleSettingsForMachine(imported map[string]any) map[string]any {
+	current := a.readUserSettingsFile()
+	settings := make(map[string]any, len(imported))
+	for key, val := range imported {
+		if !a.policy.isLocked(key) {
+			settings[key] = val
+		}
+	}
+	exists := func(val any) bool {
+		path, ok := val.(string)
+		if !ok || path == "" {
+			return false
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	for _, key := range bundleMachineKeys {
+		if _, ok := settings[key]; !ok || exists(settings[key]) {
+			continue
+		}
+		if val, ok := current[key]; ok && exists(val) {
+			settings[key] = val
+		} else {
+			delete(settings, key)
+		}
+	}
+	return settings
+}
+
+// bundleRestore remembers what the files an import overwrites held before, so a failed import
+// can put them back.
+type bundleRestore struct {
+	written  []string
+	previous map[string][]byte // nil for files that didn't exist
+}
+
+func (r *bundleRestore) write(dest string, data []byte) error {
+	if _, seen := r.previous[dest]; !seen {
+		old, err := os.ReadFile(dest)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		r.previous[dest] = old
+		r.written = append(r.written, dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// rollback restores every file written so far, newest first.
+func (r *bundleRestore) rollback() {
+	for i := len(r.written) - 1; i >= 0; i-- {
+		dest := r.written[i]
+		var err error
+		if old := r.previous[dest]; old != nil {
+			err = os.WriteFile(dest, old, 0644)
+		} else {
+			err = os.Remove(dest)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			appLog.Warn("ImportConfigBundle: could not roll back", "path", dest, "err", err)
+		}
+	}
+}
+
+// ImportConfigBundle restores a bundle created by ExportConfigBundle: all of it or, if anything
+// fails, none of it. Settings are validated first, after adapting program paths to this
+// machine. Existing presets with the same name are overwritten. If srcPath is empty, an open
+// dialog is shown.
+func (a *App) ImportConfigBundle(srcPath string) error {
+	if srcPath == "" {
+		var err error
+		srcPath, err = openFileDialog(a.ctx, "", fileFilter{DisplayName: "HushCut Config Bundle", Pattern: "*.zip"})
+		if err != nil || srcPath == "" {
+			return err
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "hushcut-bundle-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := unzip(srcPath, tempDir, bundleArchiveLimits); err != nil {
+		return fmt.Errorf("could not extract bundle: %w", err)
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(tempDir, bundleManifestName))
+	if err != nil {
+		return fmt.Errorf("not a HushCut config bundle (missing %s)", bundleManifestName)
+	}
+	var manifest ConfigBundleManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if manifest.SchemaVersion > bundleSchemaVersion {
+		return fmt.Errorf("bundle was created by a newer version of HushCut (%s)", manifest.AppVersion)
+	}
+
+	var settings map[string]any
+	if settingsData, err := os.ReadFile(filepath.Join(tempDir, settingsFileName)); err == nil {
+		var imported map[string]any
+		if err := json.Unmarshal(settingsData, &imported); err != nil {
+			return fmt.Errorf("bundle contains invalid settings: %w", err)
+		}
+		settings = a.bundleSettingsForMachine(imported)
+		if fieldErrors := a.ValidateSettings(settings); len(fieldErrors) > 0 {
+			return &SettingsValidationError{Fields: fieldErrors}
+		}
+	}
+
+	restore := &bundleRestore{previous: map[string][]byte{}}
+	err = filepath.WalkDir(tempDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return walkErr
+		}
+		rel, err := filepath.Rel(tempDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == bundleManifestName || rel == settingsFileName {
+			return nil // settings are applied below through SaveSettings
+		}
+		if !bundleEntryAllowed(rel, manifest.IncludesAppData) {
+			appLog.Warn("ImportConfigBundle: skipping unexpected entry", "entry", rel)
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return restore.write(filepath.Join(a.userResourcesPath, rel), data)
+	})
+	if err != nil {
+		restore.rollback()
+		return fmt.Errorf("failed to restore bundle contents: %w", err)
+	}
+
+	if settings != nil {
+		if err := a.SaveSettings(settings); err != nil {
+			restore.rollback()
+			return err
+		}
+	}
+
+	appLog.Info("Imported config bundle", "path", srcPath, "restored", len(restore.written))
+	a.emit("presets:changed", nil)
+	return nil
+}
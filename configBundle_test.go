@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestBundle writes a config bundle holding settings and one preset to a temporary file.
+func writeTestBundle(t *testing.T, settings map[string]any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	files := map[string]any{
+		bundleManifestName:                       ConfigBundleManifest{SchemaVersion: bundleSchemaVersion},
+		settingsFileName:                         settings,
+		presetsFolderName + "/studio-voice.json": map[string]any{"name": "Studio Voice"},
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.NewEncoder(w).Encode(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportConfigBundle(t *testing.T) {
+	localFfmpeg := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(localFfmpeg, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "elsewhere", "ffmpeg")
+
+	tests := []struct {
+		name         string
+		current      map[string]any // settings.json before the import
+		policy       map[string]any
+		imported     map[string]any
+		wantErr      bool
+		wantSettings map[string]any // checked keys of settings.json after the import; nil = absent
+	}{
+		{
+			name:         "program paths of another machine are dropped",
+			imported:     map[string]any{"ffmpegPath": missing, "whisperPath": missing, "cleanupThresholdDays": 14.0},
+			wantSettings: map[string]any{"ffmpegPath": nil, "whisperPath": nil, "cleanupThresholdDays": 14.0},
+		},
+		{
+			name:         "this machine's program path is kept",
+			current:      map[string]any{"ffmpegPath": localFfmpeg},
+			imported:     map[string]any{"ffmpegPath": missing},
+			wantSettings: map[string]any{"ffmpegPath": localFfmpeg},
+		},
+		{
+			name:         "policy-locked keys are left to the policy",
+			policy:       map[string]any{"enableCleanup": false},
+			imported:     map[string]any{"enableCleanup": true, "cleanupThresholdDays": 7.0},
+			wantSettings: map[string]any{"enableCleanup": nil, "cleanupThresholdDays": 7.0},
+		},
+		{
+			name:         "invalid settings restore nothing",
+			current:      map[string]any{"cleanupThresholdDays": 30.0},
+			imported:     map[string]any{"cleanupThresholdDays": -1.0},
+			wantErr:      true,
+			wantSettings: map[string]any{"cleanupThresholdDays": 30.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{userResourcesPath: t.TempDir(), policy: &Policy{Values: tt.policy}}
+			if tt.current != nil {
+				data, _ := json.Marshal(tt.current)
+				if err := os.WriteFile(a.getSettingsPath(), data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := a.ImportConfigBundle(writeTestBundle(t, tt.imported))
+			var validationErr *SettingsValidationError
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ImportConfigBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.As(err, &validationErr) {
+				t.Errorf("ImportConfigBundle() error = %v, want a SettingsValidationError", err)
+			}
+
+			_, presetErr := os.Stat(filepath.Join(a.userResourcesPath, presetsFolderName, "studio-voice.json"))
+			if tt.wantErr != os.IsNotExist(presetErr) {
+				t.Errorf("preset restored = %v, want %v", presetErr == nil, !tt.wantErr)
+			}
+			settings := a.readUserSettingsFile()
+			for key, want := range tt.wantSettings {
+				if got, ok := settings[key]; want == nil && ok || want != nil && got != want {
+					t.Errorf("settings[%q] = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
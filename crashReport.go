@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"time"
+)
+
+// Crash logs are written next to log.txt as crash_<timestamp>.txt. Users who enable the
+// "sendCrashReports" setting (and whose policy allows telemetry) have them uploaded on the
+// next start; uploaded logs are renamed to crash_<timestamp>.sent.txt.
+
+const (
+	crashLogPrefix     = "crash_"
+	crashLogSuffix     = ".txt"
+	crashLogSentSuffix = ".sent.txt"
+)
+
+// writeCrashLog stores message together with version information and returns the file path.
+func writeCrashLog(message string) string {
+	base, err := stateDir()
+	if err != nil {
+		return ""
+	}
+	_ = os.MkdirAll(base, 0755)
+
+	now := time.Now()
+	path := filepath.Join(base, crashLogPrefix+now.Format("2006-01-02_15-04-05")+crashLogSuffix)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HushCut %s (%s/%s), bundled ffmpeg %s\n", AppVersion, goruntime.GOOS, goruntime.GOARCH, FfmpegVersion)
+	fmt.Fprintf(&b, "Crashed at %s\n\n", now.Format(time.RFC3339))
+	b.WriteString(message + "\n")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return ""
+	}
+	return path
+}
+
+// pendingCrashLogs lists crash logs that have not been uploaded yet.
+func pendingCrashLogs() []string {
+	base, err := stateDir()
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(base, crashLogPrefix+"*"+crashLogSuffix))
+	pending := matches[:0]
+	for _, m := range matches {
+		if !strings.HasSuffix(m, crashLogSentSuffix) {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+type crashReportPayload struct {
+	AppVersion             string `json:"app_version"`
+	FfmpegVersion          string `json:"ffmpeg_version"`
+	InstalledFfmpegVersion string `json:"installed_ffmpeg_version,omitempty"`
+	OS                     string `json:"os"`
+	Arch                   string `json:"arch"`
+	FileName               string `json:"file_name"`
+	Report                 string `json:"report"`
+}
+
+// uploadCrashReports sends pending crash logs when the user opted in. Called after startup.
+func (a *App) uploadCrashReports() {
+	settings, err := a.GetSettings()
+	if err != nil || !settingBool(settings, "sendCrashReports", false) || !a.telemetryAllowed() {
+		return
+	}
+	pending := pendingCrashLogs()
+	if len(pending) == 0 {
+		return
+	}
+	if err := a.requireOnline("crashReport"); err != nil {
+		return
+	}
+
+	installedFfmpeg := a.GetFfmpegInfo().InstalledVersion
+	client := a.externalHTTPClient(30 * time.Second)
+	for _, path := range pending {
+		report, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		body, err := json.Marshal(crashReportPayload{
+			AppVersion:             a.appVersion,
+			FfmpegVersion:          a.ffmpegVersion,
+			InstalledFfmpegVersion: installedFfmpeg,
+			OS:                     goruntime.GOOS,
+			Arch:                   goruntime.GOARCH,
+			FileName:               filepath.Base(path),
+			Report:                 string(report),
+		})
+		if err != nil {
+			continue
+		}
+		resp, err := client.Post(a.apiBaseURL()+"/crash_report", "application/json", bytes.NewReader(body))
+		if err != nil {
+			appLog.Warn("Could not upload crash report", "file", filepath.Base(path), "err", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			appLog.Warn("Crash report upload rejected", "file", filepath.Base(path), "status", resp.Status)
+			return
+		}
+		sent := strings.TrimSuffix(path, crashLogSuffix) + crashLogSentSuffix
+		if err := os.Rename(path, sent); err != nil {
+			appLog.Warn("Could not mark crash report as sent", "file", filepath.Base(path), "err", err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The project data sent to Resolve can be dumped for debugging. It is off unless the
+// "debugProjectDump" setting or HUSHCUT_DEBUG_DUMP=1 turns it on, and it is written next to
+// log.txt. With "debugProjectDumpRedact" every file path in it is cut down to its file name,
+// so the dump can be attached to a bug report without revealing the user's folders.
+
+const (
+	debugDumpFileName = "debug_project_data.json"
+	debugDumpEnvVar   = "HUSHCUT_DEBUG_DUMP"
+)
+
+// redactedPathPrefix replaces the folders of a redacted path.
+const redactedPathPrefix = "[redacted]/"
+
+var windowsAbsPath = regexp.MustCompile(`^[A-Za-z]:[\\/]|^\\\\`)
+
+// debugDumpOptions reads whether to dump, and whether to redact.
+func (a *App) debugDumpOptions() (enabled, redact bool) {
+	settings, err := a.GetSettings()
+	if err != nil {
+		settings = map[string]any{}
+	}
+	enabled = settingBool(settings, "debugProjectDump", false) || os.Getenv(debugDumpEnvVar) == "1"
+	return enabled, settingBool(settings, "debugProjectDumpRedact", false)
+}
+
+// dumpProjectData writes projectData to the log directory if dumping is enabled. A dump that
+// cannot be written is only logged.
+func (a *App) dumpProjectData(projectData *ProjectDataPayload) {
+	enabled, redact := a.debugDumpOptions()
+	if !enabled {
+		return
+	}
+	if err := writeProjectDump(projectData, redact); err != nil {
+		appLog.Warn("Could not write the debug project dump", "err", err)
+	}
+}
+
+func writeProjectDump(projectData *ProjectDataPayload, redact bool) error {
+	base, err := stateDir()
+	if err != nil {
+		return err
+	}
+	var dump any = projectData
+	if redact {
+		raw, err := json.Marshal(projectData)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return err
+		}
+		dump = redactPaths(generic)
+	}
+	data, err := json.MarshalIndent(dump, "", " ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project data: %w", err)
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(base, debugDumpFileName), data, 0644)
+}
+
+// redactPaths replaces every absolute path in v, in values and map keys, with its file name.
+func redactPaths(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, value := range v {
+			redacted[redactPath(key)] = redactPaths(value)
+		}
+		return redacted
+	case []any:
+		for i := range v {
+			v[i] = redactPaths(v[i])
+		}
+		return v
+	case string:
+		return redactPath(v)
+	}
+	return v
+}
+
+func redactPath(s string) string {
+	if !strings.HasPrefix(s, "/") && !windowsAbsPath.MatchString(s) {
+		return s
+	}
+	name := s[strings.LastIndexAny(s, `/\`)+1:]
+	return redactedPathPrefix + name
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	goruntime "runtime"
+	"runtime/metrics"
+)
+
+// Profiling endpoints on the loopback server (not the TLS listener), enabled in dev builds or
+// with --debug-server:
+//
+//	/debug/pprof/...   the standard net/http/pprof handlers (heap, goroutine, profile, trace, ...)
+//	/debug/metrics     runtime/metrics samples plus HushCut's own counters as JSON
+//
+// Both require the server's auth token like the other protected endpoints, e.g.
+// go tool pprof "http://localhost:<port>/debug/pprof/profile?seconds=30&token=<token>"
+
+func (a *App) debugEndpointsEnabled() bool {
+	return a.isDev || a.debugServer
+}
+
+func (a *App) registerDebugEndpoints(mux *http.ServeMux) {
+	if !a.debugEndpointsEnabled() {
+		return
+	}
+	protect := func(h http.HandlerFunc) http.HandlerFunc {
+		return a.commonMiddleware(h, true)
+	}
+	mux.HandleFunc("/debug/pprof/", protect(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", protect(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", protect(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", protect(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", protect(pprof.Trace))
+	mux.HandleFunc("/debug/metrics", protect(a.handleDebugMetrics))
+	ipcLog.Info("Debug endpoints enabled", "paths", "/debug/pprof/, /debug/metrics")
+}
+
+// DebugMetrics is the response of /debug/metrics.
+type DebugMetrics struct {
+	Goroutines           int              `json:"goroutines"`
+	ActiveTasks          int              `json:"activeTasks"`
+	WaveformCacheEntries int              `json:"waveformCacheEntries"`
+	SilenceCacheEntries  int              `json:"silenceCacheEntries"`
+	FfmpegSlotsInUse     int              `json:"ffmpegSlotsInUse"`
+	WaveformSlotsInUse   int              `json:"waveformSlotsInUse"`
+	DetectionSlotsInUse  int              `json:"detectionSlotsInUse"`
+	CacheMemory          CacheMemoryUsage `json:"cacheMemory"`
+	Runtime              map[string]any   `json:"runtime"`
+}
+
+func (a *App) handleDebugMetrics(w http.ResponseWriter, r *http.Request) {
+	result := DebugMetrics{
+		Goroutines: goruntime.NumGoroutine(),
+		Runtime:    map[string]any{},
+	}
+	a.progressTracker.Range(func(_, _ any) bool {
+		result.ActiveTasks++
+		return true
+	})
+	a.cacheMutex.RLock()
+	result.WaveformCacheEntries = len(a.waveformCache)
+	result.SilenceCacheEntries = len(a.silenceCache)
+	a.cacheMutex.RUnlock()
+	result.CacheMemory = cacheUsage.usage()
+	a.semaphoreMu.RLock()
+	result.FfmpegSlotsInUse = len(a.ffmpegSemaphore)
+	result.WaveformSlotsInUse = len(a.waveformSemaphore)
+	result.DetectionSlotsInUse = len(a.detectSemaphore)
+	a.semaphoreMu.RUnlock()
+
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i, d := range descs {
+		samples[i].Name = d.Name
+	}
+	metrics.Read(samples)
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			result.Runtime[s.Name] = s.Value.Uint64()
+		case metrics.KindFloat64:
+			result.Runtime[s.Name] = s.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			h := s.Value.Float64Histogram()
+			var total uint64
+			for _, c := range h.Counts {
+				total += c
+			}
+			result.Runtime[s.Name] = map[string]any{"count": total}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
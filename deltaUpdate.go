@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+)
+
+// Delta updates ship as release assets named
+//
+//	hushcut_<from>_to_<to>_<os>_<arch>.bspatch
+//
+// containing a BSDIFF40 patch from one version's AppImage (Linux) or app executable
+// (macOS) to the next. They are only used between exactly those two versions; anything
+// else, and Windows (where the MSI owns the installation), uses the full installer.
+
+// findPatchAsset returns the patch asset for upgrading from one version to another, if any.
+func findPatchAsset(assets []GithubAsset, fromVersion string, toVersion string, goos string, goarch string) *GithubAsset {
+	step := fmt.Sprintf("_%s_to_%s_", strings.TrimPrefix(fromVersion, "v"), strings.TrimPrefix(toVersion, "v"))
+	for i := range assets {
+		name := strings.ToLower(assets[i].Name)
+		if !strings.HasSuffix(name, ".bspatch") || !strings.Contains(name, step) || !strings.Contains(name, "_"+goos+"_") {
+			continue
+		}
+		for _, arch := range updateArchNames(goarch) {
+			if strings.Contains(name, arch) {
+				return &assets[i]
+			}
+		}
+	}
+	return nil
+}
+
+// patchTarget returns the file a delta patch applies to on this platform.
+func patchTarget() (string, error) {
+	switch goruntime.GOOS {
+	case "linux":
+		if image := os.Getenv("APPIMAGE"); image != "" {
+			return image, nil
+		}
+		return "", fmt.Errorf("not running from an AppImage")
+	case "darwin":
+		return os.Executable()
+	}
+	return "", fmt.Errorf("delta updates are not supported on %s", goruntime.GOOS)
+}
+
+// tryDeltaUpdate downloads and applies a patch if the release has one for this version step.
+// applied is false (with a nil error) when no patch is available.
+func (a *App) tryDeltaUpdate(stagingDir string) (applied bool, err error) {
+	asset := findPatchAsset(a.updateInfo.GithubData.Assets, a.appVersion, a.updateInfo.LatestVersion, goruntime.GOOS, goruntime.GOARCH)
+	if asset == nil {
+		return false, nil
+	}
+	target, err := patchTarget()
+	if err != nil {
+		return false, nil
+	}
+
+	updateLog.Info("Downloading delta update", "asset", asset.Name)
+	patchPath, err := a.downloadUpdateAsset(asset, stagingDir)
+	if err != nil {
+		return false, err
+	}
+	patch, err := os.ReadFile(patchPath)
+	if err != nil {
+		return false, err
+	}
+	current, err := os.ReadFile(target)
+	if err != nil {
+		return false, fmt.Errorf("could not read current version: %w", err)
+	}
+
+	a.emitUpdateProgress("install", 0, "Applying patch")
+	patched, err := bspatch(current, patch)
+	if err != nil {
+		return false, fmt.Errorf("could not apply %s: %w", asset.Name, err)
+	}
+	patchedPath := filepath.Join(stagingDir, filepath.Base(target)+".new")
+	if err := os.WriteFile(patchedPath, patched, 0755); err != nil {
+		return false, err
+	}
+
+	switch goruntime.GOOS {
+	case "linux":
+		err = a.installAppImage(patchedPath)
+	case "darwin":
+		err = a.installPatchedExecutable(target, patchedPath)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// installPatchedExecutable replaces the executable inside the .app bundle after backing up
+// the whole bundle, so the watchdog can roll back like after a full DMG install.
+func (a *App) installPatchedExecutable(exe string, patchedPath string) error {
+	bundle := filepath.Dir(filepath.Dir(filepath.Dir(exe)))
+	if !strings.HasSuffix(bundle, ".app") {
+		return fmt.Errorf("HushCut is not running from an app bundle")
+	}
+	backup := strings.TrimSuffix(bundle, ".app") + ".previous.app"
+	os.RemoveAll(backup)
+	if out, err := ExecCommand("ditto", bundle, backup).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not back up the current version: %v: %s", err, out)
+	}
+	// Copy rather than rename: the staging folder may be on a different volume.
+	if err := moveFile(patchedPath, exe); err != nil {
+		os.RemoveAll(bundle)
+		os.Rename(backup, bundle)
+		return fmt.Errorf("could not install the patched executable: %w", err)
+	}
+	if err := os.Chmod(exe, 0755); err != nil {
+		return err
+	}
+	backupExe := filepath.Join(backup, "Contents", "MacOS", filepath.Base(exe))
+	return a.handOverToWatchdog(PendingUpdate{TargetPath: bundle, BackupPath: backup, Executable: exe}, backupExe)
+}
@@ -3,13 +3,36 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"time"
 )
 
+// detectionPreRoll is how much audio before a clip DetectSilences decodes when it seeks to the
+// clip, so a seek that lands a little late doesn't cost its first samples. atrim cuts it off.
+const detectionPreRoll = 0.5
+
+// silenceDetectArgs builds the ffmpeg arguments that detect silences in a clip range. It seeks
+// on the input so only the clip's range is decoded, not everything before it; the timestamps
+// ffmpeg reports then count from the returned seekStart.
+func silenceDetectArgs(absPath string, loudnessThreshold, minSilenceDurationSeconds, clipStartSeconds, clipEndSeconds float64) ([]string, float64) {
+	seekStart := math.Max(0, clipStartSeconds-detectionPreRoll)
+	filterGraph := fmt.Sprintf("atrim=start=%.6f:end=%.6f,silencedetect=n=%fdB:d=%f",
+		clipStartSeconds-seekStart, clipEndSeconds-seekStart,
+		loudnessThreshold, minSilenceDurationSeconds,
+	)
+	return []string{
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.6f", seekStart),
+		"-t", fmt.Sprintf("%.6f", clipEndSeconds-seekStart),
+		"-i", absPath, "-af", filterGraph, "-f", "null", "-",
+	}, seekStart
+}
+
 func (a *App) DetectSilences(
 	filePath string,
 	loudnessThreshold float64,
@@ -35,26 +58,28 @@ func (a *App) DetectSilences(
 	absPath := filepath.Join(a.tmpPath, filePath)
 	// Mark the input file as used after its absolute path is determined
 	a.updateFileUsage(absPath)
-	loudnessThresholdStr := fmt.Sprintf("%fdB", loudnessThreshold)
 	if minSilenceDurationSeconds < 0.009 {
 		minSilenceDurationSeconds = 0.009
 	}
 
-	minSilenceDurationForFFmpeg := fmt.Sprintf("%f", minSilenceDurationSeconds)
-
-	filterGraph := fmt.Sprintf("atrim=start=%.6f:end=%.6f,silencedetect=n=%s:d=%s",
-		clipStartSeconds, clipEndSeconds,
-		loudnessThresholdStr, minSilenceDurationForFFmpeg,
-	)
-
-	args := []string{
-		"-nostdin", "-i", absPath, "-af", filterGraph, "-f", "null", "-",
+	args, seekStart := silenceDetectArgs(absPath, loudnessThreshold, minSilenceDurationSeconds, clipStartSeconds, clipEndSeconds)
+	release, err := a.acquireDetectionSlot(absPath)
+	if err != nil {
+		return nil, err
 	}
-	cmd := ExecCommand(a.ffmpegBinaryPath, args...)
+	defer release()
+
+	cmd := a.ffmpegCommand(args...)
 	var outputBuffer bytes.Buffer
 	cmd.Stderr = &outputBuffer
 
-	if err := cmd.Run(); err != nil && len(outputBuffer.String()) == 0 {
+	started := time.Now()
+	err = runJob(jobDetection, cmd)
+	auditFFmpeg("detectSilences", cmd, started, err, outputBuffer.String())
+	if errors.Is(err, errJobCancelled) {
+		return nil, err // the output of a killed run is incomplete
+	}
+	if err != nil && len(outputBuffer.String()) == 0 {
 		return nil, fmt.Errorf("ffmpeg failed: %w. Output: %s", err, outputBuffer.String())
 	}
 
@@ -70,11 +95,12 @@ func (a *App) DetectSilences(
 		line := scanner.Text()
 		if match := silenceStartRegex.FindStringSubmatch(line); len(match) > 1 {
 			start, _ := strconv.ParseFloat(match[1], 64)
-			currentStartTime = start
+			currentStartTime = start + seekStart
 		}
 
 		if match := silenceEndRegex.FindStringSubmatch(line); len(match) > 1 && currentStartTime != -1 {
 			endTime, _ := strconv.ParseFloat(match[1], 64)
+			endTime += seekStart
 
 			adjustedStart := currentStartTime
 			adjustedEnd := endTime
@@ -154,28 +180,37 @@ func (a *App) GetOrDetectSilencesWithCache(
 		ClipEndSeconds:            clipEndSeconds,
 	}
 
+	a.recordDetectionParams(key)
+	a.updateCurrentDetectionParams(key)
+	defer slotQueue.want(filepath.Join(a.tmpPath, filePath))()
+	return a.cachedDetection(key, framerate)
+}
+
+// cachedDetection returns the silences of key from the cache, detecting them on a miss.
+func (a *App) cachedDetection(key CacheKey, framerate float64) ([]SilencePeriod, error) {
 	// 1. Try to read from cache (read lock)
 	a.cacheMutex.RLock()
 	cachedSilences, found := a.silenceCache[key]
 	a.cacheMutex.RUnlock()
 
 	if found {
-		//log.Println("Cache hit for key:", key.FilePath, key.LoudnessThreshold, key.MinSilenceDurationSeconds) // For debugging
+		cacheUsage.touch(key)
+		waveformLog.Debug("Silence cache hit", "file", key.FilePath, "threshold", key.LoudnessThreshold, "minDuration", key.MinSilenceDurationSeconds)
 		return cachedSilences, nil
 	}
 
-	// log.Println("Cache miss for key:", key.FilePath, key.LoudnessThreshold, key.MinSilenceDurationSeconds) // For debugging
+	waveformLog.Debug("Silence cache miss", "file", key.FilePath, "threshold", key.LoudnessThreshold, "minDuration", key.MinSilenceDurationSeconds)
 
 	// 2. If not found, perform the detection
 	silences, err := a.DetectSilences(
-		filePath,
-		loudnessThreshold,
-		minSilenceDurationSeconds,
-		paddingLeftSeconds,
-		paddingRightSeconds,
-		minContentDuration,
-		clipStartSeconds,
-		clipEndSeconds,
+		key.FilePath,
+		key.LoudnessThreshold,
+		key.MinSilenceDurationSeconds,
+		key.PaddingLeftSeconds,
+		key.PaddingRightSeconds,
+		key.MinContentDuration,
+		key.ClipStartSeconds,
+		key.ClipEndSeconds,
 		framerate,
 	)
 	if err != nil {
@@ -185,7 +220,7 @@ func (a *App) GetOrDetectSilencesWithCache(
 
 	// 3. Store the result in the cache (write lock)
 	a.cacheMutex.Lock()
-	a.silenceCache[key] = silences
+	a.storeSilencesLocked(key, silences)
 	a.cacheMutex.Unlock()
 	return silences, nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSilenceDetectArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end float64
+		wantSeek   float64
+		wantArgs   []string
+	}{
+		{
+			name: "clip at the start of the file",
+			end:  10,
+			wantArgs: []string{"-nostdin", "-ss", "0.000000", "-t", "10.000000", "-i", "in.wav",
+				"-af", "atrim=start=0.000000:end=10.000000,silencedetect=n=-40.000000dB:d=0.500000", "-f", "null", "-"},
+		},
+		{
+			name:  "clip closer to the start than the pre-roll",
+			start: 0.2, end: 5,
+			wantArgs: []string{"-nostdin", "-ss", "0.000000", "-t", "5.000000", "-i", "in.wav",
+				"-af", "atrim=start=0.200000:end=5.000000,silencedetect=n=-40.000000dB:d=0.500000", "-f", "null", "-"},
+		},
+		{
+			name:  "clip later in the file seeks to the pre-roll",
+			start: 60, end: 90, wantSeek: 59.5,
+			wantArgs: []string{"-nostdin", "-ss", "59.500000", "-t", "30.500000", "-i", "in.wav",
+				"-af", "atrim=start=0.500000:end=30.500000,silencedetect=n=-40.000000dB:d=0.500000", "-f", "null", "-"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, seek := silenceDetectArgs("in.wav", -40, 0.5, tt.start, tt.end)
+			if seek != tt.wantSeek {
+				t.Errorf("seekStart = %v, want %v", seek, tt.wantSeek)
+			}
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("args =\n%q\nwant\n%q", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+)
+
+// On Linux the webview's hardware acceleration ("gpuPolicy") and the display server GTK
+// connects to ("displayBackend") can be chosen with settings or the --gpu-policy and
+// --display-backend flags. Both are read before the window exists, so a changed setting
+// takes effect at the next start. Flags win over settings, and policy values over both
+// settings and defaults.
+//
+// Acceleration is on demand by default but off with the NVIDIA driver, where WebKitGTK often
+// renders a blank window. If a start with acceleration never got its window up, the next
+// start turns it off until the setting changes (or the flag is passed).
+
+const (
+	gpuPolicyAlways   = "always"
+	gpuPolicyOnDemand = "ondemand"
+	gpuPolicyNever    = "never"
+
+	displayBackendAuto    = "auto"
+	displayBackendWayland = "wayland"
+	displayBackendX11     = "x11"
+
+	// gpuStartMarkerName holds the GPU policy of a start whose window is not up yet.
+	gpuStartMarkerName = "gpu-start.pending"
+)
+
+// gpuStartMarkerWritten is set when this start wrote the marker; a start that fell back
+// keeps the marker of the one that failed.
+var gpuStartMarkerWritten bool
+
+var (
+	gpuPolicies     = []string{gpuPolicyAlways, gpuPolicyOnDemand, gpuPolicyNever}
+	displayBackends = []string{displayBackendAuto, displayBackendWayland, displayBackendX11}
+)
+
+// displayOptions are the resolved Linux window options.
+type displayOptions struct {
+	GPUPolicy string
+	Backend   string
+}
+
+func isOneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// linuxConfigDir is where settings.json lives on Linux: $XDG_CONFIG_HOME/HushCut.
+func linuxConfigDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "HushCut")
+}
+
+// resolveDisplayOptions picks the GPU policy and display backend from the flags (empty when
+// not given), the settings and the defaults, and applies the backend to the environment GTK
+// reads when the window is created.
+func resolveDisplayOptions(gpuFlag, backendFlag string) displayOptions {
+	opts := displayOptions{GPUPolicy: defaultGPUPolicy(), Backend: displayBackendAuto}
+	if goruntime.GOOS != "linux" {
+		return opts
+	}
+
+	settings := map[string]any{}
+	if err := readJSONWithRecovery(filepath.Join(linuxConfigDir(), settingsFileName), &settings); err != nil && !os.IsNotExist(err) {
+		appLog.Warn("Display: could not read settings; using defaults", "err", err)
+	}
+	loadPolicy().apply(settings)
+
+	if v := strings.ToLower(settingString(settings, "gpuPolicy", "")); v != "" {
+		if isOneOf(v, gpuPolicies) {
+			opts.GPUPolicy = v
+		} else {
+			appLog.Warn("Display: ignoring unknown gpuPolicy", "value", v)
+		}
+	}
+	if v := strings.ToLower(settingString(settings, "displayBackend", "")); v != "" {
+		if isOneOf(v, displayBackends) {
+			opts.Backend = v
+		} else {
+			appLog.Warn("Display: ignoring unknown displayBackend", "value", v)
+		}
+	}
+
+	if gpuFlag != "" {
+		opts.GPUPolicy = gpuFlag
+	} else if failed := readGPUStartMarker(); failed != "" && failed == opts.GPUPolicy && failed != gpuPolicyNever {
+		appLog.Warn("Display: the last start with this GPU policy did not open its window; hardware acceleration stays off until the gpuPolicy setting changes", "gpuPolicy", failed)
+		opts.GPUPolicy = gpuPolicyNever
+		return applyDisplayBackend(opts, backendFlag)
+	}
+	if opts.GPUPolicy != gpuPolicyNever {
+		writeGPUStartMarker(opts.GPUPolicy)
+	}
+	return applyDisplayBackend(opts, backendFlag)
+}
+
+// defaultGPUPolicy turns acceleration off where WebKitGTK is known to misbehave.
+func defaultGPUPolicy() string {
+	if _, err := os.Stat("/proc/driver/nvidia/version"); err == nil {
+		return gpuPolicyNever
+	}
+	return gpuPolicyOnDemand
+}
+
+// applyDisplayBackend sets GDK_BACKEND for the chosen backend. A GDK_BACKEND from the
+// environment is left alone, and a backend without a display to connect to falls back to
+// auto.
+func applyDisplayBackend(opts displayOptions, backendFlag string) displayOptions {
+	if backendFlag != "" {
+		opts.Backend = backendFlag
+	}
+	if env := os.Getenv("GDK_BACKEND"); env != "" {
+		if opts.Backend != displayBackendAuto {
+			appLog.Info("Display: GDK_BACKEND is set; ignoring displayBackend", "GDK_BACKEND", env, "displayBackend", opts.Backend)
+		}
+		opts.Backend = displayBackendAuto
+		return opts
+	}
+	switch opts.Backend {
+	case displayBackendWayland:
+		if os.Getenv("WAYLAND_DISPLAY") == "" {
+			appLog.Info("Display: no Wayland session (WAYLAND_DISPLAY is unset); letting GTK choose")
+			opts.Backend = displayBackendAuto
+		}
+	case displayBackendX11:
+		if os.Getenv("DISPLAY") == "" {
+			appLog.Info("Display: no X server (DISPLAY is unset); letting GTK choose")
+			opts.Backend = displayBackendAuto
+		}
+	}
+	if opts.Backend != displayBackendAuto {
+		os.Setenv("GDK_BACKEND", opts.Backend)
+	}
+	appLog.Info("Display", "gpuPolicy", opts.GPUPolicy, "backend", opts.Backend)
+	return opts
+}
+
+func gpuStartMarkerPath() string {
+	base, err := stateDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, gpuStartMarkerName)
+}
+
+func readGPUStartMarker() string {
+	path := gpuStartMarkerPath()
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func writeGPUStartMarker(policy string) {
+	if path := gpuStartMarkerPath(); path != "" {
+		gpuStartMarkerWritten = os.WriteFile(path, []byte(policy), 0644) == nil
+	}
+}
+
+// windowIsUp removes the GPU start marker once the frontend has loaded.
+func windowIsUp() {
+	if !gpuStartMarkerWritten {
+		return
+	}
+	if path := gpuStartMarkerPath(); path != "" {
+		os.Remove(path)
+	}
+}
+
+// validateDisplayFlag checks a --gpu-policy or --display-backend value.
+func validateDisplayFlag(name, value string, allowed []string) error {
+	if value == "" || isOneOf(value, allowed) {
+		return nil
+	}
+	return fmt.Errorf("invalid -%s %q: must be one of %s", name, value, strings.Join(allowed, ", "))
+}
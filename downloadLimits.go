@@ -0,0 +1,189 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Downloads and archives come from outside HushCut, so they are read with limits: a download
+// stops as soon as it grows past what the file can plausibly be, and unzip checks every entry
+// (name, type, declared size, compression ratio) before it extracts it and stops writing when
+// an entry turns out larger than it claimed. Going over a limit fails the whole operation with
+// an error wrapping errLimitExceeded; nothing partly extracted is used.
+
+const (
+	maxFFmpegDownloadBytes  = 256 << 20 // the ffbinaries zips are well under 100 MB
+	maxFFmpegExtractedBytes = 1 << 30
+	maxUpdateDownloadBytes  = 2 << 30
+	maxBundleExtractedBytes = 512 << 20
+	maxFFbinariesReplyBytes = 1 << 20
+	maxArchiveEntries       = 10000
+	// Real archives compress a few times over at most; thousands are a zip bomb.
+	maxCompressionRatio = 200
+)
+
+var errLimitExceeded = errors.New("size limit exceeded")
+
+// archiveLimits bounds what unzip may extract from one archive.
+type archiveLimits struct {
+	maxEntries    int
+	maxTotalBytes int64 // decompressed, all entries together
+	maxEntryBytes int64
+}
+
+var (
+	ffmpegArchiveLimits = archiveLimits{maxEntries: 16, maxTotalBytes: maxFFmpegExtractedBytes, maxEntryBytes: maxFFmpegExtractedBytes}
+	bundleArchiveLimits = archiveLimits{maxEntries: maxArchiveEntries, maxTotalBytes: maxBundleExtractedBytes, maxEntryBytes: maxJSONFileBytes}
+)
+
+func limitError(what string, limit int64) error {
+	if limit >= 1<<20 {
+		return fmt.Errorf("%w: %s is larger than %d MB", errLimitExceeded, what, limit>>20)
+	}
+	return fmt.Errorf("%w: %s is larger than %d bytes", errLimitExceeded, what, limit)
+}
+
+// checkDownloadSize rejects a response whose announced length is already over limit.
+func checkDownloadSize(resp *http.Response, limit int64, what string) error {
+	if resp.ContentLength > limit {
+		return limitError(what, limit)
+	}
+	return nil
+}
+
+// cappedReader fails with a limit error once more than limit bytes have been read, whatever
+// the server announced.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+	what      string
+}
+
+func capReader(r io.Reader, limit int64, what string) io.Reader {
+	return &cappedReader{r: r, remaining: limit, limit: limit, what: what}
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining < 0 {
+		return 0, limitError(c.what, c.limit)
+	}
+	if int64(len(p)) > c.remaining+1 {
+		p = p[:c.remaining+1]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining < 0 {
+		return n - 1, limitError(c.what, c.limit)
+	}
+	return n, err
+}
+
+// unzip extracts src into dest within limits.
+func unzip(src, dest string, limits archiveLimits) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if len(r.File) > limits.maxEntries {
+		return fmt.Errorf("%w: the archive has %d entries, at most %d are allowed", errLimitExceeded, len(r.File), limits.maxEntries)
+	}
+	// The declared sizes are checked up front so a bomb is refused before anything is written.
+	var declared uint64
+	for _, f := range r.File {
+		if err := checkArchiveEntry(f, limits); err != nil {
+			return err
+		}
+		declared += f.UncompressedSize64
+	}
+	if declared > uint64(limits.maxTotalBytes) {
+		return limitError("the extracted archive", limits.maxTotalBytes)
+	}
+
+	// Ensure the destination directory exists
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	remaining := limits.maxTotalBytes
+	for _, f := range r.File {
+		fpath := filepath.Join(dest, f.Name)
+
+		// Check for Zip Slip. This is a security vulnerability where a malicious
+		// zip file could write files outside of the destination directory.
+		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal file path: %s", fpath)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(fpath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			return err
+		}
+
+		written, err := extractArchiveEntry(f, fpath, min(limits.maxEntryBytes, remaining))
+		if err != nil {
+			os.Remove(fpath)
+			return err
+		}
+		remaining -= written
+	}
+	return nil
+}
+
+// checkArchiveEntry rejects entries unzip shouldn't extract, judging by the archive's directory.
+func checkArchiveEntry(f *zip.File, limits archiveLimits) error {
+	name := filepath.ToSlash(f.Name)
+	if name == "" || strings.HasPrefix(name, "/") || filepath.IsAbs(f.Name) || filepath.VolumeName(f.Name) != "" {
+		return fmt.Errorf("illegal file path: %q", f.Name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return fmt.Errorf("illegal file path: %q", f.Name)
+		}
+	}
+	if mode := f.Mode(); mode&os.ModeType != 0 && !mode.IsDir() {
+		return fmt.Errorf("%s is not a regular file", f.Name)
+	}
+	if f.UncompressedSize64 > uint64(limits.maxEntryBytes) {
+		return limitError(f.Name, limits.maxEntryBytes)
+	}
+	if f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > maxCompressionRatio {
+		return fmt.Errorf("%w: %s is compressed %d times over", errLimitExceeded, f.Name, f.UncompressedSize64/f.CompressedSize64)
+	}
+	if f.CompressedSize64 == 0 && f.UncompressedSize64 > 0 {
+		return fmt.Errorf("%s has no compressed data", f.Name)
+	}
+	return nil
+}
+
+// extractArchiveEntry writes f to path, stopping once it passes limit bytes.
+func extractArchiveEntry(f *zip.File, path string, limit int64) (int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm()&0755)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(outFile, capReader(rc, limit, f.Name))
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	return written, err
+}
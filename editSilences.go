@@ -1,11 +1,8 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
 	"math"
-	"os"
 	"sort"
 )
 
@@ -211,6 +208,33 @@ func CreateEditsWithOptionalSilence(
 	return edits
 }
 
+// editsForClip turns a clip's silences, in seconds of source audio, into its edit instructions.
+func editsForClip(item *TimelineItem, silencesInSeconds []SilencePeriod, timelineFPS float64, keepSilenceSegments bool) []EditInstruction {
+	// Ratio to convert source frames FROM timeline domain TO project domain for processing.
+	sourceToTimelineFpsRatio := item.SourceFPS / timelineFPS
+
+	var frameBasedSilences []SilenceInterval
+	for _, silenceInSec := range silencesInSeconds {
+		startFrame := silenceInSec.Start * item.SourceFPS
+		endFrame := silenceInSec.End * item.SourceFPS
+		if endFrame > startFrame+floatEpsilon {
+			frameBasedSilences = append(frameBasedSilences, SilenceInterval{Start: startFrame, End: endFrame})
+		}
+	}
+
+	clipDataItem := ClipData{
+		SourceStartFrame: item.SourceStartFrame * sourceToTimelineFpsRatio,
+		SourceEndFrame:   item.SourceEndFrame * sourceToTimelineFpsRatio,
+		// Timeline placement frames remain in the TIMELINE domain.
+		StartFrame: item.StartFrame,
+		EndFrame:   item.EndFrame,
+	}
+
+	// NO MORE CONVERSIONS. The returned source frames are already in the
+	// correct project FPS domain, which is what the Python script expects.
+	return CreateEditsWithOptionalSilence(clipDataItem, frameBasedSilences, item.SourceFPS, timelineFPS, keepSilenceSegments)
+}
+
 func (a *App) CalculateAndStoreEditsForTimeline(
 	projectData ProjectDataPayload,
 	keepSilenceSegments bool,
@@ -218,23 +242,30 @@ func (a *App) CalculateAndStoreEditsForTimeline(
 ) (ProjectDataPayload, error) {
 
 	if len(projectData.Timeline.AudioTrackItems) == 0 {
-		log.Println("CalculateAndStoreEditsForTimeline: No audio track items to process.")
+		appLog.Info("CalculateAndStoreEditsForTimeline: no audio track items to process")
 		return projectData, nil
 	}
 
+	a.updateCurrentKeepSilence(keepSilenceSegments)
+
 	timelineFPS := projectData.Timeline.FPS
 	projectFPS := projectData.Timeline.ProjectFPS // Use ProjectFPS as the source rate
 	if timelineFPS <= floatEpsilon || projectFPS <= floatEpsilon {
 		return projectData, fmt.Errorf("invalid FPS values: timeline=%.2f, project=%.2f", timelineFPS, projectFPS)
 	}
 
-	log.Printf("timelineFPS is %f - projectFPS is %f\n", timelineFPS, projectFPS)
+	appLog.Debug("Frame rates", "timelineFPS", timelineFPS, "projectFPS", projectFPS)
+
+	session, err := a.GetTimelineSession(projectData.ProjectName, projectData.Timeline.Name)
+	if err != nil || session == nil {
+		session = &TimelineSession{}
+	}
+	params := a.GetCurrentParams()
+	shifted, suppressed := 0, 0
 
 	for i := range projectData.Timeline.AudioTrackItems {
 		item := &projectData.Timeline.AudioTrackItems[i]
 		//log.Printf("sourceFPS is %f", item.SourceFPS)
-		// Ratio to convert source frames FROM timeline domain TO project domain for processing.
-		sourceToTimelineFpsRatio := item.SourceFPS / timelineFPS
 		itemSpecificSilencesInSeconds, silencesFound := allClipSilencesMap[item.ID]
 		if !silencesFound {
 			if len(item.EditInstructions) == 0 {
@@ -242,38 +273,18 @@ func (a *App) CalculateAndStoreEditsForTimeline(
 			}
 			continue
 		}
-
-		var frameBasedSilences []SilenceInterval
-		if len(itemSpecificSilencesInSeconds) > 0 {
-			for _, silenceInSec := range itemSpecificSilencesInSeconds {
-				startFrame := silenceInSec.Start * item.SourceFPS
-				endFrame := silenceInSec.End * item.SourceFPS
-				if endFrame > startFrame+floatEpsilon {
-					frameBasedSilences = append(frameBasedSilences, SilenceInterval{Start: startFrame, End: endFrame})
-				}
-			}
+		clipParams := params
+		if override, ok := session.ClipOverrides[item.ID]; ok {
+			clipParams = override
 		}
-
-		clipDataItem := ClipData{
-			SourceStartFrame: item.SourceStartFrame * sourceToTimelineFpsRatio,
-			SourceEndFrame:   item.SourceEndFrame * sourceToTimelineFpsRatio,
-			// Timeline placement frames remain in the TIMELINE domain.
-			StartFrame: item.StartFrame,
-			EndFrame:   item.EndFrame,
-		}
-
-		editInstructions := CreateEditsWithOptionalSilence(clipDataItem, frameBasedSilences, item.SourceFPS, timelineFPS, keepSilenceSegments)
-		// NO MORE CONVERSIONS. The returned source frames are already in the
-		// correct project FPS domain, which is what the Python script expects.
-		item.EditInstructions = editInstructions
+		itemSpecificSilencesInSeconds, clipShifted, clipSuppressed := session.Subtitles.constrain(item, itemSpecificSilencesInSeconds, timelineFPS, clipParams.MinSilenceDurationSeconds)
+		shifted, suppressed = shifted+clipShifted, suppressed+clipSuppressed
+		item.EditInstructions = editsForClip(item, itemSpecificSilencesInSeconds, timelineFPS, keepSilenceSegments)
 	}
-
-	debug_path := "debug_project_data_from_go.json"
-	jsonString, err := json.MarshalIndent(projectData, "", " ")
-	if err != nil {
-		log.Println("Error marshaling project data to JSON:", err)
-		return projectData, err
+	if shifted > 0 || suppressed > 0 {
+		appLog.Info("Subtitles kept captions aligned", "shifted", shifted, "suppressed", suppressed)
 	}
-	os.WriteFile(debug_path, jsonString, 0644)
+
+	a.dumpProjectData(&projectData)
 	return projectData, nil
 }
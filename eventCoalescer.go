@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Many concurrent ffmpeg and waveform jobs each report progress several times a second, and
+// every event crosses the webview bridge on its own. coalescingEvents collects the progress
+// events of a window and sends each kind once per window as "<event>:batch", with the latest
+// payload per file. Other events pass straight through, after whatever is pending, so a
+// "conversion:done" never overtakes the progress of the same file.
+
+const eventCoalesceWindow = 100 * time.Millisecond
+
+// coalescedEvents are the high-frequency events that are batched.
+var coalescedEvents = map[string]bool{
+	"analysis:progress":   true,
+	"conversion:progress": true,
+	"download:progress":   true,
+	"prep:progress":       true,
+	"waveform:progress":   true,
+}
+
+// coalesceKeyer is a payload of which only the latest per key matters, e.g. per file.
+type coalesceKeyer interface {
+	coalesceKey() string
+}
+
+func (p ProgressStatus) coalesceKey() string { return p.FilePath }
+
+func (p WaveformProgress) coalesceKey() string {
+	return p.FilePath + "|" + formatSeconds(p.ClipStart) + "|" + formatSeconds(p.ClipEnd)
+}
+
+// eventBatch is what is pending of one event.
+type eventBatch struct {
+	payloads []interface{}
+	index    map[string]int // position in payloads by coalesce key
+}
+
+type coalescingEvents struct {
+	next    eventSink
+	mu      sync.Mutex
+	pending map[string]*eventBatch
+	order   []string // event names in the order they first got pending
+	timer   *time.Timer
+}
+
+// newCoalescingEvents wraps next until ctx ends.
+func newCoalescingEvents(ctx context.Context, next eventSink) *coalescingEvents {
+	c := &coalescingEvents{next: next, pending: map[string]*eventBatch{}}
+	go saferun(func() {
+		<-ctx.Done()
+		c.flush()
+	})
+	return c
+}
+
+func (c *coalescingEvents) emit(eventName string, data ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !coalescedEvents[eventName] || len(data) != 1 {
+		c.flushLocked()
+		c.next.emit(eventName, data...)
+		return
+	}
+
+	batch, ok := c.pending[eventName]
+	if !ok {
+		batch = &eventBatch{index: map[string]int{}}
+		c.pending[eventName] = batch
+		c.order = append(c.order, eventName)
+	}
+	if keyer, ok := data[0].(coalesceKeyer); ok {
+		key := keyer.coalesceKey()
+		if i, seen := batch.index[key]; seen {
+			batch.payloads[i] = data[0]
+		} else {
+			batch.index[key] = len(batch.payloads)
+			batch.payloads = append(batch.payloads, data[0])
+		}
+	} else {
+		batch.payloads = append(batch.payloads, data[0])
+	}
+	if c.timer == nil {
+		c.timer = time.AfterFunc(eventCoalesceWindow, c.flush)
+	}
+}
+
+// flush sends what is pending.
+func (c *coalescingEvents) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked sends what is pending. c.mu is held, which keeps batches in order with the
+// events that pass through.
+func (c *coalescingEvents) flushLocked() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	for _, eventName := range c.order {
+		c.next.emit(eventName+":batch", c.pending[eventName].payloads)
+	}
+	c.pending, c.order = map[string]*eventBatch{}, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every ffmpeg run that processes audio is appended to ffmpeg_audit.log (JSON lines, next to
+// log.txt) with its exact command line, so a failed conversion can be reproduced by pasting
+// the command into a terminal. The file rotates at ffmpegAuditMaxBytes, keeping
+// ffmpegAuditGenerations old copies (ffmpeg_audit.log.1, .2, ...).
+
+const (
+	ffmpegAuditFileName    = "ffmpeg_audit.log"
+	ffmpegAuditMaxBytes    = 5 * 1024 * 1024
+	ffmpegAuditGenerations = 3
+	ffmpegAuditStderrBytes = 4096
+)
+
+// FFmpegAuditEntry is one recorded ffmpeg invocation.
+type FFmpegAuditEntry struct {
+	Time       time.Time `json:"time"`
+	Purpose    string    `json:"purpose"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"`
+}
+
+var ffmpegAuditMu sync.Mutex
+
+func ffmpegAuditPath() (string, error) {
+	base, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, ffmpegAuditFileName), nil
+}
+
+// stderrTail keeps the last ffmpegAuditStderrBytes written to it.
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - ffmpegAuditStderrBytes; over > 0 {
+		t.buf = t.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// quoteCommandLine renders args so they can be pasted into the platform's shell.
+func quoteCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case arg != "" && !strings.ContainsAny(arg, " \t\"'$`\\|&;<>()*?[]{}!#~%"):
+			quoted[i] = arg
+		case goruntime.GOOS == "windows":
+			quoted[i] = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		default:
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// auditFFmpeg records a finished ffmpeg command. stderr is truncated to its last 4 KB.
+func auditFFmpeg(purpose string, cmd *exec.Cmd, started time.Time, runErr error, stderr string) {
+	entry := FFmpegAuditEntry{
+		Time:       started,
+		Purpose:    purpose,
+		Command:    quoteCommandLine(cmd.Args),
+		Args:       cmd.Args[1:],
+		DurationMs: time.Since(started).Milliseconds(),
+		ExitCode:   -1,
+	}
+	if cmd.ProcessState != nil {
+		entry.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	if len(stderr) > ffmpegAuditStderrBytes {
+		stderr = "…" + stderr[len(stderr)-ffmpegAuditStderrBytes:]
+	}
+	entry.Stderr = stderr
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	path, err := ffmpegAuditPath()
+	if err != nil {
+		return
+	}
+
+	ffmpegAuditMu.Lock()
+	defer ffmpegAuditMu.Unlock()
+	appendAuditLine(path, line, ffmpegAuditMaxBytes)
+}
+
+// appendAuditLine appends line to the JSON lines log at path, rotating it first if it would
+// grow past maxBytes. The caller serializes access to the log.
+func appendAuditLine(path string, line []byte, maxBytes int64) {
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxBytes {
+		rotateAuditLog(path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		ffmpegLog.Warn("Could not open audit log", "file", filepath.Base(path), "err", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+func rotateAuditLog(path string) {
+	os.Remove(fmt.Sprintf("%s.%d", path, ffmpegAuditGenerations))
+	for i := ffmpegAuditGenerations - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
+
+func readFFmpegAuditFile(path string) ([]FFmpegAuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []FFmpegAuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry FFmpegAuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// GetFFmpegAuditLog returns up to n of the most recent ffmpeg invocations, newest first.
+func (a *App) GetFFmpegAuditLog(n int) ([]FFmpegAuditEntry, error) {
+	if n <= 0 {
+		n = 50
+	}
+	path, err := ffmpegAuditPath()
+	if err != nil {
+		return nil, err
+	}
+
+	ffmpegAuditMu.Lock()
+	defer ffmpegAuditMu.Unlock()
+
+	result := []FFmpegAuditEntry{}
+	for gen := 0; gen <= ffmpegAuditGenerations && len(result) < n; gen++ {
+		file := path
+		if gen > 0 {
+			file = fmt.Sprintf("%s.%d", path, gen)
+		}
+		entries, err := readFFmpegAuditFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("could not read %s: %w", filepath.Base(file), err)
+		}
+		for i := len(entries) - 1; i >= 0 && len(result) < n; i-- {
+			result = append(result, entries[i])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+)
+
+// FfmpegInfo describes the ffmpeg binary in use compared to the version HushCut ships with.
+type FfmpegInfo struct {
+	Path             string `json:"path"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	SupportedVersion string `json:"supportedVersion"`
+	// Managed is true for the copy HushCut downloaded itself (and can update).
+	Managed  bool `json:"managed"`
+	Outdated bool `json:"outdated"`
+	Corrupt  bool `json:"corrupt"`
+}
+
+var ffmpegVersionPattern = regexp.MustCompile(`ffmpeg version n?(\d+(?:\.\d+)*)`)
+
+// probeFfmpegVersion runs "ffmpeg -version" and extracts the release number.
+// Git snapshot builds have no release number and yield "".
+func probeFfmpegVersion(binaryPath string) (string, error) {
+	out, err := ExecCommand(binaryPath, "-version").Output()
+	if err != nil {
+		return "", err
+	}
+	if m := ffmpegVersionPattern.FindStringSubmatch(string(out)); m != nil {
+		return m[1], nil
+	}
+	return "", nil
+}
+
+// compareVersions compares dotted numeric versions; missing components count as 0.
+func compareVersions(a string, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (a *App) managedFfmpegPath() string {
+	name := "ffmpeg"
+	if goruntime.GOOS == "windows" {
+		name = "ffmpeg.exe"
+	}
+	return filepath.Join(a.userResourcesPath, name)
+}
+
+// GetFfmpegInfo reports which ffmpeg is used and whether it is outdated or broken.
+func (a *App) GetFfmpegInfo() FfmpegInfo {
+	a.ffmpegMutex.RLock()
+	path := a.ffmpegBinaryPath
+	a.ffmpegMutex.RUnlock()
+
+	info := FfmpegInfo{
+		Path:             path,
+		SupportedVersion: a.ffmpegVersion,
+		Managed:          path == a.managedFfmpegPath(),
+	}
+	if _, err := os.Stat(path); err != nil {
+		return info
+	}
+	version, err := probeFfmpegVersion(path)
+	if err != nil {
+		info.Corrupt = true
+		return info
+	}
+	info.InstalledVersion = version
+	info.Outdated = version != "" && a.ffmpegVersion != "" && compareVersions(version, a.ffmpegVersion) < 0
+	return info
+}
+
+// checkFfmpegVersion runs after startup and tells the UI when the managed copy needs attention.
+func (a *App) checkFfmpegVersion() {
+	info := a.GetFfmpegInfo()
+	switch {
+	case info.Corrupt:
+		ffmpegLog.Warn("ffmpeg does not run; it may be corrupt", "path", info.Path)
+		a.emit("ffmpeg:corrupt", info)
+	case info.Outdated && info.Managed:
+		ffmpegLog.Warn("Bundled ffmpeg is outdated", "installed", info.InstalledVersion, "supported", info.SupportedVersion)
+		a.emit("ffmpeg:outdated", info)
+	}
+}
+
+// UpdateFFmpeg replaces HushCut's own ffmpeg copy with the supported version. The previous
+// binary is kept until the new one has been validated and restored if validation fails.
+func (a *App) UpdateFFmpeg() (FfmpegInfo, error) {
+	managed := a.managedFfmpegPath()
+	backup := managed + ".old"
+
+	hadPrevious := false
+	if _, err := os.Stat(managed); err == nil {
+		if err := os.Rename(managed, backup); err != nil {
+			return a.GetFfmpegInfo(), fmt.Errorf("could not back up current ffmpeg: %w", err)
+		}
+		hadPrevious = true
+	}
+
+	a.ffmpegMutex.Lock()
+	previousPath := a.ffmpegBinaryPath
+	a.ffmpegBinaryPath = managed
+	a.ffmpegMutex.Unlock()
+
+	restore := func(cause error) (FfmpegInfo, error) {
+		os.Remove(managed)
+		if hadPrevious {
+			os.Rename(backup, managed)
+		}
+		a.ffmpegMutex.Lock()
+		a.ffmpegBinaryPath = previousPath
+		a.ffmpegMutex.Unlock()
+		return a.GetFfmpegInfo(), cause
+	}
+
+	if err := a.DownloadFFmpeg(); err != nil {
+		return restore(fmt.Errorf("ffmpeg download failed: %w", err))
+	}
+	info := a.GetFfmpegInfo()
+	if info.Corrupt || !binaryExists(managed) {
+		return restore(fmt.Errorf("the downloaded ffmpeg does not run"))
+	}
+
+	os.Remove(backup)
+	ffmpegLog.Info("ffmpeg updated", "version", info.InstalledVersion)
+	a.emit("ffmpeg:updated", info)
+	return info, nil
+}
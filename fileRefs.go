@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// fileRefs counts active users of cached WAVs (running tasks, pending mixdowns) and
+// remembers which files the UI is currently displaying. Cleanup never deletes a
+// referenced file, no matter how old it is.
+type fileRefs struct {
+	mu        sync.Mutex
+	counts    map[string]int
+	displayed map[string]bool
+}
+
+func newFileRefs() *fileRefs {
+	return &fileRefs{counts: make(map[string]int), displayed: make(map[string]bool)}
+}
+
+// cachePathFor turns a processed file name or path into the full path used as key.
+func (a *App) cachePathFor(nameOrPath string) string {
+	if filepath.IsAbs(nameOrPath) {
+		return filepath.Clean(nameOrPath)
+	}
+	return filepath.Join(a.tmpPath, filepath.Base(nameOrPath))
+}
+
+// acquireFileRefs marks files as in use until the returned release func is called.
+func (a *App) acquireFileRefs(namesOrPaths ...string) (release func()) {
+	paths := make([]string, 0, len(namesOrPaths))
+	for _, p := range namesOrPaths {
+		if p != "" {
+			paths = append(paths, a.cachePathFor(p))
+		}
+	}
+
+	a.fileRefs.mu.Lock()
+	for _, p := range paths {
+		a.fileRefs.counts[p]++
+	}
+	a.fileRefs.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.fileRefs.mu.Lock()
+			defer a.fileRefs.mu.Unlock()
+			for _, p := range paths {
+				if a.fileRefs.counts[p]--; a.fileRefs.counts[p] <= 0 {
+					delete(a.fileRefs.counts, p)
+				}
+			}
+		})
+	}
+}
+
+// isFileReferenced reports whether a task holds a reference to path or the UI displays it.
+func (a *App) isFileReferenced(path string) bool {
+	path = a.cachePathFor(path)
+	a.fileRefs.mu.Lock()
+	defer a.fileRefs.mu.Unlock()
+	return a.fileRefs.counts[path] > 0 || a.fileRefs.displayed[path]
+}
+
+// SetDisplayedClips tells the backend which processed files the UI is currently showing,
+// replacing the previous set. Those files are protected from cleanup.
+func (a *App) SetDisplayedClips(processedFileNames []string) {
+	displayed := make(map[string]bool, len(processedFileNames))
+	for _, name := range processedFileNames {
+		if name != "" {
+			displayed[a.cachePathFor(name)] = true
+		}
+	}
+	a.fileRefs.mu.Lock()
+	a.fileRefs.displayed = displayed
+	a.fileRefs.mu.Unlock()
+}
@@ -1,21 +1,19 @@
 package main
 
 import (
-	"archive/zip"
 	"bytes"
-	"context"
 	_ "embed"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	goruntime "runtime"
 	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"github.com/oliwoli/hushcut/internal/safejson"
 )
 
 func moveFile(sourcePath, destPath string) error {
@@ -40,7 +38,7 @@ func moveFile(sourcePath, destPath string) error {
 	err = os.Remove(sourcePath)
 	if err != nil {
 		// This is not a critical error if the copy succeeded, but good to log.
-		log.Printf("Warning: failed to remove original source file after copy: %s", sourcePath)
+		appLog.Warn("Failed to remove original source file after copy", "path", sourcePath)
 	}
 	return nil
 }
@@ -59,82 +57,23 @@ func binaryExists(path string) bool {
 	return cmd.Run() == nil
 }
 
-func unzip(src, dest string) error {
-	r, err := zip.OpenReader(src)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	// Ensure the destination directory exists
-	if err := os.MkdirAll(dest, 0755); err != nil {
-		return err
-	}
-
-	// Iterate through the files in the archive
-	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-
-		// Check for Zip Slip. This is a security vulnerability where a malicious
-		// zip file could write files outside of the destination directory.
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal file path: %s", fpath)
-		}
-
-		// Create directory if it's a directory
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
-		}
-
-		// Create the file's parent directory if it doesn't exist
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return err
-		}
-
-		// Create the destination file
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return err
-		}
-
-		// Open the source file from the zip archive
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close() // Clean up the created file
-			return err
-		}
-
-		// Copy the file content
-		_, err = io.Copy(outFile, rc)
-
-		// Close the files, important to do this before checking the copy error
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 //go:embed python-backend/src/HushCut.lua
 var luaScriptData []byte
 
 func (a *App) installLuaScript() {
 	if len(luaScriptData) == 0 {
-		log.Println("Embedded Lua script is empty. Skipping installation.")
+		appLog.Warn("Embedded Lua script is empty; skipping installation")
 		return
 	}
 
-	platform := runtime.Environment(a.ctx).Platform
+	platform := goruntime.GOOS
 	var destScriptsDir string
 	switch platform {
 	case "darwin":
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			log.Printf("Could not get user home directory on macOS: %v", err)
+			appLog.Warn("Could not get user home directory", "err", err)
+			a.emit("luaScript:error", err.Error())
 			return
 		}
 		destScriptsDir = filepath.Join(homeDir, "Library", "Application Support", "Blackmagic Design", "DaVinci Resolve", "Fusion", "Scripts", "Edit")
@@ -142,7 +81,8 @@ func (a *App) installLuaScript() {
 	case "windows":
 		appDataDir := os.Getenv("APPDATA")
 		if appDataDir == "" {
-			log.Println("Could not resolve %APPDATA% directory on Windows.")
+			appLog.Warn("Could not resolve %APPDATA% directory")
+			a.emit("luaScript:error", "could not resolve %APPDATA%")
 			return
 		}
 		destScriptsDir = filepath.Join(appDataDir, "Blackmagic Design", "DaVinci Resolve", "Support", "Fusion", "Scripts", "Edit")
@@ -150,13 +90,14 @@ func (a *App) installLuaScript() {
 	case "linux":
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			log.Printf("Could not get user home directory on Linux: %v", err)
+			appLog.Warn("Could not get user home directory", "err", err)
+			a.emit("luaScript:error", err.Error())
 			return
 		}
 		destScriptsDir = filepath.Join(homeDir, ".local", "share", "DaVinciResolve", "Fusion", "Scripts", "Edit")
 
 	default:
-		log.Printf("Resolve script installation not supported on this platform: %s", platform)
+		appLog.Info("Resolve script installation not supported on this platform", "platform", platform)
 		return
 	}
 
@@ -164,24 +105,28 @@ func (a *App) installLuaScript() {
 	existingData, err := os.ReadFile(destScriptPath)
 	if err == nil {
 		if bytes.Equal(existingData, luaScriptData) {
-			log.Printf("Resolve script is already up-to-date at %s", destScriptPath)
+			appLog.Info("Resolve script is already up to date", "path", destScriptPath)
+			a.emit("luaScript:installed", map[string]any{"path": destScriptPath, "updated": false})
 			return
 		}
 	}
 
-	log.Printf("Installing or updating Resolve script at %s", destScriptPath)
+	appLog.Info("Installing or updating Resolve script", "path", destScriptPath)
 
 	if err := os.MkdirAll(destScriptsDir, 0755); err != nil {
-		log.Printf("Failed to create destination directory %s: %v", destScriptsDir, err)
+		appLog.Error("Failed to create Resolve scripts directory", "dir", destScriptsDir, "err", err)
+		a.emit("luaScript:error", err.Error())
 		return
 	}
 
 	if err := os.WriteFile(destScriptPath, luaScriptData, 0644); err != nil {
-		log.Printf("Failed to write destination script %s: %v", destScriptPath, err)
+		appLog.Error("Failed to write Resolve script", "path", destScriptPath, "err", err)
+		a.emit("luaScript:error", err.Error())
 		return
 	}
 
-	log.Println("✅ Successfully installed DaVinci Resolve script.")
+	appLog.Info("Installed DaVinci Resolve script", "path", destScriptPath)
+	a.emit("luaScript:installed", map[string]any{"path": destScriptPath, "updated": true})
 }
 
 type FFBinariesResponse struct {
@@ -192,11 +137,11 @@ type FFBinariesResponse struct {
 }
 
 type downloadProgressWriter struct {
-	tracker     *ProgressTracker
-	totalBytes  int64
-	written     int64
-	filePath    string
-	emitContext context.Context
+	tracker    *ProgressTracker
+	totalBytes int64
+	written    int64
+	filePath   string
+	app        *App
 }
 
 func (pw *downloadProgressWriter) Write(p []byte) (int, error) {
@@ -213,7 +158,7 @@ func (pw *downloadProgressWriter) Write(p []byte) (int, error) {
 		pw.tracker.Percentage = pct
 		pw.tracker.mu.Unlock()
 
-		runtime.EventsEmit(pw.emitContext, "download:progress", ProgressStatus{
+		pw.app.emit("download:progress", ProgressStatus{
 			FilePath:   pw.filePath,
 			Percentage: pct,
 			TaskType:   "download",
@@ -227,10 +172,13 @@ func (a *App) DownloadFFmpeg() error {
 	if a.ffmpegVersion == "" {
 		return fmt.Errorf("a.ffmpegVersion must be set before calling DownloadFFmpeg")
 	}
+	if err := a.requireOnline("ffmpegDownload"); err != nil {
+		return fmt.Errorf("cannot download ffmpeg while offline: %w", err)
+	}
 
 	// Determine the platform and architecture to select the correct binary
-	platform := runtime.Environment(a.ctx).Platform // "darwin", "windows", "linux"
-	arch := runtime.Environment(a.ctx).Arch         // "amd64", "arm64", etc.
+	platform := goruntime.GOOS // "darwin", "windows", "linux"
+	arch := goruntime.GOARCH   // "amd64", "arm64", etc.
 
 	var platformKey string
 	switch platform {
@@ -268,13 +216,13 @@ func (a *App) DownloadFFmpeg() error {
 	default:
 		return fmt.Errorf("unsupported platform for ffmpeg download: %s", platform)
 	}
-	log.Printf("Resolved platform key for ffbinaries API: %s", platformKey)
+	ffmpegLog.Debug("Resolved platform key for ffbinaries API", "platformKey", platformKey)
 
 	// Fetch the download URL from the ffbinaries API
 	apiURL := fmt.Sprintf("https://ffbinaries.com/api/v1/version/%s", a.ffmpegVersion)
-	log.Printf("Fetching FFmpeg download info from: %s", apiURL)
+	ffmpegLog.Info("Fetching ffmpeg download info", "url", apiURL)
 
-	apiResp, err := http.Get(apiURL)
+	apiResp, err := a.externalHTTPClient(30 * time.Second).Get(apiURL)
 	if err != nil {
 		return fmt.Errorf("failed to call ffbinaries API: %w", err)
 	}
@@ -286,7 +234,11 @@ func (a *App) DownloadFFmpeg() error {
 	}
 
 	var ffbinariesData FFBinariesResponse
-	if err := json.NewDecoder(apiResp.Body).Decode(&ffbinariesData); err != nil {
+	apiData, err := safejson.ReadAll(apiResp.Body, maxFFbinariesReplyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read ffbinaries API response: %w", err)
+	}
+	if err := safejson.Decode(apiData, &ffbinariesData, false); err != nil {
 		return fmt.Errorf("failed to parse ffbinaries API response: %w", err)
 	}
 
@@ -315,24 +267,32 @@ func (a *App) DownloadFFmpeg() error {
 
 	downloadPath := filepath.Join(tempDir, "ffmpeg.zip")
 
-	log.Printf("Downloading FFmpeg from %s to %s", downloadURL, downloadPath)
+	ffmpegLog.Info("Downloading ffmpeg", "url", downloadURL, "to", downloadPath)
 
-	downloadResp, err := http.Get(downloadURL)
+	downloadResp, err := a.externalHTTPClient(0).Get(downloadURL)
 	if err != nil {
 		return fmt.Errorf("could not download ffmpeg zip: %w", err)
 	}
 	defer downloadResp.Body.Close()
+	if downloadResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ffmpeg download failed: %s", downloadResp.Status)
+	}
+	if err := checkDownloadSize(downloadResp, maxFFmpegDownloadBytes, "the ffmpeg download"); err != nil {
+		a.emit("download:error", progressFailed(downloadPath, "download", "progress.downloadFailed", err))
+		return err
+	}
 
 	// Get total content length for percentage calc
 	contentLength := downloadResp.ContentLength
 	if contentLength <= 0 {
-		log.Printf("Warning: server did not send Content-Length header, progress won't be accurate")
+		ffmpegLog.Warn("Server did not send Content-Length; progress won't be accurate")
 	}
 
 	// Register tracker
 	tracker := &ProgressTracker{
-		Done:     make(chan error, 1),
-		TaskType: "download",
+		Done:      make(chan error, 1),
+		TaskType:  "download",
+		StartedAt: time.Now(),
 	}
 	a.progressTracker.Store(downloadPath, tracker)
 	defer a.progressTracker.Delete(downloadPath)
@@ -346,17 +306,18 @@ func (a *App) DownloadFFmpeg() error {
 
 	// Wrap the writer with progress tracking
 	pw := &downloadProgressWriter{
-		tracker:     tracker,
-		totalBytes:  contentLength,
-		filePath:    downloadPath,
-		emitContext: a.ctx,
+		tracker:    tracker,
+		totalBytes: contentLength,
+		filePath:   downloadPath,
+		app:        a,
 	}
 
-	_, err = io.Copy(io.MultiWriter(out, pw), downloadResp.Body)
+	_, err = io.Copy(io.MultiWriter(out, pw), capReader(downloadResp.Body, maxFFmpegDownloadBytes, "the ffmpeg download"))
 	out.Close()
 
 	if err != nil {
 		tracker.Done <- err
+		a.emit("download:error", progressFailed(downloadPath, "download", "progress.downloadFailed", err))
 		return fmt.Errorf("could not write download to file: %w", err)
 	}
 
@@ -364,7 +325,7 @@ func (a *App) DownloadFFmpeg() error {
 	tracker.mu.Lock()
 	tracker.Percentage = 100
 	tracker.mu.Unlock()
-	runtime.EventsEmit(a.ctx, "progress:done", ProgressStatus{
+	a.emit("progress:done", ProgressStatus{
 		FilePath:   downloadPath,
 		Percentage: 100,
 		TaskType:   "download",
@@ -372,8 +333,10 @@ func (a *App) DownloadFFmpeg() error {
 	tracker.Done <- nil
 
 	// Extract the archive (all binaries from this API are in .zip format)
-	if err := unzip(downloadPath, tempDir); err != nil {
-		log.Printf("Unzip failed. Output:\n%s", err)
+	if err := unzip(downloadPath, tempDir, ffmpegArchiveLimits); err != nil {
+		ffmpegLog.Error("Unzip failed", "err", err)
+		a.emit("download:error", progressFailed(downloadPath, "download", "progress.downloadFailed", err))
+		return fmt.Errorf("could not extract the ffmpeg download: %w", err)
 	}
 
 	// Locate, move, and set permissions for the binary
@@ -382,7 +345,7 @@ func (a *App) DownloadFFmpeg() error {
 		return fmt.Errorf("could not find '%s' in the extracted archive", finalBinaryName)
 	}
 
-	log.Printf("Moving FFmpeg from %s to %s", extractedFfmpegPath, a.ffmpegBinaryPath)
+	ffmpegLog.Debug("Moving ffmpeg into place", "from", extractedFfmpegPath, "to", a.ffmpegBinaryPath)
 	if err := moveFile(extractedFfmpegPath, a.ffmpegBinaryPath); err != nil {
 		return fmt.Errorf("failed to move ffmpeg binary: %w", err)
 	}
@@ -396,9 +359,9 @@ func (a *App) DownloadFFmpeg() error {
 	// Update the app state
 	a.ffmpegStatus = StatusReady
 	a.signalFfmpegReady()
-	runtime.EventsEmit(a.ctx, "ffmpeg:installed", nil)
+	a.emit("ffmpeg:installed", nil)
 
-	log.Println("FFmpeg download and installation complete.")
+	ffmpegLog.Info("ffmpeg download and installation complete")
 	return nil
 }
 
@@ -406,12 +369,12 @@ func (a *App) cleanupOldFiles() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	log.Println("Starting cleanup of old temporary files...")
+	cacheLog.Info("Starting cleanup of old temporary files")
 	now := time.Now()
 
 	settings, err := a.GetSettings()
 	if err != nil {
-		log.Printf("Error getting settings for cleanup threshold: %v", err)
+		cacheLog.Warn("Error getting settings for cleanup threshold", "err", err)
 		// Fallback to default if settings can't be read
 		settings = make(map[string]any)
 		settings["cleanupThresholdDays"] = 14
@@ -423,32 +386,34 @@ func (a *App) cleanupOldFiles() {
 		enableCleanup = val
 	}
 
+	// The size quota is an explicit limit and applies even when age-based cleanup is off.
+	defer a.evictToQuotaLocked(settings)
+
 	if !enableCleanup {
-		log.Println("Cleanup of old temporary files is disabled by settings.")
+		cacheLog.Info("Cleanup of old temporary files is disabled by settings")
 		return
 	}
 
-	cleanupThresholdDays := 14                                     // Default value
-	if val, ok := settings["cleanupThresholdDays"].(float64); ok { // JSON numbers are float64 in Go
-		cleanupThresholdDays = int(val)
-	} else if val, ok := settings["cleanupThresholdDays"].(int); ok {
-		cleanupThresholdDays = val
+	cleanupThresholdDays := settingInt(settings, "cleanupThresholdDays", 14)
+	if cleanupThresholdDays < 0 || cleanupThresholdDays > maxCleanupThresholdDays {
+		cacheLog.Warn("Invalid cleanupThresholdDays in settings; falling back to 14 days", "days", cleanupThresholdDays)
+		cleanupThresholdDays = 14
 	}
 
 	cleanupThreshold := time.Duration(cleanupThresholdDays) * 24 * time.Hour
-	log.Printf("Cleanup threshold set to %d days (%v)", cleanupThresholdDays, cleanupThreshold)
+	cacheLog.Info("Cleanup threshold", "days", cleanupThresholdDays)
 
 	filesToDelete := []string{}
 	for filePath, lastUsed := range a.fileUsage {
-		if now.Sub(lastUsed) > cleanupThreshold {
+		if now.Sub(lastUsed) > cleanupThreshold && !a.isFileBusy(filePath) {
 			filesToDelete = append(filesToDelete, filePath)
 		}
 	}
 
 	for _, filePath := range filesToDelete {
-		log.Printf("Deleting old file: %s (last used %s ago)", filePath, now.Sub(a.fileUsage[filePath]))
+		cacheLog.Debug("Deleting old file", "path", filePath, "unusedFor", now.Sub(a.fileUsage[filePath]))
 		if err := os.Remove(filePath); err != nil {
-			log.Printf("Error deleting file %s: %v", filePath, err)
+			cacheLog.Warn("Error deleting file", "path", filePath, "err", err)
 			// if "no such file" error, remove from fileUsage map
 			if os.IsNotExist(err) {
 				delete(a.fileUsage, filePath)
@@ -457,7 +422,7 @@ func (a *App) cleanupOldFiles() {
 			delete(a.fileUsage, filePath)
 		}
 	}
-	log.Printf("Cleanup complete. Deleted %d old files.", len(filesToDelete))
+	cacheLog.Info("Cleanup complete", "deleted", len(filesToDelete))
 }
 
 const fileUsageFileName = "file_usage.json"
@@ -469,20 +434,24 @@ func (a *App) updateFileUsage(filePath string) {
 	// Ensure the path is absolute and clean
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		log.Printf("Error getting absolute path for file usage: %v", err)
+		cacheLog.Warn("Error getting absolute path for file usage", "err", err)
 		return
 	}
 
 	// CRITICAL SAFETY CHECK: Only track files within tmp path
-	if !strings.HasPrefix(absPath, a.tmpPath) {
-		log.Printf("WARNING: Attempted to track file outside tmp path. Skipping: %s", absPath)
+	if !a.inServedRoot(absPath) {
+		cacheLog.Warn("Attempted to track file outside tmp path; skipping", "path", absPath)
 		return
 	}
 
-	// Store only the base filename as the key
+	// Key by the full path inside tmp, the same way loadUsageData does
 	fileName := filepath.Base(absPath)
-	a.fileUsage[fileName] = time.Now()
+	fullPath := filepath.Join(a.tmpPath, fileName)
+	now := time.Now()
+	a.fileUsage[fullPath] = now
 	//log.Printf("Updated usage for file: %s", fileName)
+
+	go saferun(func() { a.recordFileTouch(fullPath, now) })
 }
 
 func (a *App) getFileUsagePath() string {
@@ -492,30 +461,43 @@ func (a *App) getFileUsagePath() string {
 func (a *App) loadUsageData() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	defer func() { a.usageLoaded = true }()
+
+	store, err := openUsageStore(a.tmpPath)
+	if err == nil {
+		a.usageStore = store
+		store.migrateLegacyUsage(a.getFileUsagePath())
+		records, err := store.all()
+		if err != nil {
+			cacheLog.Warn("Error reading usage database", "err", err)
+		}
+		a.fileUsage = make(map[string]time.Time, len(records))
+		for fileName, rec := range records {
+			a.fileUsage[filepath.Join(a.tmpPath, fileName)] = rec.LastUsed
+		}
+		cacheLog.Info("Loaded file usage", "entries", len(a.fileUsage), "file", usageDBFileName)
+		return
+	}
+	// Fall back to the JSON file, e.g. when another instance holds the database lock.
+	cacheLog.Warn("Could not open usage database; using the JSON file instead", "err", err, "file", fileUsageFileName)
 
 	filePath := a.getFileUsagePath()
-	log.Printf("Attempting to load file usage data from: %s", filePath)
+	cacheLog.Debug("Loading file usage data", "path", filePath)
 
-	data, err := os.ReadFile(filePath)
-	if err != nil {
+	var rawUsage map[string]string
+	if err := readJSONWithRecovery(filePath, &rawUsage); err != nil {
 		if os.IsNotExist(err) {
-			log.Println("file_usage.json does not exist. Initializing empty usage data.")
+			cacheLog.Info("No file usage data yet; starting empty", "file", fileUsageFileName)
 			a.fileUsage = make(map[string]time.Time)
 			return
 		}
-		log.Printf("Error reading file_usage.json: %v", err)
-		return
-	}
-
-	var rawUsage map[string]string
-	if err := json.Unmarshal(data, &rawUsage); err != nil {
-		log.Printf("Error unmarshaling file_usage.json: %v", err)
+		cacheLog.Warn("Error reading file usage data", "file", fileUsageFileName, "err", err)
 		return
 	}
 
 	// Check if rawUsage is empty after unmarshaling
 	if len(rawUsage) == 0 {
-		log.Println("file_usage.json is empty or contains no valid entries. Initializing empty usage data.")
+		cacheLog.Info("File usage data has no valid entries; starting empty", "file", fileUsageFileName)
 		a.fileUsage = make(map[string]time.Time)
 		return
 	}
@@ -524,20 +506,36 @@ func (a *App) loadUsageData() {
 	for fileName, v := range rawUsage {
 		t, err := time.Parse(time.RFC3339, v)
 		if err != nil {
-			log.Printf("Error parsing time for %s: %v", fileName, err)
+			cacheLog.Warn("Error parsing file usage time", "file", fileName, "err", err)
 			continue
 		}
 		// Reconstruct the full path for internal use
 		fullPath := filepath.Join(a.tmpPath, fileName)
 		a.fileUsage[fullPath] = t
 	}
-	log.Printf("Successfully loaded %d entries from file_usage.json", len(a.fileUsage))
+	cacheLog.Info("Loaded file usage", "entries", len(a.fileUsage), "file", fileUsageFileName)
 }
 
 func (a *App) saveUsageData() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	// Usage data loads in the background at startup; saving before that would drop it.
+	if !a.usageLoaded {
+		return
+	}
+
+	if a.usageStore != nil {
+		lastUsed := make(map[string]time.Time, len(a.fileUsage))
+		for fullPath, v := range a.fileUsage {
+			lastUsed[filepath.Base(fullPath)] = v
+		}
+		if err := a.usageStore.syncLastUsed(lastUsed); err != nil {
+			cacheLog.Warn("Error saving usage database", "err", err)
+		}
+		return
+	}
+
 	filePath := a.getFileUsagePath()
 	//log.Printf("Attempting to save file usage data to: %s. Number of entries: %d", filePath, len(rawUsage))
 
@@ -549,32 +547,228 @@ func (a *App) saveUsageData() {
 
 	data, err := json.MarshalIndent(rawUsage, "", "  ")
 	if err != nil {
-		log.Printf("Error marshaling file usage data: %v", err)
+		cacheLog.Error("Error marshaling file usage data", "err", err)
 		return
 	}
 
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		log.Printf("Error creating directory for file_usage.json: %v", err)
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
+		cacheLog.Error("Error writing file usage data", "file", fileUsageFileName, "err", err)
 		return
 	}
+	cacheLog.Debug("Saved file usage data", "entries", len(rawUsage))
+}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		log.Printf("Error writing file_usage.json: %v", err)
-		return
+// isValidWavFile reports whether path holds a complete WAV in the format produced by
+// StandardizeAudioToWav and the mixdown (16-bit PCM, mono). Files that exist but fail
+// validation are logged, so they get regenerated instead of breaking waveforms later.
+func isValidWavFile(path string) bool {
+	err := validateWavFile(path)
+	if err == nil {
+		return true
+	}
+	if !os.IsNotExist(err) {
+		cacheLog.Warn("Cached WAV is invalid and will be regenerated", "file", filepath.Base(path), "err", err)
 	}
-	log.Println("Successfully saved file usage data.")
+	return false
 }
 
-func isValidWavFile(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false // File doesn't exist
+// validateWavFile parses the RIFF structure of a WAV file: the fmt and data chunks must
+// exist, the declared sizes must agree with the file size and the format must match
+// what the downstream waveform and silence code expects.
+func validateWavFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+
+	info, err := f.Stat()
 	if err != nil {
-		log.Printf("Error stating file %s: %v", path, err)
-		return false // Some other error
+		return err
 	}
-	// Exists and is not an empty file
-	return !info.IsDir() && info.Size() > 44 // 44 bytes is a common WAV header size
+	if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+	fileSize := info.Size()
+
+	var header [12]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil {
+		return fmt.Errorf("file too short for a RIFF header")
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return fmt.Errorf("not a RIFF/WAVE file")
+	}
+	riffSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+	// The RIFF size covers everything after the first 8 bytes; allow a trailing pad byte.
+	if riffSize+8 != fileSize && riffSize+9 != fileSize {
+		return fmt.Errorf("RIFF size %d does not match file size %d (truncated?)", riffSize+8, fileSize)
+	}
+
+	var (
+		haveFmt       bool
+		audioFormat   uint16
+		channels      uint16
+		bitsPerSample uint16
+		blockAlign    uint16
+		sampleRate    uint32
+	)
+	offset := int64(12)
+	for offset+8 <= fileSize {
+		var chunkHeader [8]byte
+		if _, err := f.ReadAt(chunkHeader[:], offset); err != nil {
+			return fmt.Errorf("could not read chunk header at offset %d: %w", offset, err)
+		}
+		chunkID := string(chunkHeader[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		dataStart := offset + 8
+		if dataStart+chunkSize > fileSize {
+			return fmt.Errorf("chunk '%s' claims %d bytes but only %d remain", chunkID, chunkSize, fileSize-dataStart)
+		}
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return fmt.Errorf("fmt chunk too small (%d bytes)", chunkSize)
+			}
+			fmtData := make([]byte, 16)
+			if _, err := f.ReadAt(fmtData, dataStart); err != nil {
+				return fmt.Errorf("could not read fmt chunk: %w", err)
+			}
+			audioFormat = binary.LittleEndian.Uint16(fmtData[0:2])
+			channels = binary.LittleEndian.Uint16(fmtData[2:4])
+			sampleRate = binary.LittleEndian.Uint32(fmtData[4:8])
+			blockAlign = binary.LittleEndian.Uint16(fmtData[12:14])
+			bitsPerSample = binary.LittleEndian.Uint16(fmtData[14:16])
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return fmt.Errorf("data chunk appears before fmt chunk")
+			}
+			// 0xFFFE is WAVE_FORMAT_EXTENSIBLE, which ffmpeg may use for plain PCM as well.
+			if audioFormat != 1 && audioFormat != 0xFFFE {
+				return fmt.Errorf("unsupported audio format %d (expected PCM)", audioFormat)
+			}
+			if bitsPerSample != 16 {
+				return fmt.Errorf("unsupported bit depth %d (expected 16)", bitsPerSample)
+			}
+			if channels != 1 {
+				return fmt.Errorf("unexpected channel count %d (expected mono)", channels)
+			}
+			if sampleRate == 0 || blockAlign != channels*bitsPerSample/8 {
+				return fmt.Errorf("inconsistent fmt chunk (rate %d, block align %d)", sampleRate, blockAlign)
+			}
+			if chunkSize == 0 {
+				return fmt.Errorf("data chunk is empty")
+			}
+			if chunkSize%int64(blockAlign) != 0 {
+				return fmt.Errorf("data size %d is not a whole number of samples", chunkSize)
+			}
+			return nil
+		}
+
+		// Chunks are word-aligned.
+		offset = dataStart + chunkSize + chunkSize%2
+	}
+
+	if !haveFmt {
+		return fmt.Errorf("missing fmt chunk")
+	}
+	return fmt.Errorf("missing data chunk")
+}
+
+const backupFileSuffix = ".bak"
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file behind:
+// the data goes to a temporary file in the same folder which is then renamed over the
+// original. The previous version is kept as path+".bak" as long as it was valid JSON.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return replaceFile(path, data, perm, true)
+}
+
+// replaceFile renames a temporary file with data over path. Only a durable replace syncs the
+// data to disk and keeps a backup; without it the file survives a crash of the app, not of
+// the machine.
+func replaceFile(path string, data []byte, perm os.FileMode, durable bool) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if durable {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	if durable {
+		if previous, err := os.ReadFile(path); err == nil && json.Valid(previous) {
+			if err := os.WriteFile(path+backupFileSuffix, previous, perm); err != nil {
+				appLog.Warn("Could not write backup", "file", filepath.Base(path), "err", err)
+			}
+		}
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// maxJSONFileBytes bounds the JSON files HushCut reads back; the largest, the usage data of a
+// big cache, stays far below it.
+const maxJSONFileBytes = 64 << 20
+
+// readJSONFile reads and decodes a JSON file within maxJSONFileBytes and safejson's limits.
+func readJSONFile(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := safejson.ReadAll(f, maxJSONFileBytes)
+	if err != nil {
+		return err
+	}
+	return safejson.Decode(data, v, false)
+}
+
+// readJSONWithRecovery unmarshals path into v. If the file is corrupt, the ".bak" written by
+// writeFileAtomic is used instead and copied back into place.
+// Errors from reading path (including os.ErrNotExist) are returned unchanged.
+func readJSONWithRecovery(path string, v any) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	parseErr := readJSONFile(path, v)
+	if parseErr == nil {
+		return nil
+	}
+
+	if err := readJSONFile(path+backupFileSuffix, v); err != nil {
+		return parseErr
+	}
+	backup, err := os.ReadFile(path + backupFileSuffix)
+	if err != nil {
+		return parseErr
+	}
+	appLog.Warn("File was corrupt; restored the previous version from backup", "file", filepath.Base(path), "err", parseErr)
+	if err := writeFileAtomic(path, backup, 0644); err != nil {
+		appLog.Error("Could not restore file from backup", "file", filepath.Base(path), "err", err)
+	}
+	return nil
 }
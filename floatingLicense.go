@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// Floating licenses: when the "licenseServerUrl" setting (usually deployed via policy) points
+// to a license daemon on the LAN, HushCut checks out a seat at startup instead of using a
+// per-machine key. The daemon signs its responses with the same RSA key as the public
+// license server. Seats are leased; HushCut renews the lease while running and returns the
+// seat on shutdown.
+//
+//	POST <url>/checkout {machine_id, hostname, app_version} -> SignedLicenseData
+//	     data: {seat_id, machine_id, lease_expires (unix), seats_used, seats_total}
+//	     409 Conflict when no seat is free
+//	POST <url>/renew    {seat_id, machine_id}               -> SignedLicenseData
+//	POST <url>/release  {seat_id, machine_id}               -> 204
+
+var errNoSeatsAvailable = i18n.NewError("license.noSeats", nil)
+
+// seatLease is a checked-out seat.
+type seatLease struct {
+	SeatID       string    `json:"seatId"`
+	LeaseExpires time.Time `json:"leaseExpires"`
+	SeatsUsed    int       `json:"seatsUsed"`
+	SeatsTotal   int       `json:"seatsTotal"`
+	stopRenew    chan struct{}
+}
+
+func (a *App) licenseServerURL() string {
+	settings, err := a.GetSettings()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(settingString(settings, "licenseServerUrl", ""), "/")
+}
+
+// postToLicenseServer sends a request to the seat daemon and returns the verified signed data.
+func (a *App) postToLicenseServer(endpoint string, payload map[string]string) (map[string]interface{}, int, error) {
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("internal error creating request: %w", err)
+	}
+	resp, err := a.externalHTTPClient(15*time.Second).Post(a.licenseServerURL()+endpoint, "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, 0, i18n.Wrap(err, "license.seatServerUnreachable", i18n.Params{"detail": err.Error()})
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to read license server response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, resp.StatusCode, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("license server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var signed SignedLicenseData
+	if err := json.Unmarshal(body, &signed); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to parse license server response: %w", err)
+	}
+	if err := a.verifySignature(signed.Data, signed.Signature); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("license server response verification failed: %w", err)
+	}
+	if signed.Data["machine_id"] != a.machineID {
+		return nil, resp.StatusCode, errors.New("license server response is for a different device")
+	}
+	return signed.Data, resp.StatusCode, nil
+}
+
+func leaseFromData(data map[string]interface{}) (*seatLease, error) {
+	seatID, _ := data["seat_id"].(string)
+	expires := unixField(data, "lease_expires")
+	if seatID == "" || expires == nil {
+		return nil, errors.New("license server response is missing seat information")
+	}
+	return &seatLease{
+		SeatID:       seatID,
+		LeaseExpires: *expires,
+		SeatsUsed:    intField(data, "seats_used"),
+		SeatsTotal:   intField(data, "seats_total"),
+	}, nil
+}
+
+// checkoutSeat obtains a seat from the license server and keeps it renewed.
+func (a *App) checkoutSeat() error {
+	a.seatMu.Lock()
+	defer a.seatMu.Unlock()
+	if a.seat != nil {
+		return nil
+	}
+
+	hostname, _ := os.Hostname()
+	data, status, err := a.postToLicenseServer("/checkout", map[string]string{
+		"machine_id":  a.machineID,
+		"hostname":    hostname,
+		"app_version": a.appVersion,
+	})
+	if status == http.StatusConflict {
+		a.emit("license:noSeats", err.Error())
+		return errNoSeatsAvailable
+	}
+	if err != nil {
+		return err
+	}
+	lease, err := leaseFromData(data)
+	if err != nil {
+		return err
+	}
+	lease.stopRenew = make(chan struct{})
+	a.seat = lease
+	go saferun(func() { a.renewSeat(lease) })
+
+	licenseLog.Info("Checked out seat", "seat", lease.SeatID, "seatsUsed", lease.SeatsUsed, "seatsTotal", lease.SeatsTotal, "leaseExpires", lease.LeaseExpires.Format(time.RFC3339))
+	a.emit("license:seat", lease)
+	return nil
+}
+
+// renewSeat extends the lease when half of it has passed.
+func (a *App) renewSeat(lease *seatLease) {
+	for {
+		wait := time.Until(lease.LeaseExpires) / 2
+		if wait < 10*time.Second {
+			wait = 10 * time.Second
+		}
+		select {
+		case <-lease.stopRenew:
+			return
+		case <-time.After(wait):
+		}
+
+		data, _, err := a.postToLicenseServer("/renew", map[string]string{"seat_id": lease.SeatID, "machine_id": a.machineID})
+		if err == nil {
+			var renewed *seatLease
+			if renewed, err = leaseFromData(data); err == nil {
+				a.seatMu.Lock()
+				lease.LeaseExpires = renewed.LeaseExpires
+				a.seatMu.Unlock()
+				continue
+			}
+		}
+		licenseLog.Warn("Could not renew seat", "seat", lease.SeatID, "err", err)
+		if time.Now().After(lease.LeaseExpires) {
+			a.seatMu.Lock()
+			if a.seat == lease {
+				a.seat = nil
+			}
+			a.seatMu.Unlock()
+			a.licenseValid = false
+			a.emit("license:invalid", "seat lease expired")
+			return
+		}
+	}
+}
+
+// releaseSeat returns the seat to the pool; called on shutdown.
+func (a *App) releaseSeat() {
+	a.seatMu.Lock()
+	lease := a.seat
+	a.seat = nil
+	a.seatMu.Unlock()
+	if lease == nil {
+		return
+	}
+	close(lease.stopRenew)
+	if _, _, err := a.postToLicenseServer("/release", map[string]string{"seat_id": lease.SeatID, "machine_id": a.machineID}); err != nil {
+		licenseLog.Warn("Could not release seat; it will expire on its own", "seat", lease.SeatID, "err", err)
+		return
+	}
+	licenseLog.Info("Released seat", "seat", lease.SeatID)
+}
+
+// RetrySeatCheckout lets the UI try again after "license:noSeats".
+func (a *App) RetrySeatCheckout() error {
+	if a.licenseServerURL() == "" {
+		return errors.New("no license server is configured")
+	}
+	if err := a.checkoutSeat(); err != nil {
+		return err
+	}
+	a.signalLicenseOk()
+	return nil
+}
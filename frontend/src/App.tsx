@@ -13,14 +13,15 @@ import {
   DownloadFFmpeg,
   GetFFmpegStatus,
   GetGoServerPort,
-  MixdownCompoundClips,
-  ProcessProjectAudio,
-  SyncWithDavinci,
+  SyncAndAnalyze,
   MakeFinalTimeline,
   HasAValidLicense,
+  GetInterruptedSession,
+  ResumeInterruptedJobs,
+  DismissInterruptedSession,
 } from "@wails/go/main/App";
 
-import { GetPythonReadyStatus, GetToken } from "@wails/go/main/App";
+import { GetEndpointPrefix, GetPythonReadyStatus, GetToken } from "@wails/go/main/App";
 import { EventsEmit, EventsOn } from "@wails/runtime";
 import { main } from "@wails/go/models";
 
@@ -65,6 +66,7 @@ import Timecode, { FRAMERATE } from "smpte-timecode";
 import { Toaster } from "./components/ui/sonner";
 import { PeakMeter } from "./components/audio/peakMeter";
 import { initializeProgressListeners } from "./stores/progressStore";
+import { initLocale, translate } from "./lib/i18n";
 import ErrorBoundary from "./components/ErrorBoundary";
 import LicensePrompt from "./components/ui-custom/LicensePrompt";
 import { ChevronRightIcon, LoaderCircleIcon } from "lucide-react";
@@ -83,6 +85,7 @@ const getDefaultDetectionParams = (): DetectionParams => ({
 const createActiveFileFromTimelineItem = (
   item: main.TimelineItem,
   port: number,
+  endpointPrefix: string,
   fps: number
 ): ActiveClip | null => {
   if (
@@ -105,7 +108,8 @@ const createActiveFileFromTimelineItem = (
     name: item.name || "Unnamed Track Item", // Fallback for name
     sourceFilePath: item.source_file_path,
     processedFileName: item.processed_file_name,
-    previewUrl: `http://localhost:${port}/render_clip?file=${encodeURIComponent(
+    // Unsigned; useWaveformData signs it (SignMediaURL) before it is loaded, and again on expiry
+    previewUrl: `http://localhost:${port}${endpointPrefix}/render_clip?file=${encodeURIComponent(
       item.processed_file_name
     )}&start=${clipStartSeconds}&end=${clipEndSeconds}`,
     sourceStartFrame: item.source_start_frame,
@@ -133,6 +137,36 @@ const Status = {
   Missing: 2,
 };
 
+// After a crash: say what was interrupted and offer to resume the audio conversions.
+function showInterruptedSession(report: main.InterruptedSession) {
+  const interrupted = report.tasks.length + report.jobs.length;
+  const parts: string[] = [];
+  if (interrupted > 0) {
+    parts.push(`${interrupted} operation(s) were interrupted.`);
+  }
+  if (report.discardedOutputs.length > 0) {
+    parts.push(
+      `${report.discardedOutputs.length} incomplete file(s) were discarded.`
+    );
+  }
+  toast.warning("HushCut did not shut down cleanly", {
+    description: parts.join(" "),
+    duration: Infinity,
+    action:
+      report.jobs.length > 0
+        ? {
+            label: "Resume",
+            onClick: () => {
+              ResumeInterruptedJobs();
+            },
+          }
+        : undefined,
+    onDismiss: () => {
+      DismissInterruptedSession();
+    },
+  });
+}
+
 function AppContent() {
   const [ffmpegStatus, setFFmpegReady] = useState(Status.Unknown);
   const prevFfmpegStatus = usePrevious(ffmpegStatus);
@@ -174,6 +208,10 @@ function AppContent() {
       if (data && typeof data.isReady === "boolean") {
         console.log(`Event: Python status changed to ${data.isReady}`);
         setPythonReady(data.isReady);
+        // The backend explains, for how HushCut was launched, why it isn't coming.
+        if (!data.isReady && data.message) {
+          toast.error(translate(data.messageKey, data.params, data.message), { id: "backend-unavailable", duration: Infinity });
+        }
       }
     });
 
@@ -197,7 +235,73 @@ function AppContent() {
   const [pendingRemoveSilences, setPendingRemoveSilences] = useState(false);
 
   const [httpPort, setHttpPort] = useState<number | null>(null);
+
+  useEffect(() => {
+    // The Go server restarts on a new port if it fails; waveform and audio URLs follow httpPort.
+    const offRecovered = EventsOn("server:recovered", (data) => {
+      console.warn(`Event: server moved from port ${data.oldPort} to ${data.newPort}: ${data.reason}`);
+      setHttpPort(data.newPort);
+      toast.warning("HushCut's audio server stopped and was restarted.", {
+        id: "server-recovery",
+        description: "Waveforms and playback should work again.",
+      });
+    });
+    const offFailed = EventsOn("server:failed", (data) => {
+      console.error(`Event: server could not be restarted: ${data.reason}`);
+      setHttpPort(null);
+      toast.error("HushCut's audio server stopped and could not be restarted.", {
+        id: "server-recovery",
+        description: "Restart HushCut to load waveforms and audio again.",
+        duration: Infinity,
+      });
+    });
+    return () => {
+      offRecovered();
+      offFailed();
+    };
+  }, []);
+
+  useEffect(() => {
+    // Syncing with Resolve is only the first stage of SyncAndAnalyze; the app stays busy
+    // until the audio is analyzed.
+    return EventsOn("pipeline:stage", (data) => {
+      console.log(`Event: pipeline stage ${data.stageIndex}/${data.stageCount}: ${data.stage}`);
+      if (data.stage !== "sync") setSyncing(false);
+    });
+  }, []);
+
+  useEffect(() => {
+    return EventsOn("analysis:progress", (data) => {
+      const finished = data.done + data.failed;
+      if (finished < data.total) {
+        toast.loading("Analyzing timeline", {
+          id: "analysis-progress",
+          description: `${finished} of ${data.total} clip(s) analyzed`,
+        });
+        return;
+      }
+      toast.dismiss("analysis-progress");
+      if (data.failed > 0) {
+        toast.warning("Analysis finished with errors", {
+          description: `Silence detection failed for ${data.failed} of ${data.total} clip(s).`,
+        });
+      }
+    });
+  }, []);
+
+  useEffect(() => {
+    return EventsOn("jobs:cancelled", (data) => {
+      const stopped = data.conversions + data.mixdowns + data.detections;
+      toast.info("Jobs cancelled", {
+        id: "jobs-cancelled",
+        description: `${stopped} running and ${data.queued} queued job(s) were stopped.`,
+      });
+    });
+  }, []);
+
   const setToken = useAppState((s) => s.setToken);
+  const endpointPrefix = useAppState((s) => s.endpointPrefix);
+  const setEndpointPrefix = useAppState((s) => s.setEndpointPrefix);
 
   const [projectData, setProjectData] =
     useState<main.ProjectDataPayload | null>(null);
@@ -260,9 +364,10 @@ function AppContent() {
     return createActiveFileFromTimelineItem(
       itemToDisplay,
       httpPort,
+      endpointPrefix,
       projectData.timeline.fps
     );
-  }, [projectData, httpPort, currentClipId]);
+  }, [projectData, httpPort, endpointPrefix, currentClipId]);
 
   const handleSync = async () => {
     if (isBusy) {
@@ -316,10 +421,6 @@ function AppContent() {
         setHasProjectData(!!newData);
 
         if (!newData) return;
-        await Promise.all([
-          ProcessProjectAudio(newData),
-          MixdownCompoundClips(newData),
-        ]);
         console.log("handleSync: Project data updated.");
       } else {
         console.log(
@@ -329,16 +430,24 @@ function AppContent() {
     };
 
     try {
-      const response = await SyncWithDavinci();
-      console.log("SyncWithDavinci response from Go:", response);
+      // Go syncs, converts, mixes down, detects and precomputes waveforms in one call.
+      const result = await SyncAndAnalyze({
+        ...getDefaultDetectionParams(),
+        keepSilenceSegments: useGlobalStore.getState().keepSilence,
+      });
+      console.log("SyncAndAnalyze result from Go:", result);
       setSyncing(false);
+      const response = result?.response;
+      if (result?.failed) {
+        console.warn("Silence detection failed for some clips:", result.failed);
+      }
 
       if (response && response.alertIssued) {
         console.warn(
           "Sync operation resulted in an alert (issued by Go). Message:",
           response.message
         );
-        conditionalSetProjectData(response.data || null);
+        conditionalSetProjectData(result.project || null);
         // toast.dismiss(loadingToastId);
       } else if (response && response.status !== "success") {
         console.error(
@@ -350,14 +459,14 @@ function AppContent() {
         setBusy(false);
         setSyncing(false);
       } else if (response && response.status === "success") {
-        await conditionalSetProjectData(response.data);
+        await conditionalSetProjectData(result.project || null);
         setBusy(false);
         setSyncing(false);
       } else {
-        alert("SyncWithDavinci: Unexpected response structure from Go");
+        alert("SyncAndAnalyze: Unexpected response structure from Go");
         console.error(
-          "SyncWithDavinci: Unexpected response structure from Go",
-          response
+          "SyncAndAnalyze: Unexpected response structure from Go",
+          result
         );
         setProjectData(null);
         setHasProjectData(false);
@@ -366,7 +475,7 @@ function AppContent() {
         setSyncing(false);
       }
     } catch (err: any) {
-      console.error("Error calling SyncWithDavinci or Go-level error:", err);
+      console.error("Error calling SyncAndAnalyze or Go-level error:", err);
       setProjectData(null);
       setHasProjectData(false);
       setTimelineName(null);
@@ -415,6 +524,7 @@ function AppContent() {
     const hasBlur = supportsRealBackdrop();
     document.body.classList.add(hasBlur ? "has-blur" : "no-blur");
     initializeProgressListeners();
+    initLocale();
   }, []);
 
   const initHasRun = useRef(false);
@@ -443,6 +553,8 @@ function AppContent() {
         }
 
         console.log("App.tsx: HTTP Server Port received:", port);
+        // /render_clip is mounted under a random prefix; know it before building URLs
+        setEndpointPrefix(await GetEndpointPrefix());
         setHttpPort(port);
 
         const [isFfmpegReady, pyReady, goToken] = await Promise.all([
@@ -461,6 +573,11 @@ function AppContent() {
         if (isFfmpegReady) {
           handleSyncRef.current();
         }
+
+        const interrupted = await GetInterruptedSession();
+        if (interrupted) {
+          showInterruptedSession(interrupted);
+        }
       } catch (err) {
         console.error("App.tsx: Error during app initialization:", err);
         setHttpPort(null);
@@ -519,6 +636,15 @@ function AppContent() {
     };
   }, []);
 
+  useEffect(() => {
+    // "Sync with Resolve" in the tray menu
+    const unsubscribe = EventsOn("tray:sync", () => handleSyncRef.current());
+
+    return () => {
+      if (typeof unsubscribe === "function") unsubscribe();
+    };
+  }, []);
+
   const syncTimeoutRef = useRef<number | null>(null);
   const syncMouseUpListenerRef = useRef<(() => void) | null>(null);
 
@@ -17,7 +17,7 @@ import { SetDavinciPlayhead } from "@wails/go/main/App";
 import { secToFrames, formatDuration } from "@/lib/utils";
 import { useResizeObserver } from "@/hooks/hooks";
 import { ZoomSlider } from "@/components/ui/zoomSlider";
-import { useWaveformData } from "@/hooks/useWaveformData";
+import { mediaUrlExpiry, useWaveformData } from "@/hooks/useWaveformData";
 import { useWaveformStore } from "@/stores/waveformStore";
 
 function usePlaybackControls({
@@ -171,7 +171,7 @@ const WaveformPlayer: React.FC<WaveformPlayerProps> = ({
     return null;
   }
 
-  const { peakData, cutAudioSegmentUrl } = useWaveformData(
+  const { peakData, cutAudioSegmentUrl, resignAudioUrl } = useWaveformData(
     activeClip,
     projectFrameRate,
     httpPort
@@ -448,7 +448,7 @@ const WaveformPlayer: React.FC<WaveformPlayerProps> = ({
       fillParent: true,
       barAlign: "bottom",
       interact: true,
-      url: cutAudioSegmentUrl || audioUrl,
+      url: cutAudioSegmentUrl,
       peaks: [peakData.peaks],
       duration: peakData.duration,
       normalize: false,
@@ -460,6 +460,7 @@ const WaveformPlayer: React.FC<WaveformPlayerProps> = ({
       hideScrollbar: true,
       minPxPerSec: 15,
     };
+    let resignTimeout: ReturnType<typeof setTimeout> | undefined;
     const handleGlobalMouseMove = (event: MouseEvent) => {
       if (!isPanningRef.current || !wavesurferRef.current) return;
 
@@ -660,7 +661,39 @@ const WaveformPlayer: React.FC<WaveformPlayerProps> = ({
         }
       });
 
+      // The signed URL expires (mediaURLs.go). Shortly before it does, or when the server
+      // refuses it, the media element gets a freshly signed one in place of the player being
+      // recreated.
+      let currentUrl = cutAudioSegmentUrl;
+      let retriedUrl: string | null = null;
+      const swapAudioUrl = async () => {
+        const url = await resignAudioUrl().catch(() => null);
+        if (!url || wavesurferRef.current !== ws) return;
+        const media = ws.getMediaElement();
+        const time = media.currentTime;
+        const playing = !media.paused;
+        currentUrl = url;
+        media.src = url;
+        media.currentTime = time;
+        if (playing) media.play().catch(() => setIsPlaying(false));
+        scheduleResign();
+      };
+      const scheduleResign = () => {
+        clearTimeout(resignTimeout);
+        const delay = mediaUrlExpiry(currentUrl) - Date.now() - 60_000;
+        if (Number.isFinite(delay)) {
+          resignTimeout = setTimeout(swapAudioUrl, Math.max(delay, 0));
+        }
+      };
+      scheduleResign();
+
       ws.on("error", (err: Error | string) => {
+        if (retriedUrl !== currentUrl) {
+          // Most likely a 401 for an expired URL; one retry with a new signature.
+          retriedUrl = currentUrl;
+          swapAudioUrl();
+          return;
+        }
         console.error("WaveSurfer error:", err);
         setIsLoading(false);
         setIsPlaying(false);
@@ -791,6 +824,7 @@ const WaveformPlayer: React.FC<WaveformPlayerProps> = ({
     }
 
     return () => {
+      clearTimeout(resignTimeout);
       document.removeEventListener("mousemove", handleGlobalMouseMove);
       document.removeEventListener("mouseup", handleGlobalMouseUp);
 
@@ -18,6 +18,7 @@ import { cn } from "@/lib/utils";
 import { MarkdownRenderer } from "../MarkdownRenderer";
 import { ScrollArea } from "../ui/scroll-area";
 import { Separator } from "@radix-ui/react-dropdown-menu";
+import { MessageParams, translate } from "@/lib/i18n";
 
 const getAlertIcon = (type: AlertData["severity"]) => {
   switch (type) {
@@ -65,6 +66,10 @@ interface AlertData {
   markdown?: string;
   actions?: AlertAction[]; // Add actions array to the interface
   severity?: "error" | "warning" | "info" | "important";
+  // set by the backend so title and message can be shown in the user's language
+  titleKey?: string;
+  messageKey?: string;
+  params?: MessageParams;
 }
 
 const GlobalAlertDialog = () => {
@@ -106,8 +111,8 @@ const GlobalAlertDialog = () => {
 
       // 2. Set the alert data and open it.
       setAlertData({
-        title: data.title || "No title",
-        message: data.message || "No message",
+        title: translate(data.titleKey, data.params, data.title || "No title"),
+        message: translate(data.messageKey, data.params, data.message || "No message"),
         markdown: data.markdown || "",
         actions: data.actions || [],
         severity: data.severity || "info"
@@ -2,7 +2,7 @@
 import React, { useState, useEffect, useMemo, useRef, memo, useCallback } from "react";
 import { cn, frameToTimecode } from "@/lib/utils";
 import { main } from "@wails/go/models";
-import { GetWaveform } from "@wails/go/main/App";
+import { GetWaveform, HintVisibleClips } from "@wails/go/main/App";
 import { ScrollArea, ScrollBar } from "@/components/ui/scroll-area";
 import { Progress } from "../ui/progress";
 import { AlignJustifyIcon, AsteriskIcon, AudioLinesIcon, LayersIcon, PowerIcon, PowerOffIcon } from "lucide-react";
@@ -411,6 +411,18 @@ const _FileSelector: React.FC<FileSelectorProps> = ({
 
   const virtualItems = columnVirtualizer.getVirtualItems();
 
+  // Tell Go which clips are on screen so their waveforms are computed first.
+  const visibleClipKey = virtualItems.map((v) => sortedItems[v.index]?.id).filter(Boolean).join("|");
+  useEffect(() => {
+    if (!visibleClipKey) return;
+    const timer = setTimeout(() => {
+      HintVisibleClips(visibleClipKey.split("|")).catch((err) =>
+        console.warn("HintVisibleClips failed:", err)
+      );
+    }, 150);
+    return () => clearTimeout(timer);
+  }, [visibleClipKey]);
+
   useEffect(() => {
     const element = scrollAreaRef.current;
     if (!element) return;
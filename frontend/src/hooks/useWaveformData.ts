@@ -1,12 +1,15 @@
 // src/hooks/useWaveformData.ts
 
-import { useState, useEffect } from 'react';
-import { GetWaveform } from '@wails/go/main/App';
+import { useState, useEffect, useCallback } from 'react';
+import { GetWaveform, SignMediaURL } from '@wails/go/main/App';
 import { main } from '@wails/go/models';
 import type { ActiveClip } from '../types'; // Adjust path to types.ts
-import { useAppState } from '@/stores/appSync';
-
 
+// Media URLs are signed (mediaURLs.go) and stop working at their "exp"; this returns when, in ms.
+export function mediaUrlExpiry(url: string): number {
+    const exp = Number(new URL(url).searchParams.get("exp"));
+    return exp > 0 ? exp * 1000 : Infinity;
+}
 
 export function useWaveformData(
     activeClip: ActiveClip | null,
@@ -22,7 +25,6 @@ export function useWaveformData(
     const [isLoading, setIsLoading] = useState(false);
     const [error, setError] = useState<string | null>(null);
 
-    const token = useAppState(s => s.token)
 
 
     useEffect(() => {
@@ -58,9 +60,7 @@ export function useWaveformData(
                     return;
                 }
 
-                const newCutAudioUrl = `http://localhost:${httpPort}/render_clip?file=${encodeURIComponent(
-                    activeClip.processedFileName
-                )}&start=${clipStartSeconds}&end=${clipEndSeconds}&token=${token}`;
+                const newCutAudioUrl = await SignMediaURL(activeClip.previewUrl);
 
                 const peakDataForSegment = await GetWaveform(
                     activeClip.processedFileName,
@@ -95,6 +95,13 @@ export function useWaveformData(
         return () => {
             isCancelled = true;
         };
-    }, [activeClip, fps, httpPort, token]);
-    return { cutAudioSegmentUrl, peakData, isLoading, error };
+    }, [activeClip, fps, httpPort]);
+
+    // Signs the clip's preview URL again, for when the one in use expires or is refused.
+    const resignAudioUrl = useCallback(async () => {
+        if (!activeClip) return null;
+        return SignMediaURL(activeClip.previewUrl);
+    }, [activeClip]);
+
+    return { cutAudioSegmentUrl, peakData, isLoading, error, resignAudioUrl };
 }
\ No newline at end of file
@@ -0,0 +1,73 @@
+// lib/i18n.ts
+//
+// The Go side sends user-facing text as English plus a message key and parameters (see
+// internal/i18n). This module picks the user's language and translates those keys; a key
+// without a translation shows the English text it came with.
+import { GetSettings, SetLocale } from "@wails/go/main/App";
+
+export type MessageParams = Record<string, unknown>;
+
+// Translations by language, keyed like internal/i18n/catalog.go. English needs no entry.
+const catalogs: Record<string, Record<string, string>> = {
+  de: {
+    "alert.backendTampered.title": "HushCut muss neu installiert werden",
+    "alert.backendTampered.message":
+      "Das Backend von HushCut wurde verändert oder nur teilweise aktualisiert, daher wurde es nicht gestartet. Installiere HushCut mit dem Installer dieser Version neu, um es zu reparieren.",
+    "backend.unavailable.resolve":
+      "HushCut konnte keine Verbindung zu seinem Skript in DaVinci Resolve herstellen ({cause}). Schließe HushCut und starte es erneut über Workspace > Scripts > HushCut in Resolve.",
+    "backend.unavailable.development":
+      "Das Python-Entwicklungsbackend auf Port {port} ist nicht erreichbar ({cause}).",
+    "backend.unavailable.notBundled":
+      "HushCut wurde eigenständig gestartet, aber diese Installation enthält kein Backend. Starte es stattdessen aus DaVinci Resolve: Workspace > Scripts > HushCut.",
+    "backend.unavailable.notStarted":
+      "Das Backend von HushCut wurde nicht gestartet ({cause}). Stelle sicher, dass DaVinci Resolve läuft, oder starte HushCut über Workspace > Scripts > HushCut in Resolve.",
+    "progress.conversionFailed": "Konvertieren von {file} fehlgeschlagen: {detail}",
+    "progress.downloadFailed": "Herunterladen von {file} fehlgeschlagen: {detail}",
+    "license.emptyKey": "Der Lizenzschlüssel darf nicht leer sein",
+    "license.serverUnreachable":
+      "Keine Verbindung zum Lizenzserver; bitte prüfe deine Internetverbindung und versuche es erneut",
+    "license.machineMismatch": "Die Geräte-ID stimmt nicht überein",
+    "license.otherDevice": "Diese Lizenzdatei wurde für ein anderes Gerät ausgestellt",
+    "license.offlineNotYetValid": "Die Offline-Lizenz ist erst ab {date} gültig",
+    "license.offlineExpired": "Die Offline-Lizenz ist am {date} abgelaufen",
+    "license.noSeats": "Alle Plätze auf dem Lizenzserver sind belegt",
+    "license.seatServerUnreachable": "Der Studio-Lizenzserver ist nicht erreichbar: {detail}",
+  },
+};
+
+let locale = "en";
+
+// resolveLocale turns the "language" setting ("auto" or empty for the system's) into a tag.
+export function resolveLocale(setting?: string): string {
+  if (setting && setting !== "auto") return setting;
+  return navigator.language || "en";
+}
+
+// initLocale picks the language from the settings and tells the backend, which formats
+// reports to match.
+export async function initLocale(): Promise<string> {
+  try {
+    const settings = await GetSettings();
+    locale = resolveLocale(settings?.language);
+  } catch {
+    locale = resolveLocale();
+  }
+  SetLocale(locale).catch(() => {});
+  return locale;
+}
+
+function format(text: string, params?: MessageParams): string {
+  if (!params) return text;
+  return text.replace(/\{(\w+)\}/g, (match, name) =>
+    name in params ? String(params[name]) : match
+  );
+}
+
+// translate returns the text for key in the current language, or fallback (the English text
+// the backend sent) if there is no translation.
+export function translate(key: string | undefined, params: MessageParams | undefined, fallback: string): string {
+  if (!key) return fallback;
+  const language = locale.toLowerCase().split(/[-_]/)[0];
+  const text = catalogs[language]?.[key];
+  return text ? format(text, params) : fallback;
+}
@@ -12,6 +12,9 @@ type AppState = {
   setTimelineName: (value: string | null) => void;
   token: string | null;
   setToken: (value: string | null) => void;
+  // prefix of the server's internal endpoints such as /render_clip, random per session
+  endpointPrefix: string;
+  setEndpointPrefix: (value: string) => void;
 };
 
 export const useAppState = create<AppState>((set) => ({
@@ -24,5 +27,7 @@ export const useAppState = create<AppState>((set) => ({
   timelineName: "",
   setTimelineName: (value) => set({ timelineName: value}),
   token: "",
-  setToken: (value) => set({ token: value})
+  setToken: (value) => set({ token: value}),
+  endpointPrefix: "",
+  setEndpointPrefix: (value) => set({ endpointPrefix: value })
 }));
@@ -27,28 +27,48 @@ export const useProgressStore = create<ProgressState>()(() => ({
 
 // This function should be called ONCE when your app starts.
 export function initializeProgressListeners() {
-  EventsOn('conversion:progress', (e: { filePath: string; percentage: number }) => {
-    const fileName = getFileName(e.filePath);
-    if (fileName) {
-      useProgressStore.setState(state => ({
-        conversionProgress: { ...state.conversionProgress, [fileName]: e.percentage }
-      }));
-    }
+  // Progress events arrive batched, at most one batch per kind every ~100 ms, with the
+  // latest update per file. Each batch is applied in a single state update.
+  EventsOn('conversion:progress:batch', (batch: { filePath: string; percentage: number }[]) => {
+    useProgressStore.setState(state => {
+      const conversionProgress = { ...state.conversionProgress };
+      for (const e of batch) {
+        const fileName = getFileName(e.filePath);
+        if (fileName) conversionProgress[fileName] = e.percentage;
+      }
+      return { conversionProgress };
+    });
   });
 
-EventsOn("download:progress", (e) => {
-  const fileName = getFileName(e.filePath);
-  if (!fileName) return;
-
-  useProgressStore.setState(state => {
-    const last = state.downloadProgress[fileName] ?? 0;
-    // only update if changed by at least 1%
-    if (e.percentage !== 100 && Math.abs(last - e.percentage) < 0.1) return state;
-    return { downloadProgress: { ...state.downloadProgress, [fileName]: e.percentage } };
+  EventsOn('download:progress:batch', (batch: { filePath: string; percentage: number }[]) => {
+    useProgressStore.setState(state => {
+      const downloadProgress = { ...state.downloadProgress };
+      let changed = false;
+      for (const e of batch) {
+        const fileName = getFileName(e.filePath);
+        if (!fileName) continue;
+        const last = downloadProgress[fileName] ?? 0;
+        // only update if changed by at least 0.1%
+        if (e.percentage !== 100 && Math.abs(last - e.percentage) < 0.1) continue;
+        downloadProgress[fileName] = e.percentage;
+        changed = true;
+      }
+      return changed ? { downloadProgress } : state;
+    });
   });
-});
 
 
+  // A failed download (interrupted, or stopped at its size limit) is not coming back.
+  EventsOn('download:error', (e: { filePath: string }) => {
+    const fileName = getFileName(e.filePath);
+    if (fileName) {
+      useProgressStore.setState(state => {
+        const { [fileName]: _, ...rest } = state.downloadProgress;
+        return { downloadProgress: rest };
+      });
+    }
+  });
+
   EventsOn('conversion:done', (e: { filePath: string }) => {
     const fileName = getFileName(e.filePath);
     if (fileName) {
@@ -73,11 +93,19 @@ EventsOn("download:progress", (e) => {
     }
   });
   
-  EventsOn('waveform:progress', (e: { filePath: string; clipStart: number; clipEnd: number; percentage: number }) => {
-    const jobKey = generateWaveformJobKey(e.filePath, e.clipStart, e.clipEnd);
-    useProgressStore.setState(state => ({
-      waveformProgress: { ...state.waveformProgress, [jobKey]: e.percentage }
-    }));
+  // Cancelled conversions report neither done nor error.
+  EventsOn('jobs:cancelled', () => {
+    useProgressStore.setState({ conversionProgress: {}, waveformProgress: {} });
+  });
+
+  EventsOn('waveform:progress:batch', (batch: { filePath: string; clipStart: number; clipEnd: number; percentage: number }[]) => {
+    useProgressStore.setState(state => {
+      const waveformProgress = { ...state.waveformProgress };
+      for (const e of batch) {
+        waveformProgress[generateWaveformJobKey(e.filePath, e.clipStart, e.clipEnd)] = e.percentage;
+      }
+      return { waveformProgress };
+    });
   });
 
   EventsOn('waveform:done', (e: { filePath: string; clipStart: number; clipEnd: number; }) => {
@@ -9,7 +9,7 @@ export interface ActiveClip {
   name: string;                 // Display name for the selector (TimelineItem.Name)
   sourceFilePath: string;       // Full path to the source file (TimelineItem.SourceFilePath)
   processedFileName: string;    // Base for the preview URL (TimelineItem.ProcessedFileName)
-  previewUrl: string;           // Full URL to the .wav file
+  previewUrl: string;           // Unsigned URL of the clip's audio; load it through SignMediaURL
   sourceStartFrame: number;     // For WaveformPlayer region (TimelineItem.SourceStartFrame)
   sourceEndFrame: number;       // For WaveformPlayer region (TimelineItem.SourceEndFrame)
   startFrame: number;
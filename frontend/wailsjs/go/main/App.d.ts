@@ -2,20 +2,32 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AddHighlightMarkers(arg1:string):Promise<number>;
+
+export function AnalyzeMusicOverlap(arg1:main.MusicOverlapParams):Promise<main.MusicOverlapReport>;
+
 export function CalculateAndStoreEditsForTimeline(arg1:main.ProjectDataPayload,arg2:boolean,arg3:Record<string, Array<main.SilencePeriod>>):Promise<main.ProjectDataPayload>;
 
+export function ClearSubtitles():Promise<void>;
+
 export function CloseApp():Promise<void>;
 
+export function DetectHighlights(arg1:main.HighlightParams):Promise<main.HighlightReport>;
+
 export function DetectSilences(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number,arg7:number,arg8:number,arg9:number):Promise<Array<main.SilencePeriod>>;
 
 export function DownloadFFmpeg():Promise<void>;
 
-export function ExecuteAndTrackMixdown(arg1:number,arg2:string,arg3:Array<main.NestedAudioTimelineItem>):Promise<void>;
+export function DownloadWhisper():Promise<main.WhisperInfo>;
+
+export function ExportHighlights(arg1:string,arg2:string):Promise<string>;
 
 export function GetAppVersion():Promise<string>;
 
 export function GetCurrentConversionProgress():Promise<Record<string, number>>;
 
+export function GetEndpointPrefix():Promise<string>;
+
 export function GetFFmpegStatus():Promise<main.FfmpegStatus>;
 
 export function GetFfmpegVersion():Promise<string>;
@@ -32,32 +44,46 @@ export function GetPythonReadyStatus():Promise<boolean>;
 
 export function GetSettings():Promise<Record<string, any>>;
 
+export function GetTLSInfo():Promise<main.TLSInfo>;
+
 export function GetToken():Promise<string>;
 
+export function GetTranscript(arg1:string):Promise<main.Transcript>;
+
 export function GetUpdateInfo():Promise<main.UpdateResponseV1>;
 
 export function GetWaveform(arg1:string,arg2:number,arg3:string,arg4:number,arg5:number,arg6:number):Promise<main.PrecomputedWaveformData>;
 
+export function GetWhisperInfo():Promise<main.WhisperInfo>;
+
 export function HasAValidLicense():Promise<boolean>;
 
+export function HintVisibleClips(arg1:Array<string>):Promise<void>;
+
+export function ImportSubtitles(arg1:string,arg2:string):Promise<main.SubtitleTrack>;
+
 export function LaunchHttpServer():Promise<void>;
 
 export function LaunchPythonBackend(arg1:number,arg2:number):Promise<void>;
 
-export function MakeFinalTimeline(arg1:main.ProjectDataPayload,arg2:boolean):Promise<main.PythonCommandResponse>;
+export function ListTimelines():Promise<main.TimelineList>;
 
-export function MixdownCompoundClips(arg1:main.ProjectDataPayload):Promise<void>;
+export function MakeFinalTimeline(arg1:main.ProjectDataPayload,arg2:boolean):Promise<main.PythonCommandResponse>;
 
 export function OpenURL(arg1:string):Promise<void>;
 
 export function ProcessProjectAudio(arg1:main.ProjectDataPayload):Promise<void>;
 
+export function ProcessTimelines(arg1:main.TimelineBatchRequest):Promise<main.TimelineBatchResult>;
+
 export function ProcessWavToLinearPeaks(arg1:string,arg2:number,arg3:number,arg4:number):Promise<main.PrecomputedWaveformData>;
 
 export function ProcessWavToLogarithmicPeaks(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number):Promise<main.PrecomputedWaveformData>;
 
 export function ResolveBinaryPath(arg1:string):Promise<string>;
 
+export function RotateBackendToken():Promise<void>;
+
 export function SaveSettings(arg1:Record<string, any>):Promise<void>;
 
 export function SelectDirectory():Promise<string>;
@@ -66,10 +92,16 @@ export function SendCommandToPython(arg1:string,arg2:Record<string, any>):Promis
 
 export function SetDavinciPlayhead(arg1:string):Promise<boolean>;
 
+export function SetLocale(arg1:string):Promise<void>;
+
 export function SetWindowAlwaysOnTop(arg1:boolean):Promise<void>;
 
+export function SignMediaURL(arg1:string):Promise<string>;
+
 export function StandardizeAudioToWav(arg1:string,arg2:string,arg3:any):Promise<void>;
 
+export function SyncAndAnalyze(arg1:main.DetectionParams):Promise<main.SyncAnalysis>;
+
 export function SyncWithDavinci():Promise<main.PythonCommandResponse>;
 
 export function VerifyLicense(arg1:string):Promise<Record<string, any>>;
@@ -2,14 +2,30 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddHighlightMarkers(arg1) {
+  return window['go']['main']['App']['AddHighlightMarkers'](arg1);
+}
+
+export function AnalyzeMusicOverlap(arg1) {
+  return window['go']['main']['App']['AnalyzeMusicOverlap'](arg1);
+}
+
 export function CalculateAndStoreEditsForTimeline(arg1, arg2, arg3) {
   return window['go']['main']['App']['CalculateAndStoreEditsForTimeline'](arg1, arg2, arg3);
 }
 
+export function ClearSubtitles() {
+  return window['go']['main']['App']['ClearSubtitles']();
+}
+
 export function CloseApp() {
   return window['go']['main']['App']['CloseApp']();
 }
 
+export function DetectHighlights(arg1) {
+  return window['go']['main']['App']['DetectHighlights'](arg1);
+}
+
 export function DetectSilences(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9) {
   return window['go']['main']['App']['DetectSilences'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9);
 }
@@ -18,8 +34,12 @@ export function DownloadFFmpeg() {
   return window['go']['main']['App']['DownloadFFmpeg']();
 }
 
-export function ExecuteAndTrackMixdown(arg1, arg2, arg3) {
-  return window['go']['main']['App']['ExecuteAndTrackMixdown'](arg1, arg2, arg3);
+export function DownloadWhisper() {
+  return window['go']['main']['App']['DownloadWhisper']();
+}
+
+export function ExportHighlights(arg1, arg2) {
+  return window['go']['main']['App']['ExportHighlights'](arg1, arg2);
 }
 
 export function GetAppVersion() {
@@ -30,6 +50,10 @@ export function GetCurrentConversionProgress() {
   return window['go']['main']['App']['GetCurrentConversionProgress']();
 }
 
+export function GetEndpointPrefix() {
+  return window['go']['main']['App']['GetEndpointPrefix']();
+}
+
 export function GetFFmpegStatus() {
   return window['go']['main']['App']['GetFFmpegStatus']();
 }
@@ -66,10 +90,18 @@ export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
 
+export function GetTLSInfo() {
+  return window['go']['main']['App']['GetTLSInfo']();
+}
+
 export function GetToken() {
   return window['go']['main']['App']['GetToken']();
 }
 
+export function GetTranscript(arg1) {
+  return window['go']['main']['App']['GetTranscript'](arg1);
+}
+
 export function GetUpdateInfo() {
   return window['go']['main']['App']['GetUpdateInfo']();
 }
@@ -78,10 +110,22 @@ export function GetWaveform(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['GetWaveform'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function GetWhisperInfo() {
+  return window['go']['main']['App']['GetWhisperInfo']();
+}
+
 export function HasAValidLicense() {
   return window['go']['main']['App']['HasAValidLicense']();
 }
 
+export function HintVisibleClips(arg1) {
+  return window['go']['main']['App']['HintVisibleClips'](arg1);
+}
+
+export function ImportSubtitles(arg1, arg2) {
+  return window['go']['main']['App']['ImportSubtitles'](arg1, arg2);
+}
+
 export function LaunchHttpServer() {
   return window['go']['main']['App']['LaunchHttpServer']();
 }
@@ -90,12 +134,12 @@ export function LaunchPythonBackend(arg1, arg2) {
   return window['go']['main']['App']['LaunchPythonBackend'](arg1, arg2);
 }
 
-export function MakeFinalTimeline(arg1, arg2) {
-  return window['go']['main']['App']['MakeFinalTimeline'](arg1, arg2);
+export function ListTimelines() {
+  return window['go']['main']['App']['ListTimelines']();
 }
 
-export function MixdownCompoundClips(arg1) {
-  return window['go']['main']['App']['MixdownCompoundClips'](arg1);
+export function MakeFinalTimeline(arg1, arg2) {
+  return window['go']['main']['App']['MakeFinalTimeline'](arg1, arg2);
 }
 
 export function OpenURL(arg1) {
@@ -106,6 +150,10 @@ export function ProcessProjectAudio(arg1) {
   return window['go']['main']['App']['ProcessProjectAudio'](arg1);
 }
 
+export function ProcessTimelines(arg1) {
+  return window['go']['main']['App']['ProcessTimelines'](arg1);
+}
+
 export function ProcessWavToLinearPeaks(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ProcessWavToLinearPeaks'](arg1, arg2, arg3, arg4);
 }
@@ -118,6 +166,10 @@ export function ResolveBinaryPath(arg1) {
   return window['go']['main']['App']['ResolveBinaryPath'](arg1);
 }
 
+export function RotateBackendToken() {
+  return window['go']['main']['App']['RotateBackendToken']();
+}
+
 export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
@@ -134,14 +186,26 @@ export function SetDavinciPlayhead(arg1) {
   return window['go']['main']['App']['SetDavinciPlayhead'](arg1);
 }
 
+export function SetLocale(arg1) {
+  return window['go']['main']['App']['SetLocale'](arg1);
+}
+
 export function SetWindowAlwaysOnTop(arg1) {
   return window['go']['main']['App']['SetWindowAlwaysOnTop'](arg1);
 }
 
+export function SignMediaURL(arg1) {
+  return window['go']['main']['App']['SignMediaURL'](arg1);
+}
+
 export function StandardizeAudioToWav(arg1, arg2, arg3) {
   return window['go']['main']['App']['StandardizeAudioToWav'](arg1, arg2, arg3);
 }
 
+export function SyncAndAnalyze(arg1) {
+  return window['go']['main']['App']['SyncAndAnalyze'](arg1);
+}
+
 export function SyncWithDavinci() {
   return window['go']['main']['App']['SyncWithDavinci']();
 }
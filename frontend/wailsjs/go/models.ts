@@ -18,6 +18,28 @@ export namespace main {
 	        this.button_url = source["button_url"];
 	    }
 	}
+	export class DetectionParams {
+	    loudnessThreshold: number;
+	    minSilenceDurationSeconds: number;
+	    paddingLeftSeconds: number;
+	    paddingRightSeconds: number;
+	    minContent: number;
+	    keepSilenceSegments: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DetectionParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.loudnessThreshold = source["loudnessThreshold"];
+	        this.minSilenceDurationSeconds = source["minSilenceDurationSeconds"];
+	        this.paddingLeftSeconds = source["paddingLeftSeconds"];
+	        this.paddingRightSeconds = source["paddingRightSeconds"];
+	        this.minContent = source["minContent"];
+	        this.keepSilenceSegments = source["keepSilenceSegments"];
+	    }
+	}
 	export class EditInstruction {
 	    source_start_frame: number;
 	    source_end_frame: number;
@@ -54,6 +76,170 @@ export namespace main {
 	        this.uuid = source["uuid"];
 	    }
 	}
+	export class Highlight {
+	    clipId: string;
+	    clipName: string;
+	    fileName: string;
+	    start: number;
+	    end: number;
+	    timelineStartFrame: number;
+	    timelineEndFrame: number;
+	    peakLUFS: number;
+	    score: number;
+	    kind: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Highlight(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.clipId = source["clipId"];
+	        this.clipName = source["clipName"];
+	        this.fileName = source["fileName"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.timelineStartFrame = source["timelineStartFrame"];
+	        this.timelineEndFrame = source["timelineEndFrame"];
+	        this.peakLUFS = source["peakLUFS"];
+	        this.score = source["score"];
+	        this.kind = source["kind"];
+	    }
+	}
+	export class HighlightParams {
+	    thresholdLU: number;
+	    minDurationSeconds: number;
+	    mergeGapSeconds: number;
+	    maxHighlights: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HighlightParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.thresholdLU = source["thresholdLU"];
+	        this.minDurationSeconds = source["minDurationSeconds"];
+	        this.mergeGapSeconds = source["mergeGapSeconds"];
+	        this.maxHighlights = source["maxHighlights"];
+	    }
+	}
+	export class HighlightReport {
+	    params: HighlightParams;
+	    highlights: Highlight[];
+	    failed?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new HighlightReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.params = this.convertValues(source["params"], HighlightParams);
+	        this.highlights = this.convertValues(source["highlights"], Highlight);
+	        this.failed = source["failed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MusicJump {
+	    clipId: string;
+	    clipName: string;
+	    start: number;
+	    end: number;
+	    timelineStartFrame: number;
+	    timelineEndFrame: number;
+	    musicTrack: number;
+	    musicLUFS: number;
+	    disabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MusicJump(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.clipId = source["clipId"];
+	        this.clipName = source["clipName"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.timelineStartFrame = source["timelineStartFrame"];
+	        this.timelineEndFrame = source["timelineEndFrame"];
+	        this.musicTrack = source["musicTrack"];
+	        this.musicLUFS = source["musicLUFS"];
+	        this.disabled = source["disabled"];
+	    }
+	}
+	export class MusicOverlapParams {
+	    musicTracks: number[];
+	    activityThresholdLUFS: number;
+	    disableCuts: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MusicOverlapParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.musicTracks = source["musicTracks"];
+	        this.activityThresholdLUFS = source["activityThresholdLUFS"];
+	        this.disableCuts = source["disableCuts"];
+	    }
+	}
+	export class MusicOverlapReport {
+	    params: MusicOverlapParams;
+	    cutsChecked: number;
+	    cutsDisabled: number;
+	    jumps: MusicJump[];
+	    failed?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new MusicOverlapReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.params = this.convertValues(source["params"], MusicOverlapParams);
+	        this.cutsChecked = source["cutsChecked"];
+	        this.cutsDisabled = source["cutsDisabled"];
+	        this.jumps = this.convertValues(source["jumps"], MusicJump);
+	        this.failed = source["failed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class NestedAudioTimelineItem {
 	    source_file_path: string;
 	    processed_file_name?: string;
@@ -422,6 +608,273 @@ export namespace main {
 	}
 	
 	
+	export class SubtitleCue {
+	    index: number;
+	    start: number;
+	    end: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SubtitleCue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.text = source["text"];
+	    }
+	}
+	export class SubtitleTrack {
+	    fileName: string;
+	    // Go type: time
+	    importedAt: any;
+	    mode: string;
+	    offsetSeconds: number;
+	    cues: SubtitleCue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SubtitleTrack(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fileName = source["fileName"];
+	        this.importedAt = this.convertValues(source["importedAt"], null);
+	        this.mode = source["mode"];
+	        this.offsetSeconds = source["offsetSeconds"];
+	        this.cues = this.convertValues(source["cues"], SubtitleCue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncAnalysis {
+	    response?: PythonCommandResponse;
+	    project?: ProjectDataPayload;
+	    silences: Record<string, Array<SilencePeriod>>;
+	    failed?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncAnalysis(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.response = this.convertValues(source["response"], PythonCommandResponse);
+	        this.project = this.convertValues(source["project"], ProjectDataPayload);
+	        this.silences = source["silences"];
+	        this.failed = source["failed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TLSInfo {
+	    enabled: boolean;
+	    port: number;
+	    fingerprint: string;
+	    addresses: string[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TLSInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.port = source["port"];
+	        this.fingerprint = source["fingerprint"];
+	        this.addresses = source["addresses"];
+	        this.error = source["error"];
+	    }
+	}
+	export class TimelineBatchEntry {
+	    timeline: string;
+	    status: string;
+	    message?: string;
+	    clipsAnalyzed: number;
+	    clipsFailed: number;
+	    elapsedMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineBatchEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timeline = source["timeline"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.clipsAnalyzed = source["clipsAnalyzed"];
+	        this.clipsFailed = source["clipsFailed"];
+	        this.elapsedMs = source["elapsedMs"];
+	    }
+	}
+	export class TimelineBatchRequest {
+	    timelineNames?: string[];
+	    pattern?: string;
+	    presetName?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineBatchRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timelineNames = source["timelineNames"];
+	        this.pattern = source["pattern"];
+	        this.presetName = source["presetName"];
+	    }
+	}
+	export class TimelineBatchResult {
+	    params: DetectionParams;
+	    // Go type: time
+	    startedAt: any;
+	    succeeded: number;
+	    failed: number;
+	    cancelled: boolean;
+	    timelines: TimelineBatchEntry[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineBatchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.params = this.convertValues(source["params"], DetectionParams);
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.succeeded = source["succeeded"];
+	        this.failed = source["failed"];
+	        this.cancelled = source["cancelled"];
+	        this.timelines = this.convertValues(source["timelines"], TimelineBatchEntry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimelineList {
+	    timelines: string[];
+	    current: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineList(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timelines = source["timelines"];
+	        this.current = source["current"];
+	    }
+	}
+	export class TranscriptWord {
+	    text: string;
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptWord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class Transcript {
+	    clipId: string;
+	    fileName: string;
+	    clipStartSeconds: number;
+	    clipEndSeconds: number;
+	    model: string;
+	    language?: string;
+	    // Go type: time
+	    createdAt: any;
+	    words: TranscriptWord[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Transcript(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.clipId = source["clipId"];
+	        this.fileName = source["fileName"];
+	        this.clipStartSeconds = source["clipStartSeconds"];
+	        this.clipEndSeconds = source["clipEndSeconds"];
+	        this.model = source["model"];
+	        this.language = source["language"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.words = this.convertValues(source["words"], TranscriptWord);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class UpdateResponseV1 {
 	    schema_version: number;
 	    latest_version: string;
@@ -468,6 +921,32 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class WhisperInfo {
+	    enabled: boolean;
+	    status: number;
+	    path?: string;
+	    managed: boolean;
+	    model: string;
+	    modelPath: string;
+	    modelInstalled: boolean;
+	    downloadable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new WhisperInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.status = source["status"];
+	        this.path = source["path"];
+	        this.managed = source["managed"];
+	        this.model = source["model"];
+	        this.modelPath = source["modelPath"];
+	        this.modelInstalled = source["modelInstalled"];
+	        this.downloadable = source["downloadable"];
+	    }
+	}
 
 }
 
@@ -0,0 +1,18 @@
+package main
+
+// The Wails runtime is only touched from gui_wails.go. Building with -tags headless swaps it
+// for gui_headless.go, which leaves out Wails, the webview and the embedded frontend: the
+// binary then runs the CLI subcommands and --serve only, and needs neither cgo nor GTK, e.g. in
+// a container or a CI job.
+
+// eventSink receives the events the app emits: the frontend in the GUI, the progress display
+// in the CLI. With none set, as in --serve, events are dropped.
+type eventSink interface {
+	emit(eventName string, data ...interface{})
+}
+
+// fileFilter restricts a file dialog to one kind of file, e.g. "*.json" or "*.json;*.lic".
+type fileFilter struct {
+	DisplayName string
+	Pattern     string
+}
@@ -0,0 +1,43 @@
+//go:build headless
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// errNoGUI is returned by what needs a window in a headless build.
+var errNoGUI = errors.New("not available in a headless build")
+
+func runGUI(app *App) error {
+	return errors.New("this build has no GUI: run a subcommand (detect, cut, watch, peaks) or --serve")
+}
+
+func isProductionBuild(ctx context.Context) bool { return true }
+
+func openURL(ctx context.Context, url string) {}
+
+func quitApp(ctx context.Context) {}
+
+func openDirectoryDialog(ctx context.Context, defaultDir string) (string, error) {
+	return "", errNoGUI
+}
+
+func openFileDialog(ctx context.Context, title string, filter fileFilter) (string, error) {
+	return "", errNoGUI
+}
+
+func saveFileDialog(ctx context.Context, defaultFilename string, filter fileFilter) (string, error) {
+	return "", errNoGUI
+}
+
+func windowGeometry(ctx context.Context, state *WindowState) {}
+
+func applyWindowGeometry(ctx context.Context, state WindowState) {}
+
+func setWindowAlwaysOnTop(ctx context.Context, alwaysOnTop bool) {}
+
+func showWindow(ctx context.Context) {}
+
+func hideWindow(ctx context.Context) {}
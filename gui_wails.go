@@ -0,0 +1,162 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"embed"
+	"net/http"
+
+	"github.com/wailsapp/wails/v2"
+	"github.com/wailsapp/wails/v2/pkg/logger"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/options/linux"
+	"github.com/wailsapp/wails/v2/pkg/options/mac"
+	"github.com/wailsapp/wails/v2/pkg/options/windows"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+//go:embed all:frontend/dist
+var assets embed.FS
+
+//go:embed build/appicon.png
+var icon []byte
+
+type FileLoader struct {
+	http.Handler
+}
+
+func NewFileLoader() *FileLoader {
+	return &FileLoader{
+		// CORRECT: Initialize the embedded handler with the default
+		// file server, telling it to serve your embedded assets.
+		Handler: http.FileServer(http.FS(assets)),
+	}
+}
+
+var gpuPolicyOptions = map[string]linux.WebviewGpuPolicy{
+	gpuPolicyAlways:   linux.WebviewGpuPolicyAlways,
+	gpuPolicyOnDemand: linux.WebviewGpuPolicyOnDemand,
+	gpuPolicyNever:    linux.WebviewGpuPolicyNever,
+}
+
+// runGUI opens the main window and blocks until it is closed.
+func runGUI(app *App) error {
+	display := resolveDisplayOptions(app.displayFlags.GPUPolicy, app.displayFlags.Backend)
+	return wails.Run(&options.App{
+		Title:     "HushCut",
+		Width:     1024,
+		Height:    801,
+		MinWidth:  500,
+		MinHeight: 550,
+		AssetServer: &assetserver.Options{
+			Assets:  assets,
+			Handler: NewFileLoader(),
+		},
+		BackgroundColour: &options.RGBA{R: 40, G: 40, B: 46, A: 1},
+		OnStartup: func(ctx context.Context) {
+			app.events = newCoalescingEvents(ctx, wailsEvents{ctx})
+			app.startup(ctx)
+			app.startTray(icon)
+		},
+		OnDomReady:    func(ctx context.Context) { windowIsUp() },
+		OnShutdown:    app.shutdown,
+		OnBeforeClose: app.beforeClose,
+		Bind: []interface{}{
+			app,
+		},
+		LogLevel:    logger.INFO,
+		AlwaysOnTop: true,
+		Frameless:   true,
+		Mac: &mac.Options{
+			WebviewIsTransparent: true,
+		},
+		Windows: &windows.Options{
+			WebviewIsTransparent: true,
+		},
+		Linux: &linux.Options{
+			Icon:                icon,
+			WindowIsTranslucent: false,
+			WebviewGpuPolicy:    gpuPolicyOptions[display.GPUPolicy],
+			ProgramName:         "HushCut",
+		},
+	})
+}
+
+// wailsEvents sends events to the frontend.
+type wailsEvents struct {
+	ctx context.Context
+}
+
+func (w wailsEvents) emit(eventName string, data ...interface{}) {
+	runtime.EventsEmit(w.ctx, eventName, data...)
+}
+
+func isProductionBuild(ctx context.Context) bool {
+	return runtime.Environment(ctx).BuildType == "production"
+}
+
+func openURL(ctx context.Context, url string) {
+	runtime.BrowserOpenURL(ctx, url)
+}
+
+func quitApp(ctx context.Context) {
+	runtime.Quit(ctx)
+}
+
+func openDirectoryDialog(ctx context.Context, defaultDir string) (string, error) {
+	return runtime.OpenDirectoryDialog(ctx, runtime.OpenDialogOptions{
+		DefaultDirectory: defaultDir,
+	})
+}
+
+func openFileDialog(ctx context.Context, title string, filter fileFilter) (string, error) {
+	return runtime.OpenFileDialog(ctx, runtime.OpenDialogOptions{
+		Title:   title,
+		Filters: []runtime.FileFilter{{DisplayName: filter.DisplayName, Pattern: filter.Pattern}},
+	})
+}
+
+func saveFileDialog(ctx context.Context, defaultFilename string, filter fileFilter) (string, error) {
+	return runtime.SaveFileDialog(ctx, runtime.SaveDialogOptions{
+		DefaultFilename: defaultFilename,
+		Filters:         []runtime.FileFilter{{DisplayName: filter.DisplayName, Pattern: filter.Pattern}},
+	})
+}
+
+// windowGeometry fills in the live size, position and maximised state of the window.
+func windowGeometry(ctx context.Context, state *WindowState) {
+	state.Maximised = runtime.WindowIsMaximised(ctx)
+	if !state.Maximised {
+		state.Width, state.Height = runtime.WindowGetSize(ctx)
+		state.X, state.Y = runtime.WindowGetPosition(ctx)
+	}
+}
+
+func applyWindowGeometry(ctx context.Context, state WindowState) {
+	if state.Maximised {
+		runtime.WindowMaximise(ctx)
+	} else {
+		if state.Width > 0 && state.Height > 0 {
+			runtime.WindowSetSize(ctx, state.Width, state.Height)
+		}
+		if state.X >= 0 && state.Y >= 0 {
+			runtime.WindowSetPosition(ctx, state.X, state.Y)
+		}
+	}
+	runtime.WindowSetAlwaysOnTop(ctx, state.AlwaysOnTop)
+}
+
+func setWindowAlwaysOnTop(ctx context.Context, alwaysOnTop bool) {
+	runtime.WindowSetAlwaysOnTop(ctx, alwaysOnTop)
+}
+
+func showWindow(ctx context.Context) {
+	runtime.WindowUnminimise(ctx)
+	runtime.WindowShow(ctx)
+}
+
+func hideWindow(ctx context.Context) {
+	runtime.WindowHide(ctx)
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Highlight detection is the opposite of silence detection: it looks for the moments that
+// stand out, such as laughter, raised voices and emphasis, to give podcasters a head start on
+// picking clips. ffmpeg's ebur128 filter measures each clip's loudness every 100 ms; a moment
+// is a highlight when it is ThresholdLU louder than the clip usually is ("loud") or than the
+// three seconds around it ("burst", a sudden jump). Nearby moments are merged into regions
+// and the strongest are kept. The result can be added to the Resolve timeline as markers or
+// exported as a list.
+
+const (
+	defaultHighlightThresholdLU = 6.0
+	defaultHighlightMinDuration = 0.5
+	defaultHighlightMergeGap    = 0.5
+	defaultHighlightMarkerColor = "Yellow"
+	highlightMarkerCustomData   = "hushcut-highlight"
+	// ebur128's momentary loudness covers the 400 ms before each measurement.
+	momentaryWindowSeconds = 0.4
+	// Frames quieter than this are pauses and don't count towards a clip's typical loudness.
+	highlightSilenceFloorLUFS = -60.0
+)
+
+// HighlightParams tunes DetectHighlights; zero values use the defaults.
+type HighlightParams struct {
+	ThresholdLU        float64 `json:"thresholdLU"`
+	MinDurationSeconds float64 `json:"minDurationSeconds"`
+	MergeGapSeconds    float64 `json:"mergeGapSeconds"`
+	MaxHighlights      int     `json:"maxHighlights"` // over the whole timeline; 0 keeps all
+}
+
+// Highlight is a region of a clip that stands out.
+type Highlight struct {
+	ClipID   string `json:"clipId"`
+	ClipName string `json:"clipName"`
+	FileName string `json:"fileName"`
+	// Start and End are seconds in the clip's WAV, like SilencePeriod.
+	Start              float64 `json:"start"`
+	End                float64 `json:"end"`
+	TimelineStartFrame float64 `json:"timelineStartFrame"`
+	TimelineEndFrame   float64 `json:"timelineEndFrame"`
+	PeakLUFS           float64 `json:"peakLUFS"`
+	Score              float64 `json:"score"` // LU above the clip's typical loudness or surroundings
+	Kind               string  `json:"kind"`  // "loud" or "burst"
+}
+
+// HighlightReport is the result of DetectHighlights, also sent as "highlights:detected".
+type HighlightReport struct {
+	Params     HighlightParams   `json:"params"`
+	Highlights []Highlight       `json:"highlights"`       // in timeline order
+	Failed     map[string]string `json:"failed,omitempty"` // errors by clip ID
+}
+
+// HighlightProgress is sent as "highlights:progress" while DetectHighlights runs.
+type HighlightProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// highlightResults keeps the last report for AddHighlightMarkers and ExportHighlights.
+var highlightResults struct {
+	sync.Mutex
+	report *HighlightReport
+}
+
+func (p HighlightParams) withDefaults() HighlightParams {
+	if p.ThresholdLU <= 0 {
+		p.ThresholdLU = defaultHighlightThresholdLU
+	}
+	if p.MinDurationSeconds <= 0 {
+		p.MinDurationSeconds = defaultHighlightMinDuration
+	}
+	if p.MergeGapSeconds < 0 {
+		p.MergeGapSeconds = 0
+	} else if p.MergeGapSeconds == 0 {
+		p.MergeGapSeconds = defaultHighlightMergeGap
+	}
+	if p.MaxHighlights < 0 {
+		p.MaxHighlights = 0
+	}
+	return p
+}
+
+// loudnessFrame is one ebur128 measurement, in seconds of the WAV.
+type loudnessFrame struct {
+	Time      float64
+	Momentary float64
+	ShortTerm float64
+}
+
+var ebur128FramePattern = regexp.MustCompile(`t:\s*([0-9.]+)\s+TARGET:.*?M:\s*(-?[0-9.]+|-inf|nan)\s+S:\s*(-?[0-9.]+|-inf|nan)`)
+
+// measureLoudness runs ebur128 over a clip range of a WAV in the cache; purpose names the run
+// in the ffmpeg audit log.
+func (a *App) measureLoudness(purpose, fileName string, startSeconds, endSeconds float64) ([]loudnessFrame, error) {
+	if err := a.waitForFfmpeg(); err != nil {
+		return nil, err
+	}
+	absPath := filepath.Join(a.tmpPath, fileName)
+	a.updateFileUsage(absPath)
+
+	// Seek and trim the same way DetectSilences does.
+	seekStart := math.Max(0, startSeconds-detectionPreRoll)
+	args := []string{
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.6f", seekStart),
+		"-t", fmt.Sprintf("%.6f", endSeconds-seekStart),
+		"-i", absPath,
+		"-af", fmt.Sprintf("atrim=start=%.6f:end=%.6f,asetpts=PTS-STARTPTS,ebur128=framelog=info", startSeconds-seekStart, endSeconds-seekStart),
+		"-f", "null", "-",
+	}
+	release, err := a.acquireDetectionSlot(absPath)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cmd := a.ffmpegCommand(args...)
+	var output bytes.Buffer
+	cmd.Stderr = &output
+	started := time.Now()
+	err = runJob(jobDetection, cmd)
+	auditFFmpeg(purpose, cmd, started, err, output.String())
+	if errors.Is(err, errJobCancelled) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed to measure the loudness of %s: %w", fileName, err)
+	}
+	return parseEbur128Frames(&output, startSeconds)
+}
+
+// parseEbur128Frames reads ebur128's frame log; its times count from the start of the clip.
+func parseEbur128Frames(output *bytes.Buffer, startSeconds float64) ([]loudnessFrame, error) {
+	parseLoudness := func(s string) float64 {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) {
+			return math.Inf(-1)
+		}
+		return v
+	}
+	var frames []loudnessFrame
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		m := ebur128FramePattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		t, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, loudnessFrame{
+			Time:      startSeconds + t,
+			Momentary: parseLoudness(m[2]),
+			ShortTerm: parseLoudness(m[3]),
+		})
+	}
+	return frames, scanner.Err()
+}
+
+// typicalLoudness is the median momentary loudness of the frames that aren't pauses.
+func typicalLoudness(frames []loudnessFrame) (float64, bool) {
+	var levels []float64
+	for _, f := range frames {
+		if f.Momentary > highlightSilenceFloorLUFS {
+			levels = append(levels, f.Momentary)
+		}
+	}
+	if len(levels) == 0 {
+		return 0, false
+	}
+	sort.Float64s(levels)
+	mid := len(levels) / 2
+	if len(levels)%2 == 0 {
+		return (levels[mid-1] + levels[mid]) / 2, true
+	}
+	return levels[mid], true
+}
+
+// findHighlights turns the loudness of a clip range into highlight regions. The ClipID,
+// ClipName, FileName and timeline positions are left to the caller.
+func findHighlights(frames []loudnessFrame, startSeconds, endSeconds float64, params HighlightParams) []Highlight {
+	typical, ok := typicalLoudness(frames)
+	if !ok {
+		return nil
+	}
+
+	var highlights []Highlight
+	var current *Highlight
+	flush := func() {
+		if current != nil && current.End-current.Start >= params.MinDurationSeconds {
+			highlights = append(highlights, *current)
+		}
+		current = nil
+	}
+	for _, f := range frames {
+		if f.Momentary <= highlightSilenceFloorLUFS {
+			continue
+		}
+		loud := f.Momentary - typical
+		burst := math.Inf(-1)
+		if !math.IsInf(f.ShortTerm, -1) {
+			burst = f.Momentary - f.ShortTerm
+		}
+		score, kind := loud, "loud"
+		if burst > loud {
+			score, kind = burst, "burst"
+		}
+		if score < params.ThresholdLU {
+			continue
+		}
+
+		start := math.Max(startSeconds, f.Time-momentaryWindowSeconds)
+		end := math.Min(endSeconds, f.Time)
+		if current != nil && start-current.End > params.MergeGapSeconds {
+			flush()
+		}
+		if current == nil {
+			current = &Highlight{Start: start, End: end, PeakLUFS: f.Momentary, Score: score, Kind: kind}
+			continue
+		}
+		current.End = math.Max(current.End, end)
+		current.PeakLUFS = math.Max(current.PeakLUFS, f.Momentary)
+		if score > current.Score {
+			current.Score, current.Kind = score, kind
+		}
+	}
+	flush()
+	return highlights
+}
+
+// DetectHighlights looks for highlights in the audio clips of the synced project. Clips that
+// fail are listed in Failed; the others are still reported.
+func (a *App) DetectHighlights(params HighlightParams) (*HighlightReport, error) {
+	params = params.withDefaults()
+
+	a.mu.Lock()
+	if a.currentProject == nil {
+		a.mu.Unlock()
+		return nil, fmt.Errorf("no project has been synced yet")
+	}
+	fps := a.currentProject.Timeline.FPS
+	items := append([]TimelineItem(nil), a.currentProject.Timeline.AudioTrackItems...)
+	a.mu.Unlock()
+	if fps <= floatEpsilon {
+		return nil, fmt.Errorf("the synced timeline has no frame rate")
+	}
+
+	var clips []TimelineItem
+	for _, item := range items {
+		if item.ProcessedFileName != nil && *item.ProcessedFileName != "" {
+			clips = append(clips, item)
+		}
+	}
+
+	report := &HighlightReport{Params: params, Highlights: []Highlight{}, Failed: map[string]string{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	a.emit("highlights:progress", HighlightProgress{Done: 0, Total: len(clips)})
+	for _, item := range clips {
+		item := item
+		wg.Add(1)
+		go saferun(func() {
+			defer wg.Done()
+			found, err := a.clipHighlights(item, fps, params)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				appLog.Warn("DetectHighlights: clip failed", "clip", item.Name, "err", err)
+				report.Failed[item.ID] = err.Error()
+			} else {
+				report.Highlights = append(report.Highlights, found...)
+			}
+			done++
+			a.emit("highlights:progress", HighlightProgress{Done: done, Total: len(clips)})
+		})
+	}
+	wg.Wait()
+
+	if params.MaxHighlights > 0 && len(report.Highlights) > params.MaxHighlights {
+		sort.SliceStable(report.Highlights, func(i, j int) bool { return report.Highlights[i].Score > report.Highlights[j].Score })
+		report.Highlights = report.Highlights[:params.MaxHighlights]
+	}
+	sort.SliceStable(report.Highlights, func(i, j int) bool {
+		return report.Highlights[i].TimelineStartFrame < report.Highlights[j].TimelineStartFrame
+	})
+
+	highlightResults.Lock()
+	highlightResults.report = report
+	highlightResults.Unlock()
+	appLog.Info("DetectHighlights done", "highlights", len(report.Highlights), "clips", len(clips), "failed", len(report.Failed))
+	a.emit("highlights:detected", report)
+	return report, nil
+}
+
+// clipHighlights detects the highlights of one clip and places them on the timeline.
+func (a *App) clipHighlights(item TimelineItem, fps float64, params HighlightParams) ([]Highlight, error) {
+	fileName := filepath.Base(*item.ProcessedFileName)
+	startSeconds := item.SourceStartFrame / fps
+	endSeconds := item.SourceEndFrame / fps
+	if endSeconds <= startSeconds {
+		return nil, nil
+	}
+	release := a.acquireFileRefs(filepath.Join(a.tmpPath, fileName))
+	defer release()
+
+	frames, err := a.measureLoudness("detectHighlights", fileName, startSeconds, endSeconds)
+	if err != nil {
+		return nil, err
+	}
+	highlights := findHighlights(frames, startSeconds, endSeconds, params)
+	for i := range highlights {
+		h := &highlights[i]
+		h.ClipID = item.ID
+		h.ClipName = item.Name
+		h.FileName = fileName
+		h.TimelineStartFrame = math.Round(item.StartFrame + (h.Start-startSeconds)*fps)
+		h.TimelineEndFrame = math.Round(item.StartFrame + (h.End-startSeconds)*fps)
+	}
+	return highlights, nil
+}
+
+func lastHighlightReport() (*HighlightReport, error) {
+	highlightResults.Lock()
+	defer highlightResults.Unlock()
+	if highlightResults.report == nil {
+		return nil, fmt.Errorf("no highlights have been detected yet")
+	}
+	return highlightResults.report, nil
+}
+
+// AddHighlightMarkers adds the last detected highlights to the current Resolve timeline as
+// markers of the given color (Resolve's color names; empty for yellow) and returns how many
+// were added. Resolve refuses a marker where one already is.
+func (a *App) AddHighlightMarkers(color string) (int, error) {
+	report, err := lastHighlightReport()
+	if err != nil {
+		return 0, err
+	}
+	if !a.pythonReady {
+		return 0, fmt.Errorf("python backend not ready")
+	}
+	if color == "" {
+		color = defaultHighlightMarkerColor
+	}
+
+	markers := make([]map[string]any, 0, len(report.Highlights))
+	for _, h := range report.Highlights {
+		markers = append(markers, map[string]any{
+			"frame":      h.TimelineStartFrame,
+			"duration":   math.Max(1, h.TimelineEndFrame-h.TimelineStartFrame),
+			"color":      color,
+			"name":       fmt.Sprintf("Highlight (%s)", h.Kind),
+			"note":       fmt.Sprintf("%s: +%.1f LU, peak %.1f LUFS", h.ClipName, h.Score, h.PeakLUFS),
+			"customData": highlightMarkerCustomData,
+		})
+	}
+	pyResponse, err := a.SendCommandToPython("addMarkers", map[string]any{"markers": markers})
+	if err != nil {
+		return 0, fmt.Errorf("failed to send 'addMarkers' command: %w", err)
+	}
+	if pyResponse.Status != "success" {
+		return 0, fmt.Errorf("python 'addMarkers' error: %s", pyResponse.Message)
+	}
+	added := len(markers)
+	if data, ok := pyResponse.Data.(map[string]any); ok {
+		if n, ok := data["added"].(float64); ok {
+			added = int(n)
+		}
+	}
+	return added, nil
+}
+
+// ExportHighlights writes the last detected highlights as "csv" or "json". If destPath is
+// empty, a save dialog is shown.
+func (a *App) ExportHighlights(format string, destPath string) (string, error) {
+	report, err := lastHighlightReport()
+	if err != nil {
+		return "", err
+	}
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		return "", fmt.Errorf("unknown highlight export format %q", format)
+	}
+	if destPath == "" {
+		destPath, err = saveFileDialog(a.ctx, "highlights."+format,
+			fileFilter{DisplayName: strings.ToUpper(format), Pattern: "*." + format})
+		if err != nil || destPath == "" {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeHighlights(&buf, format, report); err != nil {
+		return "", err
+	}
+	return destPath, writeFileAtomic(destPath, buf.Bytes(), 0644)
+}
+
+func writeHighlights(buf *bytes.Buffer, format string, report *HighlightReport) error {
+	if format == "csv" {
+		cw := csv.NewWriter(buf)
+		cw.Write([]string{"clip", "file", "start", "end", "timeline_start_frame", "timeline_end_frame", "kind", "score_lu", "peak_lufs"})
+		for _, h := range report.Highlights {
+			cw.Write([]string{
+				h.ClipName, h.FileName, formatSeconds(h.Start), formatSeconds(h.End),
+				strconv.FormatFloat(h.TimelineStartFrame, 'f', -1, 64),
+				strconv.FormatFloat(h.TimelineEndFrame, 'f', -1, 64),
+				h.Kind, strconv.FormatFloat(h.Score, 'f', 1, 64), strconv.FormatFloat(h.PeakLUFS, 'f', 1, 64),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -6,26 +6,28 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"github.com/oliwoli/hushcut/internal/i18n"
+	"github.com/oliwoli/hushcut/internal/luahelperlogic"
+	"github.com/oliwoli/hushcut/internal/safejson"
 )
 
 //go:embed frontend/src/assets/images/hc-512.png
 var logo []byte
 
-const relativeAudioFolderName = "tmp" // User-defined relative folder
-
 var (
 	serverListenAddress string // Stores "localhost:PORT" for display or "IP:PORT" from listener.Addr()
 	actualPort          int    // port for audio server + messages from python backend to go
@@ -43,15 +45,31 @@ type TaskUpdatePayload struct {
 	Progress float64 `json:"progress,omitempty"` // Optional progress percentage (0.0 to 1.0)
 }
 
+// ToastPayload and AlertPayload carry English text. Senders that know the message keys
+// (internal/i18n) add them so the frontend can show the text in the user's language.
 type ToastPayload struct {
-	Message   string `json:"message"`
-	ToastType string `json:"toastType,omitempty"` // e.g., "info", "success", "warning", "error"
+	Message    string      `json:"message"`
+	ToastType  string      `json:"toastType,omitempty"` // e.g., "info", "success", "warning", "error"
+	MessageKey string      `json:"messageKey,omitempty"`
+	Params     i18n.Params `json:"params,omitempty"`
 }
 
 type AlertPayload struct {
-	Title    string `json:"title"`
-	Message  string `json:"message"`
-	Severity string `json:"severity"` // e.g., "info", "warning", "error"
+	Title      string      `json:"title"`
+	Message    string      `json:"message"`
+	Markdown   string      `json:"markdown,omitempty"`
+	Severity   string      `json:"severity"` // e.g., "info", "warning", "error"
+	TitleKey   string      `json:"titleKey,omitempty"`
+	MessageKey string      `json:"messageKey,omitempty"`
+	Params     i18n.Params `json:"params,omitempty"` // for both keys
+}
+
+// FocusPayload is the Resolve context sent with a "focus" message by lua-helper
+// --launch-or-focus, so the frontend can switch to what the user is looking at.
+type FocusPayload struct {
+	ProjectName  string `json:"project_name,omitempty"`
+	TimelineName string `json:"timeline_name,omitempty"`
+	TimelineID   string `json:"timeline_id,omitempty"`
 }
 
 type ClipInfo struct {
@@ -94,7 +112,7 @@ func (a *App) sendRequestToPython(ctx context.Context, method, path string, payl
 		// Optional: Log payload for debugging. Be careful with sensitive data.
 		// log.Printf("Go -> Python [%s %s]: %s", method, path, string(jsonBody))
 	} else {
-		log.Printf("Go -> Python [%s %s]", method, path)
+		ipcLog.Debug("Go -> Python", "method", method, "path", path)
 	}
 
 	// Create request with context, which allows for per-request timeouts
@@ -108,8 +126,8 @@ func (a *App) sendRequestToPython(ctx context.Context, method, path string, payl
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	if a.authToken != "" {
-		req.Header.Set("Authorization", "Bearer "+a.authToken)
+	if token := a.backendToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	// Use the single, shared httpClient from the App struct
@@ -126,7 +144,7 @@ func (a *App) sendRequestToPython(ctx context.Context, method, path string, payl
 
 	// Check for non-successful status codes
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Python responded to %s with status %s. Body: %s", path, resp.Status, string(responseBody))
+		ipcLog.Warn("Python responded with an error status", "path", path, "status", resp.Status, "body", string(responseBody))
 		// Return the body along with the error, as it might contain a structured error message
 		return responseBody, fmt.Errorf("python server responded with non-200 status: %s", resp.Status)
 	}
@@ -163,24 +181,20 @@ func (a *App) SendCommandToPython(commandName string, params map[string]interfac
 		return nil, fmt.Errorf("error unmarshalling successful python response for command '%s': %w. Body: %s", commandName, err, string(responseBody))
 	}
 
-	log.Printf("Go: Response from Python for command '%s': Status: '%s', Message: '%s'", commandName, pyResp.Status, pyResp.Message)
+	ipcLog.Info("Response from Python", "command", commandName, "status", pyResp.Status, "message", pyResp.Message)
 	return &pyResp, nil
 }
 
 func (a *App) commonMiddleware(next http.HandlerFunc, endpointRequiresAuth bool) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		// 1. Set CORS Headers
-		// 'actualPort' is assumed to be the globally available port of this server
-		// If 'actualPort' is 0 (server not fully initialized), this might not be ideal,
-		// but typically middleware runs after port is known.
-		origin := fmt.Sprintf("http://localhost:%d", actualPort)
-		writer.Header().Set("Access-Control-Allow-Origin", origin)
+		setAllowedOrigin(writer, request)
 		writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")           // Common methods
 		writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Auth-Token") // Common headers + future auth
 
 		// 2. Handle OPTIONS (pre-flight) requests
 		if request.Method == http.MethodOptions {
-			log.Printf("Middleware: Responding to OPTIONS request for %s", request.URL.Path)
+			ipcLog.Debug("Responding to OPTIONS request", "path", request.URL.Path)
 			writer.WriteHeader(http.StatusOK)
 			return
 		}
@@ -196,7 +210,7 @@ func (a *App) commonMiddleware(next http.HandlerFunc, endpointRequiresAuth bool)
 				//log.Printf("Middleware: Global auth is ENABLED. Performing token check for %s.", request.URL.Path)
 
 				if a.authToken == "" { // Assuming App struct has 'authToken string'
-					log.Printf("Auth Error: Auth token not configured on server for %s", request.URL.Path)
+					ipcLog.Error("Auth token not configured on server", "path", request.URL.Path)
 					http.Error(writer, "Internal Server Error - Auth not configured", http.StatusInternalServerError)
 					return
 				}
@@ -220,17 +234,14 @@ func (a *App) commonMiddleware(next http.HandlerFunc, endpointRequiresAuth bool)
 				}
 
 				if clientToken == "" {
-					log.Printf("Auth Warning: No token provided by client for protected endpoint %s", request.URL.Path)
+					ipcLog.Warn("No token provided for protected endpoint", "path", request.URL.Path)
 					http.Error(writer, "Unauthorized - Token required", http.StatusUnauthorized)
 					return
 				}
 
-				if clientToken != a.authToken {
-					log.Printf("Auth Warning: Invalid token provided for %s. Client: [%s...], Expected: [%s...]",
-						request.URL.Path,
-						clientToken,
-						a.authToken,
-					)
+				backendOnly := request.URL.Path == a.endpointPrefix+"/ready" || request.URL.Path == a.endpointPrefix+"/msg"
+				if !a.tokenAccepted(clientToken, backendOnly) {
+					ipcLog.Warn("Invalid token provided", "path", request.URL.Path)
 					//truncateTokenForLog(clientToken),
 					//truncateTokenForLog(a.authToken))
 					http.Error(writer, "Unauthorized - Invalid token", http.StatusUnauthorized)
@@ -239,7 +250,7 @@ func (a *App) commonMiddleware(next http.HandlerFunc, endpointRequiresAuth bool)
 				// log.Printf("Auth: Token validated successfully for %s", request.URL.Path)
 
 			} else {
-				log.Printf("Middleware: Global auth is DISABLED. Token check skipped for %s (even though endpoint requires it).", request.URL.Path)
+				ipcLog.Warn("Global auth is disabled; token check skipped", "path", request.URL.Path)
 			}
 		}
 		// } else {
@@ -251,6 +262,18 @@ func (a *App) commonMiddleware(next http.HandlerFunc, endpointRequiresAuth bool)
 	}
 }
 
+// setAllowedOrigin sets the CORS origin: the app's own on the loopback server. Requests over
+// the TLS listener come from LAN clients whose pages can be served from anywhere; they
+// authenticate with a token or a signed URL, never with cookies, so their origin is allowed.
+func setAllowedOrigin(writer http.ResponseWriter, request *http.Request) {
+	origin := fmt.Sprintf("http://localhost:%d", actualPort)
+	if request.TLS != nil && request.Header.Get("Origin") != "" {
+		origin = request.Header.Get("Origin")
+		writer.Header().Add("Vary", "Origin")
+	}
+	writer.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
 func findFreePort() (int, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -268,19 +291,51 @@ func (a *App) GetToken() string {
 	return a.authToken
 }
 
+// handleHealth tells a caller holding the discovery file that this instance is alive.
+func (a *App) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      "ok",
+		"pid":         os.Getpid(),
+		"version":     a.appVersion,
+		"pythonReady": a.pythonReady,
+	})
+}
+
+// publishDiscovery writes the discovery file the Lua script and lua-helper --discover read
+// to find this instance without being told the port.
+func (a *App) publishDiscovery() {
+	err := luahelperlogic.WriteDiscovery(luahelperlogic.Discovery{
+		Port:      actualPort,
+		Token:     a.authToken,
+		Prefix:    a.endpointPrefix,
+		PID:       os.Getpid(),
+		Version:   a.appVersion,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		ipcLog.Warn("Could not write discovery file", "err", err)
+		return
+	}
+	ipcLog.Debug("Discovery file written")
+}
+
 // initializes and starts the HTTP server in a goroutine.
 // It sets the global actualPort and serverListenAddress if successful.
 // Returns an error if listener setup fails.
 func (a *App) LaunchHttpServer() error {
 	if a.authToken == "" {
-		a.authToken = "HushCut-" + uuid.NewString()
+		a.authToken = newToken()
+		ipcLog.Debug("Generated server auth token")
+	}
+	if a.endpointPrefix == "" {
+		a.endpointPrefix = newEndpointPrefix()
 	}
-	log.Println("Auth: Generated server auth token.")
 
-	log.Printf("Audio Server: Attempting to serve .wav files from: %s", a.tmpPath)
+	ipcLog.Info("Audio server serving .wav files", "dir", a.tmpPath)
 
 	if _, err := os.Stat(a.tmpPath); os.IsNotExist(err) {
-		log.Printf("Audio Server Warning: The audio folder '%s' does not exist. Please ensure it's created next to the executable.", a.tmpPath)
+		ipcLog.Warn("Audio folder does not exist", "dir", a.tmpPath)
 	}
 
 	mux := http.NewServeMux()
@@ -293,63 +348,184 @@ func (a *App) LaunchHttpServer() error {
 		w.Write(logo)
 	})
 
-	// Audio files
+	// Audio files; the media endpoints take signed URLs (see mediaURLs.go)
 	coreAudioHandler := http.HandlerFunc(a.audioFileEndpoint)
-	mux.Handle("/", a.commonMiddleware(coreAudioHandler, true))
+	mux.Handle("/", a.mediaMiddleware(coreAudioHandler))
 
-	// Ready signal
+	// Ready signal; this and the next two are mounted under the session's prefix (sessionToken.go)
 	readyHandler := func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodPost { // Allow GET or POST
 			http.Error(w, "Method not allowed for ready signal", http.StatusMethodNotAllowed)
-			log.Printf("PythonReadyHandler: Method %s blocked", r.Method)
+			ipcLog.Warn("Ready handler: method blocked", "method", r.Method)
 			return
 		}
-		log.Println("HTTP Server: Received ready signal from Python backend.")
+		ipcLog.Info("Received ready signal from Python backend")
 		a.pythonReadyChan <- true
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, "Go server acknowledges Python backend readiness.")
 	}
-	mux.Handle("/ready", a.commonMiddleware(http.HandlerFunc(readyHandler), false)) // false: no auth
+	mux.Handle(a.endpointPrefix+"/ready", a.commonMiddleware(http.HandlerFunc(readyHandler), true))
 
 	// Main communication endpoint
 	pythonMsgHandlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { a.msgEndpoint(w, r) })
-	mux.Handle("/msg", a.commonMiddleware(pythonMsgHandlerFunc, true))
+	mux.Handle(a.endpointPrefix+"/msg", a.commonMiddleware(pythonMsgHandlerFunc, true))
+
+	// Health check for lua-helper --launch-or-focus
+	mux.HandleFunc("/health", a.commonMiddleware(http.HandlerFunc(a.handleHealth), true))
 
 	// Clip rendering endpoint
-	mux.HandleFunc("/render_clip", a.commonMiddleware(http.HandlerFunc(a.handleRenderClip), true))
+	mux.HandleFunc(a.endpointPrefix+"/render_clip", a.mediaMiddleware(http.HandlerFunc(a.handleRenderClip)))
+
+	// Stitched preview of a clip with its silences removed
+	mux.HandleFunc("/preview_cut", a.mediaMiddleware(http.HandlerFunc(a.handlePreviewCut)))
+
+	// Original and cut version of a clip for A/B comparison
+	mux.HandleFunc("/preview_compare", a.mediaMiddleware(http.HandlerFunc(a.handlePreviewCompare)))
+
+	// Short loop around one cut of a clip
+	mux.HandleFunc("/preview_loop", a.mediaMiddleware(http.HandlerFunc(a.handlePreviewLoop)))
+
+	// Profiling, dev builds and --debug-server only
+	a.registerDebugEndpoints(mux)
+
+	// Detection, waveform and timeline API, --serve only
+	a.registerServeEndpoints(mux)
 
 	// Server
-	port, err := findFreePort()
-	if err != nil {
-		return fmt.Errorf("could not find free port: %w", err)
+	port := a.serverPort
+	if port == 0 {
+		var err error
+		if port, err = findFreePort(); err != nil {
+			return fmt.Errorf("could not find free port: %w", err)
+		}
 	}
 	actualPort = port
 	serverListenAddress = fmt.Sprintf("localhost:%d", actualPort)
 	isServerInitialized = true
-	log.Printf("🎵 Audio Server: Starting on http://%s", serverListenAddress)
-	log.Printf("Audio Server: Serving .wav files from: %s", a.tmpPath)
+	ipcLog.Info("Audio server starting", "addr", "http://"+serverListenAddress)
+	ipcLog.Debug("Audio server directory", "dir", a.tmpPath)
 
 	listener, err := net.Listen("tcp", serverListenAddress)
 	if err != nil {
 		return fmt.Errorf("could not start HTTP server listener: %w", err)
 	}
 	// Start the HTTP server in a new goroutine so it doesn't block
-	go func() {
-		if errServe := http.Serve(listener, mux); errServe != nil && errServe != http.ErrServerClosed {
-			log.Printf("ERROR: Audio Server failed: %v", errServe)
-			isServerInitialized = false
-			// You might want to signal this failure to the main Wails app
-			// if user interaction or state change is needed.
-		}
-		log.Println("Audio Server: Goroutine finished.")
-	}()
+	go saferun(func() {
+		a.serveSupervised(listener, mux)
+		ipcLog.Debug("Audio server goroutine finished")
+	})
+
+	// LAN clients get the same endpoints over TLS, if enabled (see tlsServer.go)
+	a.startTLSServer(mux)
 
+	a.publishDiscovery()
+	if a.serve {
+		a.announceServe()
+	}
 	return nil // Listener setup and goroutine launch successful
 }
 
+const (
+	serverRestartAttempts = 5
+	serverRestartBackoff  = time.Second
+)
+
+// ServerRecovery describes a restart of the HTTP server after it failed mid-session. It is
+// sent as "server:recovered", or as "server:failed" when no restart worked (NewPort is 0).
+type ServerRecovery struct {
+	OldPort  int    `json:"oldPort"`
+	NewPort  int    `json:"newPort"`
+	Reason   string `json:"reason"`
+	Attempts int    `json:"attempts"`
+}
+
+// serveSupervised serves mux until shutdown. If serving fails mid-session, the server is
+// started again on a new port and everything that knows the port (the discovery file, the
+// Python backend and the frontend) is told about it.
+func (a *App) serveSupervised(listener net.Listener, mux http.Handler) {
+	for {
+		err := http.Serve(listener, mux)
+		if err == nil || errors.Is(err, http.ErrServerClosed) || a.shuttingDown() {
+			return
+		}
+		ipcLog.Error("Audio server failed", "port", actualPort, "err", err)
+		isServerInitialized = false
+		if listener = a.restartHttpServer(err); listener == nil {
+			return
+		}
+	}
+}
+
+func (a *App) shuttingDown() bool {
+	return a.ctx != nil && a.ctx.Err() != nil
+}
+
+// restartHttpServer listens on a new port, with growing pauses between attempts. A port given
+// with --port is tried first, since clients of --serve may have it configured. It returns nil
+// if every attempt failed or the app is shutting down.
+func (a *App) restartHttpServer(cause error) net.Listener {
+	recovery := ServerRecovery{OldPort: actualPort, Reason: cause.Error()}
+	for attempt := 1; attempt <= serverRestartAttempts; attempt++ {
+		recovery.Attempts = attempt
+		select {
+		case <-time.After(time.Duration(attempt) * serverRestartBackoff):
+		case <-a.done():
+			return nil
+		}
+
+		port := a.serverPort
+		if port == 0 || attempt > 1 {
+			var err error
+			if port, err = findFreePort(); err != nil {
+				ipcLog.Warn("Audio server restart: no free port", "attempt", attempt, "err", err)
+				continue
+			}
+		}
+		addr := fmt.Sprintf("localhost:%d", port)
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			ipcLog.Warn("Audio server restart failed", "attempt", attempt, "addr", addr, "err", err)
+			continue
+		}
+
+		actualPort = port
+		serverListenAddress = addr
+		isServerInitialized = true
+		recovery.NewPort = port
+		ipcLog.Info("Audio server restarted", "addr", "http://"+addr, "oldPort", recovery.OldPort, "attempts", attempt)
+
+		a.publishDiscovery()
+		if a.serve {
+			a.announceServe()
+		}
+		a.emit("server:recovered", recovery)
+		if a.pythonCommandPort != 0 {
+			// Registration retries for a while; the server has to be serving meanwhile.
+			go saferun(func() {
+				if err := a.registerWithPython(port); err != nil {
+					ipcLog.Error("Could not register the restarted server with Python", "err", err)
+					a.reportBackendUnavailable(err.Error())
+				}
+			})
+		}
+		return listener
+	}
+
+	ipcLog.Error("Audio server could not be restarted", "attempts", serverRestartAttempts, "reason", recovery.Reason)
+	a.emit("server:failed", recovery)
+	return nil
+}
+
+// done is a.ctx.Done(), or a channel that never closes before startup.
+func (a *App) done() <-chan struct{} {
+	if a.ctx == nil {
+		return nil
+	}
+	return a.ctx.Done()
+}
+
 func (a *App) audioFileEndpoint(writer http.ResponseWriter, request *http.Request) {
-	origin := fmt.Sprintf("http://localhost:%d", actualPort)
-	writer.Header().Set("Access-Control-Allow-Origin", origin)
+	setAllowedOrigin(writer, request)
 	writer.Header().Set("Access-Control-Allow-Methods", "GET")
 
 	if request.Method == http.MethodOptions {
@@ -359,17 +535,11 @@ func (a *App) audioFileEndpoint(writer http.ResponseWriter, request *http.Reques
 
 	if request.Method != http.MethodGet {
 		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
-		log.Printf("Audio Server Warning: Non-GET request (%s) blocked for: %s", request.Method, request.URL.Path)
+		ipcLog.Warn("Audio server: non-GET request blocked", "method", request.Method, "path", request.URL.Path)
 		return
 	}
 
 	requestedPath := filepath.Clean(request.URL.Path)
-	if strings.Contains(requestedPath, "..") {
-		http.Error(writer, "Invalid path", http.StatusBadRequest)
-		log.Printf("Audio Server Warning: Path traversal attempt blocked for: %s", request.URL.Path)
-		return
-	}
-
 	if !strings.HasSuffix(strings.ToLower(requestedPath), ".wav") {
 		if requestedPath == "/" || requestedPath == "" {
 			welcomeMsg := "Welcome to the internal WAV audio server."
@@ -382,62 +552,23 @@ func (a *App) audioFileEndpoint(writer http.ResponseWriter, request *http.Reques
 			return
 		}
 		http.Error(writer, "File type not allowed. Only .wav files are served.", http.StatusForbidden)
-		log.Printf("Audio Server Warning: Non-WAV file request blocked: %s", requestedPath)
+		ipcLog.Warn("Audio server: non-WAV request blocked", "path", requestedPath)
 		return
 	}
 
-	fullPath := filepath.Join(a.tmpPath, requestedPath)
-	absEffectiveAudioFolderPath, err := filepath.Abs(a.tmpPath)
+	fullPath, err := a.resolveServedFile(requestedPath)
 	if err != nil {
-		http.Error(writer, "Internal server error", http.StatusInternalServerError)
-		log.Printf("Audio Server Error: getting absolute path for effectiveAudioFolderPath: %v", err)
-		return
-	}
-	absFullPath, err := filepath.Abs(fullPath)
-	if err != nil {
-		http.Error(writer, "Internal server error", http.StatusInternalServerError)
-		log.Printf("Audio Server Error: getting absolute path for fullPath: %v", err)
-		return
-	}
-
-	if !strings.HasPrefix(absFullPath, absEffectiveAudioFolderPath) {
-		http.Error(writer, "Invalid path (escapes base directory)", http.StatusBadRequest)
-		log.Printf("Audio Server Warning: Attempt to access file outside base directory: %s (resolved from %s) vs base %s", requestedPath, absFullPath, absEffectiveAudioFolderPath)
-		return
-	}
-
-	fileInfo, err := os.Stat(fullPath)
-	if os.IsNotExist(err) {
-		if _, statErr := os.Stat(a.tmpPath); os.IsNotExist(statErr) {
-			errMsg := fmt.Sprintf("Audio folder '%s' not found. Please ensure it exists next to the executable and is named '%s'.", a.tmpPath, relativeAudioFolderName)
-			http.Error(writer, errMsg, http.StatusInternalServerError)
-			log.Printf("Base audio folder not found: %s", a.tmpPath)
-			return
-		}
-		http.NotFound(writer, request)
-		log.Printf("Audio Server Info: File not found: %s", fullPath)
-		return
-	}
-	if err != nil {
-		http.Error(writer, "Internal server error", http.StatusInternalServerError)
-		log.Printf("Audio Server Error: accessing file stats for %s: %v", fullPath, err)
-		return
-	}
-
-	if fileInfo.IsDir() {
-		http.Error(writer, "Cannot serve directories", http.StatusForbidden)
-		log.Printf("Audio Server Warning: Attempt to access directory: %s", fullPath)
+		serveFileError(writer, request, err)
 		return
 	}
+	auditFileAccess(request, "audio", fullPath)
 
 	writer.Header().Set("Content-Type", "audio/wav")
 	writer.Header().Set("Accept-Ranges", "bytes") // Good for media seeking
 	http.ServeFile(writer, request, fullPath)
-	log.Printf("Audio Server Served: %s (Client: %s)", fullPath, request.RemoteAddr)
+	ipcLog.Debug("Audio server served file", "path", fullPath, "client", request.RemoteAddr)
 }
 
-// (Assuming a.effectiveAudioFolderPath is correctly set up as in your original code)
-
 func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 	// --- Parameter validation (same as your original code) ---
 	query := r.URL.Query()
@@ -454,21 +585,21 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid start or end time parameters", http.StatusBadRequest)
 		return
 	}
-	cleanFileName := filepath.Base(fileName)
-	if cleanFileName != fileName || strings.Contains(fileName, "..") || strings.ContainsAny(fileName, "/\\") {
+	if filepath.Base(fileName) != fileName || strings.ContainsAny(fileName, "/\\") {
 		http.Error(w, "Invalid file name parameter", http.StatusBadRequest)
 		return
 	}
-	originalFilePath := filepath.Join(a.tmpPath, cleanFileName)
-	if _, err := os.Stat(originalFilePath); os.IsNotExist(err) {
-		http.NotFound(w, r)
+	originalFilePath, err := a.resolveServedFile(fileName)
+	if err != nil {
+		serveFileError(w, r, err)
 		return
 	}
+	auditFileAccess(r, "render_clip", originalFilePath)
 
-	log.Printf("RenderClip: BUFFERING request for %s, segment %f to %f", originalFilePath, startSeconds, endSeconds)
+	ipcLog.Debug("RenderClip: buffering", "file", originalFilePath, "start", startSeconds, "end", endSeconds)
 
 	// --- FFMPEG Command Setup ---
-	cmd := ExecCommand(a.ffmpegBinaryPath,
+	cmd := a.ffmpegCommand(
 		"-i", originalFilePath,
 		"-af", fmt.Sprintf("atrim=start=%.6f:end=%.6f", startSeconds, endSeconds),
 		"-f", "wav",
@@ -478,6 +609,10 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 		"pipe:1",
 	)
 
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+	started := time.Now()
+
 	// --- 1. THE SAFETY NET: Guaranteed Process Cleanup ---
 	// This defer block is the most important part. It ensures that no matter what happens,
 	// the ffmpeg process is killed and its resources are released.
@@ -486,7 +621,9 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 			cmd.Process.Kill() // Ensure the process is terminated.
 		}
 		// Wait is still required to release the process resources from Go's perspective.
-		cmd.Wait()
+		waitErr := cmd.Wait()
+		childProcesses.untrack(cmd)
+		auditFFmpeg("renderClip", cmd, started, waitErr, stderr.String())
 		//log.Printf("RenderClip Cleanup: Successfully cleaned up ffmpeg process for %s", originalFilePath)
 	}()
 
@@ -501,16 +638,18 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error (ffmpeg start)", http.StatusInternalServerError)
 		return // defer will run
 	}
+	limitStarted(cmd)
+	childProcesses.track(cmd)
 
 	// --- 2. THE BUFFERING LOGIC ---
 	var audioData bytes.Buffer
 	doneCh := make(chan error, 1) // A channel to signal when copying is complete.
 
-	go func() {
+	go saferun(func() {
 		// Perform the copy in a separate goroutine.
 		_, err := io.Copy(&audioData, ffmpegOutput)
 		doneCh <- err
-	}()
+	})
 
 	// --- 3. THE STABILITY ADDITION: Handle Client Disconnects ---
 	// We wait for one of two things to happen:
@@ -520,23 +659,23 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 	case err := <-doneCh:
 		// Copying finished. Check for errors.
 		if err != nil {
-			log.Printf("RenderClip: Failed to buffer ffmpeg output: %v", err)
+			ipcLog.Error("RenderClip: failed to buffer ffmpeg output", "err", err)
 			http.Error(w, "Failed to generate audio segment", http.StatusInternalServerError)
 			return // defer will run
 		}
 	case <-r.Context().Done():
 		// Client disconnected before we finished buffering.
-		log.Printf("RenderClip: Client disconnected during buffering. Aborting.")
+		ipcLog.Info("RenderClip: client disconnected during buffering")
 		// We don't need to write an error to the response, as the client is gone.
 		// We simply return, and the defer block will kill ffmpeg and clean up.
 		return
 	}
 
 	// If we get here, the audioData buffer is successfully filled.
-	log.Printf("RenderClip: Successfully buffered %d bytes. Now serving content.", audioData.Len())
+	ipcLog.Debug("RenderClip: buffered", "bytes", audioData.Len())
 	audioDataReader := bytes.NewReader(audioData.Bytes())
 
-	serveName := fmt.Sprintf("rendered_clip_%s_%.2f_%.2f.wav", cleanFileName, startSeconds, endSeconds)
+	serveName := fmt.Sprintf("rendered_clip_%s_%.2f_%.2f.wav", fileName, startSeconds, endSeconds)
 	modTime := time.Now()
 
 	// http.ServeContent is perfect for serving data from an in-memory buffer (via io.ReadSeeker).
@@ -544,28 +683,309 @@ func (a *App) handleRenderClip(w http.ResponseWriter, r *http.Request) {
 	http.ServeContent(w, r, serveName, modTime, audioDataReader)
 }
 
+// findClipByID looks up an audio item of the most recently processed project. Clips inside
+// compound clips have no ID of their own; like the frontend does for items without one, they
+// are found by their processed file name, as a copy with the track of the top-level item.
+func (a *App) findClipByID(clipID string) (*TimelineItem, float64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.currentProject == nil || clipID == "" {
+		return nil, 0, false
+	}
+	fps := a.currentProject.Timeline.FPS
+	items := a.currentProject.Timeline.AudioTrackItems
+	for i := range items {
+		item := &items[i]
+		if item.ID == clipID || (item.ID == "" && item.ProcessedFileName != nil && *item.ProcessedFileName == clipID) {
+			return item, fps, true
+		}
+	}
+	for i := range items {
+		if nested := findNestedClip(items[i].NestedClips, clipID); nested != nil {
+			return nested.asTimelineItem(&items[i]), fps, true
+		}
+	}
+	return nil, 0, false
+}
+
+// findNestedClip searches clips and the clips nested in them for processedFileName.
+func findNestedClip(clips []*NestedAudioTimelineItem, processedFileName string) *NestedAudioTimelineItem {
+	for _, clip := range clips {
+		if clip.ProcessedFileName == processedFileName {
+			return clip
+		}
+		if found := findNestedClip(clip.NestedItems, processedFileName); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// asTimelineItem returns the nested clip as an item of top's track.
+func (n *NestedAudioTimelineItem) asTimelineItem(top *TimelineItem) *TimelineItem {
+	processedFileName := n.ProcessedFileName
+	item := &TimelineItem{
+		Name:              filepath.Base(n.SourceFilePath),
+		ID:                n.ProcessedFileName,
+		TrackType:         top.TrackType,
+		TrackIndex:        top.TrackIndex,
+		SourceFilePath:    n.SourceFilePath,
+		ProcessedFileName: &processedFileName,
+		StartFrame:        n.StartFrame,
+		EndFrame:          n.EndFrame,
+		SourceStartFrame:  n.SourceStartFrame,
+		SourceEndFrame:    n.SourceEndFrame,
+		Duration:          n.Duration,
+		EditInstructions:  n.EditInstructions,
+		SourceChannel:     n.SourceChannel,
+		NestedClips:       n.NestedItems,
+	}
+	if len(n.NestedItems) > 0 {
+		item.Type = "Compound"
+		item.Name = n.ProcessedFileName
+	}
+	return item
+}
+
+// cachedSilencesForRange returns the cached silence detection of a file segment with params,
+// the parameters the clip is detected with (see clipDetectionParams).
+func (a *App) cachedSilencesForRange(filePath string, startSec, endSec float64, params DetectionParams) ([]SilencePeriod, bool) {
+	const rangeEpsilon = 1e-6
+	key := CacheKey{
+		FilePath:                  filePath,
+		LoudnessThreshold:         params.LoudnessThreshold,
+		MinSilenceDurationSeconds: params.MinSilenceDurationSeconds,
+		PaddingLeftSeconds:        params.PaddingLeftSeconds,
+		PaddingRightSeconds:       params.PaddingRightSeconds,
+		MinContentDuration:        params.MinContent,
+		ClipStartSeconds:          startSec,
+		ClipEndSeconds:            endSec,
+	}
+	matches := func(k CacheKey) bool {
+		if math.Abs(k.ClipStartSeconds-startSec) >= rangeEpsilon || math.Abs(k.ClipEndSeconds-endSec) >= rangeEpsilon {
+			return false
+		}
+		k.ClipStartSeconds, k.ClipEndSeconds = startSec, endSec
+		return k == key
+	}
+
+	a.cacheMutex.RLock()
+	defer a.cacheMutex.RUnlock()
+
+	if silences, found := a.silenceCache[key]; found {
+		cacheUsage.touch(key)
+		return silences, true
+	}
+	// The range may differ in the last bits from the one the detection was run with.
+	for k, silences := range a.silenceCache {
+		if matches(k) {
+			cacheUsage.touch(k)
+			return silences, true
+		}
+	}
+	return nil, false
+}
+
+// keepRanges returns the parts of [startSec, endSec] that are not covered by silences, in order.
+func keepRanges(startSec, endSec float64, silences []SilencePeriod) [][2]float64 {
+	sorted := make([]SilencePeriod, len(silences))
+	copy(sorted, silences)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var ranges [][2]float64
+	cursor := startSec
+	for _, s := range sorted {
+		if s.Start > cursor {
+			ranges = append(ranges, [2]float64{cursor, math.Min(s.Start, endSec)})
+		}
+		cursor = math.Max(cursor, s.End)
+		if cursor >= endSec {
+			break
+		}
+	}
+	if cursor < endSec {
+		ranges = append(ranges, [2]float64{cursor, endSec})
+	}
+	return ranges
+}
+
+// buildKeepSelectExpr turns a clip range minus its silences into an aselect expression.
+func buildKeepSelectExpr(startSec, endSec float64, silences []SilencePeriod) string {
+	var terms []string
+	for _, r := range keepRanges(startSec, endSec, silences) {
+		terms = append(terms, fmt.Sprintf("between(t,%.6f,%.6f)", r[0], r[1]))
+	}
+	if len(terms) == 0 {
+		return "0"
+	}
+	return strings.Join(terms, "+")
+}
+
+// previewSource is a clip of the current project with the silences cached for it.
+type previewSource struct {
+	fileName     string
+	filePath     string
+	startSeconds float64
+	endSeconds   float64
+	silences     []SilencePeriod
+}
+
+// findPreviewSource looks up a clip for the preview endpoints. If it can't be previewed, the
+// returned status and message explain why.
+func (a *App) findPreviewSource(clipID string) (*previewSource, int, string) {
+	if clipID == "" {
+		return nil, http.StatusBadRequest, "Missing required query parameter 'clipId'"
+	}
+	item, fps, ok := a.findClipByID(clipID)
+	if !ok {
+		return nil, http.StatusNotFound, "Unknown clip"
+	}
+	if item.ProcessedFileName == nil || *item.ProcessedFileName == "" || fps <= floatEpsilon {
+		return nil, http.StatusConflict, "Clip has no processed audio"
+	}
+
+	src := &previewSource{
+		fileName:     *item.ProcessedFileName,
+		filePath:     filepath.Join(a.tmpPath, filepath.Base(*item.ProcessedFileName)),
+		startSeconds: item.SourceStartFrame / fps,
+		endSeconds:   item.SourceEndFrame / fps,
+	}
+	params := a.GetCurrentParams()
+	if session, err := a.currentTimelineSession(); err == nil {
+		params = a.clipDetectionParams(item.ID, session)
+	}
+	silences, found := a.cachedSilencesForRange(src.fileName, src.startSeconds, src.endSeconds, params)
+	if !found {
+		return nil, http.StatusConflict, "No silence detection cached for this clip yet"
+	}
+	src.silences = silences
+	return src, 0, ""
+}
+
+func (a *App) handlePreviewCut(w http.ResponseWriter, r *http.Request) {
+	src, status, msg := a.findPreviewSource(r.URL.Query().Get("clipId"))
+	if src == nil {
+		http.Error(w, msg, status)
+		return
+	}
+	selectExpr := buildKeepSelectExpr(src.startSeconds, src.endSeconds, src.silences)
+	ipcLog.Info("PreviewCut: streaming", "file", src.fileName, "silencesRemoved", len(src.silences))
+	a.streamPreview(w, r, src, fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", selectExpr), "previewCut")
+}
+
+// streamPreview streams src's file through the audio filter as WAV.
+func (a *App) streamPreview(w http.ResponseWriter, r *http.Request, src *previewSource, filter, auditName string) {
+	release := a.acquireFileRefs(src.filePath)
+	defer release()
+	if err := a.WaitForFile(src.filePath); err != nil {
+		http.Error(w, "Audio for this clip could not be prepared", http.StatusInternalServerError)
+		return
+	}
+	filePath, err := a.resolveServedFile(filepath.Base(src.filePath))
+	if err != nil {
+		serveFileError(w, r, err)
+		return
+	}
+	auditFileAccess(r, auditName, filePath)
+	a.updateFileUsage(src.filePath)
+
+	cmd := a.ffmpegCommand(
+		"-i", filePath,
+		"-af", filter,
+		"-f", "wav",
+		"-vn",
+		"-hide_banner",
+		"-loglevel", "error",
+		"pipe:1",
+	)
+
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+	started := time.Now()
+
+	// Same guarantee as in handleRenderClip: ffmpeg never outlives the request.
+	defer func() {
+		if cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+		waitErr := cmd.Wait()
+		childProcesses.untrack(cmd)
+		auditFFmpeg(auditName, cmd, started, waitErr, stderr.String())
+	}()
+
+	ffmpegOutput, err := cmd.StdoutPipe()
+	if err != nil {
+		http.Error(w, "Internal server error (stdout pipe)", http.StatusInternalServerError)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		http.Error(w, "Internal server error (ffmpeg start)", http.StatusInternalServerError)
+		return
+	}
+	limitStarted(cmd)
+	childProcesses.track(cmd)
+
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, ffmpegOutput); err != nil {
+		ipcLog.Info("Preview: streaming stopped", "file", src.fileName, "err", err)
+	}
+}
+
+// maxMessageBytes bounds /msg bodies; project data of long timelines is the largest.
+const maxMessageBytes = 64 << 20
+
+// hasPayload reports whether msg carries a payload; "null" doesn't count.
+func (msg PythonMessage) hasPayload() bool {
+	return len(msg.Payload) > 0 && string(msg.Payload) != "null"
+}
+
+// decodeMessagePayload decodes the payload of msg into v, or answers 400 and returns false.
+func decodeMessagePayload(w http.ResponseWriter, msg PythonMessage, v any) bool {
+	err := errors.New("missing payload")
+	if msg.hasPayload() {
+		err = safejson.Decode(msg.Payload, v, false)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid payload for '%s'", msg.Type), http.StatusBadRequest)
+		ipcLog.Error("msgEndpoint: unmarshalling payload", "type", msg.Type, "err", err, "bytes", len(msg.Payload))
+		return false
+	}
+	return true
+}
+
 func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	body, err := safejson.ReadAll(r.Body, maxMessageBytes)
+	if errors.Is(err, safejson.ErrTooLarge) {
+		http.Error(w, "Message too large", http.StatusRequestEntityTooLarge)
+		ipcLog.Warn("msgEndpoint: message too large", "limit", maxMessageBytes)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error reading request body", http.StatusInternalServerError)
-		log.Printf("msgEndpoint: Error reading body: %v", err)
+		ipcLog.Error("msgEndpoint: reading body", "err", err)
 		return
 	}
 	defer r.Body.Close()
 
+	// The envelope is ours and strict; payloads may carry fields a newer script added.
 	var msg PythonMessage
-	if err := json.Unmarshal(body, &msg); err != nil {
+	if err := safejson.Decode(body, &msg, true); err != nil {
 		http.Error(w, "Invalid JSON format for PythonMessage", http.StatusBadRequest)
-		log.Printf("msgEndpoint: Error unmarshalling PythonMessage: %v. Body: %s", err, string(body))
+		ipcLog.Error("msgEndpoint: unmarshalling message", "err", err, "bytes", len(body))
+		return
+	}
+	if msg.Type == "" {
+		http.Error(w, "Message has no type", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("msgEndpoint: Received type: '%s'", msg.Type)
+	ipcLog.Debug("msgEndpoint: received", "type", msg.Type)
 	taskID := r.URL.Query().Get("task_id")
 
 	if msg.Type == "taskUpdate" {
@@ -575,15 +995,13 @@ func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var updateData TaskUpdatePayload
-		if err := json.Unmarshal(msg.Payload, &updateData); err != nil {
-			http.Error(w, "Invalid payload for 'taskUpdate'", http.StatusBadRequest)
-			log.Printf("msgEndpoint: Error unmarshalling taskUpdate payload: %v", err)
+		if !decodeMessagePayload(w, msg, &updateData) {
 			return
 		}
 
 		// Emit an event to the frontend with the progress update.
 		// The frontend will listen for "taskProgressUpdate".
-		runtime.EventsEmit(a.ctx, "taskProgressUpdate", map[string]interface{}{
+		a.emit("taskProgressUpdate", map[string]interface{}{
 			"taskID":   taskID,
 			"message":  updateData.Message,
 			"progress": updateData.Progress,
@@ -597,20 +1015,17 @@ func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 	// --- New Primary Handler for Task-Related Responses from Python ---
 	if msg.Type == "taskResult" {
 		if taskID == "" {
-			log.Printf("msgEndpoint: Received 'taskResult' without task_id. Ignoring for task channel.")
+			ipcLog.Warn("msgEndpoint: taskResult without task_id; ignoring for task channel")
 			// Optionally, if it has ShouldShowAlert, you could emit a generic alert, but it's cleaner if Python always includes task_id for these.
 			http.Error(w, "'taskResult' requires a task_id", http.StatusBadRequest)
 			return
 		}
 
 		var taskData PythonCommandResponse // This struct now includes ShouldShowAlert etc.
-		if err := json.Unmarshal(msg.Payload, &taskData); err != nil {
-			http.Error(w, "Invalid payload for 'taskResult'", http.StatusBadRequest)
-			log.Printf("msgEndpoint: Error unmarshalling taskResult payload: %v. Body: %s", err, string(msg.Payload))
+		if !decodeMessagePayload(w, msg, &taskData) {
 			return
 		}
-		log.Printf("msgEndpoint: Received 'taskResult' for taskID '%s'. Status: '%s', ShouldShowAlert: %t",
-			taskID, taskData.Status, taskData.ShouldShowAlert)
+		ipcLog.Info("msgEndpoint: taskResult", "task", taskID, "status", taskData.Status, "showAlert", taskData.ShouldShowAlert)
 
 		a.pendingMu.Lock()
 		respCh, ok := a.pendingTasks[taskID]
@@ -620,14 +1035,14 @@ func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 			// Send the entire taskData (which includes Python's alert *request*) to SyncWithDavinci
 			select {
 			case respCh <- taskData:
-				log.Printf("msgEndpoint: Successfully sent taskData to SyncWithDavinci channel for task %s", taskID)
+				ipcLog.Debug("msgEndpoint: delivered task result", "task", taskID)
 			default:
-				log.Printf("msgEndpoint: WARNING - Could not send to respCh for task %s. Channel full/listener gone.", taskID)
+				ipcLog.Warn("msgEndpoint: could not deliver task result; channel full or listener gone", "task", taskID)
 				// If SyncWithDavinci is gone but Python wanted an alert, we *could* emit it here as a fallback.
 				// However, this implies SyncWithDavinci might have timed out or errored earlier.
 				if taskData.ShouldShowAlert && a.licenseValid {
-					log.Printf("msgEndpoint: SyncWithDavinci listener gone for task %s, but Python requested alert. Emitting globally.", taskID)
-					runtime.EventsEmit(a.ctx, "showAlert", map[string]interface{}{
+					ipcLog.Info("msgEndpoint: listener gone but Python requested alert; emitting globally", "task", taskID)
+					a.emit("showAlert", map[string]interface{}{
 						"title":    taskData.AlertTitle,
 						"message":  taskData.AlertMessage,
 						"severity": taskData.AlertSeverity,
@@ -635,11 +1050,11 @@ func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		} else {
-			log.Printf("msgEndpoint: Warning - Received 'taskResult' for taskID '%s', but no pending task found.", taskID)
+			ipcLog.Warn("msgEndpoint: taskResult for unknown task", "task", taskID)
 			// Similar to above, if no pending task, but Python wanted an alert for this orphaned task_id.
 			if taskData.ShouldShowAlert && a.licenseValid {
-				log.Printf("msgEndpoint: No pending task for %s, but Python requested alert. Emitting globally.", taskID)
-				runtime.EventsEmit(a.ctx, "showAlert", map[string]interface{}{
+				ipcLog.Info("msgEndpoint: no pending task but Python requested alert; emitting globally", "task", taskID)
+				a.emit("showAlert", map[string]interface{}{
 					"title":    taskData.AlertTitle,
 					"message":  taskData.AlertMessage,
 					"severity": taskData.AlertSeverity,
@@ -655,39 +1070,48 @@ func (a *App) msgEndpoint(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case "showToast":
 		var data ToastPayload
-		if err := json.Unmarshal(msg.Payload, &data); err != nil { /* ... error handling ... */
+		if !decodeMessagePayload(w, msg, &data) {
 			return
 		}
-		runtime.EventsEmit(a.ctx, "showToast", data)
+		a.emit("showToast", data)
 
 	case "showAlert": // This is now for alerts NOT related to a SyncWithDavinci task
 		if !a.licenseValid {
 			return
 		}
 		if taskID != "" {
-			log.Printf("msgEndpoint: 'showAlert' with task_id '%s' received. This is likely an old Python flow. Emitting alert globally but not notifying task channel.", taskID)
+			ipcLog.Warn("msgEndpoint: showAlert with task_id (old Python flow); emitting globally", "task", taskID)
 		}
 		var data AlertPayload
-		if err := json.Unmarshal(msg.Payload, &data); err != nil { /* ... error handling ... */
+		if !decodeMessagePayload(w, msg, &data) {
 			return
 		}
-		runtime.EventsEmit(a.ctx, "showAlert", data) // Global alert
+		a.emit("showAlert", data) // Global alert
+
+	case "focus": // Sent when the user starts HushCut from Resolve while it is already running
+		var data FocusPayload
+		if msg.hasPayload() && !decodeMessagePayload(w, msg, &data) {
+			return
+		}
+		a.focusWindow()
+		a.emit("app:focus", data)
 
 	case "projectData": // This is now for generic data pushes NOT related to a SyncWithDavinci task completion
 		if taskID != "" {
-			log.Printf("msgEndpoint: 'projectData' with task_id '%s' received. If this is a task response, Python should use 'taskResult' type.", taskID)
+			ipcLog.Warn("msgEndpoint: projectData with task_id; task responses should use taskResult", "task", taskID)
 			// If you need to temporarily support old Python sending projectData as task response:
 			// ... (handle by trying to parse as ProjectDataPayload and sending a minimal PythonCommandResponse to channel)
 			// But it's better to update Python.
 		}
 		var data ProjectDataPayload
-		if err := json.Unmarshal(msg.Payload, &data); err != nil { /* ... error handling ... */
+		if !decodeMessagePayload(w, msg, &data) {
 			return
 		}
-		runtime.EventsEmit(a.ctx, "projectDataReceived", data) // Generic data update
+		a.emit("projectDataReceived", data) // Generic data update
+		a.startBackgroundAnalysis(&data)
 
 	default:
-		log.Printf("msgEndpoint: Received unknown message type: '%s'", msg.Type)
+		ipcLog.Warn("msgEndpoint: unknown message type", "type", msg.Type)
 		http.Error(w, fmt.Sprintf("Unknown message type: %s", msg.Type), http.StatusBadRequest)
 		return
 	}
@@ -710,6 +1134,12 @@ func (a *App) GetProjectDataPayloadType() ProjectDataPayload {
 }
 
 func (a *App) SyncWithDavinci() (*PythonCommandResponse, error) { // Use your actual PythonCommandResponse type
+	return a.syncTimeline("")
+}
+
+// syncTimeline syncs the timeline named timelineName, making it Resolve's current timeline
+// first, or the current timeline if the name is empty.
+func (a *App) syncTimeline(timelineName string) (*PythonCommandResponse, error) {
 	if !a.pythonReady {
 		// This error will be caught by JS, and a toast will be shown. No AlertIssued flag needed.
 		return nil, fmt.Errorf("python backend not ready")
@@ -722,18 +1152,23 @@ func (a *App) SyncWithDavinci() (*PythonCommandResponse, error) { // Use your ac
 	a.pendingMu.Lock()
 	a.pendingTasks[taskID] = respCh
 	a.pendingMu.Unlock()
+	a.checkpoint.taskStarted(taskID, "sync")
 
 	// Cleanup deferred to ensure it runs
 	defer func() {
 		a.pendingMu.Lock()
 		delete(a.pendingTasks, taskID)
 		a.pendingMu.Unlock()
-		log.Printf("Go: Cleaned up task %s", taskID)
+		a.checkpoint.taskFinished(taskID)
+		ipcLog.Debug("Cleaned up task", "task", taskID)
 	}()
 
 	params := map[string]interface{}{
 		"taskId": taskID,
 	}
+	if timelineName != "" {
+		params["timelineName"] = timelineName
+	}
 
 	pyAckResp, err := a.SendCommandToPython("sync", params) // This is the initial ACK from Python
 	if err != nil {
@@ -743,15 +1178,14 @@ func (a *App) SyncWithDavinci() (*PythonCommandResponse, error) { // Use your ac
 		return nil, fmt.Errorf("python command acknowledgement error: %s", pyAckResp.Message)
 	}
 
-	log.Printf("Go: Waiting for final Python response for task %s...", taskID)
+	ipcLog.Debug("Waiting for final Python response", "task", taskID)
 	finalResponse := <-respCh // Wait for Python's actual processing response
-	log.Printf("Go: Received final Python response for task %s", taskID)
+	ipcLog.Debug("Received final Python response", "task", taskID)
 
 	if finalResponse.ShouldShowAlert && a.licenseValid {
-		log.Printf("Go: Python requested an alert. Title: '%s', Message: '%s', Severity: '%s'",
-			finalResponse.AlertTitle, finalResponse.AlertMessage, finalResponse.AlertSeverity)
+		ipcLog.Info("Python requested an alert", "title", finalResponse.AlertTitle, "message", finalResponse.AlertMessage, "severity", finalResponse.AlertSeverity)
 
-		runtime.EventsEmit(a.ctx, "showAlert", map[string]interface{}{
+		a.emit("showAlert", map[string]interface{}{
 			"title":    finalResponse.AlertTitle,
 			"message":  finalResponse.AlertMessage,
 			"severity": finalResponse.AlertSeverity,
@@ -768,13 +1202,12 @@ func (a *App) SyncWithDavinci() (*PythonCommandResponse, error) { // Use your ac
 	}
 
 	if finalResponse.Status != "success" {
-		log.Printf("Go: Python task %s reported status '%s'. AlertIssued: %t. Message: %s",
-			taskID, finalResponse.Status, finalResponse.AlertIssued, finalResponse.Message)
+		ipcLog.Warn("Python task did not succeed", "task", taskID, "status", finalResponse.Status, "alertIssued", finalResponse.AlertIssued, "message", finalResponse.Message)
 		return &finalResponse, nil
 	}
 
 	// Python reported success, and no alert was needed (or it was handled)
-	log.Printf("Go: Python task %s reported success. Message: %s", taskID, finalResponse.Message)
+	ipcLog.Info("Python task succeeded", "task", taskID, "message", finalResponse.Message)
 	return &finalResponse, nil // finalResponse.AlertIssued will be false if no alert was processed
 }
 
@@ -785,7 +1218,8 @@ func (a *App) MakeFinalTimeline(projectData *ProjectDataPayload, makeNewTimeline
 	if !a.licenseValid {
 		return nil, fmt.Errorf("invalid license. Action not permitted")
 	}
-	runtime.EventsEmit(a.ctx, "showFinalTimelineProgress")
+	startTime := time.Now()
+	a.emit("showFinalTimelineProgress")
 
 	// 1. Adopt the async task pattern
 	taskID := uuid.NewString()
@@ -794,16 +1228,18 @@ func (a *App) MakeFinalTimeline(projectData *ProjectDataPayload, makeNewTimeline
 	a.pendingMu.Lock()
 	a.pendingTasks[taskID] = respCh
 	a.pendingMu.Unlock()
+	a.checkpoint.taskStarted(taskID, "makeFinalTimeline")
 
 	defer func() {
 		a.pendingMu.Lock()
 		delete(a.pendingTasks, taskID)
 		a.pendingMu.Unlock()
-		log.Printf("Go: Cleaned up task %s", taskID)
+		a.checkpoint.taskFinished(taskID)
+		ipcLog.Debug("Cleaned up task", "task", taskID)
 	}()
 
 	// The frontend can now listen for "taskProgressUpdate" events with this taskID
-	log.Printf("Go: Starting task 'makeFinalTimeline' with ID: %s", taskID)
+	ipcLog.Info("Starting task makeFinalTimeline", "task", taskID)
 
 	// 2. Add taskId to the parameters sent to Python
 	params := map[string]interface{}{
@@ -821,15 +1257,15 @@ func (a *App) MakeFinalTimeline(projectData *ProjectDataPayload, makeNewTimeline
 		return nil, fmt.Errorf("python 'makeFinalTimeline' ack error: %s", pyAckResp.Message)
 	}
 
-	log.Printf("Go: Waiting for final timeline result for task %s...", taskID)
+	ipcLog.Debug("Waiting for final timeline result", "task", taskID)
 
 	// 4. Wait for the final result from the channel
 	finalResponse := <-respCh
-	log.Printf("Go: Received final timeline result for task %s", taskID)
+	ipcLog.Debug("Received final timeline result", "task", taskID)
 
 	// 5. Process the final response (handle alerts, errors, etc.)
 	if finalResponse.ShouldShowAlert {
-		runtime.EventsEmit(a.ctx, "showAlert", map[string]interface{}{
+		a.emit("showAlert", map[string]interface{}{
 			"title": finalResponse.AlertTitle, "message": finalResponse.AlertMessage, "severity": finalResponse.AlertSeverity,
 		})
 		finalResponse.AlertIssued = true
@@ -848,7 +1284,9 @@ func (a *App) MakeFinalTimeline(projectData *ProjectDataPayload, makeNewTimeline
 		// The frontend should check the Status field of the returned object.
 		return &finalResponse, nil
 	}
-	runtime.EventsEmit(a.ctx, "finished")
+	a.generateProcessingReport(projectData, makeNewTimeline, finalResponse.Status, time.Since(startTime))
+	a.recordFinishedSession(projectData)
+	a.emit("finished")
 	return &finalResponse, nil
 }
 
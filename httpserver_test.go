@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oliwoli/hushcut/internal/safejson"
+)
+
+func FuzzMsgEndpoint(f *testing.F) {
+	for _, seed := range []string{
+		`{"type":"taskUpdate","payload":{"message":"Syncing","progress":0.5}}`,
+		`{"type":"taskResult","payload":{"status":"success","message":"ok","data":{"a":[1,2]}}}`,
+		`{"type":"taskResult","payload":{"status":"error","shouldShowAlert":true,"alertTitle":"t"}}`,
+		`{"type":"showToast","payload":{"message":"hi"}}`,
+		`{"type":"showAlert","payload":{"title":"t","message":"m","severity":"error"}}`,
+		`{"type":"projectData","payload":{"project_name":"p","timeline":{"name":"t","fps":25,"audio_track_items":[{"id":"1","nested_clips":[{"nested_items":[]}]}]}}}`,
+		`{"type":"focus"}`,
+		`{"type":"taskResult","payload":null}`,
+		`{"type":"unknown","extra":1}`,
+		`{"type":"showToast","payload":{"message":1}}`,
+	} {
+		f.Add([]byte(seed), "task-1")
+		f.Add([]byte(seed), "")
+	}
+	app := &App{licenseValid: true}
+	f.Fuzz(func(t *testing.T, body []byte, taskID string) {
+		// focus and projectData raise the window and start the background analysis, so only
+		// their payload decoding is exercised.
+		var msg PythonMessage
+		if safejson.Decode(body, &msg, true) == nil && (msg.Type == "focus" || msg.Type == "projectData") {
+			var data any = &FocusPayload{}
+			if msg.Type == "projectData" {
+				data = &ProjectDataPayload{}
+			}
+			decodeMessagePayload(httptest.NewRecorder(), msg, data)
+			return
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/msg", bytes.NewReader(body))
+		if taskID != "" {
+			q := req.URL.Query()
+			q.Set("task_id", taskID)
+			req.URL.RawQuery = q.Encode()
+		}
+		rec := httptest.NewRecorder()
+		app.msgEndpoint(rec, req)
+		if rec.Code >= 500 {
+			t.Fatalf("msgEndpoint answered %d for %q", rec.Code, body)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package i18n
+
+// english holds the English text of every key. The frontend's catalogs
+// (frontend/src/lib/i18n.ts) translate the same keys and placeholders.
+var english = map[string]string{
+	// Backend startup
+	"alert.backendTampered.title":     "HushCut needs to be reinstalled",
+	"alert.backendTampered.message":   "HushCut's backend was modified or only partly updated, so HushCut did not start it. Reinstall HushCut with the installer of this version to repair it.",
+	"backend.unavailable.resolve":     "HushCut could not connect to its script in DaVinci Resolve ({cause}). Close HushCut and start it again from Workspace > Scripts > HushCut in Resolve.",
+	"backend.unavailable.development": "The development Python backend on port {port} is not reachable ({cause}).",
+	"backend.unavailable.notBundled":  "HushCut was started on its own, but this installation has no bundled backend. Start it from DaVinci Resolve instead: Workspace > Scripts > HushCut.",
+	"backend.unavailable.notStarted":  "HushCut's backend did not start ({cause}). Make sure DaVinci Resolve is running, or start HushCut from Workspace > Scripts > HushCut in Resolve.",
+
+	// Progress
+	"progress.conversionFailed": "Converting {file} failed: {detail}",
+	"progress.downloadFailed":   "Downloading {file} failed: {detail}",
+
+	// Licensing
+	"license.emptyKey":              "license key cannot be empty",
+	"license.serverUnreachable":     "cannot connect to verification server; please check your internet connection and try again",
+	"license.machineMismatch":       "machine ID does not match",
+	"license.otherDevice":           "this license file was issued for a different device",
+	"license.offlineNotYetValid":    "offline license is not valid before {date}",
+	"license.offlineExpired":        "offline license expired on {date}",
+	"license.noSeats":               "all seats on the license server are in use",
+	"license.seatServerUnreachable": "cannot reach the studio license server: {detail}",
+}
@@ -0,0 +1,77 @@
+// Package i18n names the user-facing text HushCut's Go side produces. A message is a stable
+// key plus parameters; the frontend looks the key up in the user's language and falls back to
+// the English text sent along with it, so a missing translation is never a blank alert. Keys
+// are part of the frontend contract: renaming one loses its translations.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Params fills the {name} placeholders of a message.
+type Params map[string]any
+
+// Message is localizable text as it is sent to the frontend.
+type Message struct {
+	Key    string `json:"key"`
+	Params Params `json:"params,omitempty"`
+	Text   string `json:"text"` // English, for clients without a translation
+}
+
+// New returns the message for key with its English text filled in.
+func New(key string, params Params) Message {
+	return Message{Key: key, Params: params, Text: English(key, params)}
+}
+
+// Error is an error whose text is a Message. Err, if set, is the cause; it is unwrapped but
+// not part of the text.
+type Error struct {
+	Message
+	Err error
+}
+
+// NewError returns an error carrying the message for key.
+func NewError(key string, params Params) *Error {
+	return &Error{Message: New(key, params)}
+}
+
+// Wrap is NewError with a cause.
+func Wrap(err error, key string, params Params) *Error {
+	return &Error{Message: New(key, params), Err: err}
+}
+
+func (e *Error) Error() string { return e.Text }
+func (e *Error) Unwrap() error { return e.Err }
+
+// As returns the message of the first localizable error in err's chain.
+func As(err error) (Message, bool) {
+	var localized *Error
+	if errors.As(err, &localized) {
+		return localized.Message, true
+	}
+	return Message{}, false
+}
+
+// English renders key in English. An unknown key renders as itself, which is easy to spot.
+func English(key string, params Params) string {
+	text, ok := english[key]
+	if !ok {
+		return key
+	}
+	return Format(text, params)
+}
+
+// Format replaces the {name} placeholders in text with params. Placeholders without a value
+// are left as they are.
+func Format(text string, params Params) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale formats numbers and times the way a language expects. Only what the reports need is
+// covered; languages HushCut doesn't know fall back to English.
+type Locale struct {
+	Tag        string // the language, e.g. "de"
+	decimal    string
+	group      string
+	dateLayout string
+	timeLayout string
+}
+
+var locales = map[string]Locale{
+	"en": {Tag: "en", decimal: ".", group: ",", dateLayout: "2006-01-02", timeLayout: "15:04:05"},
+	"de": {Tag: "de", decimal: ",", group: ".", dateLayout: "02.01.2006", timeLayout: "15:04:05"},
+	"fr": {Tag: "fr", decimal: ",", group: " ", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+	"es": {Tag: "es", decimal: ",", group: ".", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+	"it": {Tag: "it", decimal: ",", group: ".", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+	"nl": {Tag: "nl", decimal: ",", group: ".", dateLayout: "02-01-2006", timeLayout: "15:04:05"},
+	"pt": {Tag: "pt", decimal: ",", group: ".", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+	"ja": {Tag: "ja", decimal: ".", group: ",", dateLayout: "2006/01/02", timeLayout: "15:04:05"},
+}
+
+// LookupLocale returns the locale for a language tag such as "de-AT", "pt_BR" or
+// "de_DE.UTF-8"; unknown or empty tags get English.
+func LookupLocale(tag string) Locale {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_.@"); i >= 0 {
+		tag = tag[:i]
+	}
+	if locale, ok := locales[tag]; ok {
+		return locale
+	}
+	return locales["en"]
+}
+
+// Number formats f with the given number of decimals (-1 for as many as f needs) and the
+// locale's separators.
+func (l Locale) Number(f float64, decimals int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(digit)
+	}
+	if frac != "" {
+		b.WriteString(l.decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// DateTime formats t as date and time of day.
+func (l Locale) DateTime(t time.Time) string {
+	return t.Format(l.dateLayout + " " + l.timeLayout)
+}
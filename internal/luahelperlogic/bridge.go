@@ -0,0 +1,157 @@
+package luahelperlogic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The helper sits between the Resolve Lua script and the HushCut app. Commands HushCut sends
+// to /command are written to stdout, where the Lua script picks them up. In the other
+// direction the Lua script can POST {"target": "hushcut", "command": <message type>,
+// "params": {...}} to /command; the helper forwards it to HushCut's /msg endpoint with the
+// app's auth token and relays HushCut's response.
+//
+// HushCut's port and the random prefix its /msg endpoint is mounted under are learned from its
+// /register call (or HUSHCUT_PORT); the token comes from HUSHCUT_AUTH_TOKEN or from the
+// Authorization header of HushCut's own requests. Failing those, all are read from HushCut's
+// discovery file.
+
+var errNotRegistered = errors.New("HushCut has not registered with the helper yet")
+
+type hushcutInstance struct {
+	mu     sync.Mutex
+	port   int
+	prefix string
+	token  string
+}
+
+var hushcut = &hushcutInstance{}
+
+func (h *hushcutInstance) setToken(token string) {
+	h.mu.Lock()
+	h.token = token
+	h.mu.Unlock()
+}
+
+func (h *hushcutInstance) setPort(port int, prefix string) {
+	h.mu.Lock()
+	h.port, h.prefix = port, prefix
+	h.mu.Unlock()
+}
+
+// discover returns the port, endpoint prefix and token of the running HushCut instance.
+func (h *hushcutInstance) discover() (int, string, string, error) {
+	h.mu.Lock()
+	port, prefix, token := h.port, h.prefix, h.token
+	h.mu.Unlock()
+
+	if port == 0 {
+		if p, err := strconv.Atoi(os.Getenv("HUSHCUT_PORT")); err == nil {
+			port = p
+		}
+	}
+	if token == "" {
+		token = os.Getenv("HUSHCUT_AUTH_TOKEN")
+	}
+	if port == 0 {
+		if d, err := ReadDiscovery(); err == nil && d.Reachable() {
+			port, prefix = d.Port, d.Prefix
+			if token == "" {
+				token = d.Token
+			}
+		}
+	}
+	if port == 0 {
+		return 0, "", "", errNotRegistered
+	}
+	return port, prefix, token, nil
+}
+
+// rememberRegistration picks HushCut's port and endpoint prefix out of a /register body.
+func rememberRegistration(body []byte) {
+	var reg struct {
+		GoServerPort   int    `json:"go_server_port"`
+		EndpointPrefix string `json:"endpoint_prefix"`
+	}
+	if json.Unmarshal(body, &reg) == nil && reg.GoServerPort != 0 {
+		hushcut.setPort(reg.GoServerPort, reg.EndpointPrefix)
+		events.Info("HushCut registered", "port", reg.GoServerPort)
+	}
+}
+
+var forwardClient = &http.Client{Timeout: 60 * time.Second}
+
+// postToHushCut sends a message to HushCut's /msg endpoint and returns its response.
+func postToHushCut(messageType string, params map[string]interface{}) (*http.Response, []byte, error) {
+	port, prefix, token, err := hushcut.discover()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid params: %w", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{"Type": messageType, "Payload": json.RawMessage(payload)})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target := fmt.Sprintf("http://localhost:%d%s/msg", port, prefix)
+	if taskID, _ := params["taskId"].(string); taskID != "" {
+		target += "?task_id=" + url.QueryEscape(taskID)
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := forwardClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not reach HushCut: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, fmt.Errorf("could not read HushCut's response: %w", err)
+	}
+	return resp, respBody, nil
+}
+
+// forwardToHushCut posts a message to HushCut and copies its response to w.
+func forwardToHushCut(w http.ResponseWriter, messageType string, params map[string]interface{}) {
+	resp, body, err := postToHushCut(messageType, params)
+	if err != nil {
+		events.Warn("forward to HushCut failed", "type", messageType, "err", err)
+		status := http.StatusBadGateway
+		if errors.Is(err, errNotRegistered) {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+}
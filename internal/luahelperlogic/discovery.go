@@ -0,0 +1,128 @@
+package luahelperlogic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Discovery is what a running HushCut instance publishes about itself so the Lua script (via
+// --discover) and the helper can connect without being told the port.
+type Discovery struct {
+	Port      int       `json:"port"`
+	Token     string    `json:"token"`
+	Prefix    string    `json:"prefix,omitempty"` // of the /msg endpoint
+	PID       int       `json:"pid"`
+	Version   string    `json:"version,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+const discoveryFileName = "discovery.json"
+
+// ErrNoDiscovery means no HushCut instance has published a discovery file.
+var ErrNoDiscovery = errors.New("HushCut is not running (no discovery file)")
+
+// DiscoveryFilePath is the per-user location of the discovery file:
+// <user config dir>/HushCut/discovery.json, i.e. %AppData%\HushCut on Windows,
+// ~/Library/Application Support/HushCut on macOS and ~/.config/HushCut on Linux.
+func DiscoveryFilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate user config dir: %w", err)
+	}
+	return filepath.Join(dir, "HushCut", discoveryFileName), nil
+}
+
+// WriteDiscovery publishes d. The file holds the auth token, so it is only readable by the
+// current user, and it is replaced atomically so readers never see a partial file.
+func WriteDiscovery(d Discovery) error {
+	path, err := DiscoveryFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// ReadDiscovery loads the discovery file. It returns ErrNoDiscovery if there is none.
+func ReadDiscovery() (Discovery, error) {
+	var d Discovery
+	path, err := DiscoveryFilePath()
+	if err != nil {
+		return d, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, ErrNoDiscovery
+	}
+	if err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return d, fmt.Errorf("invalid discovery file %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// RemoveDiscovery deletes the discovery file if it was written by process pid, so an exiting
+// instance never removes the file of one that started after it.
+func RemoveDiscovery(pid int) error {
+	d, err := ReadDiscovery()
+	if err != nil || d.PID != pid {
+		return nil
+	}
+	path, err := DiscoveryFilePath()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Reachable reports whether something is listening on the published port. A crashed instance
+// leaves its file behind; this tells such a stale file apart from a live one.
+func (d Discovery) Reachable() bool {
+	if d.Port <= 0 {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", d.Port), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Discover implements --discover: it prints the discovery file as JSON and returns the exit
+// code, 0 if HushCut is running and reachable and 1 otherwise.
+func Discover() int {
+	d, err := ReadDiscovery()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !d.Reachable() {
+		fmt.Fprintf(os.Stderr, "HushCut (pid %d) is not reachable on port %d; the discovery file is stale\n", d.PID, d.Port)
+		return 1
+	}
+	out, _ := json.Marshal(d)
+	fmt.Println(string(out))
+	return 0
+}
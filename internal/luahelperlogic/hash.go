@@ -0,0 +1,97 @@
+package luahelperlogic
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+)
+
+// FingerprintBytes is how much of a file FileFingerprint reads.
+const FingerprintBytes = 64 * 1024
+
+// HashAlgorithms lists the names accepted by HashFile.
+var HashAlgorithms = []string{"sha256", "md5", "xxh64", "fingerprint"}
+
+// FileFingerprint hashes the size and the first FingerprintBytes of a file. It is the cheap
+// identity HushCut uses for cached WAVs; the size is returned too.
+func FileFingerprint(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:", info.Size())
+	if _, err := io.CopyN(h, f, FingerprintBytes); err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], info.Size(), nil
+}
+
+// HashFile returns the lowercase hex digest of the file at path. algorithm is one of
+// HashAlgorithms; "fingerprint" gives the same value as HushCut's cache fingerprint.
+func HashFile(algorithm string, path string) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	case "xxh64":
+		h = newXXH64()
+	case "fingerprint":
+		sum, _, err := FileFingerprint(path)
+		return sum, err
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm '%s': use sha256, md5, xxh64 or fingerprint", algorithm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Hash implements --hash: it prints the digest of each path in args, or of each path in the
+// piped/--input-file content when no path is given, one line per path in input order. A path
+// that cannot be hashed prints an empty line (the error goes to stderr) so lines stay aligned.
+// The return value is the process exit code.
+func Hash(algorithm string, args []string, pipeContent string) int {
+	if !slices.Contains(HashAlgorithms, algorithm) {
+		fmt.Fprintf(os.Stderr, "unsupported hash algorithm '%s': use sha256, md5, xxh64 or fingerprint\n", algorithm)
+		return 2
+	}
+	paths := args
+	if len(paths) == 0 {
+		paths = batchItems(pipeContent)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "--hash needs a file path or a list of paths on stdin/--input-file")
+		return 2
+	}
+
+	code := 0
+	for _, path := range paths {
+		sum, err := HashFile(algorithm, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = 1
+		}
+		fmt.Println(sum)
+	}
+	return code
+}
@@ -0,0 +1,128 @@
+package luahelperlogic
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Version is the helper's version. HushCut sets it to its own version when running in
+// --lua-helper mode; the standalone binary gets it via
+// -ldflags "-X github.com/oliwoli/hushcut/internal/luahelperlogic.Version=<version>".
+var Version = "dev"
+
+const (
+	helperLogFileName = "lua-helper.log"
+	helperLogMaxSize  = 5 * 1024 * 1024
+)
+
+// StateDir is HushCut's per-user log directory, shared by the app and the helper:
+// %LOCALAPPDATA%\HushCut, ~/Library/Application Support/HushCut or ~/.local/state/HushCut.
+func StateDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "HushCut"), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home dir: %w", err)
+		}
+		return filepath.Join(home, "Library", "Application Support", "HushCut"), nil
+	case "linux":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home dir: %w", err)
+		}
+		return filepath.Join(home, ".local", "state", "HushCut"), nil
+	}
+	goExecutablePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("could not get executable path: %w", err)
+	}
+	return filepath.Dir(goExecutablePath), nil
+}
+
+// events is the helper's structured log: JSON lines in lua-helper.log in StateDir. It is
+// separate from stdout, which the Lua script parses and must keep its plain format.
+var events = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// openEventLog points events at lua-helper.log, keeping one previous generation once the file
+// exceeds helperLogMaxSize. Failure is not fatal; the helper then runs without it.
+func openEventLog() {
+	dir, err := StateDir()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	path := filepath.Join(dir, helperLogFileName)
+	if info, err := os.Stat(path); err == nil && info.Size() > helperLogMaxSize {
+		os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	events = slog.New(slog.NewJSONHandler(f, nil)).With("pid", os.Getpid(), "version", Version)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// logRequests records every request with its status and duration in the event log.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		events.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status,
+			"duration_ms", time.Since(started).Milliseconds())
+	})
+}
+
+// HelperHealth is the response of the helper's /health endpoint.
+type HelperHealth struct {
+	Status        string        `json:"status"`
+	Version       string        `json:"version"`
+	PID           int           `json:"pid"`
+	UptimeSeconds int64         `json:"uptimeSeconds"`
+	HushCut       HushCutStatus `json:"hushcut"`
+}
+
+// HushCutStatus describes the helper's connection to the main app.
+type HushCutStatus struct {
+	Known     bool   `json:"known"` // port learned from /register, env or the discovery file
+	Port      int    `json:"port,omitempty"`
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func hushcutStatus() HushCutStatus {
+	port, _, token, err := hushcut.discover()
+	if err != nil {
+		return HushCutStatus{Error: err.Error()}
+	}
+	status := HushCutStatus{Known: true, Port: port}
+	health, err := fetchAppHealth(port, token)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+	status.Version = health.Version
+	return status
+}
@@ -0,0 +1,165 @@
+package luahelperlogic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// launchWait is how long --launch-or-focus waits for a freshly started HushCut to publish its
+// discovery file before giving up on handing it the Resolve context.
+const launchWait = 30 * time.Second
+
+// LaunchOrFocus implements --launch-or-focus. If a HushCut instance is already running it is
+// brought to the front and sent resolveContext (a JSON object, may be empty); otherwise appPath
+// is started with appArgs and receives the context once it is up. It prints one JSON status
+// line and returns the exit code.
+func LaunchOrFocus(appPath string, appArgs []string, resolveContext string) int {
+	var params map[string]interface{}
+	if resolveContext != "" {
+		if err := json.Unmarshal([]byte(resolveContext), &params); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid Resolve context JSON: %v\n", err)
+			return 2
+		}
+	}
+
+	if d, err := ReadDiscovery(); err == nil && healthy(d) {
+		if err := sendFocus(d, params); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printStatus("focused", d.PID)
+		return 0
+	}
+
+	if appPath == "" {
+		path, err := defaultAppPath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		appPath = path
+	}
+	cmd := exec.Command(appPath, appArgs...)
+	cmd.Env = append(os.Environ(), "HUSHCUT_LAUNCHED_BY=resolve")
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not start %s: %v\n", appPath, err)
+		return 1
+	}
+	pid := cmd.Process.Pid
+	// The app outlives the helper; don't keep a handle to it.
+	cmd.Process.Release()
+	log.Printf("Started %s (pid %d)", appPath, pid)
+
+	deadline := time.Now().Add(launchWait)
+	for time.Now().Before(deadline) {
+		time.Sleep(250 * time.Millisecond)
+		d, err := ReadDiscovery()
+		if err != nil || d.PID != pid || !healthy(d) {
+			continue
+		}
+		if len(params) > 0 {
+			if err := sendFocus(d, params); err != nil {
+				log.Printf("Could not pass the Resolve context to HushCut: %v", err)
+			}
+		}
+		printStatus("launched", pid)
+		return 0
+	}
+	log.Printf("HushCut (pid %d) did not come up within %s", pid, launchWait)
+	printStatus("launching", pid)
+	return 0
+}
+
+func printStatus(status string, pid int) {
+	out, _ := json.Marshal(map[string]interface{}{"status": status, "pid": pid})
+	fmt.Println(string(out))
+}
+
+var healthClient = &http.Client{Timeout: 2 * time.Second}
+
+// appHealth is the part of HushCut's /health response the helper uses.
+type appHealth struct {
+	Status  string `json:"status"`
+	PID     int    `json:"pid"`
+	Version string `json:"version"`
+}
+
+// fetchAppHealth calls HushCut's /health endpoint.
+func fetchAppHealth(port int, token string) (appHealth, error) {
+	var health appHealth
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/health", port), nil)
+	if err != nil {
+		return health, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return health, fmt.Errorf("could not reach HushCut: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return health, fmt.Errorf("HushCut health check failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return health, fmt.Errorf("invalid health response: %w", err)
+	}
+	return health, nil
+}
+
+// healthy asks the instance behind d whether it is alive and accepts d's token.
+func healthy(d Discovery) bool {
+	_, err := fetchAppHealth(d.Port, d.Token)
+	return err == nil
+}
+
+func sendFocus(d Discovery, params map[string]interface{}) error {
+	hushcut.setPort(d.Port, d.Prefix)
+	hushcut.setToken(d.Token)
+	resp, body, err := postToHushCut("focus", params)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HushCut refused focus (%d): %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// defaultAppPath finds the HushCut executable: HUSHCUT_APP_PATH, then the running executable
+// if it is HushCut itself (--lua-helper mode), then a HushCut binary next to the helper.
+func defaultAppPath() (string, error) {
+	if p := os.Getenv("HUSHCUT_APP_PATH"); p != "" {
+		return p, nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("could not locate the HushCut executable: %w", err)
+	}
+	name := "HushCut"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if filepath.Base(self) == name {
+		return self, nil
+	}
+	candidates := []string{filepath.Join(filepath.Dir(self), name)}
+	if runtime.GOOS == "darwin" {
+		candidates = append(candidates, "/Applications/HushCut.app/Contents/MacOS/HushCut")
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, nil
+		}
+	}
+	return "", errors.New("could not find the HushCut executable; pass --app or set HUSHCUT_APP_PATH")
+}
@@ -1,8 +1,11 @@
 package luahelperlogic
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,94 +13,283 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/oliwoli/hushcut/internal/safejson"
 )
 
+// maxCommandBytes bounds /command bodies; makeFinalTimeline carries a whole project.
+const maxCommandBytes = 64 << 20
+
+// maxRegisterBytes bounds /register bodies, which only carry HushCut's port and endpoint prefix.
+const maxRegisterBytes = 64 << 10
+
+// ServerOptions configures the helper's HTTP server.
+type ServerOptions struct {
+	IdleTimeout time.Duration // shut down after this long without requests; 0 disables it
+	Token       string        // required on every endpoint; without one the server refuses all requests
+}
+
 // Start runs the helper logic based on the provided parameters.
 // This is the single, shared entry point for the logic.
-func Start(port int, findPort bool, uuidCount int, uuidStr string, pipeContent string) {
+func Start(port int, findPort bool, uuidCount int, uuidStr string, pipeContent string, uuidOpts UUIDOptions, serverOpts ServerOptions) {
 	// --- UUID logic ---
 	if uuidCount > 0 {
 		for range uuidCount {
-			fmt.Println(uuid.New())
+			fmt.Println(uuidOpts.Format(uuid.New()))
 		}
 		return
 	}
 
 	// If we have pipeContent, prefer it over uuidStr
-	if pipeContent != "" {
-		var items []string
-		if err := json.Unmarshal([]byte(pipeContent), &items); err != nil {
-			log.Fatalf("invalid JSON input from stdin: %v", err)
-		}
+	if items := batchItems(pipeContent); items != nil {
 		for _, s := range items {
-			u := uuid.NewMD5(uuid.Nil, []byte(s))
-			fmt.Println(u.String())
+			fmt.Println(uuidOpts.Format(uuidOpts.Deterministic(s)))
 		}
 		return
 	}
 
 	// Fallback: deterministic UUID from --uuid-from-str
 	if uuidStr != "" {
-		u := uuid.NewMD5(uuid.Nil, []byte(uuidStr))
-		fmt.Println(u.String())
+		fmt.Println(uuidOpts.Format(uuidOpts.Deterministic(uuidStr)))
 		return
 	}
 
 	// --- Server Logic ---
 	if findPort {
-		addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+		port, err := freePort()
 		if err != nil {
-			log.Fatalf("could not resolve tcp addr: %v", err)
+			log.Fatal(err)
 		}
-		l, err := net.ListenTCP("tcp", addr)
-		if err != nil {
-			log.Fatalf("could not listen on tcp addr: %v", err)
+		fmt.Println(port)
+		return
+	}
+
+	if serverOpts.IdleTimeout == 0 {
+		if v := os.Getenv("HUSHCUT_HELPER_IDLE_TIMEOUT"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				log.Fatalf("invalid HUSHCUT_HELPER_IDLE_TIMEOUT '%s': %v", v, err)
+			}
+			serverOpts.IdleTimeout = d
 		}
-		defer l.Close()
-		fmt.Println(l.Addr().(*net.TCPAddr).Port)
+	}
+	startHttpServer(port, serverOpts)
+}
+
+// ResolveToken picks the helper's auth token: the --token flag, then the first line of the
+// piped input when fromStdin is set, then HUSHCUT_AUTH_TOKEN (the variable HushCut itself
+// reads, so both sides share one token). It returns the token and the remaining input.
+func ResolveToken(flagValue string, fromStdin bool, pipeContent string) (string, string) {
+	if flagValue != "" {
+		return strings.TrimSpace(flagValue), pipeContent
+	}
+	if fromStdin {
+		line, rest, _ := strings.Cut(pipeContent, "\n")
+		return strings.TrimSpace(line), rest
+	}
+	return strings.TrimSpace(os.Getenv("HUSHCUT_AUTH_TOKEN")), pipeContent
+}
+
+// requireToken rejects requests that don't carry token, either as "Authorization: Bearer
+// <token>" or as a ?token= query parameter, like the main app's server.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			clientToken = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(clientToken), []byte(token)) != 1 {
+			log.Printf("Rejected unauthenticated %s %s", r.Method, r.URL.Path)
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// refuseAll answers every request with 503, for a server started without a token: anything
+// on the machine could otherwise drive Resolve through it.
+func refuseAll() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusServiceUnavailable, "no auth token configured")
+	})
+}
+
+// luaOut is where relayToLua writes; the Lua script reads the helper's output line by line.
+var luaOut io.Writer = os.Stdout
+
+// relayToLua hands an authenticated message to the Lua script as a single "Relay: <json>"
+// line. Only relayed lines are acted on, so logs never need to carry bodies or headers.
+func relayToLua(body []byte) {
+	var line bytes.Buffer
+	if err := json.Compact(&line, body); err != nil {
+		log.Printf("Not relaying invalid JSON: %v", err)
 		return
 	}
+	fmt.Fprintf(luaOut, "Relay: %s\n", line.Bytes())
+}
+
+// freePort asks the OS for a currently unused localhost port.
+func freePort() (int, error) {
+	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+	if err != nil {
+		return 0, fmt.Errorf("could not resolve tcp addr: %w", err)
+	}
+	l, err := net.ListenTCP("tcp", addr)
+	if err != nil {
+		return 0, fmt.Errorf("could not listen on tcp addr: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// batchItems splits piped input for batch UUID generation. A JSON array of strings yields one
+// item per element; anything else is taken as one item per non-empty line. Whitespace-only
+// input returns nil so the other modes still apply.
+func batchItems(pipeContent string) []string {
+	trimmed := strings.TrimSpace(pipeContent)
+	if trimmed == "" {
+		return nil
+	}
+	if strings.HasPrefix(trimmed, "[") {
+		var items []string
+		if err := json.Unmarshal([]byte(trimmed), &items); err != nil {
+			log.Fatalf("invalid JSON input: %v", err)
+		}
+		return items
+	}
+	var items []string
+	for _, line := range strings.Split(trimmed, "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// idleTracker records request activity so an orphaned helper (e.g. after Resolve crashed)
+// can shut itself down.
+type idleTracker struct {
+	lastActivity atomic.Int64 // unix nanoseconds
+	inFlight     atomic.Int32
+}
+
+func (t *idleTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.inFlight.Add(1)
+		t.lastActivity.Store(time.Now().UnixNano())
+		defer func() {
+			t.lastActivity.Store(time.Now().UnixNano())
+			t.inFlight.Add(-1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
 
-	startHttpServer(port)
+// watch closes idle once no request has been served for timeout.
+func (t *idleTracker) watch(timeout time.Duration, idle chan<- struct{}) {
+	t.lastActivity.Store(time.Now().UnixNano())
+	interval := min(timeout/4, 30*time.Second)
+	ticker := time.NewTicker(max(interval, 100*time.Millisecond))
+	defer ticker.Stop()
+	for range ticker.C {
+		if t.inFlight.Load() > 0 {
+			continue
+		}
+		if time.Since(time.Unix(0, t.lastActivity.Load())) >= timeout {
+			close(idle)
+			return
+		}
+	}
 }
 
 // startHttpServer is now an unexported helper function within this package.
-func startHttpServer(port int) {
+// commandRequest is the body of /command.
+type commandRequest struct {
+	Target  string                 `json:"target"`
+	Command string                 `json:"command"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+// decodeCommand decodes a /command body strictly and checks that it names a command.
+func decodeCommand(body []byte) (commandRequest, error) {
+	var payload commandRequest
+	if err := safejson.Decode(body, &payload, true); err != nil {
+		return commandRequest{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if payload.Command == "" {
+		return commandRequest{}, errors.New("missing command")
+	}
+	return payload, nil
+}
+
+func startHttpServer(port int, opts ServerOptions) {
 	log.Println("starting local http server as IPC between lua and go")
+	started := time.Now()
+	openEventLog()
+	events.Info("helper starting", "port", port, "idle_timeout", opts.IdleTimeout.String(), "auth", opts.Token != "")
 	// Channel for listening to OS signals (like Ctrl+C)
 	osSignalChan := make(chan os.Signal, 1)
 	signal.Notify(osSignalChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Channel to listen for the shutdown request from our HTTP handler
 	httpShutdownChan := make(chan struct{})
+	var shutdownOnce sync.Once
+
+	// Closed when no request arrived within idleTimeout
+	idleChan := make(chan struct{})
+	tracker := &idleTracker{}
 
 	mux := http.NewServeMux()
+	var handler http.Handler = mux
+	if opts.Token != "" {
+		// The token is shared with HushCut, so it also authenticates forwarded requests.
+		hushcut.setToken(opts.Token)
+		handler = requireToken(opts.Token, handler)
+	} else {
+		log.Println("No auth token set (--token or HUSHCUT_AUTH_TOKEN), refusing all requests")
+		handler = refuseAll()
+	}
 	server := &http.Server{
 		Addr:    fmt.Sprintf("localhost:%d", port),
-		Handler: mux,
+		Handler: logRequests(tracker.middleware(handler)),
 	}
 
-	// Root handler to print requests
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		log.Println("---- Incoming Request ----")
-		log.Printf("%s %s %s", r.Method, r.URL.Path, r.Proto)
+	// Version, uptime and the connection to HushCut, for diagnosing mismatched installs
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HelperHealth{
+			Status:        "ok",
+			Version:       Version,
+			PID:           os.Getpid(),
+			UptimeSeconds: int64(time.Since(started).Seconds()),
+			HushCut:       hushcutStatus(),
+		})
+	})
 
-		for name, values := range r.Header {
-			for _, value := range values {
-				log.Printf("Header: %s: %s", name, value)
+	// Root handler: HushCut's /register lands here, anything else is only acknowledged
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s", r.Method, r.URL.Path)
+
+		if r.URL.Path == "/register" && r.Body != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRegisterBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				log.Printf("Rejected /register body over %d bytes", tooLarge.Limit)
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "registration too large")
+				return
 			}
-		}
-
-		if r.Body != nil {
-			body, err := io.ReadAll(r.Body)
 			if err != nil {
 				log.Printf("Error reading body: %v", err)
 			} else if len(body) > 0 {
-				log.Printf("Body: %s", string(body))
+				rememberRegistration(body)
+				relayToLua(body)
 			}
 		}
 
@@ -112,86 +304,44 @@ func startHttpServer(port int) {
 		}
 
 		// Read and store the body once
-		bodyBytes, err := io.ReadAll(r.Body)
+		bodyBytes, err := safejson.ReadAll(r.Body, maxCommandBytes)
+		if errors.Is(err, safejson.ErrTooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "command too large")
+			return
+		}
 		if err != nil {
 			http.Error(w, "Failed to read request body", http.StatusBadRequest)
 			return
 		}
 
-		var payload struct {
-			Command string                 `json:"command"`
-			Params  map[string]interface{} `json:"params"`
-		}
-		if err := json.Unmarshal(bodyBytes, &payload); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		payload, err := decodeCommand(bodyBytes)
+		if err != nil {
+			log.Printf("Rejected /command body: %v", err)
+			writeJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		log.Printf("Received command: %s", payload.Command)
 
-		switch payload.Command {
-		case "sync":
-			// Log the request metadata
-			log.Printf("%s %s %s", r.Method, r.URL.Path, r.Proto)
-			for name, values := range r.Header {
-				for _, value := range values {
-					log.Printf("Header: %s: %s", name, value)
-				}
-			}
-			if len(bodyBytes) > 0 {
-				log.Printf("Body: %s", string(bodyBytes))
-			}
+		// Lua -> HushCut
+		if payload.Target == "hushcut" {
+			forwardToHushCut(w, payload.Command, payload.Params)
+			return
+		}
 
-			// Send response
-			response := map[string]string{
-				"status":  "success",
-				"message": "Sync command received.",
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
-
-		case "setPlayhead":
-			// Log the request metadata
-			log.Printf("%s %s %s", r.Method, r.URL.Path, r.Proto)
-			for name, values := range r.Header {
-				for _, value := range values {
-					log.Printf("Header: %s: %s", name, value)
-				}
-			}
-			if len(bodyBytes) > 0 {
-				log.Printf("Body: %s", string(bodyBytes))
-			}
+		// HushCut -> Lua
+		switch payload.Command {
+		case "sync", "setPlayhead", "makeFinalTimeline", "saveProject", "addMarkers", "listTimelines":
+			// The Lua script reads the relayed line from our stdout and runs the command; its
+			// result reaches HushCut through /msg, so this response only confirms the hand-over.
+			relayToLua(bodyBytes)
 
-			// send response
-			response := map[string]string{
-				"status":  "success",
-				"message": "Set playhead command received.",
-			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
-
-		case "makeFinalTimeline":
-			// Log the request metadata
-			log.Printf("%s %s %s", r.Method, r.URL.Path, r.Proto)
-			for name, values := range r.Header {
-				for _, value := range values {
-					log.Printf("Header: %s: %s", name, value)
-				}
-			}
-			if len(bodyBytes) > 0 {
-				log.Printf("Body: %s", string(bodyBytes))
-			}
-
-			// send response
-			response := map[string]string{
+			json.NewEncoder(w).Encode(map[string]string{
 				"status":  "success",
-				"message": "Set playhead command received.",
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
+				"message": fmt.Sprintf("Command '%s' passed to Resolve.", payload.Command),
+			})
 
 		default:
 			// Unsupported command
@@ -217,7 +367,7 @@ func startHttpServer(port int) {
 		w.Write([]byte("Server shutting down..."))
 
 		// **THE FIX**: Signal the main goroutine to shutdown by closing the channel.
-		close(httpShutdownChan)
+		shutdownOnce.Do(func() { close(httpShutdownChan) })
 	})
 
 	// Start the server in a goroutine
@@ -228,12 +378,22 @@ func startHttpServer(port int) {
 		}
 	}()
 
-	// **THE FIX**: Block here until a signal is received from either source.
+	if opts.IdleTimeout > 0 {
+		log.Printf("Shutting down after %s without requests", opts.IdleTimeout)
+		go tracker.watch(opts.IdleTimeout, idleChan)
+	}
+
+	// **THE FIX**: Block here until a signal is received from any source.
 	select {
 	case <-osSignalChan:
 		log.Println("Shutdown signal received from OS.")
+		events.Info("helper stopping", "reason", "signal")
 	case <-httpShutdownChan:
 		log.Println("Shutdown signal received from HTTP /shutdown endpoint.")
+		events.Info("helper stopping", "reason", "shutdown request")
+	case <-idleChan:
+		log.Printf("No requests for %s, shutting down idle helper.", opts.IdleTimeout)
+		events.Info("helper stopping", "reason", "idle")
 	}
 
 	log.Println("Initiating graceful shutdown...")
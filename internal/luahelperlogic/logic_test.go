@@ -0,0 +1,33 @@
+package luahelperlogic
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzDecodeCommand(f *testing.F) {
+	for _, seed := range []string{
+		`{"command":"sync","params":{"taskId":"1"}}`,
+		`{"target":"hushcut","command":"focus","params":{}}`,
+		`{"command":"makeFinalTimeline","params":{"projectData":{"timeline":{"audio_track_items":[]}}}}`,
+		`{"command":""}`,
+		`{"command":"sync","extra":true}`,
+		`{"command":"sync"} trailing`,
+		`{"params":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		payload, err := decodeCommand(body)
+		if err != nil {
+			if payload.Command != "" || payload.Params != nil {
+				t.Fatalf("decodeCommand returned %+v with error %v", payload, err)
+			}
+			return
+		}
+		if payload.Command == "" {
+			t.Fatal("decodeCommand accepted a body without a command")
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package luahelperlogic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// ServeStdio runs the helper without networking: it reads one JSON-RPC 2.0 request per line
+// from in and writes one response per line to out, until in is closed or "shutdown" is
+// called. Log output goes to stderr so it never mixes with responses.
+//
+// Methods:
+//
+//	ping                                         -> "pong"
+//	uuid             {"count": n}                -> ["<uuid>", ...]
+//	uuidFromStrings  {"strings": ["a", ...]}     -> ["<uuid>", ...] (deterministic)
+//
+// Both uuid methods also take the optional fields "version" (3 or 5), "namespace" (nil, dns,
+// url, oid, x500 or a UUID), "braces", "uppercase" and "hex", as on the command line.
+//
+//	hash             {"algorithm": "sha256", "paths": ["/a.wav", ...]}
+//	                 -> [{"path": "/a.wav", "hash": "..."} or {"path": ..., "error": "..."}, ...]
+//	findPort                                     -> port
+//	discover                                     -> {"port": n, "token": "...", "prefix": "...", ...}
+//	register         {"port": n, "prefix": "...", "token": "..."} -> true
+//	forward          {"type": "...", "params": {...}} -> {"status": code, "body": "..."}
+//	shutdown                                     -> true
+func ServeStdio(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+
+		result, rpcErr := handleRPC(req)
+		switch {
+		case req.ID == nil:
+			// Notifications get no response.
+		case rpcErr != nil:
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+		default:
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+		if req.Method == "shutdown" {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("stdio: reading requests failed: %v", err)
+	}
+}
+
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+}
+
+// rpcUUIDOptions are the UUID option fields shared by the uuid methods.
+type rpcUUIDOptions struct {
+	Version   int    `json:"version"`
+	Namespace string `json:"namespace"`
+	Braces    bool   `json:"braces"`
+	Uppercase bool   `json:"uppercase"`
+	Hex       bool   `json:"hex"`
+}
+
+func (o rpcUUIDOptions) options() (UUIDOptions, error) {
+	version := o.Version
+	if version == 0 {
+		version = DefaultUUIDOptions().Version
+	}
+	return NewUUIDOptions(version, o.Namespace, o.Braces, o.Uppercase, o.Hex)
+}
+
+func handleRPC(req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "ping":
+		return "pong", nil
+
+	case "uuid":
+		var p struct {
+			Count int `json:"count"`
+			rpcUUIDOptions
+		}
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				return nil, invalidParams(err)
+			}
+		}
+		opts, err := p.options()
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		if p.Count <= 0 {
+			p.Count = 1
+		}
+		ids := make([]string, p.Count)
+		for i := range ids {
+			ids[i] = opts.Format(uuid.New())
+		}
+		return ids, nil
+
+	case "uuidFromStrings":
+		var p struct {
+			Strings []string `json:"strings"`
+			rpcUUIDOptions
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		opts, err := p.options()
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		ids := make([]string, len(p.Strings))
+		for i, s := range p.Strings {
+			ids[i] = opts.Format(opts.Deterministic(s))
+		}
+		return ids, nil
+
+	case "hash":
+		var p struct {
+			Algorithm string   `json:"algorithm"`
+			Paths     []string `json:"paths"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if !slices.Contains(HashAlgorithms, p.Algorithm) {
+			return nil, invalidParams(fmt.Errorf("unsupported hash algorithm '%s'", p.Algorithm))
+		}
+		results := make([]map[string]string, len(p.Paths))
+		for i, path := range p.Paths {
+			results[i] = map[string]string{"path": path}
+			if sum, err := HashFile(p.Algorithm, path); err != nil {
+				results[i]["error"] = err.Error()
+			} else {
+				results[i]["hash"] = sum
+			}
+		}
+		return results, nil
+
+	case "findPort":
+		port, err := freePort()
+		if err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return port, nil
+
+	case "discover":
+		d, err := ReadDiscovery()
+		if err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		if !d.Reachable() {
+			return nil, &rpcError{Code: rpcInternalError, Message: fmt.Sprintf("HushCut is not reachable on port %d", d.Port)}
+		}
+		return d, nil
+
+	case "register":
+		var p struct {
+			Port   int    `json:"port"`
+			Prefix string `json:"prefix"`
+			Token  string `json:"token"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if p.Port <= 0 {
+			return nil, invalidParams(fmt.Errorf("port is required"))
+		}
+		hushcut.setPort(p.Port, p.Prefix)
+		if p.Token != "" {
+			hushcut.setToken(p.Token)
+		}
+		return true, nil
+
+	case "forward":
+		var p struct {
+			Type   string                 `json:"type"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if p.Type == "" {
+			return nil, invalidParams(fmt.Errorf("type is required"))
+		}
+		resp, body, err := postToHushCut(p.Type, p.Params)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return map[string]interface{}{"status": resp.StatusCode, "body": string(body)}, nil
+
+	case "shutdown":
+		return true, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method '%s'", req.Method)}
+}
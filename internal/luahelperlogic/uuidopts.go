@@ -0,0 +1,97 @@
+package luahelperlogic
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UUIDOptions controls how deterministic UUIDs are derived and how all UUIDs are printed.
+// The defaults (version 3, nil namespace, canonical lowercase) match the IDs the helper has
+// always produced.
+type UUIDOptions struct {
+	Version   int       // 3 (MD5) or 5 (SHA-1) for deterministic IDs
+	Namespace uuid.UUID // namespace deterministic IDs are derived in
+	Braces    bool      // {xxxxxxxx-...}
+	Uppercase bool
+	Hex       bool // 32 hex digits without dashes or braces
+}
+
+// DefaultUUIDOptions returns the options used when no flags are given.
+func DefaultUUIDOptions() UUIDOptions {
+	return UUIDOptions{Version: 3, Namespace: uuid.Nil}
+}
+
+// ParseNamespace accepts "nil", "dns", "url", "oid", "x500" or a literal UUID.
+func ParseNamespace(s string) (uuid.UUID, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "nil":
+		return uuid.Nil, nil
+	case "dns":
+		return uuid.NameSpaceDNS, nil
+	case "url":
+		return uuid.NameSpaceURL, nil
+	case "oid":
+		return uuid.NameSpaceOID, nil
+	case "x500":
+		return uuid.NameSpaceX500, nil
+	}
+	ns, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid namespace '%s': use nil, dns, url, oid, x500 or a UUID", s)
+	}
+	return ns, nil
+}
+
+// RegisterUUIDFlags adds --uuid-version, --namespace, --braces, --uppercase and --hex to fs.
+// The returned function builds the options after fs has been parsed.
+func RegisterUUIDFlags(fs *flag.FlagSet) func() (UUIDOptions, error) {
+	version := fs.Int("uuid-version", 3, "UUID version for deterministic IDs: 3 (MD5) or 5 (SHA-1)")
+	namespace := fs.String("namespace", "nil", "namespace for deterministic IDs: nil, dns, url, oid, x500 or a UUID")
+	braces := fs.Bool("braces", false, "print UUIDs wrapped in braces")
+	uppercase := fs.Bool("uppercase", false, "print UUIDs in uppercase")
+	hex := fs.Bool("hex", false, "print UUIDs as 32 hex digits without dashes")
+
+	return func() (UUIDOptions, error) {
+		return NewUUIDOptions(*version, *namespace, *braces, *uppercase, *hex)
+	}
+}
+
+// NewUUIDOptions validates and combines the individual options.
+func NewUUIDOptions(version int, namespace string, braces bool, uppercase bool, hex bool) (UUIDOptions, error) {
+	opts := DefaultUUIDOptions()
+	if version != 3 && version != 5 {
+		return opts, fmt.Errorf("unsupported UUID version %d: use 3 or 5", version)
+	}
+	ns, err := ParseNamespace(namespace)
+	if err != nil {
+		return opts, err
+	}
+	opts.Version, opts.Namespace = version, ns
+	opts.Braces, opts.Uppercase, opts.Hex = braces, uppercase, hex
+	return opts, nil
+}
+
+// Deterministic derives the UUID for name.
+func (o UUIDOptions) Deterministic(name string) uuid.UUID {
+	if o.Version == 5 {
+		return uuid.NewSHA1(o.Namespace, []byte(name))
+	}
+	return uuid.NewMD5(o.Namespace, []byte(name))
+}
+
+// Format renders u according to the output options.
+func (o UUIDOptions) Format(u uuid.UUID) string {
+	s := u.String()
+	if o.Hex {
+		s = strings.ReplaceAll(s, "-", "")
+	} else if o.Braces {
+		s = "{" + s + "}"
+	}
+	if o.Uppercase {
+		s = strings.ToUpper(s)
+	}
+	return s
+}
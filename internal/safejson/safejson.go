@@ -0,0 +1,82 @@
+// Package safejson decodes JSON that comes from outside the process (the Python and Lua
+// bridges, files the user can edit or import) with limits: a bounded size and nesting depth,
+// exactly one value, and optionally no fields the target type doesn't know. Malformed input
+// becomes an error instead of a panic or a half-filled value.
+package safejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxDepth is how deeply objects and arrays may nest. Nothing HushCut exchanges comes close;
+// the limit keeps hostile input from exhausting the stack of whoever walks the result.
+const MaxDepth = 64
+
+var (
+	ErrTooLarge = errors.New("JSON input is too large")
+	ErrTooDeep  = fmt.Errorf("JSON input nests deeper than %d levels", MaxDepth)
+)
+
+// ReadAll reads r up to limit bytes and fails with ErrTooLarge if there is more.
+func ReadAll(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Decode unmarshals data into v. With strict, fields v has no place for are an error.
+// Trailing data after the value is always one.
+func Decode(data []byte, v any, strict bool) error {
+	if err := CheckDepth(data); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// CheckDepth fails with ErrTooDeep if objects and arrays in data nest deeper than MaxDepth.
+// It doesn't validate anything else.
+func CheckDepth(data []byte) error {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			if depth++; depth > MaxDepth {
+				return ErrTooDeep
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return nil
+}
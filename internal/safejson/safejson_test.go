@@ -0,0 +1,99 @@
+package safejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var seeds = []string{
+	`{}`,
+	`[]`,
+	`null`,
+	`{"type":"taskResult","payload":{"status":"success","data":[1,2,3]}}`,
+	`{"a":"}{[\"]"}`,
+	`{"a":1} {"b":2}`,
+	`{"a":1}garbage`,
+	`"\\"`,
+	strings.Repeat("[", MaxDepth) + strings.Repeat("]", MaxDepth),
+	strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1),
+	strings.Repeat(`{"a":`, MaxDepth+1) + "1" + strings.Repeat("}", MaxDepth+1),
+}
+
+// depth returns how deeply valid JSON nests, walking its tokens.
+func depth(t *testing.T, data []byte) int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	current, deepest := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return deepest
+		}
+		if err != nil {
+			t.Fatalf("token walk of valid JSON failed: %v", err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			current++
+			deepest = max(deepest, current)
+		case json.Delim('}'), json.Delim(']'):
+			current--
+		}
+	}
+}
+
+func FuzzCheckDepth(f *testing.F) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err := CheckDepth(data)
+		if err != nil && !errors.Is(err, ErrTooDeep) {
+			t.Fatalf("CheckDepth returned %v, want nil or ErrTooDeep", err)
+		}
+		if !json.Valid(data) {
+			return
+		}
+		if tooDeep := depth(t, data) > MaxDepth; tooDeep != (err != nil) {
+			t.Fatalf("CheckDepth = %v for JSON nesting %d levels", err, depth(t, data))
+		}
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed), false)
+		f.Add([]byte(seed), true)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, strict bool) {
+		var generic any
+		err := Decode(data, &generic, strict)
+		if CheckDepth(data) != nil && !errors.Is(err, ErrTooDeep) {
+			t.Fatalf("Decode of input nesting too deep returned %v", err)
+		}
+		if err == nil && !json.Valid(data) {
+			t.Fatalf("Decode accepted invalid JSON %q", data)
+		}
+
+		var typed struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+			Count   int             `json:"count"`
+		}
+		if err := Decode(data, &typed, strict); err == nil && typed.Payload != nil && !json.Valid(typed.Payload) {
+			t.Fatalf("Decode produced an invalid raw payload %q", typed.Payload)
+		}
+
+		if _, err := ReadAll(bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatalf("ReadAll at the exact limit: %v", err)
+		}
+		if len(data) > 0 {
+			if _, err := ReadAll(bytes.NewReader(data), int64(len(data)-1)); !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("ReadAll over the limit returned %v, want ErrTooLarge", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// HushCut is either started by the HushCut script inside DaVinci Resolve, which runs the
+// lua-helper as the backend and passes its port with --python-port, or on its own, in which
+// case it launches python_backend itself. The launch context records which one it was so that
+// startup and error messages can follow it.
+
+const (
+	launchModeResolve     = "resolve"
+	launchModeStandalone  = "standalone"
+	launchModeDevelopment = "development"
+
+	// launchedByEnvVar is set to "resolve" by the Resolve script and --launch-or-focus.
+	launchedByEnvVar = "HUSHCUT_LAUNCHED_BY"
+)
+
+// LaunchContext says how this instance was started.
+type LaunchContext struct {
+	Mode string `json:"mode"` // resolve, standalone or development
+	// DetectedBy is what the mode was taken from: the --launched-by flag, the
+	// HUSHCUT_LAUNCHED_BY or WAILS_PYTHON_PORT environment variable, --python-port, or
+	// "default".
+	DetectedBy string `json:"detectedBy"`
+	PythonPort int    `json:"pythonPort,omitempty"`
+	// BackendBundled tells whether python_backend ships next to the executable, which a
+	// standalone start needs.
+	BackendBundled bool `json:"backendBundled"`
+}
+
+// detectLaunchContext works out the launch mode from the --launched-by flag (empty when not
+// given), the environment and --python-port.
+func detectLaunchContext(launchedByFlag string, pythonPort int) LaunchContext {
+	ctx := LaunchContext{PythonPort: pythonPort}
+	switch {
+	case launchedByFlag != "":
+		ctx.Mode, ctx.DetectedBy = launchedByFlag, "flag"
+	case strings.TrimSpace(os.Getenv(launchedByEnvVar)) == launchModeResolve: // cmd's "set" keeps a trailing space
+		ctx.Mode, ctx.DetectedBy = launchModeResolve, launchedByEnvVar
+	case os.Getenv("WAILS_PYTHON_PORT") != "":
+		ctx.Mode, ctx.DetectedBy = launchModeDevelopment, "WAILS_PYTHON_PORT"
+	case pythonPort != 0:
+		// Only the Resolve script passes --python-port.
+		ctx.Mode, ctx.DetectedBy = launchModeResolve, "python-port"
+	default:
+		ctx.Mode, ctx.DetectedBy = launchModeStandalone, "default"
+	}
+	return ctx
+}
+
+func validLaunchMode(mode string) error {
+	switch mode {
+	case "", launchModeResolve, launchModeStandalone, launchModeDevelopment:
+		return nil
+	}
+	return fmt.Errorf("invalid -launched-by %q: must be resolve, standalone or development", mode)
+}
+
+// pythonBackendPath is the python_backend executable a standalone start launches.
+func (a *App) pythonBackendPath() string {
+	if goruntime.GOOS == "windows" {
+		return filepath.Join(a.resourcesPath, "python_backend.exe")
+	}
+	return filepath.Join(a.resourcesPath, "python_backend")
+}
+
+// GetLaunchContext tells the frontend whether HushCut was started from Resolve or on its own.
+func (a *App) GetLaunchContext() LaunchContext {
+	return a.launch
+}
+
+// backendUnavailableMessage explains, for the way HushCut was started, why it can't talk to
+// Resolve and what to do about it.
+func (a *App) backendUnavailableMessage(cause string) i18n.Message {
+	switch a.launch.Mode {
+	case launchModeResolve:
+		return i18n.New("backend.unavailable.resolve", i18n.Params{"cause": cause})
+	case launchModeDevelopment:
+		return i18n.New("backend.unavailable.development", i18n.Params{"port": a.pythonCommandPort, "cause": cause})
+	}
+	if !a.launch.BackendBundled {
+		return i18n.New("backend.unavailable.notBundled", nil)
+	}
+	return i18n.New("backend.unavailable.notStarted", i18n.Params{"cause": cause})
+}
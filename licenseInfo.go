@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// licenseFreshness is how long a license stays "fresh" before HushCut re-validates it online.
+const licenseFreshness = 24 * time.Hour
+
+// LicenseInfo is what the settings screen shows about the active license.
+type LicenseInfo struct {
+	Valid      bool       `json:"valid"`
+	Provider   string     `json:"provider,omitempty"`
+	Tier       string     `json:"tier,omitempty"`
+	Email      string     `json:"email,omitempty"` // masked
+	IssuedAt   *time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	SeatsUsed  int        `json:"seatsUsed,omitempty"`
+	SeatsTotal int        `json:"seatsTotal,omitempty"`
+	Offline    bool       `json:"offline"`
+	Floating   bool       `json:"floating"` // seat checked out from a studio license server
+	// Stale means the last successful online check is older than 24 hours and
+	// the app is running on the grace period until it can reach the server again.
+	Stale bool   `json:"stale"`
+	Error string `json:"error,omitempty"`
+	// ErrorKey and ErrorParams let the frontend show Error in the user's language.
+	ErrorKey    string      `json:"errorKey,omitempty"`
+	ErrorParams i18n.Params `json:"errorParams,omitempty"`
+}
+
+// maskEmail keeps the first character of the local part and the domain: "j***@example.com".
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return ""
+	}
+	return local[:1] + "***@" + domain
+}
+
+func unixField(data map[string]interface{}, key string) *time.Time {
+	if v, ok := data[key].(float64); ok && v > 0 {
+		t := time.Unix(int64(v), 0)
+		return &t
+	}
+	return nil
+}
+
+func intField(data map[string]interface{}, key string) int {
+	if v, ok := data[key].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+// GetLicenseInfo returns details of the locally stored license without contacting the server.
+func (a *App) GetLicenseInfo() LicenseInfo {
+	a.seatMu.Lock()
+	seat := a.seat
+	a.seatMu.Unlock()
+	if seat != nil {
+		expires := seat.LeaseExpires
+		return LicenseInfo{Valid: true, Floating: true, ExpiresAt: &expires, SeatsUsed: seat.SeatsUsed, SeatsTotal: seat.SeatsTotal}
+	}
+
+	license, err := a.loadAndVerifyLocalLicense()
+	if err != nil {
+		info := LicenseInfo{Error: err.Error()}
+		if message, ok := i18n.As(err); ok {
+			info.ErrorKey, info.ErrorParams = message.Key, message.Params
+		}
+		return info
+	}
+
+	info := LicenseInfo{Valid: true}
+	info.IssuedAt = unixField(license.Data, "issued_at")
+
+	if _, until, ok := offlineLicenseWindow(license.Data); ok {
+		info.Offline = true
+		info.ExpiresAt = &until
+		info.Valid = checkOfflineLicense(license.Data) == nil
+	} else {
+		info.ExpiresAt = unixField(license.Data, "expires_at")
+		info.Stale = info.IssuedAt == nil || time.Since(*info.IssuedAt) >= licenseFreshness
+	}
+
+	details := parseLicenseDetails(license.Data)
+	info.Provider = licenseProviderFor(license.Data).Name()
+	info.Email = maskEmail(details.Email)
+	info.Tier = details.Tier
+	if tier, ok := license.Data["tier"].(string); ok {
+		info.Tier = tier
+	}
+	info.SeatsUsed = details.SeatsUsed
+	info.SeatsTotal = details.SeatsTotal
+	if total := intField(license.Data, "max_uses"); total > 0 {
+		info.SeatsTotal = total
+	}
+	if info.ExpiresAt == nil {
+		info.ExpiresAt = details.ExpiresAt
+	}
+	return info
+}
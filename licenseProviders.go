@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// LicenseDetails is the provider-independent view of a signed license's "details".
+type LicenseDetails struct {
+	LicenseKey string
+	Email      string
+	Tier       string
+	SeatsUsed  int
+	SeatsTotal int
+	ExpiresAt  *time.Time
+}
+
+// LicenseProvider knows how one storefront structures the "details" object that the
+// license server signs. The server names the provider in the signed data's "provider"
+// field; licenses issued before that field existed are Gumroad licenses.
+type LicenseProvider interface {
+	Name() string
+	Parse(details map[string]interface{}) LicenseDetails
+}
+
+var licenseProviders = map[string]LicenseProvider{
+	"gumroad":      gumroadProvider{},
+	"lemonsqueezy": lemonSqueezyProvider{},
+	"paddle":       flatProvider{name: "paddle"},
+	"stripe":       flatProvider{name: "stripe"},
+}
+
+// licenseProviderFor picks the provider of signed license data.
+func licenseProviderFor(data map[string]interface{}) LicenseProvider {
+	if name, ok := data["provider"].(string); ok {
+		if p, ok := licenseProviders[strings.ToLower(name)]; ok {
+			return p
+		}
+	}
+	return gumroadProvider{}
+}
+
+// parseLicenseDetails extracts key, customer and entitlement info from signed license data.
+func parseLicenseDetails(data map[string]interface{}) LicenseDetails {
+	details, _ := data["details"].(map[string]interface{})
+	if details == nil {
+		return LicenseDetails{}
+	}
+	return licenseProviderFor(data).Parse(details)
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+func timeField(data map[string]interface{}, key string) *time.Time {
+	if s := stringField(data, key); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return &t
+		}
+	}
+	return unixField(data, key)
+}
+
+// gumroadProvider: {"uses": n, "purchase": {"license_key", "email", "variants", "subscription_ended_at"}}
+type gumroadProvider struct{}
+
+func (gumroadProvider) Name() string { return "gumroad" }
+
+func (gumroadProvider) Parse(details map[string]interface{}) LicenseDetails {
+	d := LicenseDetails{SeatsUsed: intField(details, "uses")}
+	if purchase, ok := details["purchase"].(map[string]interface{}); ok {
+		d.LicenseKey = stringField(purchase, "license_key")
+		d.Email = stringField(purchase, "email")
+		d.Tier = strings.Trim(stringField(purchase, "variants"), "() ")
+		d.ExpiresAt = timeField(purchase, "subscription_ended_at")
+	}
+	return d
+}
+
+// lemonSqueezyProvider: {"license_key": {"key", "activation_usage", "activation_limit", "expires_at"},
+// "meta": {"customer_email", "variant_name"}}
+type lemonSqueezyProvider struct{}
+
+func (lemonSqueezyProvider) Name() string { return "lemonsqueezy" }
+
+func (lemonSqueezyProvider) Parse(details map[string]interface{}) LicenseDetails {
+	var d LicenseDetails
+	if key, ok := details["license_key"].(map[string]interface{}); ok {
+		d.LicenseKey = stringField(key, "key")
+		d.SeatsUsed = intField(key, "activation_usage")
+		d.SeatsTotal = intField(key, "activation_limit")
+		d.ExpiresAt = timeField(key, "expires_at")
+	}
+	if meta, ok := details["meta"].(map[string]interface{}); ok {
+		d.Email = stringField(meta, "customer_email")
+		d.Tier = stringField(meta, "variant_name")
+	}
+	return d
+}
+
+// flatProvider covers storefronts without native license keys (Paddle, Stripe), for which the
+// license server issues its own keys and signs a flat object:
+// {"license_key", "email", "tier", "activations", "max_activations", "expires_at"}
+type flatProvider struct{ name string }
+
+func (p flatProvider) Name() string { return p.name }
+
+func (flatProvider) Parse(details map[string]interface{}) LicenseDetails {
+	return LicenseDetails{
+		LicenseKey: stringField(details, "license_key"),
+		Email:      stringField(details, "email"),
+		Tier:       stringField(details, "tier"),
+		SeatsUsed:  intField(details, "activations"),
+		SeatsTotal: intField(details, "max_activations"),
+		ExpiresAt:  timeField(details, "expires_at"),
+	}
+}
@@ -12,23 +12,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path"
+	goruntime "runtime"
 	"strings"
 	"time"
 
 	"github.com/denisbrodbeck/machineid"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"github.com/oliwoli/hushcut/internal/i18n"
+	"github.com/oliwoli/hushcut/internal/safejson"
 )
 
 func (a *App) signalLicenseOk() {
-	log.Println("Signaling that license is now valid.")
+	licenseLog.Info("Signaling that license is now valid")
 	a.licenseValid = true
-	a.licenseOkChan <- true
-	runtime.EventsEmit(a.ctx, "license:valid", nil)
+	select {
+	case a.licenseOkChan <- true:
+	default: // a signal is already pending
+	}
+	a.emit("license:valid", nil)
 }
 
 func (a *App) waitForValidLicense() error {
@@ -40,13 +44,13 @@ func (a *App) waitForValidLicense() error {
 		return nil
 	}
 
-	log.Println("Task is waiting for License activation...")
+	licenseLog.Info("Task is waiting for license activation")
 	select {
 	case <-a.licenseOkChan:
-		log.Println("License activated. Resuming task.")
+		licenseLog.Info("License activated; resuming task")
 		return nil
 	case <-a.ctx.Done():
-		log.Println("Application is shutting down; aborting License activation.")
+		licenseLog.Info("Application is shutting down; aborting license activation")
 		return a.ctx.Err()
 	}
 }
@@ -93,16 +97,49 @@ type SignedLicenseData struct {
 	Signature string                 `json:"signature"`
 }
 
-// loadAndVerifyLocalLicense attempts to read, decode, and verify the license file.
-func (a *App) loadAndVerifyLocalLicense() (*SignedLicenseData, error) {
-	fileBytes, err := os.ReadFile(path.Join(a.userResourcesPath, "license.json"))
+// maxLicenseFileBytes bounds license files; a real one is a few hundred bytes.
+const maxLicenseFileBytes = 1 << 20
+
+// readLicenseFile reads a license file the user imports. Anything but data and a signature is
+// rejected before the signature is even looked at.
+func readLicenseFile(path string, license *SignedLicenseData) error {
+	f, err := os.Open(path)
 	if err != nil {
-		// This is not a critical error, just means no local license exists.
-		return nil, fmt.Errorf("local license file not found: %w", err)
+		return fmt.Errorf("could not read license file: %w", err)
 	}
+	defer f.Close()
+	data, err := safejson.ReadAll(f, maxLicenseFileBytes)
+	if err != nil {
+		return fmt.Errorf("could not read license file: %w", err)
+	}
+	if err := safejson.Decode(data, license, true); err != nil {
+		return fmt.Errorf("license file is not valid: %w", err)
+	}
+	if err := license.checkShape(); err != nil {
+		return fmt.Errorf("license file is not valid: %w", err)
+	}
+	return nil
+}
 
+// checkShape rejects decoded license data that has no data or no signature to verify.
+func (l *SignedLicenseData) checkShape() error {
+	if len(l.Data) == 0 || l.Signature == "" {
+		return errors.New("data or signature missing")
+	}
+	return nil
+}
+
+// loadAndVerifyLocalLicense attempts to read, decode, and verify the license file.
+func (a *App) loadAndVerifyLocalLicense() (*SignedLicenseData, error) {
 	var license SignedLicenseData
-	if err := json.Unmarshal(fileBytes, &license); err != nil {
+	if err := readJSONWithRecovery(path.Join(a.userResourcesPath, "license.json"), &license); err != nil {
+		if os.IsNotExist(err) {
+			// This is not a critical error, just means no local license exists.
+			return nil, fmt.Errorf("local license file not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to parse local license file: %w", err)
+	}
+	if err := license.checkShape(); err != nil {
 		return nil, fmt.Errorf("failed to parse local license file: %w", err)
 	}
 
@@ -111,22 +148,24 @@ func (a *App) loadAndVerifyLocalLicense() (*SignedLicenseData, error) {
 	}
 	jsonMachineID := license.Data["machine_id"]
 	if jsonMachineID == nil {
-		return nil, fmt.Errorf("no machine ID in license file %w", err)
+		return nil, fmt.Errorf("no machine ID in license file")
 	}
 	if jsonMachineID != a.machineID {
 		// Extract the license key from the local data to perform the check.
-		var licenseKey string
-		if gumroadResponse, ok := license.Data["details"].(map[string]interface{}); ok {
-			if purchase, ok := gumroadResponse["purchase"].(map[string]interface{}); ok {
-				if key, ok := purchase["license_key"].(string); ok {
-					licenseKey = key
-				}
+		licenseKey := parseLicenseDetails(license.Data).LicenseKey
+		// The hardware changed: if the license was bound to one of our previous IDs,
+		// ask the server to move it to the current one.
+		if licenseKey != "" && a.isPreviousMachineID(fmt.Sprint(jsonMachineID)) {
+			rebound, err := a.rebindLicense(licenseKey, fmt.Sprint(jsonMachineID))
+			if err == nil {
+				return rebound, nil
 			}
+			licenseLog.Warn("License re-binding failed", "err", err)
 		}
 		if licenseKey != "" {
-			runtime.EventsEmit(a.ctx, "licenseKeyMismatch", licenseKey)
+			a.emit("licenseKeyMismatch", licenseKey)
 		}
-		return nil, fmt.Errorf("machine ID does not match %w", err)
+		return nil, i18n.NewError("license.machineMismatch", nil)
 	}
 
 	return &license, nil
@@ -139,64 +178,106 @@ func (a *App) saveLocalLicense(license *SignedLicenseData) error {
 		return fmt.Errorf("failed to serialize license for saving: %w", err)
 	}
 	licenseFile := path.Join(a.userResourcesPath, "license.json")
-	return os.WriteFile(licenseFile, fileBytes, 0644)
+	return writeFileAtomic(licenseFile, fileBytes, 0644)
 }
 
+// HasAValidLicense checks the local license (online when it is stale) or checks out a
+// floating seat. A call from the frontend during startup waits for the startup check.
 func (a *App) HasAValidLicense() bool {
+	a.licenseCheckMu.Lock()
+	defer a.licenseCheckMu.Unlock()
+	if a.machineID == "" {
+		machineID, err := a.getMachineID()
+		if err != nil {
+			licenseLog.Warn("Could not retrieve machine ID", "err", err)
+		}
+		a.machineID = machineID
+	}
+	return a.checkLicense()
+}
+
+// verifyLicenseAtStartup records the result of the startup license check and announces it.
+func (a *App) verifyLicenseAtStartup() {
+	if a.HasAValidLicense() {
+		a.signalLicenseOk()
+		return
+	}
+	a.emit("license:invalid", nil)
+	licenseLog.Info("License is invalid or not found")
+}
+
+func (a *App) checkLicense() bool {
 	if a.licenseValid {
-		log.Printf("Returning saved value for license check. (%t)", a.licenseValid)
+		licenseLog.Debug("Returning saved value for license check", "valid", a.licenseValid)
 		return a.licenseValid
 	}
 
 	if a.licenseVerifyKey == nil {
-		log.Println("License check failed: public key not configured.")
+		licenseLog.Error("License check failed: public key not configured")
 		return false
 	}
 
+	// Studios with a license server check out a floating seat instead of using a local key.
+	if a.licenseServerURL() != "" {
+		if err := a.checkoutSeat(); err != nil {
+			licenseLog.Warn("Floating license checkout failed", "err", err)
+			return false
+		}
+		return true
+	}
+
 	// 1. Try to load and verify the local license.
 	localLicense, err := a.loadAndVerifyLocalLicense()
 	if err != nil {
-		log.Printf("No valid local license found: %v", err)
+		licenseLog.Info("No valid local license found", "err", err)
 		return false // No local license means no access.
 	}
 
+	// Offline licenses are never re-validated online; only their validity window counts.
+	if _, _, isOffline := offlineLicenseWindow(localLicense.Data); isOffline {
+		if err := checkOfflineLicense(localLicense.Data); err != nil {
+			licenseLog.Warn("Offline license rejected", "err", err)
+			return false
+		}
+		licenseLog.Info("Verified using offline license")
+		return true
+	}
+
 	// 2. Check if the local license is fresh enough (e.g., < 24 hours old).
 	if issuedAt, ok := localLicense.Data["issued_at"].(float64); ok {
 		issueTime := time.Unix(int64(issuedAt), 0)
-		if time.Since(issueTime) < 24*time.Hour {
-			log.Println("Verified using fresh local license.")
+		if time.Since(issueTime) < licenseFreshness {
+			licenseLog.Info("Verified using fresh local license")
 			return true // License is fresh and valid.
 		}
 	}
 
 	// 3. If stale, attempt an online re-validation.
-	log.Println("Local license is stale, attempting online re-verification.")
+	licenseLog.Info("Local license is stale, attempting online re-verification")
 
 	// Extract the license key from the local data to perform the check.
-	var licenseKey string
-	if gumroadResponse, ok := localLicense.Data["details"].(map[string]interface{}); ok {
-		if purchase, ok := gumroadResponse["purchase"].(map[string]interface{}); ok {
-			if key, ok := purchase["license_key"].(string); ok {
-				licenseKey = key
-			}
-		}
-	}
+	licenseKey := parseLicenseDetails(localLicense.Data).LicenseKey
 
 	if licenseKey == "" {
-		log.Println("Could not extract license key from stale local file. Access denied.")
+		licenseLog.Warn("Could not extract license key from stale local file; access denied")
 		return false // Can't re-verify without the key.
 	}
 
+	if err := a.requireOnline("licenseCheck"); err != nil {
+		licenseLog.Info("Offline: granting access based on stale license")
+		return true
+	}
+
 	// Use the public verification function to re-validate.
 	_, err = a.VerifyLicense(licenseKey)
 	if err != nil {
-		log.Printf("Online re-verification failed: %v. Granting access based on stale license (offline mode).", err)
+		licenseLog.Warn("Online re-verification failed; granting access based on stale license", "err", err)
 		// The re-validation failed (e.g., offline), but since a valid (though stale)
 		// license exists, we can grant access in a grace period.
 		return true
 	}
 
-	log.Println("Online re-verification successful.")
+	licenseLog.Info("Online re-verification successful")
 	return true
 }
 
@@ -204,7 +285,7 @@ func (a *App) HasAValidLicense() bool {
 // It requires an internet connection and returns the verified license data or an error.
 func (a *App) VerifyLicense(licenseKey string) (map[string]interface{}, error) {
 	if licenseKey == "" {
-		return nil, errors.New("license key cannot be empty")
+		return nil, i18n.NewError("license.emptyKey", nil)
 	}
 	// 1. Perform online verification.
 	verifyURL := "https://api.hushcut.app/verify_license"
@@ -217,7 +298,7 @@ func (a *App) VerifyLicense(licenseKey string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("could not retrieve Device ID")
 	}
 
-	log.Printf("Device ID: %s", machineID)
+	licenseLog.Debug("Verifying license", "deviceId", machineID)
 
 	reqBody, err := json.Marshal(map[string]string{
 		"license_key": licenseKey,
@@ -227,16 +308,16 @@ func (a *App) VerifyLicense(licenseKey string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("internal error creating request: %w", err)
 	}
 
-	resp, err := http.Post(verifyURL, "application/json", bytes.NewBuffer(reqBody))
+	resp, err := a.externalHTTPClient(30*time.Second).Post(verifyURL, "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to verification server; please check your internet connection and try again")
+		return nil, i18n.Wrap(err, "license.serverUnreachable", nil)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		// read the http error header
 		body, _ := io.ReadAll(resp.Body)
-		log.Println("Body:", string(body))
+		licenseLog.Warn("License server rejected verification", "status", resp.Status, "body", string(body))
 		returnMessage := string(body)
 		if returnMessage == "" {
 			returnMessage = fmt.Sprintf("license key is invalid or server returned an error (status: %s)", resp.Status)
@@ -244,13 +325,16 @@ func (a *App) VerifyLicense(licenseKey string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("%s", returnMessage)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := safejson.ReadAll(resp.Body, maxLicenseFileBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read server response: %w", err)
 	}
 
 	var newLicense SignedLicenseData
-	if err := json.Unmarshal(body, &newLicense); err != nil {
+	if err := safejson.Decode(body, &newLicense, false); err != nil {
+		return nil, fmt.Errorf("failed to parse server response: %w", err)
+	}
+	if err := newLicense.checkShape(); err != nil {
 		return nil, fmt.Errorf("failed to parse server response: %w", err)
 	}
 
@@ -262,29 +346,27 @@ func (a *App) VerifyLicense(licenseKey string) (map[string]interface{}, error) {
 	// 3. Save the newly verified license data locally for future checks.
 	if err := a.saveLocalLicense(&newLicense); err != nil {
 		// This is not a fatal error for the current check, but we should log it.
-		log.Printf("Warning: failed to save updated license file: %v", err)
+		licenseLog.Warn("Failed to save updated license file", "err", err)
 	}
 
-	log.Println("Successfully verified and saved license online.")
+	licenseLog.Info("Verified and saved license online")
 	a.signalLicenseOk() // Signal that the license is now valid.
 	return newLicense.Data, nil
 }
 
-// getMachineID retrieves a platform-specific unique machine identifier.
+// osMachineID retrieves the platform-specific machine GUID.
 // Works on Windows, macOS, and Linux.
-func (a *App) getMachineID() (string, error) {
+func (a *App) osMachineID() (string, error) {
 	machineID, err := machineid.ID()
 	if err != nil {
-		log.Println("Error getting machine ID:\n", err)
+		licenseLog.Warn("Error getting machine ID", "err", err)
 	} else {
-		log.Println("detected machine ID: ", machineID)
+		licenseLog.Debug("Detected machine ID", "machineId", machineID)
 		return machineID, nil
 	}
 
 	// fallback
-	env := runtime.Environment(a.ctx)
-
-	switch env.Platform {
+	switch goruntime.GOOS {
 	case "windows":
 		return getWindowsUUID()
 	case "darwin":
@@ -292,7 +374,7 @@ func (a *App) getMachineID() (string, error) {
 	case "linux":
 		return getLinuxMachineID()
 	default:
-		return "", fmt.Errorf("unsupported platform: %s", env.Platform)
+		return "", fmt.Errorf("unsupported platform: %s", goruntime.GOOS)
 	}
 }
 
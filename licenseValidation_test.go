@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func FuzzLicenseFile(f *testing.F) {
+	for _, seed := range []string{
+		`{"data":{"machine_id":"m","license_key":"k"},"signature":"c2lnbmF0dXJl"}`,
+		`{"data":{},"signature":""}`,
+		`{"data":{"machine_id":"m"},"signature":"not base64!"}`,
+		`{"data":{"machine_id":"m"},"signature":"c2ln","extra":1}`,
+		`{"data":null}`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		f.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	verifyKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "license.json")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		var imported SignedLicenseData
+		if readLicenseFile(path, &imported) == nil && imported.checkShape() != nil {
+			t.Fatal("readLicenseFile accepted a license without data or signature")
+		}
+
+		// Nothing the fuzzer produces carries a valid signature.
+		app := &App{userResourcesPath: dir, licenseVerifyKey: verifyKey, machineID: "m"}
+		if license, err := app.loadAndVerifyLocalLicense(); err == nil {
+			t.Fatalf("unsigned license accepted: %+v", license)
+		}
+	})
+}
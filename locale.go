@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// User-facing text from the Go side goes out as English plus a message key (internal/i18n);
+// the frontend picks the language and translates. The frontend also tells the backend the
+// language it settled on (SetLocale), so what Go writes for the user itself, the processing
+// reports, formats numbers and times to match. Without a frontend (the CLI), the "language"
+// setting or the system locale decide.
+
+var uiLocale struct {
+	sync.Mutex
+	tag string
+}
+
+// SetLocale records the language the frontend displays, e.g. "de-DE".
+func (a *App) SetLocale(tag string) {
+	uiLocale.Lock()
+	uiLocale.tag = strings.TrimSpace(tag)
+	uiLocale.Unlock()
+}
+
+// reportLocale is the locale the processing reports are formatted for.
+func (a *App) reportLocale() i18n.Locale {
+	uiLocale.Lock()
+	tag := uiLocale.tag
+	uiLocale.Unlock()
+	if tag == "" {
+		if settings, err := a.GetSettings(); err == nil {
+			tag = settingString(settings, "language", "")
+		}
+	}
+	if tag == "" || tag == "auto" {
+		tag = systemLanguage()
+	}
+	return i18n.LookupLocale(tag)
+}
+
+// systemLanguage reads the POSIX locale variables, e.g. "de_DE.UTF-8".
+func systemLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" && value != "C" && value != "POSIX" {
+			return value
+		}
+	}
+	return ""
+}
+
+// showAlert opens the frontend's alert dialog with localizable title and message.
+func (a *App) showAlert(severity, titleKey, messageKey string, params i18n.Params, markdown string) {
+	a.emit("showAlert", AlertPayload{
+		Title:      i18n.English(titleKey, params),
+		Message:    i18n.English(messageKey, params),
+		Markdown:   markdown,
+		Severity:   severity,
+		TitleKey:   titleKey,
+		MessageKey: messageKey,
+		Params:     params,
+	})
+}
+
+// emitBackendStatus sends "pythonStatusUpdate" with a localizable explanation.
+func (a *App) emitBackendStatus(ready bool, message i18n.Message) {
+	a.emit("pythonStatusUpdate", map[string]interface{}{
+		"isReady":    ready,
+		"message":    message.Text,
+		"messageKey": message.Key,
+		"params":     message.Params,
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// The in-app log console reads the most recent records from a ring buffer and follows new
+// ones through the "log:entry" event.
+
+const recentLogCapacity = 2000
+
+// LogEntry is one log record as shown in the log console.
+type LogEntry struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Subsystem string            `json:"subsystem"`
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+type logRing struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+	// emit forwards entries to the frontend once the app has started.
+	emit func(LogEntry)
+}
+
+var recentLogs = &logRing{entries: make([]LogEntry, recentLogCapacity)}
+
+func (r *logRing) add(entry LogEntry) {
+	r.mu.Lock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	emit := r.emit
+	r.mu.Unlock()
+
+	if emit != nil {
+		emit(entry)
+	}
+}
+
+// last returns up to n entries at or above minLevel, oldest first.
+func (r *logRing) last(n int, minLevel slog.Level) []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+	result := []LogEntry{}
+	for i := 1; i <= count && len(result) < n; i++ {
+		entry := r.entries[(r.next-i+len(r.entries))%len(r.entries)]
+		var lvl slog.Level
+		if lvl.UnmarshalText([]byte(entry.Level)) == nil && lvl < minLevel {
+			continue
+		}
+		result = append(result, entry)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+func (r *logRing) setEmitter(emit func(LogEntry)) {
+	r.mu.Lock()
+	r.emit = emit
+	r.mu.Unlock()
+}
+
+func newLogEntry(subsystem string, rec slog.Record) LogEntry {
+	entry := LogEntry{
+		Time:      rec.Time,
+		Level:     rec.Level.String(),
+		Subsystem: subsystem,
+		Message:   rec.Message,
+	}
+	rec.Attrs(func(attr slog.Attr) bool {
+		if entry.Attrs == nil {
+			entry.Attrs = make(map[string]string, rec.NumAttrs())
+		}
+		entry.Attrs[attr.Key] = fmt.Sprint(attr.Value.Resolve().Any())
+		return true
+	})
+	return entry
+}
+
+// startLogStream forwards new log records to the frontend as "log:entry" events.
+func (a *App) startLogStream() {
+	recentLogs.setEmitter(func(entry LogEntry) {
+		a.emit("log:entry", entry)
+	})
+}
+
+// GetRecentLogs returns up to n of the most recent log entries at or above level
+// ("debug", "info", "warn", "error"; empty means all), oldest first.
+func (a *App) GetRecentLogs(n int, level string) ([]LogEntry, error) {
+	if n <= 0 || n > recentLogCapacity {
+		n = recentLogCapacity
+	}
+	minLevel := slog.LevelDebug
+	if level != "" {
+		lvl, err := parseLogLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		minLevel = lvl
+	}
+	return recentLogs.last(n, minLevel), nil
+}
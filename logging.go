@@ -1,46 +1,285 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/oliwoli/hushcut/internal/luahelperlogic"
 )
 
-func init() {
-	goExecutablePath, err_exec := os.Executable()
-	if err_exec != nil {
-		log.Fatalf("Could not get executable path: %v", err_exec)
+// Log output goes through slog. Every record carries a "subsystem" attribute and each
+// subsystem has its own minimum level, so e.g. ipc can log at debug while the rest stays at
+// info. Plain log.Printf calls (from dependencies) are routed to the "app" subsystem at info level.
+//
+// Levels come from the "logLevel" (default for all subsystems) and "logLevels"
+// ({"ipc": "debug"}) settings. The HUSHCUT_LOG_LEVEL environment variable takes precedence
+// and uses the same syntax as a comma-separated list: "warn,ffmpeg=debug".
+
+const (
+	logApp      = "app"
+	logFFmpeg   = "ffmpeg"
+	logIPC      = "ipc"
+	logWaveform = "waveform"
+	logLicense  = "license"
+	logBackend  = "backend"
+	logUpdate   = "update"
+	logCache    = "cache"
+)
+
+var logSubsystems = []string{logApp, logFFmpeg, logIPC, logWaveform, logLicense, logBackend, logUpdate, logCache}
+
+const logLevelEnvVar = "HUSHCUT_LOG_LEVEL"
+
+// logLevelTable holds the active level of every subsystem.
+type logLevelTable struct {
+	mu           sync.RWMutex
+	defaultLevel slog.Level
+	levels       map[string]slog.Level
+}
+
+var logLevels = &logLevelTable{defaultLevel: slog.LevelInfo, levels: map[string]slog.Level{}}
+
+func (t *logLevelTable) level(subsystem string) slog.Level {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if lvl, ok := t.levels[subsystem]; ok {
+		return lvl
 	}
-	goExecutableDir := filepath.Dir(goExecutablePath)
+	return t.defaultLevel
+}
 
-	platform := runtime.GOOS
-	base := goExecutableDir
+func (t *logLevelTable) set(defaultLevel slog.Level, levels map[string]slog.Level) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaultLevel = defaultLevel
+	t.levels = levels
+}
 
-	switch platform {
-	case "windows":
-		base = filepath.Join(os.Getenv("LOCALAPPDATA"), "HushCut")
-	case "darwin":
-		home, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("failed to get home dir: %v", err)
+// subsystemHandler filters records by the level of the subsystem it was created for.
+type subsystemHandler struct {
+	inner     slog.Handler
+	subsystem string
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= logLevels.level(h.subsystem)
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	recentLogs.add(newLogEntry(h.subsystem, r))
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	subsystem := h.subsystem
+	for _, attr := range attrs {
+		if attr.Key == "subsystem" {
+			subsystem = attr.Value.String()
 		}
-		base = filepath.Join(home, "Library", "Application Support", "HushCut")
-	case "linux":
-		home, err := os.UserHomeDir()
+	}
+	return &subsystemHandler{inner: h.inner.WithAttrs(attrs), subsystem: subsystem}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{inner: h.inner.WithGroup(name), subsystem: h.subsystem}
+}
+
+// logOutput is where all log records are written; init adds log.txt to it.
+var logOutput io.Writer = os.Stdout
+
+// outputWriter defers to logOutput so loggers can be created before init has run.
+type outputWriter struct{}
+
+func (outputWriter) Write(p []byte) (int, error) { return logOutput.Write(p) }
+
+var rootLogHandler = &subsystemHandler{
+	inner:     slog.NewTextHandler(outputWriter{}, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	subsystem: logApp,
+}
+
+func newSubsystemLogger(subsystem string) *slog.Logger {
+	return slog.New(rootLogHandler).With("subsystem", subsystem)
+}
+
+var (
+	appLog      = newSubsystemLogger(logApp)
+	ffmpegLog   = newSubsystemLogger(logFFmpeg)
+	ipcLog      = newSubsystemLogger(logIPC)
+	waveformLog = newSubsystemLogger(logWaveform)
+	licenseLog  = newSubsystemLogger(logLicense)
+	backendLog  = newSubsystemLogger(logBackend) // the Python backend process
+	updateLog   = newSubsystemLogger(logUpdate)
+	cacheLog    = newSubsystemLogger(logCache) // cached files and their usage
+)
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var lvl slog.Level
+	if strings.EqualFold(strings.TrimSpace(s), "warning") {
+		return slog.LevelWarn, nil
+	}
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level '%s' (use debug, info, warn or error)", s)
+	}
+	return lvl, nil
+}
+
+func isLogSubsystem(name string) bool {
+	for _, s := range logSubsystems {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLogLevelSpec parses "warn,ffmpeg=debug" into a default level and per-subsystem levels.
+func parseLogLevelSpec(spec string, defaultLevel *slog.Level, levels map[string]slog.Level) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		subsystem, levelName, hasSubsystem := strings.Cut(part, "=")
+		if !hasSubsystem {
+			lvl, err := parseLogLevel(part)
+			if err != nil {
+				return err
+			}
+			*defaultLevel = lvl
+			continue
+		}
+		subsystem = strings.TrimSpace(subsystem)
+		if !isLogSubsystem(subsystem) {
+			return fmt.Errorf("unknown log subsystem '%s'", subsystem)
+		}
+		lvl, err := parseLogLevel(levelName)
 		if err != nil {
-			log.Fatalf("failed to get home dir: %v", err)
+			return err
 		}
-		configDir := filepath.Join(home, ".local", "state")
-		base = filepath.Join(configDir, "HushCut")
+		levels[subsystem] = lvl
 	}
+	return nil
+}
+
+// applyLogLevels sets subsystem levels from the settings, then from the environment.
+func applyLogLevels(settings map[string]any) {
+	defaultLevel := slog.LevelInfo
+	levels := map[string]slog.Level{}
 
+	if name := settingString(settings, "logLevel", ""); name != "" {
+		if lvl, err := parseLogLevel(name); err == nil {
+			defaultLevel = lvl
+		}
+	}
+	if perSubsystem, ok := settings["logLevels"].(map[string]any); ok {
+		for subsystem, raw := range perSubsystem {
+			name, _ := raw.(string)
+			if lvl, err := parseLogLevel(name); err == nil && isLogSubsystem(subsystem) {
+				levels[subsystem] = lvl
+			}
+		}
+	}
+	if spec := os.Getenv(logLevelEnvVar); spec != "" {
+		if err := parseLogLevelSpec(spec, &defaultLevel, levels); err != nil {
+			appLog.Warn("Ignoring invalid log level", "var", logLevelEnvVar, "err", err)
+		}
+	}
+	logLevels.set(defaultLevel, levels)
+}
+
+// stateDir is where log.txt and crash logs are written:
+// %LOCALAPPDATA%\HushCut, ~/Library/Application Support/HushCut or ~/.local/state/HushCut.
+// The lua-helper writes its log to the same directory.
+func stateDir() (string, error) {
+	return luahelperlogic.StateDir()
+}
+
+func init() {
+	base, err := stateDir()
+	if err != nil {
+		appLog.Error("Could not determine the state directory", "err", err)
+		os.Exit(1)
+	}
 	_ = os.MkdirAll(base, 0755)
 
-	logFile, err := os.Create(filepath.Join(base, "log.txt"))
-	if err == nil {
-		mw := io.MultiWriter(os.Stdout, logFile)
-		log.SetOutput(mw)
+	if cliSubcommand() != "" {
+		// CLI output goes to stdout; logs must neither mix with it nor clobber the app's log.txt
+		logOutput = os.Stderr
+	} else {
+		console := io.Writer(os.Stdout)
+		if serveRequested() {
+			// stdout carries the ServeInfo line a controller reads
+			console = os.Stderr
+		}
+		logOutput = console
+		if logFile, err := os.Create(filepath.Join(base, "log.txt")); err == nil {
+			logOutput = io.MultiWriter(console, logFile)
+		}
+	}
+
+	slog.SetDefault(newSubsystemLogger(logApp))
+	applyLogLevels(nil)
+}
+
+// SetLogLevel changes the level of one subsystem ("ipc", "ffmpeg", ...) or, with an empty
+// subsystem, the default for all of them. An empty level removes the override. The change
+// takes effect immediately and is saved to the settings; HUSHCUT_LOG_LEVEL still wins.
+func (a *App) SetLogLevel(subsystem string, level string) error {
+	if level != "" {
+		if _, err := parseLogLevel(level); err != nil {
+			return err
+		}
+	}
+	key := "logLevels"
+	if subsystem == "" {
+		key = "logLevel"
+	} else if !isLogSubsystem(subsystem) {
+		return fmt.Errorf("unknown log subsystem '%s'", subsystem)
+	}
+	if a.policy.isLocked(key) {
+		return fmt.Errorf("log levels are managed by your administrator")
+	}
+
+	var value any
+	if subsystem == "" {
+		value = strings.ToLower(level)
+	} else {
+		perSubsystem, _ := a.readUserSettingsFile()["logLevels"].(map[string]any)
+		if perSubsystem == nil {
+			perSubsystem = map[string]any{}
+		}
+		if level == "" {
+			delete(perSubsystem, subsystem)
+		} else {
+			perSubsystem[subsystem] = strings.ToLower(level)
+		}
+		value = perSubsystem
+	}
+	if err := a.updateSetting(key, value); err != nil {
+		return err
+	}
+
+	settings, err := a.GetSettings()
+	if err != nil {
+		return err
+	}
+	applyLogLevels(settings)
+	appLog.Info("Log level set", "subsystem", cmp.Or(subsystem, "all"), "level", cmp.Or(level, "default"))
+	return nil
+}
+
+// GetLogLevels returns the effective level of every subsystem.
+func (a *App) GetLogLevels() map[string]string {
+	levels := make(map[string]string, len(logSubsystems))
+	for _, s := range logSubsystems {
+		levels[s] = strings.ToLower(logLevels.level(s).String())
 	}
+	return levels
 }
@@ -2,8 +2,10 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/oliwoli/hushcut/internal/luahelperlogic"
 )
@@ -17,9 +19,34 @@ func main() {
 	uuidStr := flag.String("uuid-from-str", "", "string to generate a deterministic UUID from")
 	luaHelper := flag.Bool("lua-helper", true, "set mode")
 	inputFile := flag.String("input-file", "", "JSON file with array of strings to batch UUID") // <-- new
+	idleTimeout := flag.Duration("idle-timeout", 0, "shut the server down after this long without requests, e.g. 30m (0 = never; env HUSHCUT_HELPER_IDLE_TIMEOUT)")
+	token := flag.String("token", "", "auth token required on all endpoints (default: env HUSHCUT_AUTH_TOKEN)")
+	tokenStdin := flag.Bool("token-stdin", false, "read the auth token from the first line of stdin")
+	stdio := flag.Bool("stdio", false, "serve line-delimited JSON-RPC on stdin/stdout instead of HTTP")
+	uuidOptions := luahelperlogic.RegisterUUIDFlags(flag.CommandLine)
+	discover := flag.Bool("discover", false, "print the port, token and pid of the running HushCut instance as JSON")
+	launchOrFocus := flag.Bool("launch-or-focus", false, "focus the running HushCut, or start it if none is running; arguments after the flags are passed to the app")
+	appPath := flag.String("app", "", "HushCut executable for --launch-or-focus (default: env HUSHCUT_APP_PATH or next to the helper)")
+	resolveContext := flag.String("context", "", "JSON object with the current Resolve context for --launch-or-focus (default: stdin)")
+	hashAlgo := flag.String("hash", "", "print the sha256, md5, xxh64 or fingerprint digest of the file given as argument, or of each path in the input")
 
 	flag.Parse()
 
+	uuidOpts, err := uuidOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *discover {
+		os.Exit(luahelperlogic.Discover())
+	}
+
+	if *stdio {
+		luahelperlogic.ServeStdio(os.Stdin, os.Stdout)
+		return
+	}
+
 	var pipeContent string
 	if *inputFile != "" {
 		data, err := os.ReadFile(*inputFile)
@@ -38,10 +65,26 @@ func main() {
 		}
 	}
 
+	var serverOpts luahelperlogic.ServerOptions
+	serverOpts.Token, pipeContent = luahelperlogic.ResolveToken(*token, *tokenStdin, pipeContent)
+	serverOpts.IdleTimeout = *idleTimeout
+
+	if *launchOrFocus {
+		ctx := *resolveContext
+		if ctx == "" {
+			ctx = strings.TrimSpace(pipeContent)
+		}
+		os.Exit(luahelperlogic.LaunchOrFocus(*appPath, flag.Args(), ctx))
+	}
+
+	if *hashAlgo != "" {
+		os.Exit(luahelperlogic.Hash(*hashAlgo, flag.Args(), pipeContent))
+	}
+
 	if *luaHelper {
 		// nothing
 	}
 
 	// Call the shared logic with pipeContent
-	luahelperlogic.Start(*port, *findPort, *uuidCount, *uuidStr, pipeContent)
+	luahelperlogic.Start(*port, *findPort, *uuidCount, *uuidStr, pipeContent, uuidOpts, serverOpts)
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	deviceIdentityFileName = "device_id.json"
+	maxPreviousMachineIDs  = 5
+)
+
+// DeviceIdentity is the persisted machine ID that licenses are bound to.
+//
+// The ID comes from the first source that works: the OS machine GUID, a hash of the
+// network adapters' MAC addresses, or a random ID. Once stored, the ID is kept as long
+// as at least one of the recorded components still matches, so replacing a network
+// card or reinstalling a driver doesn't invalidate the license. When nothing matches
+// anymore, a new ID is created and the old one is kept in Previous so the license
+// can be re-bound on the server.
+type DeviceIdentity struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"` // "os", "mac" or "random"
+	OSHash    string    `json:"osHash,omitempty"`
+	MACHash   string    `json:"macHash,omitempty"`
+	Previous  []string  `json:"previous,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (a *App) getDeviceIdentityPath() string {
+	return filepath.Join(a.userResourcesPath, deviceIdentityFileName)
+}
+
+func hashComponent(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// macAddressesID joins the hardware addresses of all physical interfaces.
+func macAddressesID() string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	var macs []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		macs = append(macs, iface.HardwareAddr.String())
+	}
+	sort.Strings(macs)
+	return strings.Join(macs, ",")
+}
+
+func (id *DeviceIdentity) matches(osHash string, macHash string) bool {
+	if id.OSHash == "" && id.MACHash == "" {
+		// A random ID has nothing to compare against; it stays until deleted.
+		return id.Source == "random"
+	}
+	return (osHash != "" && osHash == id.OSHash) || (macHash != "" && macHash == id.MACHash)
+}
+
+// getMachineID returns the ID licenses are bound to, creating or rotating the
+// persisted DeviceIdentity as needed. It only fails if the identity can't be stored.
+func (a *App) getMachineID() (string, error) {
+	osID, osErr := a.osMachineID()
+	if osErr != nil {
+		licenseLog.Warn("OS machine ID unavailable", "err", osErr)
+	}
+	macID := macAddressesID()
+	osHash, macHash := hashComponent(osID), hashComponent(macID)
+
+	var stored DeviceIdentity
+	path := a.getDeviceIdentityPath()
+	if err := readJSONWithRecovery(path, &stored); err == nil && stored.ID != "" && stored.matches(osHash, macHash) {
+		// Let the recorded components follow gradual hardware changes.
+		if (osHash != "" && osHash != stored.OSHash) || (macHash != "" && macHash != stored.MACHash) {
+			if osHash != "" {
+				stored.OSHash = osHash
+			}
+			if macHash != "" {
+				stored.MACHash = macHash
+			}
+			a.saveDeviceIdentity(&stored)
+		}
+		return stored.ID, nil
+	}
+
+	next := DeviceIdentity{OSHash: osHash, MACHash: macHash, CreatedAt: time.Now()}
+	switch {
+	case osID != "":
+		// Kept verbatim so licenses activated before the identity file existed stay valid.
+		next.ID, next.Source = osID, "os"
+	case macID != "":
+		next.ID, next.Source = macHash[:32], "mac"
+	default:
+		next.ID, next.Source = uuid.NewString(), "random"
+	}
+	if stored.ID != "" && stored.ID != next.ID {
+		licenseLog.Warn("Device identity changed; the license may need to be re-bound", "from", stored.Source, "to", next.Source)
+		next.Previous = append([]string{stored.ID}, stored.Previous...)
+		if len(next.Previous) > maxPreviousMachineIDs {
+			next.Previous = next.Previous[:maxPreviousMachineIDs]
+		}
+	}
+	if err := a.saveDeviceIdentity(&next); err != nil {
+		return next.ID, fmt.Errorf("could not persist device identity: %w", err)
+	}
+	licenseLog.Info("Using device ID", "source", next.Source)
+	return next.ID, nil
+}
+
+func (a *App) saveDeviceIdentity(identity *DeviceIdentity) error {
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(a.getDeviceIdentityPath(), data, 0644)
+}
+
+// isPreviousMachineID reports whether id was this machine's ID before a hardware change.
+func (a *App) isPreviousMachineID(id string) bool {
+	var stored DeviceIdentity
+	if err := readJSONWithRecovery(a.getDeviceIdentityPath(), &stored); err != nil {
+		return false
+	}
+	return slices.Contains(stored.Previous, id)
+}
+
+// rebindLicense asks the license server to move a license from a previous machine ID
+// of this device to the current one, and stores the newly signed license.
+func (a *App) rebindLicense(licenseKey string, previousMachineID string) (*SignedLicenseData, error) {
+	rebindURL := "https://api.hushcut.app/rebind_license"
+	if a.testApi {
+		rebindURL = "http://localhost:8080/rebind_license"
+	}
+
+	reqBody, err := json.Marshal(map[string]string{
+		"license_key":         licenseKey,
+		"previous_machine_id": previousMachineID,
+		"machine_id":          a.machineID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("internal error creating request: %w", err)
+	}
+
+	resp, err := a.externalHTTPClient(30*time.Second).Post(rebindURL, "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to license server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server refused re-binding (status: %s): %s", resp.Status, string(body))
+	}
+
+	var license SignedLicenseData
+	if err := json.Unmarshal(body, &license); err != nil {
+		return nil, fmt.Errorf("failed to parse server response: %w", err)
+	}
+	if err := a.verifySignature(license.Data, license.Signature); err != nil {
+		return nil, fmt.Errorf("server response verification failed: %w", err)
+	}
+	if license.Data["machine_id"] != a.machineID {
+		return nil, fmt.Errorf("server returned a license for a different device")
+	}
+	if err := a.saveLocalLicense(&license); err != nil {
+		licenseLog.Warn("Failed to save re-bound license file", "err", err)
+	}
+	licenseLog.Info("License re-bound to the current device")
+	return &license, nil
+}
@@ -1,32 +1,19 @@
 package main
 
 import (
-	"embed"
+	_ "embed"
 	"flag"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
-	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/oliwoli/hushcut/internal/luahelperlogic"
-	"github.com/wailsapp/wails/v2"
-	"github.com/wailsapp/wails/v2/pkg/logger"
-	"github.com/wailsapp/wails/v2/pkg/options"
-	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
-	"github.com/wailsapp/wails/v2/pkg/options/linux"
-	"github.com/wailsapp/wails/v2/pkg/options/mac"
-	"github.com/wailsapp/wails/v2/pkg/options/windows"
 )
 
-//go:embed all:frontend/dist
-var assets embed.FS
-
 // SilencePeriod (from previous step)
 type SilencePeriod struct {
 	Start float64 `json:"start"`
@@ -53,48 +40,21 @@ type WaveformCacheKey struct {
 	MaxDb           float64 // maxDb is used by ProcessWavToLogarithmicPeaks
 }
 
-type FileLoader struct {
-	http.Handler
-}
-
-func NewFileLoader() *FileLoader {
-	return &FileLoader{
-		// CORRECT: Initialize the embedded handler with the default
-		// file server, telling it to serve your embedded assets.
-		Handler: http.FileServer(http.FS(assets)),
-	}
-}
-
-//go:embed build/appicon.png
-var icon []byte
-
 //go:embed secrets/public_key.pem
 var PublicKeyPEM []byte
 
-func writeCrashLog(message string) {
-	now := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("crash_%s.txt", now)
-
-	base := filepath.Join(os.Getenv("LOCALAPPDATA"), "HushCut")
-	_ = os.MkdirAll(base, 0755)
-
-	f, err := os.Create(filepath.Join(base, filename))
-	if err != nil {
-		return
-	}
-	defer f.Close()
-
-	f.WriteString(message + "\n")
-}
-
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
-			writeCrashLog(fmt.Sprintf("panic: %v", r))
+			writeCrashLog(fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack()))
 			panic(r)
 		}
 	}()
 
+	if subcommand := cliSubcommand(); subcommand != "" {
+		os.Exit(runCLI(subcommand, os.Args[2:]))
+	}
+
 	testApi := os.Getenv("TEST_API") == "1"
 
 	luaMode := flag.Bool("lua-helper", false, "start headless in lua-helper mode")
@@ -104,8 +64,38 @@ func main() {
 	uuidStr := flag.String("uuid-from-str", "", "comma-separated list of strings to generate deterministic UUIDs")
 	pythonPort := flag.Int("python-port", 0, "port python should listen on")
 	inputFile := flag.String("input-file", "", "JSON file with array of strings to batch UUID")
+	updateWatchdog := flag.String("update-watchdog", "", "supervise the first start after a self-update (internal)")
+	debugServer := flag.Bool("debug-server", false, "expose pprof and runtime metrics on the local server")
+	serve := flag.Bool("serve", false, "run without a window: only the local server, job queue and Python bridge, with the /api/ endpoints (--port sets the port)")
+	idleTimeout := flag.Duration("idle-timeout", 0, "with --lua-helper: shut the server down after this long without requests, e.g. 30m (0 = never; env HUSHCUT_HELPER_IDLE_TIMEOUT)")
+	token := flag.String("token", "", "with --lua-helper or --serve: auth token required on all endpoints (default: env HUSHCUT_AUTH_TOKEN)")
+	tokenStdin := flag.Bool("token-stdin", false, "with --lua-helper or --serve: read the auth token from the first line of stdin")
+	stdio := flag.Bool("stdio", false, "with --lua-helper: serve line-delimited JSON-RPC on stdin/stdout instead of HTTP")
+	uuidOptions := luahelperlogic.RegisterUUIDFlags(flag.CommandLine)
+	discover := flag.Bool("discover", false, "with --lua-helper: print the port, token and pid of the running HushCut instance as JSON")
+	launchOrFocus := flag.Bool("launch-or-focus", false, "with --lua-helper: focus the running HushCut, or start it if none is running; arguments after the flags are passed to the app")
+	appPath := flag.String("app", "", "with --lua-helper: HushCut executable for --launch-or-focus (default: env HUSHCUT_APP_PATH or next to the helper)")
+	resolveContext := flag.String("context", "", "with --lua-helper: JSON object with the current Resolve context for --launch-or-focus (default: stdin)")
+	hashAlgo := flag.String("hash", "", "with --lua-helper: print the sha256, md5, xxh64 or fingerprint digest of the file given as argument, or of each path in the input")
+	launchedBy := flag.String("launched-by", "", "how HushCut was started, resolve, standalone or development (default: detected; env HUSHCUT_LAUNCHED_BY)")
+	gpuPolicy := flag.String("gpu-policy", "", "Linux: webview hardware acceleration, always, ondemand or never (default: the gpuPolicy setting)")
+	displayBackend := flag.String("display-backend", "", "Linux: display server to use, auto, wayland or x11 (default: the displayBackend setting)")
 	flag.Parse()
 
+	if *updateWatchdog != "" {
+		runUpdateWatchdog(*updateWatchdog)
+		return
+	}
+
+	if *luaMode && *discover {
+		os.Exit(luahelperlogic.Discover())
+	}
+
+	if *luaMode && *stdio {
+		luahelperlogic.ServeStdio(os.Stdin, os.Stdout)
+		return
+	}
+
 	var pipeContent string
 	if *inputFile != "" {
 		data, err := os.ReadFile(*inputFile)
@@ -125,7 +115,28 @@ func main() {
 	}
 
 	if *luaMode {
-		luahelperlogic.Start(*port, *findPort, *uuidCount, *uuidStr, pipeContent)
+		luahelperlogic.Version = AppVersion
+		serverOpts := luahelperlogic.ServerOptions{IdleTimeout: *idleTimeout}
+		serverOpts.Token, pipeContent = luahelperlogic.ResolveToken(*token, *tokenStdin, pipeContent)
+
+		if *launchOrFocus {
+			ctx := *resolveContext
+			if ctx == "" {
+				ctx = strings.TrimSpace(pipeContent)
+			}
+			os.Exit(luahelperlogic.LaunchOrFocus(*appPath, flag.Args(), ctx))
+		}
+
+		if *hashAlgo != "" {
+			os.Exit(luahelperlogic.Hash(*hashAlgo, flag.Args(), pipeContent))
+		}
+
+		uuidOpts, err := uuidOptions()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		luahelperlogic.Start(*port, *findPort, *uuidCount, *uuidStr, pipeContent, uuidOpts, serverOpts)
 		return // Exit after running in helper mode
 	}
 
@@ -136,59 +147,58 @@ func main() {
 		app.pythonCommandPort = *pythonPort
 	}
 	app.testApi = testApi
+	app.debugServer = *debugServer
 
 	if token := os.Getenv("HUSHCUT_AUTH_TOKEN"); token != "" {
-		log.Printf("Received HushCut Token from environment variable.")
+		appLog.Info("Received HushCut token from environment variable")
 		app.authToken = strings.TrimSpace(token)
+		// Nothing HushCut starts, ffmpeg and the Python backend included, inherits it.
+		os.Unsetenv("HUSHCUT_AUTH_TOKEN")
 	} else {
-		log.Printf("No HUSHCUT_AUTH_TOKEN provided in environment.")
+		appLog.Info("No HUSHCUT_AUTH_TOKEN provided in environment")
 	}
 
 	// Check for WAILS_PYTHON_PORT environment variable (used when launched by Python in dev mode)
 	if pythonPortStr := os.Getenv("WAILS_PYTHON_PORT"); pythonPortStr != "" {
 		if p, err := strconv.Atoi(pythonPortStr); err == nil {
 			app.pythonCommandPort = p
-			log.Printf("Go App: Received Python port from environment variable: %d", app.pythonCommandPort)
+			backendLog.Info("Python port set by environment", "var", "WAILS_PYTHON_PORT", "port", app.pythonCommandPort)
 		} else {
-			log.Printf("Go App: Could not parse WAILS_PYTHON_PORT environment variable: %v", err)
+			backendLog.Warn("Could not parse WAILS_PYTHON_PORT", "err", err)
+		}
+	}
+
+	if err := validLaunchMode(*launchedBy); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	app.launch = detectLaunchContext(*launchedBy, app.pythonCommandPort)
+
+	if *serve {
+		if t, _ := luahelperlogic.ResolveToken(*token, *tokenStdin, pipeContent); t != "" {
+			app.authToken = t
+		}
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "port" {
+				app.serverPort = *port
+			}
+		})
+		os.Exit(runServe(app))
+	}
+
+	for _, err := range []error{
+		validateDisplayFlag("gpu-policy", *gpuPolicy, gpuPolicies),
+		validateDisplayFlag("display-backend", *displayBackend, displayBackends),
+	} {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
 		}
 	}
+	app.displayFlags = displayOptions{GPUPolicy: *gpuPolicy, Backend: *displayBackend}
 
-	// Create application with options
-	err := wails.Run(&options.App{
-		Title:     "HushCut",
-		Width:     1024,
-		Height:    801,
-		MinWidth:  500,
-		MinHeight: 550,
-		AssetServer: &assetserver.Options{
-			Assets:  assets,
-			Handler: NewFileLoader(),
-		},
-		BackgroundColour: &options.RGBA{R: 40, G: 40, B: 46, A: 1},
-		OnStartup:        app.startup,
-		OnShutdown:       app.shutdown,
-		Bind: []interface{}{
-			app,
-		},
-		LogLevel:    logger.INFO,
-		AlwaysOnTop: true,
-		Frameless:   true,
-		Mac: &mac.Options{
-			WebviewIsTransparent: true,
-		},
-		Windows: &windows.Options{
-			WebviewIsTransparent: true,
-		},
-		Linux: &linux.Options{
-			Icon:                icon,
-			WindowIsTranslucent: false,
-			WebviewGpuPolicy:    linux.WebviewGpuPolicyNever,
-			ProgramName:         "HushCut",
-		},
-	})
-
-	if err != nil {
-		log.Println("Error:", err.Error())
+	if err := runGUI(app); err != nil {
+		appLog.Error("HushCut exited with an error", "err", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The media endpoints (WAV files, render_clip and the previews) don't take the session token,
+// which every local process that reads the discovery file has. Their URLs are signed instead:
+// "exp" is when the URL stops working and "sig" an HMAC of the path, the query and exp, keyed
+// with a secret derived from the session token that never leaves the process.
+
+const mediaURLLifetime = 15 * time.Minute
+
+var mediaSigning struct {
+	once sync.Once
+	key  []byte
+}
+
+func (a *App) mediaSigningKey() []byte {
+	mediaSigning.once.Do(func() {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			panic("crypto/rand failed: " + err.Error())
+		}
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(a.authToken))
+		mediaSigning.key = mac.Sum(nil)
+	})
+	return mediaSigning.key
+}
+
+// mediaSignature signs path with query, which must not hold "sig".
+func (a *App) mediaSignature(path string, query url.Values) string {
+	mac := hmac.New(sha256.New, a.mediaSigningKey())
+	mac.Write([]byte(path + "?" + query.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signMediaPath signs a server-relative media URL such as "/render_clip?file=...".
+func (a *App) signMediaPath(path string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Del("token")
+	query.Del("sig")
+	query.Set("exp", strconv.FormatInt(time.Now().Add(mediaURLLifetime).Unix(), 10))
+	query.Set("sig", a.mediaSignature(u.Path, query))
+	return u.Path + "?" + query.Encode(), nil
+}
+
+// SignMediaURL signs a URL of a media endpoint for the frontend, absolute or relative to the
+// server. It stays valid for mediaURLLifetime.
+func (a *App) SignMediaURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	signed, err := a.signMediaPath(u.RequestURI())
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return signed, nil
+	}
+	return u.Scheme + "://" + u.Host + signed, nil
+}
+
+var (
+	errMediaURLUnsigned = errors.New("unsigned media URL")
+	errMediaURLExpired  = errors.New("media URL expired")
+	errMediaURLInvalid  = errors.New("invalid media URL signature")
+)
+
+func (a *App) verifyMediaURL(r *http.Request) error {
+	query := r.URL.Query()
+	sig, exp := query.Get("sig"), query.Get("exp")
+	if sig == "" || exp == "" {
+		return errMediaURLUnsigned
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return errMediaURLInvalid
+	}
+	if time.Now().Unix() > expires {
+		return errMediaURLExpired
+	}
+	query.Del("sig")
+	query.Del("token")
+	if !hmac.Equal([]byte(sig), []byte(a.mediaSignature(r.URL.Path, query))) {
+		return errMediaURLInvalid
+	}
+	return nil
+}
+
+// mediaMiddleware is commonMiddleware for the media endpoints, which take a signed URL instead
+// of the session token.
+func (a *App) mediaMiddleware(next http.Handler) http.HandlerFunc {
+	return a.commonMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.verifyMediaURL(r); err != nil {
+			ipcLog.Warn("Media request rejected", "path", r.URL.Path, "err", err)
+			http.Error(w, "Unauthorized - "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), false)
+}
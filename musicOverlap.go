@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Shows with a continuous music bed under the dialog can't lose a dialog silence without the
+// music jumping at the cut. The music overlap analysis measures the loudness of the music
+// tracks (with ebur128, like highlight detection) and checks every dialog silence: if a music
+// track plays on both sides of it, removing it makes the music jump. Those cuts are reported
+// and, if asked, disabled by dropping them from the clip's silences in the timeline session,
+// the same place manual adjustments are kept.
+
+// defaultMusicActivityLUFS is the momentary loudness above which music counts as playing.
+const defaultMusicActivityLUFS = -50.0
+
+// MusicOverlapParams selects the music tracks of AnalyzeMusicOverlap; all other audio tracks
+// are dialog.
+type MusicOverlapParams struct {
+	MusicTracks []int `json:"musicTracks"` // audio track indices, 1-based like Resolve's
+	// ActivityThresholdLUFS is the loudness above which music counts as playing; zero uses
+	// the default.
+	ActivityThresholdLUFS float64 `json:"activityThresholdLUFS"`
+	// DisableCuts keeps the reported silences from being cut.
+	DisableCuts bool `json:"disableCuts"`
+}
+
+// MusicJump is a dialog silence whose removal would make a music track jump.
+type MusicJump struct {
+	ClipID   string `json:"clipId"`
+	ClipName string `json:"clipName"`
+	// Start and End are seconds in the clip's WAV, like SilencePeriod.
+	Start              float64 `json:"start"`
+	End                float64 `json:"end"`
+	TimelineStartFrame float64 `json:"timelineStartFrame"`
+	TimelineEndFrame   float64 `json:"timelineEndFrame"`
+	MusicTrack         int     `json:"musicTrack"`
+	MusicLUFS          float64 `json:"musicLUFS"` // the quieter side of the cut
+	Disabled           bool    `json:"disabled"`
+}
+
+// MusicOverlapReport is the result of AnalyzeMusicOverlap, also sent as "musicOverlap:analyzed".
+type MusicOverlapReport struct {
+	Params       MusicOverlapParams `json:"params"`
+	CutsChecked  int                `json:"cutsChecked"`
+	CutsDisabled int                `json:"cutsDisabled"`
+	Jumps        []MusicJump        `json:"jumps"`            // in timeline order
+	Failed       map[string]string  `json:"failed,omitempty"` // errors by clip ID
+}
+
+// MusicOverlapProgress is sent as "musicOverlap:progress" while the music is measured.
+type MusicOverlapProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// musicClip is the loudness of a music clip, with the frames' times in timeline frames.
+type musicClip struct {
+	item   TimelineItem
+	frames []loudnessFrame
+}
+
+// musicLevelAt returns the momentary loudness of the clip of track playing at timeline frame,
+// if any.
+func musicLevelAt(track []musicClip, frame float64) (float64, bool) {
+	for _, clip := range track {
+		if frame < clip.item.StartFrame || frame > clip.item.EndFrame || len(clip.frames) == 0 {
+			continue
+		}
+		// Each measurement covers the momentary window before it.
+		i := sort.Search(len(clip.frames), func(i int) bool { return clip.frames[i].Time >= frame })
+		if i == len(clip.frames) {
+			i--
+		}
+		return clip.frames[i].Momentary, true
+	}
+	return 0, false
+}
+
+// findMusicJump checks the cut of timeline frames [start, end] against the music tracks and
+// returns the track that plays on both sides of it, with the quieter side's loudness.
+func findMusicJump(tracks map[int][]musicClip, start, end, thresholdLUFS float64) (track int, lufs float64, found bool) {
+	indices := make([]int, 0, len(tracks))
+	for index := range tracks {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		before, okBefore := musicLevelAt(tracks[index], start)
+		after, okAfter := musicLevelAt(tracks[index], end)
+		if okBefore && okAfter && before > thresholdLUFS && after > thresholdLUFS {
+			return index, math.Min(before, after), true
+		}
+	}
+	return 0, 0, false
+}
+
+// AnalyzeMusicOverlap reports the dialog silences of the synced timeline whose removal would
+// make the music jump, and with params.DisableCuts keeps them from being cut.
+func (a *App) AnalyzeMusicOverlap(params MusicOverlapParams) (*MusicOverlapReport, error) {
+	if len(params.MusicTracks) == 0 {
+		return nil, fmt.Errorf("select the audio tracks that hold the music")
+	}
+	if params.ActivityThresholdLUFS == 0 {
+		params.ActivityThresholdLUFS = defaultMusicActivityLUFS
+	}
+
+	a.mu.Lock()
+	if a.currentProject == nil {
+		a.mu.Unlock()
+		return nil, fmt.Errorf("no project has been synced yet")
+	}
+	fps := a.currentProject.Timeline.FPS
+	items := append([]TimelineItem(nil), a.currentProject.Timeline.AudioTrackItems...)
+	a.mu.Unlock()
+	if fps <= floatEpsilon {
+		return nil, fmt.Errorf("the synced timeline has no frame rate")
+	}
+	session, err := a.currentTimelineSession()
+	if err != nil {
+		return nil, err
+	}
+
+	var music, dialog []TimelineItem
+	for _, item := range items {
+		if item.ProcessedFileName == nil || *item.ProcessedFileName == "" {
+			continue
+		}
+		if slices.Contains(params.MusicTracks, item.TrackIndex) {
+			music = append(music, item)
+		} else if !slices.Contains(session.BypassedClips, item.ID) {
+			dialog = append(dialog, item)
+		}
+	}
+	if len(music) == 0 {
+		return nil, fmt.Errorf("the music tracks have no clips")
+	}
+
+	report := &MusicOverlapReport{Params: params, Jumps: []MusicJump{}, Failed: map[string]string{}}
+	tracks := map[int][]musicClip{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	a.emit("musicOverlap:progress", MusicOverlapProgress{Done: 0, Total: len(music)})
+	for _, item := range music {
+		item := item
+		wg.Add(1)
+		go saferun(func() {
+			defer wg.Done()
+			frames, err := a.measureMusicClip(item, fps)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				appLog.Warn("AnalyzeMusicOverlap: clip failed", "clip", item.Name, "err", err)
+				report.Failed[item.ID] = err.Error()
+			} else {
+				tracks[item.TrackIndex] = append(tracks[item.TrackIndex], musicClip{item: item, frames: frames})
+			}
+			done++
+			a.emit("musicOverlap:progress", MusicOverlapProgress{Done: done, Total: len(music)})
+		})
+	}
+	wg.Wait()
+
+	disabled := false
+	for i := range dialog {
+		item := &dialog[i]
+		silences, err := a.clipSilences(item, fps, session)
+		if err != nil {
+			report.Failed[item.ID] = err.Error()
+			continue
+		}
+		startSeconds := item.SourceStartFrame / fps
+		kept := make([]SilencePeriod, 0, len(silences))
+		for _, silence := range silences {
+			report.CutsChecked++
+			start := item.StartFrame + (silence.Start-startSeconds)*fps
+			end := item.StartFrame + (silence.End-startSeconds)*fps
+			track, lufs, found := findMusicJump(tracks, start, end, params.ActivityThresholdLUFS)
+			if !found {
+				kept = append(kept, silence)
+				continue
+			}
+			report.Jumps = append(report.Jumps, MusicJump{
+				ClipID: item.ID, ClipName: item.Name, Start: silence.Start, End: silence.End,
+				TimelineStartFrame: math.Round(start), TimelineEndFrame: math.Round(end),
+				MusicTrack: track, MusicLUFS: lufs, Disabled: params.DisableCuts,
+			})
+		}
+		if params.DisableCuts && len(kept) < len(silences) {
+			session.SilenceAdjustments[item.ID] = kept
+			report.CutsDisabled += len(silences) - len(kept)
+			disabled = true
+		}
+	}
+	if disabled {
+		if err := a.SaveTimelineSession(*session); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(report.Jumps, func(i, j int) bool {
+		return report.Jumps[i].TimelineStartFrame < report.Jumps[j].TimelineStartFrame
+	})
+
+	appLog.Info("AnalyzeMusicOverlap done", "jumps", len(report.Jumps), "checked", report.CutsChecked, "disabled", report.CutsDisabled)
+	a.emit("musicOverlap:analyzed", report)
+	return report, nil
+}
+
+// measureMusicClip measures the loudness of a music clip, with the times in timeline frames.
+func (a *App) measureMusicClip(item TimelineItem, fps float64) ([]loudnessFrame, error) {
+	fileName := filepath.Base(*item.ProcessedFileName)
+	startSeconds := item.SourceStartFrame / fps
+	endSeconds := item.SourceEndFrame / fps
+	if endSeconds <= startSeconds {
+		return nil, nil
+	}
+	release := a.acquireFileRefs(filepath.Join(a.tmpPath, fileName))
+	defer release()
+
+	frames, err := a.measureLoudness("analyzeMusicOverlap", fileName, startSeconds, endSeconds)
+	if err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		frames[i].Time = item.StartFrame + (frames[i].Time-startSeconds)*fps
+	}
+	return frames, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Network-dependent features (update checks, license re-verification, ffmpeg downloads) ask
+// requireOnline first instead of running into their own timeouts and retries. HushCut is
+// offline when the "offlineMode" setting is on or when the API host cannot be reached. The
+// UI receives one "network:offline" event per offline period and "network:online" once the
+// connection is back.
+
+var errOffline = errors.New("HushCut is offline")
+
+const connectivityCacheDuration = 30 * time.Second
+
+// NetworkStatus is the payload of "network:offline" / "network:online" and GetNetworkStatus.
+type NetworkStatus struct {
+	Online bool `json:"online"`
+	// Reason is "offlineMode" when the user chose to work offline, "unreachable" otherwise.
+	Reason string `json:"reason,omitempty"`
+	// Skipped lists features that were skipped while offline, e.g. "updateCheck".
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type connectivityState struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	status    NetworkStatus
+	announced bool
+}
+
+func (a *App) apiBaseURL() string {
+	if a.testApi {
+		return "http://localhost:8080"
+	}
+	return "https://api.hushcut.app"
+}
+
+// probeConnectivity dials the API host (or the proxy in front of it) without sending a request.
+func (a *App) probeConnectivity() bool {
+	req, err := http.NewRequest(http.MethodHead, a.apiBaseURL(), nil)
+	if err != nil {
+		return false
+	}
+	target := req.URL.Host
+	if req.URL.Port() == "" {
+		target = net.JoinHostPort(req.URL.Hostname(), "443")
+	}
+	if proxy, err := a.proxyForRequest(req); err == nil && proxy != nil {
+		target = proxy.Host
+		if proxy.Port() == "" {
+			target = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", target, 3*time.Second)
+	if err != nil {
+		appLog.Info("Connectivity check failed", "target", target, "err", err)
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// networkStatus returns the cached connectivity state, probing again when it is stale.
+func (a *App) networkStatus() NetworkStatus {
+	a.connectivity.mu.Lock()
+	defer a.connectivity.mu.Unlock()
+
+	settings, _ := a.GetSettings()
+	if settingBool(settings, "offlineMode", false) {
+		a.setNetworkStatusLocked(NetworkStatus{Online: false, Reason: "offlineMode"})
+		return a.connectivity.status
+	}
+	if time.Since(a.connectivity.checkedAt) > connectivityCacheDuration || a.connectivity.status.Reason == "offlineMode" {
+		if a.probeConnectivity() {
+			a.setNetworkStatusLocked(NetworkStatus{Online: true})
+		} else {
+			a.setNetworkStatusLocked(NetworkStatus{Online: false, Reason: "unreachable"})
+		}
+	}
+	return a.connectivity.status
+}
+
+func (a *App) setNetworkStatusLocked(status NetworkStatus) {
+	previous := a.connectivity.status
+	a.connectivity.checkedAt = time.Now()
+	if status.Online {
+		a.connectivity.status = status
+		if a.connectivity.announced {
+			a.connectivity.announced = false
+			appLog.Info("Network connection is available again")
+			a.emit("network:online", status)
+		}
+		return
+	}
+	if previous.Online || previous.Reason != status.Reason {
+		a.connectivity.announced = false
+	} else {
+		status.Skipped = previous.Skipped
+	}
+	a.connectivity.status = status
+}
+
+// requireOnline reports errOffline when the network is unavailable and records the skipped
+// feature. The "network:offline" event is emitted once per offline period, shortly after the
+// first feature has been skipped so that features skipped together are reported together.
+func (a *App) requireOnline(feature string) error {
+	if a.networkStatus().Online {
+		return nil
+	}
+
+	a.connectivity.mu.Lock()
+	defer a.connectivity.mu.Unlock()
+	for _, f := range a.connectivity.status.Skipped {
+		if f == feature {
+			return errOffline
+		}
+	}
+	a.connectivity.status.Skipped = append(a.connectivity.status.Skipped, feature)
+	appLog.Info("Offline; skipping feature", "reason", a.connectivity.status.Reason, "feature", feature)
+
+	if !a.connectivity.announced {
+		a.connectivity.announced = true
+		time.AfterFunc(2*time.Second, func() {
+			a.connectivity.mu.Lock()
+			status := a.connectivity.status
+			status.Skipped = append([]string(nil), status.Skipped...)
+			a.connectivity.mu.Unlock()
+			if !status.Online {
+				a.emit("network:offline", status)
+			}
+		})
+	}
+	return errOffline
+}
+
+// GetNetworkStatus lets the UI show the offline banner without waiting for an event.
+func (a *App) GetNetworkStatus() NetworkStatus {
+	return a.networkStatus()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Silence edges can be fine-tuned from the keyboard a frame at a time. A nudged clip's
+// silences are kept as its manual adjustment in the timeline session, so the next nudge, a
+// restart and the final timeline all start from them.
+
+const (
+	silenceEdgeStart = "start"
+	silenceEdgeEnd   = "end"
+)
+
+// SilenceNudge is the result of NudgeSilenceBoundary.
+type SilenceNudge struct {
+	ClipID           string            `json:"clipId"`
+	Index            int               `json:"index"` // of the nudged silence; silences are sorted by start
+	Silences         []SilencePeriod   `json:"silences"`
+	EditInstructions []EditInstruction `json:"editInstructions"`
+}
+
+// NudgeSilenceBoundary moves the start or end of a clip's index-th silence by deltaFrames,
+// snapped to the clip's frames. The nudge is refused if the silence would get shorter than
+// the minimum silence duration, or the audio between it and its neighbours shorter than the
+// minimum content. It returns the clip's silences and edit instructions with the nudge
+// applied.
+func (a *App) NudgeSilenceBoundary(clipID string, index int, edge string, deltaFrames int) (*SilenceNudge, error) {
+	if edge != silenceEdgeStart && edge != silenceEdgeEnd {
+		return nil, fmt.Errorf("edge must be %q or %q, not %q", silenceEdgeStart, silenceEdgeEnd, edge)
+	}
+	item, timelineFPS, ok := a.findClipByID(clipID)
+	if !ok {
+		return nil, fmt.Errorf("unknown clip %q", clipID)
+	}
+	fps := item.SourceFPS
+	if fps <= floatEpsilon {
+		fps = timelineFPS
+	}
+	if fps <= floatEpsilon || timelineFPS <= floatEpsilon {
+		return nil, fmt.Errorf("clip %q has no frame rate", clipID)
+	}
+
+	session, err := a.currentTimelineSession()
+	if err != nil {
+		return nil, err
+	}
+	silences, err := a.clipSilences(item, timelineFPS, session)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(silences) {
+		return nil, fmt.Errorf("the clip has %d silence(s), no silence %d", len(silences), index)
+	}
+
+	params := a.GetCurrentParams()
+	if override, ok := session.ClipOverrides[clipID]; ok {
+		params = override
+	}
+	clipStart := item.SourceStartFrame / timelineFPS
+	clipEnd := item.SourceEndFrame / timelineFPS
+
+	nudged := silences[index]
+	if edge == silenceEdgeStart {
+		nudged.Start = math.Max(clipStart, snapToFrame(nudged.Start, fps, deltaFrames))
+	} else {
+		nudged.End = math.Min(clipEnd, snapToFrame(nudged.End, fps, deltaFrames))
+	}
+	if err := validateNudge(silences, index, nudged, params); err != nil {
+		return nil, err
+	}
+	silences[index] = nudged
+
+	session.SilenceAdjustments[clipID] = silences
+	if err := a.SaveTimelineSession(*session); err != nil {
+		return nil, err
+	}
+	constrained, _, _ := session.Subtitles.constrain(item, silences, timelineFPS, params.MinSilenceDurationSeconds)
+	return &SilenceNudge{
+		ClipID:           clipID,
+		Index:            index,
+		Silences:         silences,
+		EditInstructions: editsForClip(item, constrained, timelineFPS, params.KeepSilenceSegments),
+	}, nil
+}
+
+// snapToFrame moves seconds to the nearest frame and then by deltaFrames.
+func snapToFrame(seconds, fps float64, deltaFrames int) float64 {
+	return (math.Round(seconds*fps) + float64(deltaFrames)) / fps
+}
+
+// validateNudge checks the silence at index, moved to nudged, against its neighbours and the
+// minimum durations.
+func validateNudge(silences []SilencePeriod, index int, nudged SilencePeriod, params DetectionParams) error {
+	if nudged.End-nudged.Start < params.MinSilenceDurationSeconds-floatEpsilon || nudged.End <= nudged.Start {
+		return fmt.Errorf("the silence would be shorter than the minimum of %.3f s", params.MinSilenceDurationSeconds)
+	}
+	if index > 0 && nudged.Start-silences[index-1].End < params.MinContent-floatEpsilon {
+		return errors.New("the audio before the silence would be shorter than the minimum content")
+	}
+	if index < len(silences)-1 && silences[index+1].Start-nudged.End < params.MinContent-floatEpsilon {
+		return errors.New("the audio after the silence would be shorter than the minimum content")
+	}
+	return nil
+}
+
+// clipSilences returns a clip's silences sorted by start: its manual adjustment if it has
+// one, otherwise the cached detection.
+func (a *App) clipSilences(item *TimelineItem, timelineFPS float64, session *TimelineSession) ([]SilencePeriod, error) {
+	var silences []SilencePeriod
+	if adjusted, ok := session.SilenceAdjustments[item.ID]; ok {
+		silences = append(silences, adjusted...)
+	} else {
+		if item.ProcessedFileName == nil || *item.ProcessedFileName == "" {
+			return nil, fmt.Errorf("clip %q has no processed audio", item.ID)
+		}
+		cached, found := a.cachedSilencesForRange(*item.ProcessedFileName, item.SourceStartFrame/timelineFPS, item.SourceEndFrame/timelineFPS, a.clipDetectionParams(item.ID, session))
+		if !found {
+			return nil, fmt.Errorf("no silence detection cached for clip %q yet", item.ID)
+		}
+		silences = append(silences, cached...)
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].Start < silences[j].Start })
+	return silences, nil
+}
+
+// currentTimelineSession returns the session of the synced timeline, a new one if it has none.
+// clipDetectionParams returns the parameters a clip is detected with: its override in the
+// timeline session, or the current ones.
+func (a *App) clipDetectionParams(clipID string, session *TimelineSession) DetectionParams {
+	if override, ok := session.ClipOverrides[clipID]; ok {
+		return override
+	}
+	return a.GetCurrentParams()
+}
+
+func (a *App) currentTimelineSession() (*TimelineSession, error) {
+	a.mu.Lock()
+	project := a.currentProject
+	a.mu.Unlock()
+	if project == nil {
+		return nil, errors.New("no timeline has been synced yet")
+	}
+	session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session = &TimelineSession{ProjectName: project.ProjectName, TimelineName: project.Timeline.Name, Params: a.GetCurrentParams()}
+	}
+	if session.SilenceAdjustments == nil {
+		session.SilenceAdjustments = map[string][]SilencePeriod{}
+	}
+	return session, nil
+}
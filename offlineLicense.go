@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+// Offline licenses use the regular SignedLicenseData format. Their data additionally carries
+//   - "offline":     true
+//   - "valid_from":  unix seconds
+//   - "valid_until": unix seconds
+// and is trusted without any network check while the current time is inside that window.
+
+// offlineLicenseWindow returns the validity window of an offline license.
+// ok is false for licenses that were issued online.
+func offlineLicenseWindow(data map[string]interface{}) (from time.Time, until time.Time, ok bool) {
+	if offline, _ := data["offline"].(bool); !offline {
+		return time.Time{}, time.Time{}, false
+	}
+	validFrom, fromOk := data["valid_from"].(float64)
+	validUntil, untilOk := data["valid_until"].(float64)
+	if !fromOk || !untilOk {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(int64(validFrom), 0), time.Unix(int64(validUntil), 0), true
+}
+
+// checkOfflineLicense reports whether an offline license is currently inside its window.
+func checkOfflineLicense(data map[string]interface{}) error {
+	from, until, ok := offlineLicenseWindow(data)
+	if !ok {
+		return errors.New("not an offline license (missing offline validity window)")
+	}
+	now := time.Now()
+	if now.Before(from) {
+		return i18n.NewError("license.offlineNotYetValid", i18n.Params{"date": from.Format("2006-01-02")})
+	}
+	if now.After(until) {
+		return i18n.NewError("license.offlineExpired", i18n.Params{"date": until.Format("2006-01-02")})
+	}
+	return nil
+}
+
+// GetDeviceID returns this machine's ID, which is needed to request an offline license.
+func (a *App) GetDeviceID() (string, error) {
+	if a.machineID != "" {
+		return a.machineID, nil
+	}
+	return a.getMachineID()
+}
+
+// ImportOfflineLicense activates HushCut from a signed license file generated out-of-band,
+// for machines that cannot reach the license server. If srcPath is empty, an open dialog is shown.
+func (a *App) ImportOfflineLicense(srcPath string) (map[string]interface{}, error) {
+	if srcPath == "" {
+		var err error
+		srcPath, err = openFileDialog(a.ctx, "Import Offline License",
+			fileFilter{DisplayName: "HushCut License", Pattern: "*.json;*.lic"})
+		if err != nil || srcPath == "" {
+			return nil, err
+		}
+	}
+	if a.licenseVerifyKey == nil {
+		return nil, errors.New("license verification is not configured in this build")
+	}
+
+	var license SignedLicenseData
+	if err := readLicenseFile(srcPath, &license); err != nil {
+		return nil, err
+	}
+
+	if err := a.verifySignature(license.Data, license.Signature); err != nil {
+		return nil, fmt.Errorf("license file signature is invalid: %w", err)
+	}
+
+	deviceID, err := a.GetDeviceID()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve Device ID")
+	}
+	if license.Data["machine_id"] != deviceID {
+		return nil, i18n.NewError("license.otherDevice", nil)
+	}
+	if err := checkOfflineLicense(license.Data); err != nil {
+		return nil, err
+	}
+
+	if err := a.saveLocalLicense(&license); err != nil {
+		return nil, fmt.Errorf("failed to save license: %w", err)
+	}
+
+	_, until, _ := offlineLicenseWindow(license.Data)
+	licenseLog.Info("Offline license imported", "validUntil", until.Format(time.RFC3339))
+	a.signalLicenseOk()
+	return license.Data, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A crash leaves python_backend and running ffmpeg jobs behind, holding their ports and cache
+// files. Every session therefore records the children it starts in
+// <state dir>/processes/<pid>.json, and startup terminates the children of sessions whose
+// process is gone. A process is identified by its PID together with its start time, so a
+// reused PID is never taken for a recorded process.
+//
+// The file is rewritten when a child starts, and when one exits only once none are left: an
+// exited child that stays listed is harmless, as its identity no longer matches. The file
+// isn't synced or backed up, since the children don't outlive a crash of the machine anyway.
+
+const processesDirName = "processes"
+
+type trackedProcess struct {
+	PID      int    `json:"pid"`
+	Name     string `json:"name"`
+	Identity string `json:"identity"`
+}
+
+// processSession is the file of one running app, CLI or --serve process.
+type processSession struct {
+	trackedProcess
+	Children []trackedProcess `json:"children"`
+}
+
+type childRegistry struct {
+	mu      sync.Mutex
+	session *processSession
+	path    string
+}
+
+// childProcesses records the children of this process.
+var childProcesses childRegistry
+
+func processesDir() (string, error) {
+	base, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, processesDirName), nil
+}
+
+// track records a started child. Children whose identity can't be read are not recorded:
+// without it they could not be told apart from an unrelated process later.
+func (r *childRegistry) track(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	identity, err := processIdentity(cmd.Process.Pid)
+	if err != nil {
+		appLog.Warn("Not tracking child process", "pid", cmd.Process.Pid, "err", err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session == nil && !r.open() {
+		return
+	}
+	r.session.Children = append(r.session.Children, trackedProcess{
+		PID:      cmd.Process.Pid,
+		Name:     strings.TrimSuffix(filepath.Base(cmd.Path), ".exe"),
+		Identity: identity,
+	})
+	r.save()
+}
+
+// untrack forgets a child once it has exited.
+func (r *childRegistry) untrack(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session == nil {
+		return
+	}
+	for i, child := range r.session.Children {
+		if child.PID == cmd.Process.Pid {
+			r.session.Children = append(r.session.Children[:i], r.session.Children[i+1:]...)
+			if len(r.session.Children) == 0 {
+				r.save()
+			}
+			return
+		}
+	}
+}
+
+// close removes the session file on a clean exit.
+func (r *childRegistry) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session == nil {
+		return
+	}
+	if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+		appLog.Warn("Could not remove child process record", "path", r.path, "err", err)
+	}
+	r.session = nil
+}
+
+// open starts this process's session. r.mu is held.
+func (r *childRegistry) open() bool {
+	dir, err := processesDir()
+	if err != nil {
+		appLog.Warn("Not tracking child processes", "err", err)
+		return false
+	}
+	pid := os.Getpid()
+	identity, err := processIdentity(pid)
+	if err != nil {
+		appLog.Warn("Not tracking child processes", "err", err)
+		return false
+	}
+	name, _ := os.Executable()
+	r.path = filepath.Join(dir, fmt.Sprintf("%d.json", pid))
+	r.session = &processSession{
+		trackedProcess: trackedProcess{PID: pid, Name: filepath.Base(name), Identity: identity},
+		Children:       []trackedProcess{},
+	}
+	return true
+}
+
+// save writes the session file. r.mu is held. It is replaced atomically: a crash while
+// writing must not lose the list.
+func (r *childRegistry) save() {
+	data, err := json.Marshal(r.session)
+	if err != nil {
+		return
+	}
+	if err := replaceFile(r.path, data, 0644, false); err != nil {
+		appLog.Warn("Could not record child processes", "err", err)
+	}
+}
+
+// runTracked is cmd.Run for a child that should not outlive a crash of this process.
+func runTracked(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	limitStarted(cmd)
+	childProcesses.track(cmd)
+	defer childProcesses.untrack(cmd)
+	return cmd.Wait()
+}
+
+// isRunning reports whether p is still the process that was recorded.
+func (p trackedProcess) isRunning() bool {
+	identity, err := processIdentity(p.PID)
+	return err == nil && identity == p.Identity
+}
+
+// cleanupOrphanedProcesses terminates the children of earlier sessions that ended without
+// cleaning up. Sessions that are still running, e.g. a CLI batch next to the app, are left
+// alone.
+func cleanupOrphanedProcesses() {
+	dir, err := processesDir()
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var session processSession
+		if err := json.Unmarshal(data, &session); err == nil && session.isRunning() {
+			continue
+		}
+		for _, child := range session.Children {
+			if !child.isRunning() {
+				continue
+			}
+			appLog.Info("Terminating orphaned process left behind by an earlier session", "name", child.Name, "pid", child.PID, "session", session.PID)
+			if err := terminateProcess(child.PID); err != nil {
+				appLog.Warn("Could not terminate orphaned process", "name", child.Name, "pid", child.PID, "err", err)
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			appLog.Warn("Could not remove child process record", "path", path, "err", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// useTempStateDir points stateDir at a temporary folder for the test.
+func useTempStateDir(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("LOCALAPPDATA", home)
+	dir, err := processesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestChildRegistry(t *testing.T) {
+	dir := useTempStateDir(t)
+	var r childRegistry
+
+	// The test binary without tests is a child that exits right away.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r.track(cmd)
+	if r.session == nil {
+		t.Skip("process identities are not available here")
+	}
+	var session processSession
+	readSession := func() {
+		t.Helper()
+		data, err := os.ReadFile(r.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readSession()
+	if len(session.Children) != 1 || session.Children[0].PID != cmd.Process.Pid {
+		t.Fatalf("after track: children = %+v, want the started child", session.Children)
+	}
+
+	cmd.Wait()
+	r.untrack(cmd)
+	readSession()
+	if len(session.Children) != 0 {
+		t.Fatalf("after untrack: children = %+v, want none", session.Children)
+	}
+
+	r.close()
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		t.Errorf("after close: %s left in the processes folder", entry.Name())
+	}
+}
+
+func TestCleanupOrphanedProcesses(t *testing.T) {
+	dir := useTempStateDir(t)
+	identity, err := processIdentity(os.Getpid())
+	if err != nil {
+		t.Skip("process identities are not available here")
+	}
+	self := trackedProcess{PID: os.Getpid(), Name: "hushcut", Identity: identity}
+	gone := trackedProcess{PID: os.Getpid(), Name: "hushcut", Identity: "an earlier process"}
+
+	tests := []struct {
+		name     string
+		file     string
+		content  any
+		wantKept bool
+	}{
+		{"running session", "1.json", processSession{trackedProcess: self}, true},
+		{"ended session", "2.json", processSession{trackedProcess: gone, Children: []trackedProcess{gone}}, false},
+		{"malformed record", "3.json", "not a session", false},
+		{"temporary file", "4.json.123.tmp", processSession{trackedProcess: gone}, true},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(tt.content)
+		if err := os.WriteFile(filepath.Join(dir, tt.file), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleanupOrphanedProcesses()
+
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(dir, tt.file))
+		if kept := err == nil; kept != tt.wantKept {
+			t.Errorf("%s: kept = %v, want %v", tt.name, kept, tt.wantKept)
+		}
+	}
+}
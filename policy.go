@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+)
+
+const (
+	policyFileName   = "policy.json"
+	policyFileEnvVar = "HUSHCUT_POLICY_FILE"
+)
+
+// Policy is a read-only set of settings deployed by an administrator.
+// Its values always win over the user's settings.json.
+//
+// Besides any regular settings key (e.g. "enableCleanup", "cleanupThresholdDays"),
+// the policy understands:
+//   - "cachePath":      folder used for converted audio instead of the per-user cache
+//   - "allowTelemetry": false disables anything that sends data off the machine
+type Policy struct {
+	Path   string         `json:"path"`
+	Values map[string]any `json:"values"`
+}
+
+// machinePolicyPath returns the machine-wide location IT can deploy a policy file to.
+func machinePolicyPath() string {
+	switch goruntime.GOOS {
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "HushCut", policyFileName)
+	case "darwin":
+		return filepath.Join("/Library", "Application Support", "HushCut", policyFileName)
+	default:
+		return filepath.Join("/etc", "hushcut", policyFileName)
+	}
+}
+
+// loadPolicy reads the policy file pointed to by HUSHCUT_POLICY_FILE, falling back to the
+// machine-wide path. A missing file simply means no policy is in effect.
+func loadPolicy() *Policy {
+	policyPath := os.Getenv(policyFileEnvVar)
+	if policyPath == "" {
+		policyPath = machinePolicyPath()
+	}
+
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			appLog.Warn("Policy: could not read policy file", "path", policyPath, "err", err)
+		}
+		return &Policy{Values: map[string]any{}}
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		appLog.Warn("Policy: ignoring malformed policy file", "path", policyPath, "err", err)
+		return &Policy{Values: map[string]any{}}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	appLog.Info("Policy loaded", "path", policyPath, "locked", keys)
+	return &Policy{Path: policyPath, Values: values}
+}
+
+// isLocked reports whether a settings key is controlled by policy.
+func (p *Policy) isLocked(key string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Values[key]
+	return ok
+}
+
+// apply overlays the policy values onto settings in place.
+func (p *Policy) apply(settings map[string]any) {
+	if p == nil {
+		return
+	}
+	for k, v := range p.Values {
+		settings[k] = v
+	}
+}
+
+// GetPolicy returns the active policy so the UI can show locked fields as read-only.
+func (a *App) GetPolicy() *Policy {
+	return a.policy
+}
+
+// telemetryAllowed reports whether policy permits sending data off the machine.
+func (a *App) telemetryAllowed() bool {
+	if a.policy == nil {
+		return true
+	}
+	return settingBool(a.policy.Values, "allowTelemetry", true)
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Preparing a timeline's audio is a graph of tasks: every source stream is standardized to a
+// WAV, compound clips are mixed down from the WAVs of their nested clips, and each clip's WAV
+// then gets its waveform and silence detection. prepGraph runs every task as soon as the
+// tasks it needs are done, so a clip is detected while other files are still converting;
+// the ffmpeg and waveform slots bound how many run at once. A task shared by several clips
+// is only added, and run, once.
+
+const (
+	prepStandardize = "standardize"
+	prepMixdown     = "mixdown"
+	prepWaveform    = "waveform"
+	prepDetect      = "detect"
+)
+
+var prepKinds = []string{prepStandardize, prepMixdown, prepWaveform, prepDetect}
+
+const (
+	prepPending   = "pending"
+	prepRunning   = "running"
+	prepDone      = "done"
+	prepFailed    = "failed"
+	prepSkipped   = "skipped" // a task it needs failed
+	prepCancelled = "cancelled"
+)
+
+// prepTask is one node of the graph.
+type prepTask struct {
+	id     string
+	kind   string
+	target string // the file the task writes or reads
+	deps   []*prepTask
+	level  int // of a mixdown, see compoundMixdown
+	run    func() error
+	state  string
+	err    error
+	done   chan struct{}
+}
+
+type prepGraph struct {
+	app   *App
+	mu    sync.Mutex
+	tasks map[string]*prepTask
+	order []*prepTask
+}
+
+func (a *App) newPrepGraph() *prepGraph {
+	return &prepGraph{app: a, tasks: map[string]*prepTask{}}
+}
+
+// add adds the task of kind on id, or returns the one already added. deps that are nil are
+// ignored, so a task can depend on a producer that may not exist.
+func (g *prepGraph) add(kind, id, target string, run func() error, deps ...*prepTask) *prepTask {
+	key := kind + "|" + id
+	if task, ok := g.tasks[key]; ok {
+		return task
+	}
+	task := &prepTask{id: key, kind: kind, target: target, run: run, state: prepPending, done: make(chan struct{})}
+	for _, dep := range deps {
+		if dep != nil {
+			task.deps = append(task.deps, dep)
+		}
+	}
+	g.tasks[key] = task
+	g.order = append(g.order, task)
+	return task
+}
+
+// execute runs all tasks and waits for them. It fails if a standardize or mixdown task
+// failed, wrapping errJobCancelled if the jobs were cancelled. Waveform and detect tasks
+// that fail only cost their clip; the progress and the caller tell which.
+func (g *prepGraph) execute() error {
+	g.report()
+	var wg sync.WaitGroup
+	for _, task := range g.order {
+		wg.Add(1)
+		go saferun(func() {
+			defer wg.Done()
+			defer close(task.done)
+			g.runTask(task)
+		})
+	}
+	wg.Wait()
+	g.report()
+
+	var failed []string
+	cancelled := false
+	for _, task := range g.order {
+		switch task.state {
+		case prepCancelled:
+			cancelled = true
+		case prepFailed:
+			if task.kind == prepStandardize || task.kind == prepMixdown {
+				failed = append(failed, task.err.Error())
+			} else {
+				ffmpegLog.Warn("Audio preparation task failed", "task", task.id, "err", task.err)
+			}
+		}
+	}
+	if cancelled {
+		return fmt.Errorf("audio preparation: %w", errJobCancelled)
+	}
+	if len(failed) > 0 {
+		return &prepError{Errors: failed}
+	}
+	return nil
+}
+
+func (g *prepGraph) runTask(task *prepTask) {
+	for _, dep := range task.deps {
+		<-dep.done
+		if dep.state != prepDone {
+			state := prepSkipped
+			if dep.state == prepCancelled {
+				state = prepCancelled
+			}
+			g.setState(task, state, fmt.Errorf("%s of %s did not finish", dep.kind, filepath.Base(dep.target)))
+			return
+		}
+	}
+
+	g.setState(task, prepRunning, nil)
+	err := task.run()
+	switch {
+	case errors.Is(err, errJobCancelled):
+		g.setState(task, prepCancelled, err)
+	case err != nil:
+		g.setState(task, prepFailed, err)
+	default:
+		g.setState(task, prepDone, nil)
+	}
+}
+
+func (g *prepGraph) setState(task *prepTask, state string, err error) {
+	g.mu.Lock()
+	task.state, task.err = state, err
+	g.mu.Unlock()
+	g.report()
+}
+
+// prepError lists the standardize and mixdown tasks that failed.
+type prepError struct {
+	Errors []string
+}
+
+func (e *prepError) Error() string {
+	return fmt.Sprintf("%d audio preparation task(s) failed: %s", len(e.Errors), e.Errors[0])
+}
+
+// PrepProgress is the state of the whole graph, sent as "prep:progress" whenever a task
+// changes state.
+type PrepProgress struct {
+	Total  int             `json:"total"`
+	Done   int             `json:"done"`
+	Failed int             `json:"failed"` // failed, skipped or cancelled
+	Stages []PrepStageInfo `json:"stages"`
+}
+
+// PrepStageInfo sums up the tasks of one kind.
+type PrepStageInfo struct {
+	Kind    string         `json:"kind"`
+	Total   int            `json:"total"`
+	Done    int            `json:"done"`
+	Running int            `json:"running"`
+	Failed  int            `json:"failed"`
+	Tasks   []PrepTaskInfo `json:"tasks"`
+	// Levels sums up the mixdowns by nesting level, inner compounds first.
+	Levels []PrepLevelInfo `json:"levels,omitempty"`
+}
+
+// PrepLevelInfo sums up the mixdowns of one nesting level.
+type PrepLevelInfo struct {
+	Level  int `json:"level"`
+	Total  int `json:"total"`
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+}
+
+type PrepTaskInfo struct {
+	ID        string   `json:"id"`
+	Target    string   `json:"target"`
+	Level     int      `json:"level,omitempty"`
+	State     string   `json:"state"`
+	Error     string   `json:"error,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func (p PrepProgress) coalesceKey() string { return "prep" }
+
+func (g *prepGraph) progress() PrepProgress {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stages := map[string]*PrepStageInfo{}
+	for _, kind := range prepKinds {
+		stages[kind] = &PrepStageInfo{Kind: kind, Tasks: []PrepTaskInfo{}}
+	}
+	var progress PrepProgress
+	for _, task := range g.order {
+		info := PrepTaskInfo{ID: task.id, Target: filepath.Base(task.target), Level: task.level, State: task.state}
+		if task.err != nil {
+			info.Error = task.err.Error()
+		}
+		for _, dep := range task.deps {
+			info.DependsOn = append(info.DependsOn, dep.id)
+		}
+		stage := stages[task.kind]
+		stage.Tasks = append(stage.Tasks, info)
+		stage.Total++
+		progress.Total++
+		switch task.state {
+		case prepDone:
+			stage.Done++
+			progress.Done++
+		case prepRunning:
+			stage.Running++
+		case prepFailed, prepSkipped, prepCancelled:
+			stage.Failed++
+			progress.Failed++
+		}
+		if task.level > 0 {
+			for len(stage.Levels) < task.level {
+				stage.Levels = append(stage.Levels, PrepLevelInfo{Level: len(stage.Levels) + 1})
+			}
+			level := &stage.Levels[task.level-1]
+			level.Total++
+			switch task.state {
+			case prepDone:
+				level.Done++
+			case prepFailed, prepSkipped, prepCancelled:
+				level.Failed++
+			}
+		}
+	}
+	for _, kind := range prepKinds {
+		sort.Slice(stages[kind].Tasks, func(i, j int) bool { return stages[kind].Tasks[i].ID < stages[kind].Tasks[j].ID })
+		progress.Stages = append(progress.Stages, *stages[kind])
+	}
+	return progress
+}
+
+func (g *prepGraph) report() {
+	g.app.emit("prep:progress", g.progress())
+}
+
+// prepDetection is a clip range to detect. Clips with the same audio, range and parameters
+// share one detection.
+type prepDetection struct {
+	clipIDs    []string
+	fileName   string
+	start, end float64
+	fps        float64
+	params     DetectionParams
+	silences   []SilencePeriod
+	task       *prepTask
+	reported   bool // counted in the analysis progress
+}
+
+// projectPrep is the graph of a project's audio.
+type projectPrep struct {
+	graph      *prepGraph
+	detections []*prepDetection
+	mu         sync.Mutex
+	analysis   AnalysisProgress
+	// background is set for the background analysis, which neither records its parameters
+	// as the current ones nor reports its progress.
+	background bool
+}
+
+// buildProjectPrep adds the standardize and mixdown tasks of project, and with analyze the
+// waveform and detect tasks of its clips, using params unless the timeline session holds a
+// clip's own.
+func (a *App) buildProjectPrep(project *ProjectDataPayload, analyze bool, params DetectionParams) *projectPrep {
+	prep := &projectPrep{graph: a.newPrepGraph()}
+	g := prep.graph
+	producers := map[string]*prepTask{} // by processed file name
+
+	standardize := func(fileName, source string, channel *SourceChannel) {
+		target := filepath.Join(a.tmpPath, fileName)
+		a.updateFileUsage(target)
+		producers[fileName] = g.add(prepStandardize, fileName, target, func() error {
+			release, err := a.acquireFfmpegSlot(jobConversion, target)
+			if err != nil {
+				return err
+			}
+			defer release()
+			return a.standardizeAudioToWav(source, target, channel, false)
+		})
+	}
+	for _, item := range project.Timeline.AudioTrackItems {
+		if item.Type == "" && item.ProcessedFileName != nil && *item.ProcessedFileName != "" {
+			standardize(*item.ProcessedFileName, item.SourceFilePath, item.SourceChannel)
+		}
+	}
+	compounds := planCompoundMixdowns(project.Timeline.AudioTrackItems)
+	for _, nested := range compounds.Sources {
+		standardize(nested.ProcessedFileName, nested.SourceFilePath, nested.SourceChannel)
+	}
+
+	// Inner compounds come first, so their mixdowns are the producers the outer ones need.
+	for _, mixdown := range compounds.Mixdowns {
+		var deps []*prepTask
+		for _, nested := range mixdown.Clips {
+			deps = append(deps, producers[nested.ProcessedFileName])
+		}
+		nestedClips, planErr := mixdown.Clips, mixdown.Err
+		target := filepath.Join(a.tmpPath, mixdown.FileName)
+		a.updateFileUsage(target)
+		fps := project.Timeline.ProjectFPS
+		task := g.add(prepMixdown, mixdown.FileName, target, func() error {
+			if planErr != nil {
+				return planErr
+			}
+			return a.mixdownAndWait(fps, target, nestedClips)
+		}, deps...)
+		task.level = mixdown.Level
+		producers[mixdown.FileName] = task
+	}
+
+	if !analyze {
+		return prep
+	}
+
+	session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name)
+	if err != nil || session == nil {
+		session = &TimelineSession{}
+	}
+	fps := project.Timeline.FPS
+	detections := map[CacheKey]*prepDetection{}
+	for _, item := range project.Timeline.AudioTrackItems {
+		if item.ProcessedFileName == nil || *item.ProcessedFileName == "" || fps <= floatEpsilon {
+			continue
+		}
+		fileName := *item.ProcessedFileName
+		producer := producers[fileName]
+		if !a.headless {
+			g.add(prepWaveform, fileName, filepath.Join(a.tmpPath, fileName), func() error {
+				return a.precomputeWaveform(fileName)
+			}, producer)
+		}
+
+		clipParams := params
+		if override, ok := session.ClipOverrides[item.ID]; ok {
+			clipParams = override
+		}
+		start, end := item.SourceStartFrame/fps, item.SourceEndFrame/fps
+		if end <= start {
+			continue
+		}
+		key := CacheKey{
+			FilePath:                  fileName,
+			LoudnessThreshold:         clipParams.LoudnessThreshold,
+			MinSilenceDurationSeconds: clipParams.MinSilenceDurationSeconds,
+			PaddingLeftSeconds:        clipParams.PaddingLeftSeconds,
+			PaddingRightSeconds:       clipParams.PaddingRightSeconds,
+			MinContentDuration:        clipParams.MinContent,
+			ClipStartSeconds:          start,
+			ClipEndSeconds:            end,
+		}
+		if detection, ok := detections[key]; ok {
+			detection.clipIDs = append(detection.clipIDs, item.ID)
+			continue
+		}
+		detection := &prepDetection{clipIDs: []string{item.ID}, fileName: fileName, start: start, end: end, fps: fps, params: clipParams}
+		detections[key] = detection
+		prep.detections = append(prep.detections, detection)
+		id := fmt.Sprintf("%s@%s-%s", fileName, formatSeconds(start), formatSeconds(end))
+		detection.task = g.add(prepDetect, id, filepath.Join(a.tmpPath, fileName), func() error {
+			// DetectSilences waits for a slot of the detection pool.
+			var err error
+			if prep.background {
+				detection.silences, err = a.cachedDetection(key, detection.fps)
+			} else {
+				p := detection.params
+				detection.silences, err = a.GetOrDetectSilencesWithCache(fileName,
+					p.LoudnessThreshold, p.MinSilenceDurationSeconds,
+					p.PaddingLeftSeconds, p.PaddingRightSeconds, p.MinContent,
+					detection.start, detection.end, detection.fps)
+			}
+			prep.detectionFinished(detection, err)
+			return err
+		}, producer)
+	}
+	for _, detection := range prep.detections {
+		prep.analysis.Total += len(detection.clipIDs)
+	}
+	return prep
+}
+
+// AnalysisProgress counts the clips of a timeline whose silences are detected, sent as
+// "analysis:progress" when the analysis starts and whenever a detection finishes.
+type AnalysisProgress struct {
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+	Total  int `json:"total"`
+}
+
+func (p AnalysisProgress) coalesceKey() string { return "analysis" }
+
+func (prep *projectPrep) detectionFinished(detection *prepDetection, err error) {
+	prep.mu.Lock()
+	detection.reported = true
+	if err != nil {
+		prep.analysis.Failed += len(detection.clipIDs)
+	} else {
+		prep.analysis.Done += len(detection.clipIDs)
+	}
+	progress := prep.analysis
+	prep.mu.Unlock()
+	prep.reportAnalysis(progress)
+}
+
+func (prep *projectPrep) reportAnalysis(progress AnalysisProgress) {
+	if !prep.background {
+		prep.graph.app.emit("analysis:progress", progress)
+	}
+}
+
+// execute runs the graph, reporting the analysis of the clips it detects. Detections that
+// never ran because their audio failed count as failed.
+func (prep *projectPrep) execute() error {
+	if prep.analysis.Total == 0 {
+		return prep.graph.execute()
+	}
+	prep.reportAnalysis(prep.analysis)
+	err := prep.graph.execute()
+	prep.mu.Lock()
+	skipped := 0
+	for _, detection := range prep.detections {
+		if !detection.reported {
+			detection.reported = true
+			skipped += len(detection.clipIDs)
+		}
+	}
+	prep.analysis.Failed += skipped
+	progress := prep.analysis
+	prep.mu.Unlock()
+	if skipped > 0 {
+		prep.reportAnalysis(progress)
+	}
+	return err
+}
+
+// mixdownAndWait mixes down nestedClips into outputPath, or waits for the mixdown that is
+// already running, and reports whether it produced a valid WAV.
+func (a *App) mixdownAndWait(fps float64, outputPath string, nestedClips []*NestedAudioTimelineItem) error {
+	if err := <-a.startMixdown(fps, outputPath, nestedClips); err != nil {
+		return err
+	}
+	if !isValidWavFile(outputPath) {
+		return fmt.Errorf("mixdown of %s did not produce a valid WAV", filepath.Base(outputPath))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrepGraphPropagation(t *testing.T) {
+	errFailed := errors.New("ffmpeg failed")
+	tests := []struct {
+		name       string
+		source     error // result of the standardize task the mixdowns need
+		inner      error // result of the inner mixdown
+		wantStates map[string]string
+		wantErr    error // nil, errJobCancelled, or any error (errFailed)
+	}{
+		{
+			name: "everything succeeds",
+			wantStates: map[string]string{
+				"standardize|a.wav": prepDone, "mixdown|inner.wav": prepDone,
+				"mixdown|outer.wav": prepDone, "detect|outer.wav": prepDone,
+			},
+		},
+		{
+			name:   "a failed source skips what needs it",
+			source: errFailed,
+			wantStates: map[string]string{
+				"standardize|a.wav": prepFailed, "mixdown|inner.wav": prepSkipped,
+				"mixdown|outer.wav": prepSkipped, "detect|outer.wav": prepSkipped,
+			},
+			wantErr: errFailed,
+		},
+		{
+			name:  "a failed inner mixdown skips the outer one",
+			inner: errFailed,
+			wantStates: map[string]string{
+				"standardize|a.wav": prepDone, "mixdown|inner.wav": prepFailed,
+				"mixdown|outer.wav": prepSkipped, "detect|outer.wav": prepSkipped,
+			},
+			wantErr: errFailed,
+		},
+		{
+			name:  "cancellation reaches the dependent tasks",
+			inner: errJobCancelled,
+			wantStates: map[string]string{
+				"standardize|a.wav": prepDone, "mixdown|inner.wav": prepCancelled,
+				"mixdown|outer.wav": prepCancelled, "detect|outer.wav": prepCancelled,
+			},
+			wantErr: errJobCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := (&App{}).newPrepGraph()
+			ran := map[string]int{}
+			task := func(kind, id string, result error, deps ...*prepTask) *prepTask {
+				return g.add(kind, id, id, func() error {
+					ran[kind+"|"+id]++ // the tasks form a chain, so they run one after the other
+					return result
+				}, deps...)
+			}
+			source := task(prepStandardize, "a.wav", tt.source)
+			inner := task(prepMixdown, "inner.wav", tt.inner, source)
+			outer := task(prepMixdown, "outer.wav", nil, inner, nil)
+			task(prepDetect, "outer.wav", nil, outer)
+			if again := task(prepMixdown, "inner.wav", nil, source); again != inner {
+				t.Fatal("adding a task twice must return the first one")
+			}
+
+			err := g.execute()
+			switch {
+			case tt.wantErr == nil && err != nil,
+				errors.Is(tt.wantErr, errJobCancelled) && !errors.Is(err, errJobCancelled):
+				t.Errorf("execute() = %v, want %v", err, tt.wantErr)
+			case errors.Is(tt.wantErr, errFailed):
+				var prepErr *prepError
+				if !errors.As(err, &prepErr) {
+					t.Errorf("execute() = %v, want a prepError", err)
+				}
+			}
+			for id, want := range tt.wantStates {
+				if got := g.tasks[id].state; got != want {
+					t.Errorf("%s: state %s, want %s", id, got, want)
+				}
+				if g.tasks[id].state == prepSkipped && ran[id] > 0 || ran[id] > 1 {
+					t.Errorf("%s ran %d times", id, ran[id])
+				}
+			}
+		})
+	}
+}
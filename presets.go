@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	presetsFolderName   = "presets"
+	presetFileExtension = ".json"
+	presetSchemaVersion = 1
+)
+
+// DetectionParams mirrors the frontend's DetectionParams plus the edit options
+// that together define how a timeline gets cut.
+type DetectionParams struct {
+	LoudnessThreshold         float64 `json:"loudnessThreshold"`
+	MinSilenceDurationSeconds float64 `json:"minSilenceDurationSeconds"`
+	PaddingLeftSeconds        float64 `json:"paddingLeftSeconds"`
+	PaddingRightSeconds       float64 `json:"paddingRightSeconds"`
+	MinContent                float64 `json:"minContent"`
+	KeepSilenceSegments       bool    `json:"keepSilenceSegments"`
+}
+
+// Preset is the on-disk (and shareable) format of a named parameter set.
+type Preset struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	AppVersion    string          `json:"appVersion"`
+	Params        DetectionParams `json:"params"`
+}
+
+var presetSlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+func presetSlug(name string) string {
+	slug := strings.Trim(presetSlugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "preset"
+	}
+	return slug
+}
+
+func (a *App) getPresetsDir() string {
+	return filepath.Join(a.userResourcesPath, presetsFolderName)
+}
+
+func (a *App) getPresetPath(name string) string {
+	return filepath.Join(a.getPresetsDir(), presetSlug(name)+presetFileExtension)
+}
+
+// presetPathForWrite returns where the preset name is stored. Names that differ only in case
+// or punctuation share a file, so a name whose file holds another preset is refused rather
+// than overwriting it.
+func (a *App) presetPathForWrite(name string) (string, error) {
+	path := a.getPresetPath(name)
+	if existing, err := readPresetFile(path); err == nil && existing.Name != name {
+		return "", fmt.Errorf("preset name %q is too similar to the existing preset %q: choose another name", name, existing.Name)
+	}
+	return path, nil
+}
+
+// SetCurrentParams records the parameters the UI is currently working with.
+func (a *App) SetCurrentParams(params DetectionParams) {
+	a.mu.Lock()
+	a.currentParams = params
+	a.mu.Unlock()
+}
+
+func (a *App) updateCurrentDetectionParams(key CacheKey) {
+	a.mu.Lock()
+	a.currentParams.LoudnessThreshold = key.LoudnessThreshold
+	a.currentParams.MinSilenceDurationSeconds = key.MinSilenceDurationSeconds
+	a.currentParams.PaddingLeftSeconds = key.PaddingLeftSeconds
+	a.currentParams.PaddingRightSeconds = key.PaddingRightSeconds
+	a.currentParams.MinContent = key.MinContentDuration
+	a.mu.Unlock()
+}
+
+func (a *App) updateCurrentKeepSilence(keepSilenceSegments bool) {
+	a.mu.Lock()
+	a.currentParams.KeepSilenceSegments = keepSilenceSegments
+	a.mu.Unlock()
+}
+
+// GetCurrentParams returns the parameter set that was last used for detection and editing.
+func (a *App) GetCurrentParams() DetectionParams {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.currentParams
+}
+
+func readPresetFile(path string) (*Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preset %s: %w", path, err)
+	}
+	var preset Preset
+	if err := json.Unmarshal(data, &preset); err != nil {
+		return nil, fmt.Errorf("failed to parse preset %s: %w", path, err)
+	}
+	if preset.Name == "" {
+		return nil, fmt.Errorf("preset %s has no name", path)
+	}
+	if preset.SchemaVersion > presetSchemaVersion {
+		return nil, fmt.Errorf("preset %s was made with a newer version of HushCut", path)
+	}
+	return &preset, nil
+}
+
+func writePresetFile(path string, preset *Preset) error {
+	data, err := json.MarshalIndent(preset, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preset: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create preset directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write preset %s: %w", path, err)
+	}
+	return nil
+}
+
+// ListPresets returns all stored presets sorted by name.
+func (a *App) ListPresets() ([]Preset, error) {
+	entries, err := os.ReadDir(a.getPresetsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Preset{}, nil
+		}
+		return nil, fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	presets := []Preset{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != presetFileExtension {
+			continue
+		}
+		preset, err := readPresetFile(filepath.Join(a.getPresetsDir(), entry.Name()))
+		if err != nil {
+			appLog.Warn("Skipping preset", "err", err)
+			continue
+		}
+		presets = append(presets, *preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return strings.ToLower(presets[i].Name) < strings.ToLower(presets[j].Name)
+	})
+	return presets, nil
+}
+
+// ApplyPreset makes the named preset the current parameter set and tells the UI about it.
+func (a *App) ApplyPreset(name string) (*Preset, error) {
+	preset, err := readPresetFile(a.getPresetPath(name))
+	if err != nil {
+		return nil, err
+	}
+	a.SetCurrentParams(preset.Params)
+
+	if err := a.updateSetting("activePreset", presetSlug(preset.Name)); err != nil {
+		appLog.Warn("ApplyPreset: could not store active preset", "err", err)
+	}
+
+	a.emit("preset:applied", preset)
+	return preset, nil
+}
+
+// SavePresetFromCurrent stores the current parameter set under the given name,
+// overwriting any preset with the same name; see presetPathForWrite for similar names.
+func (a *App) SavePresetFromCurrent(name string, description string) (*Preset, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("preset name cannot be empty")
+	}
+	path, err := a.presetPathForWrite(name)
+	if err != nil {
+		return nil, err
+	}
+
+	preset := &Preset{
+		SchemaVersion: presetSchemaVersion,
+		Name:          name,
+		Description:   description,
+		CreatedAt:     time.Now(),
+		AppVersion:    a.appVersion,
+		Params:        a.GetCurrentParams(),
+	}
+	if err := writePresetFile(path, preset); err != nil {
+		return nil, err
+	}
+	a.emit("presets:changed", nil)
+	return preset, nil
+}
+
+// DeletePreset removes a stored preset.
+func (a *App) DeletePreset(name string) error {
+	if err := os.Remove(a.getPresetPath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete preset %s: %w", name, err)
+	}
+	a.emit("presets:changed", nil)
+	return nil
+}
+
+// ExportPreset writes a preset to a file of the user's choice, e.g. to share a house style.
+// If destPath is empty, a save dialog is shown.
+func (a *App) ExportPreset(name string, destPath string) (string, error) {
+	preset, err := readPresetFile(a.getPresetPath(name))
+	if err != nil {
+		return "", err
+	}
+	if destPath == "" {
+		destPath, err = saveFileDialog(a.ctx, presetSlug(preset.Name)+".hushcut-preset.json",
+			fileFilter{DisplayName: "HushCut Preset", Pattern: "*.json"})
+		if err != nil || destPath == "" {
+			return "", err
+		}
+	}
+	return destPath, writePresetFile(destPath, preset)
+}
+
+// ImportPreset copies a shared preset file into the presets folder.
+// If srcPath is empty, an open dialog is shown.
+func (a *App) ImportPreset(srcPath string) (*Preset, error) {
+	var err error
+	if srcPath == "" {
+		srcPath, err = openFileDialog(a.ctx, "", fileFilter{DisplayName: "HushCut Preset", Pattern: "*.json"})
+		if err != nil || srcPath == "" {
+			return nil, err
+		}
+	}
+
+	preset, err := readPresetFile(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	preset.SchemaVersion = presetSchemaVersion
+	path, err := a.presetPathForWrite(preset.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePresetFile(path, preset); err != nil {
+		return nil, err
+	}
+	a.emit("presets:changed", nil)
+	return preset, nil
+}
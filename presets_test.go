@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetNames(t *testing.T) {
+	a := &App{userResourcesPath: t.TempDir()}
+	if _, err := a.SavePresetFromCurrent("Podcast", ""); err != nil {
+		t.Fatal(err)
+	}
+	shared := filepath.Join(t.TempDir(), "shared.json")
+	if err := writePresetFile(shared, &Preset{Name: "PODCAST"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		save    func() (*Preset, error)
+		wantErr bool
+	}{
+		{"same name overwrites", func() (*Preset, error) { return a.SavePresetFromCurrent("Podcast", "again") }, false},
+		{"other name", func() (*Preset, error) { return a.SavePresetFromCurrent("Podcast 2", "") }, false},
+		{"same slug, other case", func() (*Preset, error) { return a.SavePresetFromCurrent("podcast", "") }, true},
+		{"same slug, other punctuation", func() (*Preset, error) { return a.SavePresetFromCurrent("Podcast!", "") }, true},
+		{"imported preset with the same slug", func() (*Preset, error) { return a.ImportPreset(shared) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.save(); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if preset, err := readPresetFile(a.getPresetPath("Podcast")); err != nil || preset.Name != "Podcast" {
+		t.Errorf("preset file holds %+v (%v), want Podcast", preset, err)
+	}
+}
+
+func TestApplyPresetKeepsOtherSettings(t *testing.T) {
+	a := &App{userResourcesPath: t.TempDir()}
+	// A path that no longer exists must not keep a preset from being applied, nor be touched.
+	missing := filepath.Join(t.TempDir(), "gone", "ffmpeg")
+	data, _ := json.Marshal(map[string]any{"ffmpegPath": missing})
+	if err := os.WriteFile(a.getSettingsPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.SavePresetFromCurrent("Studio Voice", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.ApplyPreset("Studio Voice"); err != nil {
+		t.Fatalf("ApplyPreset() error = %v", err)
+	}
+	settings := a.readUserSettingsFile()
+	if settings["activePreset"] != "studio-voice" {
+		t.Errorf("activePreset = %v, want studio-voice", settings["activePreset"])
+	}
+	if settings["ffmpegPath"] != missing || len(settings) != 2 {
+		t.Errorf("settings = %v, want only activePreset added", settings)
+	}
+}
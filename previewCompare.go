@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// For A/B auditioning the UI loads a clip twice from /preview_compare: as it is and with the
+// cached silences cut out, both rendered from the same WAV at the same sample rate. The
+// segments of GetPreviewComparison map positions between the two, so switching keeps the
+// playhead on the same word.
+
+const (
+	previewVariantOriginal = "original"
+	previewVariantCut      = "cut"
+)
+
+// PreviewSegment is a kept part of the clip: OriginalStart..OriginalEnd in the original
+// preview plays from CutStart in the cut one. Times are seconds from the start of the clip.
+type PreviewSegment struct {
+	OriginalStart float64 `json:"originalStart"`
+	OriginalEnd   float64 `json:"originalEnd"`
+	CutStart      float64 `json:"cutStart"`
+}
+
+// PreviewComparison describes the two previews of a clip. The paths are relative to the
+// server and signed (see mediaURLs.go); the frontend adds the port.
+type PreviewComparison struct {
+	ClipID           string           `json:"clipId"`
+	OriginalPath     string           `json:"originalPath"`
+	CutPath          string           `json:"cutPath"`
+	OriginalDuration float64          `json:"originalDuration"`
+	CutDuration      float64          `json:"cutDuration"`
+	Segments         []PreviewSegment `json:"segments"`
+}
+
+// GetPreviewComparison returns the previews of a clip whose silences have been detected.
+func (a *App) GetPreviewComparison(clipID string) (*PreviewComparison, error) {
+	src, _, msg := a.findPreviewSource(clipID)
+	if src == nil {
+		return nil, errors.New(msg)
+	}
+	query := func(variant string) string {
+		path, _ := a.signMediaPath("/preview_compare?" + url.Values{"clipId": {clipID}, "variant": {variant}}.Encode())
+		return path
+	}
+	comparison := &PreviewComparison{
+		ClipID:           clipID,
+		OriginalPath:     query(previewVariantOriginal),
+		CutPath:          query(previewVariantCut),
+		OriginalDuration: src.endSeconds - src.startSeconds,
+		Segments:         []PreviewSegment{},
+	}
+	for _, r := range keepRanges(src.startSeconds, src.endSeconds, src.silences) {
+		comparison.Segments = append(comparison.Segments, PreviewSegment{
+			OriginalStart: r[0] - src.startSeconds,
+			OriginalEnd:   r[1] - src.startSeconds,
+			CutStart:      comparison.CutDuration,
+		})
+		comparison.CutDuration += r[1] - r[0]
+	}
+	return comparison, nil
+}
+
+// handlePreviewCompare streams one variant of a clip: ?clipId=...&variant=original|cut.
+func (a *App) handlePreviewCompare(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	src, status, msg := a.findPreviewSource(query.Get("clipId"))
+	if src == nil {
+		http.Error(w, msg, status)
+		return
+	}
+
+	var filter string
+	switch query.Get("variant") {
+	case previewVariantOriginal:
+		filter = fmt.Sprintf("atrim=start=%.6f:end=%.6f,asetpts=N/SR/TB", src.startSeconds, src.endSeconds)
+	case previewVariantCut:
+		filter = fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", buildKeepSelectExpr(src.startSeconds, src.endSeconds, src.silences))
+	default:
+		http.Error(w, "Query parameter 'variant' must be 'original' or 'cut'", http.StatusBadRequest)
+		return
+	}
+	ipcLog.Info("PreviewCompare: streaming", "file", src.fileName, "variant", query.Get("variant"))
+	a.streamPreview(w, r, src, filter, "previewCompare")
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// A loop preview plays a short stretch around one cut of a clip with the cut applied: the
+// audio before the removed silence runs straight into the audio after it. The UI loops it to
+// judge whether the cut sounds natural, and can pass other edges for the silence to try a
+// different padding for just that cut before changing it.
+
+const (
+	defaultLoopContext = 1.0 // seconds before and after the cut
+	maxLoopContext     = 10.0
+)
+
+// LoopPreview describes the loop around one cut. Times are seconds in the source audio,
+// except CutAt, which is where the cut falls in the loop.
+type LoopPreview struct {
+	ClipID       string  `json:"clipId"`
+	Index        int     `json:"index"` // of the silence among the clip's silences, by start
+	SilenceStart float64 `json:"silenceStart"`
+	SilenceEnd   float64 `json:"silenceEnd"`
+	LoopStart    float64 `json:"loopStart"`
+	LoopEnd      float64 `json:"loopEnd"`
+	CutAt        float64 `json:"cutAt"`
+	Duration     float64 `json:"duration"`
+	Path         string  `json:"path"` // relative to the server and signed, like the other audio URLs
+}
+
+// loopRequest is a loop preview as the endpoint gets it. A negative start or end keeps the
+// detected edge.
+type loopRequest struct {
+	index         int
+	before, after float64
+	start, end    float64
+}
+
+// GetLoopPreview describes the loop around the cut of the clip's index-th silence, with
+// before and after seconds of context (0 for the default). start and end move the silence's
+// edges; pass -1 to keep the detected ones.
+func (a *App) GetLoopPreview(clipID string, index int, before, after, start, end float64) (*LoopPreview, error) {
+	src, _, msg := a.findPreviewSource(clipID)
+	if src == nil {
+		return nil, errors.New(msg)
+	}
+	loop, err := loopPreviewFor(clipID, src, loopRequest{index: index, before: before, after: after, start: start, end: end})
+	if err != nil {
+		return nil, err
+	}
+	if loop.Path, err = a.signMediaPath(loop.Path); err != nil {
+		return nil, err
+	}
+	return loop, nil
+}
+
+func loopPreviewFor(clipID string, src *previewSource, req loopRequest) (*LoopPreview, error) {
+	silences := make([]SilencePeriod, len(src.silences))
+	copy(silences, src.silences)
+	sort.Slice(silences, func(i, j int) bool { return silences[i].Start < silences[j].Start })
+	if req.index < 0 || req.index >= len(silences) {
+		return nil, fmt.Errorf("the clip has %d silence(s), no silence %d", len(silences), req.index)
+	}
+
+	silence := silences[req.index]
+	if req.start >= 0 {
+		silence.Start = req.start
+	}
+	if req.end >= 0 {
+		silence.End = req.end
+	}
+	silence.Start = math.Max(silence.Start, src.startSeconds)
+	silence.End = math.Min(silence.End, src.endSeconds)
+	if silence.End <= silence.Start {
+		return nil, errors.New("the silence must end after it starts")
+	}
+
+	before, after := loopContext(req.before), loopContext(req.after)
+	loop := &LoopPreview{
+		ClipID:       clipID,
+		Index:        req.index,
+		SilenceStart: silence.Start,
+		SilenceEnd:   silence.End,
+		LoopStart:    math.Max(src.startSeconds, silence.Start-before),
+		LoopEnd:      math.Min(src.endSeconds, silence.End+after),
+	}
+	loop.CutAt = silence.Start - loop.LoopStart
+	loop.Duration = loop.CutAt + (loop.LoopEnd - silence.End)
+	loop.Path = "/preview_loop?" + url.Values{
+		"clipId": {clipID},
+		"index":  {strconv.Itoa(req.index)},
+		"before": {formatSeconds(before)},
+		"after":  {formatSeconds(after)},
+		"start":  {formatSeconds(silence.Start)},
+		"end":    {formatSeconds(silence.End)},
+	}.Encode()
+	return loop, nil
+}
+
+// loopContext clamps the seconds of context around a cut; 0 means the default.
+func loopContext(seconds float64) float64 {
+	if seconds <= 0 {
+		return defaultLoopContext
+	}
+	return math.Min(seconds, maxLoopContext)
+}
+
+// handlePreviewLoop streams the loop around a cut:
+// ?clipId=...&index=N[&before=s][&after=s][&start=s][&end=s].
+func (a *App) handlePreviewLoop(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	src, status, msg := a.findPreviewSource(query.Get("clipId"))
+	if src == nil {
+		http.Error(w, msg, status)
+		return
+	}
+
+	req := loopRequest{start: -1, end: -1}
+	var err error
+	if req.index, err = strconv.Atoi(query.Get("index")); err != nil {
+		http.Error(w, "Query parameter 'index' must be a number", http.StatusBadRequest)
+		return
+	}
+	for name, target := range map[string]*float64{"before": &req.before, "after": &req.after, "start": &req.start, "end": &req.end} {
+		if v := query.Get(name); v != "" {
+			if *target, err = strconv.ParseFloat(v, 64); err != nil {
+				http.Error(w, fmt.Sprintf("Query parameter '%s' must be a number", name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	loop, err := loopPreviewFor(query.Get("clipId"), src, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	selectExpr := buildKeepSelectExpr(loop.LoopStart, loop.LoopEnd, []SilencePeriod{{Start: loop.SilenceStart, End: loop.SilenceEnd}})
+	ipcLog.Info("PreviewLoop: streaming", "file", src.fileName, "index", loop.Index, "start", loop.SilenceStart, "end", loop.SilenceEnd)
+	a.streamPreview(w, r, src, fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", selectExpr), "previewLoop")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// ffmpeg runs on whatever media the user hands HushCut, and a pathological file can keep it
+// busy on every core or let it allocate until the machine swaps. Every ffmpeg process is
+// therefore started below normal priority ("ffmpegNiceness", 0 leaves it alone), optionally
+// capped in memory ("ffmpegMemoryLimitMB", 0 for no cap: a job object on Windows, its address
+// space on Linux, not available on macOS), and in a working directory of its own, so nothing
+// it writes next to itself lands in the app's.
+
+const (
+	defaultFfmpegNiceness  = 10
+	maxFfmpegNiceness      = 19
+	maxFfmpegMemoryLimitMB = 1024 * 1024
+)
+
+type processLimits struct {
+	nice     int
+	memoryMB int
+}
+
+var ffmpegLimits = struct {
+	sync.Mutex
+	processLimits
+}{processLimits: processLimits{nice: defaultFfmpegNiceness}}
+
+// limitedCommands holds the limits of the commands ffmpegCommand built until they start.
+var limitedCommands sync.Map // *exec.Cmd -> processLimits
+
+var limitWarning sync.Once
+
+func setFfmpegLimits(limits processLimits) {
+	ffmpegLimits.Lock()
+	ffmpegLimits.processLimits = limits
+	ffmpegLimits.Unlock()
+}
+
+var ffmpegWorkDir = sync.OnceValue(func() string {
+	dir := filepath.Join(os.TempDir(), "hushcut-ffmpeg")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		ffmpegLog.Warn("Could not create the ffmpeg working directory", "dir", dir, "err", err)
+		return ""
+	}
+	return dir
+})
+
+// ffmpegCommand is ExecCommand for ffmpeg, in its working directory and with the current
+// limits. Paths in args must be absolute. Whoever starts it calls limitStarted right after.
+func (a *App) ffmpegCommand(args ...string) *exec.Cmd {
+	cmd := ExecCommand(a.ffmpegBinaryPath, args...)
+	cmd.Dir = ffmpegWorkDir()
+	ffmpegLimits.Lock()
+	limits := ffmpegLimits.processLimits
+	ffmpegLimits.Unlock()
+	prepareProcessLimits(cmd, limits)
+	limitedCommands.Store(cmd, limits)
+	return cmd
+}
+
+// limitStarted applies the limits of a command ffmpegCommand built to its process. Other
+// commands are left alone.
+func limitStarted(cmd *exec.Cmd) {
+	limits, ok := limitedCommands.LoadAndDelete(cmd)
+	if !ok || cmd.Process == nil {
+		return
+	}
+	if err := limitProcess(cmd.Process.Pid, limits.(processLimits)); err != nil {
+		limitWarning.Do(func() {
+			ffmpegLog.Warn("Could not limit the resources of ffmpeg", "err", err)
+		})
+		ffmpegLog.Debug("ffmpeg runs without limits", "pid", cmd.Process.Pid, "err", err)
+	}
+}
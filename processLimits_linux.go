@@ -0,0 +1,17 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// limitMemory caps the address space of pid with prlimit.
+func limitMemory(pid int, bytes uint64) error {
+	limit := syscall.Rlimit{Cur: bytes, Max: bytes}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), syscall.RLIMIT_AS,
+		uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
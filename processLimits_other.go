@@ -0,0 +1,10 @@
+//go:build !windows && !linux
+
+package main
+
+import "errors"
+
+// limitMemory is not available: macOS can't limit the resources of another process.
+func limitMemory(pid int, bytes uint64) error {
+	return errors.New("memory limits are not supported on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// prepareProcessLimits has nothing to set before the start on Unix.
+func prepareProcessLimits(cmd *exec.Cmd, limits processLimits) {}
+
+// limitProcess renices pid and caps its memory.
+func limitProcess(pid int, limits processLimits) error {
+	var errs []error
+	if limits.nice > 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, limits.nice); err != nil {
+			errs = append(errs, fmt.Errorf("niceness: %w", err))
+		}
+	}
+	if limits.memoryMB > 0 {
+		if err := limitMemory(pid, uint64(limits.memoryMB)<<20); err != nil {
+			errs = append(errs, fmt.Errorf("memory limit: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
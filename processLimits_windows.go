@@ -0,0 +1,87 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	belowNormalPriorityClass        = 0x00004000
+	jobObjectExtendedLimitInfoClass = 9
+	jobObjectLimitProcessMemory     = 0x00000100
+	processSetQuota                 = 0x0100
+	processTerminate                = 0x0001
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// prepareProcessLimits starts the process below normal priority; Windows has no finer
+// niceness.
+func prepareProcessLimits(cmd *exec.Cmd, limits processLimits) {
+	if limits.nice > 0 && cmd.SysProcAttr != nil {
+		cmd.SysProcAttr.CreationFlags |= belowNormalPriorityClass
+	}
+}
+
+// limitProcess caps the memory of pid with a job object of its own. The job lives on after
+// its handle is closed, as long as the process does.
+func limitProcess(pid int, limits processLimits) error {
+	if limits.memoryMB <= 0 {
+		return nil
+	}
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	job, _, err := kernel32.NewProc("CreateJobObjectW").Call(0, 0)
+	if job == 0 {
+		return fmt.Errorf("CreateJobObject: %w", err)
+	}
+	defer syscall.CloseHandle(syscall.Handle(job))
+
+	info := jobObjectExtendedLimitInformation{ProcessMemoryLimit: uintptr(limits.memoryMB) << 20}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitProcessMemory
+	if ok, _, err := kernel32.NewProc("SetInformationJobObject").Call(job, jobObjectExtendedLimitInfoClass,
+		uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); ok == 0 {
+		return fmt.Errorf("SetInformationJobObject: %w", err)
+	}
+
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(process)
+	if ok, _, err := kernel32.NewProc("AssignProcessToJobObject").Call(job, uintptr(process)); ok == 0 {
+		return fmt.Errorf("AssignProcessToJobObject: %w", err)
+	}
+	return nil
+}
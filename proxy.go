@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outbound requests (license, update checks, ffmpeg downloads) pick their proxy in this order:
+//  1. the "proxyUrl" setting, if set
+//  2. HTTPS_PROXY / HTTP_PROXY / NO_PROXY from the environment
+//  3. the operating system's proxy configuration (Windows and macOS)
+
+var (
+	systemProxyOnce sync.Once
+	systemProxy     *url.URL
+)
+
+// parseProxyURL accepts "host:port" as well as full URLs.
+func parseProxyURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme '%s'", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing proxy host")
+	}
+	return u, nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// proxyForRequest is used as http.Transport.Proxy for all outbound clients.
+func (a *App) proxyForRequest(req *http.Request) (*url.URL, error) {
+	if isLoopbackHost(req.URL.Hostname()) {
+		return nil, nil
+	}
+
+	if settings, err := a.GetSettings(); err == nil {
+		if manual := settingString(settings, "proxyUrl", ""); manual != "" {
+			u, err := parseProxyURL(manual)
+			if err != nil {
+				appLog.Warn("Ignoring invalid proxyUrl setting", "err", err)
+			} else {
+				return u, nil
+			}
+		}
+	}
+
+	if u, err := http.ProxyFromEnvironment(req); u != nil || err != nil {
+		return u, err
+	}
+
+	systemProxyOnce.Do(func() {
+		systemProxy = detectSystemProxy()
+		if systemProxy != nil {
+			appLog.Info("Using system proxy", "host", systemProxy.Host)
+		}
+	})
+	return systemProxy, nil
+}
+
+// externalHTTPClient returns a client for requests leaving the machine. A zero timeout
+// means no overall limit, which is what large downloads need.
+func (a *App) externalHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = a.proxyForRequest
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// detectSystemProxy reads the OS proxy configuration where Go doesn't do so itself.
+func detectSystemProxy() *url.URL {
+	switch goruntime.GOOS {
+	case "windows":
+		return windowsSystemProxy()
+	case "darwin":
+		return macSystemProxy()
+	}
+	return nil
+}
+
+func windowsSystemProxy() *url.URL {
+	out, err := ExecCommand("reg", "query", `HKCU\Software\Microsoft\Windows\CurrentVersion\Internet Settings`).Output()
+	if err != nil {
+		return nil
+	}
+	var enabled bool
+	var server string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[0] {
+		case "ProxyEnable":
+			enabled = fields[len(fields)-1] == "0x1"
+		case "ProxyServer":
+			server = fields[len(fields)-1]
+		}
+	}
+	if !enabled || server == "" {
+		return nil
+	}
+	// Either "host:port" or per-protocol "http=host:port;https=host:port".
+	if strings.Contains(server, "=") {
+		perProto := make(map[string]string)
+		for _, part := range strings.Split(server, ";") {
+			if proto, addr, ok := strings.Cut(part, "="); ok {
+				perProto[proto] = addr
+			}
+		}
+		server = perProto["https"]
+		if server == "" {
+			server = perProto["http"]
+		}
+	}
+	u, err := parseProxyURL(server)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+func macSystemProxy() *url.URL {
+	out, err := ExecCommand("scutil", "--proxy").Output()
+	if err != nil {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		key, val, ok := strings.Cut(line, ":")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+	for _, proto := range []string{"HTTPS", "HTTP"} {
+		if values[proto+"Enable"] == "1" && values[proto+"Proxy"] != "" {
+			u, err := parseProxyURL(net.JoinHostPort(values[proto+"Proxy"], values[proto+"Port"]))
+			if err == nil {
+				return u
+			}
+		}
+	}
+	return nil
+}
@@ -750,6 +750,7 @@ ffi.cdef [[
     BOOL SetHandleInformation(HANDLE hObject, DWORD dwMask, DWORD dwFlags);
     BOOL CloseHandle(HANDLE hObject);
     BOOL ReadFile(HANDLE hFile, void* lpBuffer, DWORD nNumberOfBytesToRead, DWORD* lpNumberOfBytesRead, void* lpOverlapped);
+    BOOL WriteFile(HANDLE hFile, const void* lpBuffer, DWORD nNumberOfBytesToWrite, DWORD* lpNumberOfBytesWritten, void* lpOverlapped);
     BOOL CreateProcessW(LPCWSTR, wchar_t*, void*, void*, BOOL, DWORD, void*, LPCWSTR, STARTUPINFOW*, PROCESS_INFORMATION*);
     HANDLE CreateFileW(LPCWSTR, DWORD, DWORD, SECURITY_ATTRIBUTES*, DWORD, DWORD, HANDLE);
 ]]
@@ -779,24 +780,43 @@ local function utf8_to_utf16(str)
 end
 
 -- popen_hidden: returns an object with :read("*a") and :close()
-local function popen_hidden_windows(cmdline)
+-- stdin_data, when given, is written to the child's stdin, which is then closed; otherwise
+-- stdin is NUL.
+local function popen_hidden_windows(cmdline, stdin_data)
   local sa = ffi.new("SECURITY_ATTRIBUTES"); sa.nLength = ffi.sizeof(sa); sa.bInheritHandle = 1
   local hRead, hWrite = ffi.new("HANDLE[1]"), ffi.new("HANDLE[1]")
   if ffi.C.CreatePipe(hRead, hWrite, sa, 0) == 0 then error("CreatePipe failed") end
   ffi.C.SetHandleInformation(hRead[0], HANDLE_FLAG_INHERIT, 0)
-  local hReadNul = ffi.C.CreateFileW(utf8_to_utf16("NUL"), GENERIC_READ, FILE_SHARE_READ, sa, OPEN_EXISTING, 0, nil)
-  if hReadNul == ffi.cast("HANDLE", -1) then error("CreateFileW for NUL failed") end
+  local hStdin, hStdinWrite
+  if stdin_data then
+    local hIn, hInWrite = ffi.new("HANDLE[1]"), ffi.new("HANDLE[1]")
+    if ffi.C.CreatePipe(hIn, hInWrite, sa, 0) == 0 then error("CreatePipe for stdin failed") end
+    ffi.C.SetHandleInformation(hInWrite[0], HANDLE_FLAG_INHERIT, 0)
+    hStdin, hStdinWrite = hIn[0], hInWrite[0]
+  else
+    hStdin = ffi.C.CreateFileW(utf8_to_utf16("NUL"), GENERIC_READ, FILE_SHARE_READ, sa, OPEN_EXISTING, 0, nil)
+    if hStdin == ffi.cast("HANDLE", -1) then error("CreateFileW for NUL failed") end
+  end
 
   local si = ffi.new("STARTUPINFOW"); si.cb = ffi.sizeof(si)
   si.dwFlags = STARTF_USESTDHANDLES
-  si.hStdInput, si.hStdOutput, si.hStdError = hReadNul, hWrite[0], hWrite[0]
+  si.hStdInput, si.hStdOutput, si.hStdError = hStdin, hWrite[0], hWrite[0]
 
   local pi = ffi.new("PROCESS_INFORMATION")
   local creation_flags = bit.bor(CREATE_NO_WINDOW, DETACHED_PROCESS)
   local ok = ffi.C.CreateProcessW(nil, utf8_to_utf16(cmdline), nil, nil, true, creation_flags, nil, nil, si, pi)
 
   ffi.C.SetHandleInformation(hWrite[0], HANDLE_FLAG_INHERIT, 0)
-  ffi.C.CloseHandle(hReadNul); ffi.C.CloseHandle(hWrite[0])
+  ffi.C.CloseHandle(hStdin); ffi.C.CloseHandle(hWrite[0])
+  if hStdinWrite then
+    -- The data is far below the pipe buffer, so this does not block; closing the handle
+    -- gives the child EOF.
+    if ok ~= 0 then
+      local written = ffi.new("DWORD[1]")
+      ffi.C.WriteFile(hStdinWrite, stdin_data, #stdin_data, written, nil)
+    end
+    ffi.C.CloseHandle(hStdinWrite)
+  end
   if ok == 0 then
     ffi.C.CloseHandle(hRead[0]); error("CreateProcessW failed")
   end
@@ -834,10 +854,10 @@ local function popen_hidden_windows(cmdline)
   return handle_obj
 end
 
-local function popen_hidden(cmd, os_type)
+local function popen_hidden(cmd, os_type, stdin_data)
   if os_type == "Windows" then
     -- use the FFI-based implementation we built
-    return popen_hidden_windows(cmd) -- the CreateProcessW + pipe version
+    return popen_hidden_windows(cmd, stdin_data) -- the CreateProcessW + pipe version
   else
     -- just use normal io.popen on Linux/macOS
     return io.popen(cmd)
@@ -948,6 +968,8 @@ function GetWinInstallPath()
 end
 
 local go_server_port = nil
+-- Go mounts /msg under a random prefix for each session and sends it with /register
+local go_endpoint_prefix = ""
 local TEMP_DIR = path_join(script_dir, ".hushcut_res", "tmp")
 local AUTH_TOKEN = ""
 
@@ -991,7 +1013,7 @@ local function send_message_to_go(message_type, payload, task_id)
   end
 
 
-  local path = "/msg"
+  local path = go_endpoint_prefix .. "/msg"
   if task_id then
     path = path .. "?task_id=" .. task_id
   end
@@ -1424,12 +1446,9 @@ function PrintTable(t, indent)
   end
 end
 
-local function generate_uuid_from_nested_clips(top_level_item, nested_clips)
-  -- 1. Start with the top-level clip's unique properties.
-  local bmd_item = top_level_item.bmd_mpi
-  local seed_string = bmd_item and ("bmd_id:" .. bmd_item:GetUniqueId() .. ";") or "bmd_id:<unknown>;"
-
-  -- 2. Add properties from all nested clips.
+-- Returns a canonical string of the nested clips' content. A nested compound clip is
+-- represented by its processed file name, which is derived from its own content.
+local function nested_clips_signature(nested_clips)
   -- Sort by the clip's start time within the container.
   table.sort(nested_clips, function(a, b) return a.start_frame < b.start_frame end)
 
@@ -1439,12 +1458,16 @@ local function generate_uuid_from_nested_clips(top_level_item, nested_clips)
       tostring(clip.source_file_path), tostring(clip.start_frame), tostring(clip.end_frame),
       tostring(clip.source_start_frame), tostring(clip.source_end_frame)
     )
+    if clip.nested_items and #clip.nested_items > 0 then
+      clip_signature = clip_signature .. ",compound:" .. tostring(clip.processed_file_name)
+    end
     table.insert(nested_strings, clip_signature)
   end
+  return table.concat(nested_strings, "||")
+end
 
-  seed_string = seed_string .. "nested_clips[" .. table.concat(nested_strings, "||") .. "]"
-
-  -- 3. Generate a deterministic UUID from the canonical seed string by calling Go helper.
+-- Generates a deterministic UUID from seed_string by calling the Go helper.
+local function uuid_from_seed(seed_string)
   local command = string.format("%s --lua-helper --uuid-from-str '%s'", quote(lua_helper_path), seed_string)
   local handle = popen_hidden(command, os_type)
   if not handle then
@@ -1466,6 +1489,18 @@ local function generate_uuid_from_nested_clips(top_level_item, nested_clips)
   end
 end
 
+local function generate_uuid_from_nested_clips(top_level_item, nested_clips)
+  -- 1. Start with the top-level clip's unique properties.
+  local bmd_item = top_level_item.bmd_mpi
+  local seed_string = bmd_item and ("bmd_id:" .. bmd_item:GetUniqueId() .. ";") or "bmd_id:<unknown>;"
+
+  -- 2. Add properties from all nested clips.
+  seed_string = seed_string .. "nested_clips[" .. nested_clips_signature(nested_clips) .. "]"
+
+  -- 3. Generate a deterministic UUID from the canonical seed string.
+  return uuid_from_seed(seed_string)
+end
+
 ---
 -- Finds unique compound/multicam content and updates their properties.
 -- @param audio_timeline_items (table) A list of all audio items from the timeline.
@@ -1760,6 +1795,24 @@ local function _create_nested_audio_item_from_otio(otio_clip, clip_start_in_cont
   return nested_item
 end
 
+-- Builds the item of a compound clip nested in another one. It has no source file of its
+-- own; its processed file is the mixdown of nested_items, named after their content.
+local function _create_nested_compound_item(nested_items, clip_start_in_container, source_start_frame, duration_frames)
+  local content_uuid = uuid_from_seed("nested_compound;nested_clips[" .. nested_clips_signature(nested_items) .. "]")
+  return {
+    source_file_path = "",
+    processed_file_name = content_uuid .. ".wav",
+    source_channel = { stream_idx = 0, channel_idx = 0 },
+    start_frame = clip_start_in_container,
+    end_frame = clip_start_in_container + duration_frames,
+    source_start_frame = source_start_frame,
+    source_end_frame = source_start_frame + duration_frames,
+    duration = duration_frames,
+    edit_instructions = {},
+    nested_items = nested_items,
+  }
+end
+
 local _recursive_otio_parser -- Forward declaration
 _recursive_otio_parser = function(otio_composable, timeline_fps, active_angle_name, container_duration)
   local found_clips = {}
@@ -1791,12 +1844,16 @@ _recursive_otio_parser = function(otio_composable, timeline_fps, active_angle_na
                   effective_duration)
                 if item then table.insert(found_clips, item) end
               elseif schema:find("stack", 1, true) then
-                local nested_clips = _recursive_otio_parser(item_in_track, timeline_fps, active_angle_name,
-                  container_duration)
-                for _, nested_clip in ipairs(nested_clips) do
-                  nested_clip.start_frame = nested_clip.start_frame + playhead
-                  nested_clip.end_frame = nested_clip.end_frame + playhead
-                  table.insert(found_clips, nested_clip)
+                -- A compound clip inside the compound keeps its own clips as nested_items: Go
+                -- mixes it down first and uses that mixdown like any other source, so compounds
+                -- can nest to any depth.
+                local start_rt = safe_get(item_in_track, "source_range", "start_time") or {}
+                local inner_start = (start_rt.value or 0.0) / (start_rt.rate or timeline_fps) * timeline_fps
+                local inner_clips = _recursive_otio_parser(item_in_track, timeline_fps, active_angle_name,
+                  inner_start + item_duration)
+                if #inner_clips > 0 then
+                  table.insert(found_clips,
+                    _create_nested_compound_item(inner_clips, playhead, inner_start, item_duration))
                 end
               end
             end
@@ -2980,7 +3037,44 @@ end
 
 --- @param sync boolean
 --- @param task_id string|nil
-local function main(sync, task_id)
+local function find_timeline(proj, name)
+  for index = 1, proj:GetTimelineCount() do
+    local candidate = proj:GetTimelineByIndex(index)
+    if candidate and candidate:GetName() == name then
+      return candidate
+    end
+  end
+  return nil
+end
+
+-- Sends the names of the current project's timelines, in Resolve's order.
+local function list_timelines(task_id)
+  if not resolve_obj then
+    resolve_obj = get_resolve()
+  end
+  pm = resolve_obj and resolve_obj:GetProjectManager()
+  project = pm and pm:GetCurrentProject()
+  if not project then
+    send_result_with_alert("No open project", "Please open a project.", task_id, "error")
+    return
+  end
+
+  local names = {}
+  for index = 1, project:GetTimelineCount() do
+    local candidate = project:GetTimelineByIndex(index)
+    if candidate then
+      table.insert(names, candidate:GetName())
+    end
+  end
+  local current = project:GetCurrentTimeline()
+  send_message_to_go("taskResult", {
+    status = "success",
+    message = #names .. " timelines.",
+    data = { timelines = names, current = current and current:GetName() or "" },
+  }, task_id)
+end
+
+local function main(sync, task_id, timeline_name)
   if not resolve_obj then
     resolve_obj = get_resolve()
   end
@@ -3013,6 +3107,15 @@ local function main(sync, task_id)
     return
   end
 
+  if timeline_name then
+    local requested = find_timeline(project, timeline_name)
+    if not requested or not project:SetCurrentTimeline(requested) then
+      project_data = nil
+      send_result_with_alert("Timeline not found", "There is no timeline named '" .. timeline_name .. "' in this project.", task_id, "error")
+      return
+    end
+  end
+
   timeline = project:GetCurrentTimeline()
   if not timeline then
     project_data = nil
@@ -3069,6 +3172,26 @@ local function main(sync, task_id)
 end
 
 
+-- Adds markers to the current timeline. Frames are absolute timeline frames, as in a timeline
+-- item's start_frame; Resolve counts marker frames from the timeline's start.
+local function add_markers(markers)
+  if not resolve_obj or not timeline then
+    print("No timeline found. Cannot add markers.")
+    return 0
+  end
+
+  local timeline_start = timeline:GetStartFrame()
+  local added = 0
+  for _, marker in ipairs(markers or {}) do
+    local frame = math.floor((marker.frame or 0) + 0.5) - timeline_start
+    local duration = math.max(1, math.floor((marker.duration or 1) + 0.5))
+    if frame >= 0 and timeline:AddMarker(frame, marker.color or "Yellow", marker.name or "", marker.note or "", duration, marker.customData or "") then
+      added = added + 1
+    end
+  end
+  return added
+end
+
 local function set_timecode(time_value)
   if not resolve_obj then
     print("Resolve not found. Cannot set playhead.")
@@ -3110,19 +3233,19 @@ if go_app_path and free_port then
       quote(go_app_path),
       free_port
     )
-    local env = { [token_env_var] = AUTH_TOKEN }
+    local env = { [token_env_var] = AUTH_TOKEN, HUSHCUT_LAUNCHED_BY = "resolve" }
 
     print("Starting HushCut app with command: " .. command_line)
     execute_hidden_with_env(command_line, env)
   else
     if os_type == "Linux" then
       hushcut_command = string.format(
-        "env %s=%s GDK_BACKEND=x11 %s --python-port=%s &",
+        "env %s=%s HUSHCUT_LAUNCHED_BY=resolve GDK_BACKEND=x11 %s --python-port=%s &",
         token_env_var, AUTH_TOKEN, quote(go_app_path), free_port
       )
     else -- OSX
       hushcut_command = string.format(
-        "%s=%s %s --python-port=%s &",
+        "%s=%s HUSHCUT_LAUNCHED_BY=resolve %s --python-port=%s &",
         token_env_var, AUTH_TOKEN, quote(go_app_path), free_port
       )
     end
@@ -3130,44 +3253,42 @@ if go_app_path and free_port then
     os.execute(hushcut_command)
   end
 
-  local server_command = quote(lua_helper_path) .. " --lua-helper" .. " --port=" .. free_port .. " 2>&1"
-  print("Starting http server with command: " .. server_command)
-  local handle = popen_hidden(server_command, os_type)
+  -- The helper refuses every request without a token. CreateProcessW has no shell to set
+  -- an environment variable with, and a --token argument would show up in the process
+  -- list, so Windows writes the token to the helper's stdin.
+  local server_command, server_stdin
+  if os_type == "Windows" then
+    server_command = quote(lua_helper_path) .. " --lua-helper --port=" .. free_port .. " --token-stdin 2>&1"
+    server_stdin = AUTH_TOKEN .. "\n"
+  else
+    server_command = token_env_var .. "=" .. AUTH_TOKEN .. " " .. quote(lua_helper_path) .. " --lua-helper --port=" .. free_port .. " 2>&1"
+  end
+  print("Starting http server on port " .. free_port)
+  local handle = popen_hidden(server_command, os_type, server_stdin)
   if not handle then
     print("Failed to start http server.")
     return
   end
 
-  local req_auth = ""
   for line in handle:lines() do
-    print("server output: " .. line)
-
-    if line:find("---- Incoming Request ----") then
-      -- reset auth for new request
-      req_auth = ""
-    end
-
-    if line:find("Header: Authorization: Bearer") then
-      req_auth = line:match("Authorization: Bearer (.*)")
-      print("request auth is:<" .. req_auth .. ">")
+    -- The helper only relays requests that carried AUTH_TOKEN, as "Relay: <json>" lines;
+    -- everything else is its log.
+    local json_str = line:match("^Relay: ({.*)")
+    if not json_str then
+      print("server output: " .. line)
     end
 
     -- try to parse the line as JSON
     local json_data, pos, err = nil, nil, nil
     local params = nil
     local task_id = nil
-    if line:find("Body: {") then
-      local json_str = line:match("Body: (.*)")
-      if json_str then
-        json_data, pos, err = json.decode(json_str, 1, nil)
-        if err then
-          print("Error parsing JSON: " .. err)
-        else
-          -- print the parsed JSON data
-          -- print("Parsed JSON data: " .. json.encode(json_data))
-        end
+    if json_str then
+      json_data, pos, err = json.decode(json_str, 1, nil)
+      if err then
+        print("Error parsing JSON: " .. err)
       else
-        print("No JSON found in line: " .. line)
+        -- print the parsed JSON data
+        -- print("Parsed JSON data: " .. json.encode(json_data))
       end
     end
 
@@ -3179,22 +3300,18 @@ if go_app_path and free_port then
       task_id = params.taskId
     end
 
-    local auth_passed = false
-    if req_auth == AUTH_TOKEN then
-      auth_passed = true
-    else
-      print("auth failed")
-    end
-
     if json_data and json_data.go_server_port then
       print("Register endpoint called.")
       go_server_port = json_data.go_server_port
+      go_endpoint_prefix = json_data.endpoint_prefix or go_endpoint_prefix
       print("Go server port detected: " .. go_server_port)
-    elseif auth_passed and json_data and json_data.command then
+    elseif json_data and json_data.command then
       local command = json_data.command
       print("Command detected: " .. command)
       if command == "sync" then
-        main(true, task_id)
+        main(true, task_id, params and params.timelineName)
+      elseif command == "listTimelines" then
+        list_timelines(task_id)
       elseif command == "makeFinalTimeline" then
         print("Make final timeline command detected.")
         if params and params.projectData then
@@ -3211,7 +3328,7 @@ if go_app_path and free_port then
         else
           send_result_with_alert("Data Error", "makeFinalTimeline command received without projectData.", task_id)
         end
-      elseif auth_passed and command == "saveProject" then
+      elseif command == "saveProject" then
         if not pm then
           main(true)
         end
@@ -3222,7 +3339,10 @@ if go_app_path and free_port then
         else
           send_result_with_alert("Error", "No project is open to save.", task_id)
         end
-      elseif auth_passed and command == "setPlayhead" then
+      elseif command == "addMarkers" then
+        local added = add_markers(params and params.markers)
+        print("Added " .. added .. " markers.")
+      elseif command == "setPlayhead" then
         if params then
           local time_value = params.time
           if time_value and set_timecode(time_value) then
@@ -49,8 +49,15 @@ MEDIA_POOL = None
 
 # This will be the token Go sends, which Python expects for Go-to-Python commands (future)
 AUTH_TOKEN: str = ""
+# After /rotate_token the old token keeps working until Go authenticates with the new one,
+# at most for TOKEN_ROTATION_GRACE_SECONDS, in case Go never saw the confirmation.
+PREVIOUS_AUTH_TOKEN: str = ""
+PREVIOUS_AUTH_TOKEN_UNTIL = 0.0
+TOKEN_ROTATION_GRACE_SECONDS = 30
 ENABLE_COMMAND_AUTH = False  # Master switch for auth on Python's command server
 GO_SERVER_PORT = 0
+# Go mounts /msg and /ready under a random prefix for each session
+GO_ENDPOINT_PREFIX = ""
 PYTHON_LISTEN_PORT = 0
 SERVER_INSTANCE_HOLDER = []
 SHUTDOWN_EVENT = threading.Event()
@@ -448,6 +455,34 @@ def _create_nested_audio_item_from_otio(
     return nested_item
 
 
+def _create_nested_compound_item(
+    nested_items: List[NestedAudioTimelineItem],
+    clip_start_in_container: float,
+    source_start_frame: float,
+    duration_frames: float,
+) -> NestedAudioTimelineItem:
+    """
+    Builds the item of a compound clip nested in another one. It has no source file of
+    its own; its processed file is the mixdown of nested_items, named after their content.
+    """
+    content_uuid = uuid.uuid5(
+        uuid.NAMESPACE_DNS,
+        "nested_compound;nested_clips[" + _nested_clips_signature(nested_items) + "]",
+    )
+    return {
+        "source_file_path": "",
+        "processed_file_name": f"{content_uuid}.wav",
+        "source_channel": {"stream_idx": 0, "channel_idx": 0},
+        "start_frame": clip_start_in_container,
+        "end_frame": clip_start_in_container + duration_frames,
+        "source_start_frame": source_start_frame,
+        "source_end_frame": source_start_frame + duration_frames,
+        "duration": duration_frames,
+        "edit_instructions": [],
+        "nested_items": nested_items,
+    }
+
+
 def _recursive_otio_parser(
     otio_composable: Dict[str, Any],
     timeline_fps: float,
@@ -505,17 +540,27 @@ def _recursive_otio_parser(
                     found_clips.append(item)
 
             elif "stack" in schema:
-                # Pass both constraints down in the recursion.
-                nested_clips = _recursive_otio_parser(
+                # A compound clip inside the compound keeps its own clips as nested_items:
+                # Go mixes it down first and uses that mixdown like any other source, so
+                # compounds can nest to any depth.
+                start_rt = (item_in_track.get("source_range") or {}).get("start_time", {})
+                inner_start = (
+                    start_rt.get("value", 0.0)
+                    / start_rt.get("rate", timeline_fps)
+                    * timeline_fps
+                )
+                inner_clips = _recursive_otio_parser(
                     item_in_track,
                     timeline_fps,
                     active_angle_name=active_angle_name,
-                    container_duration=container_duration,
+                    container_duration=inner_start + item_duration,
                 )
-                for nested_clip in nested_clips:
-                    nested_clip["start_frame"] += playhead
-                    nested_clip["end_frame"] += playhead
-                    found_clips.append(nested_clip)
+                if inner_clips:
+                    found_clips.append(
+                        _create_nested_compound_item(
+                            inner_clips, playhead, inner_start, item_duration
+                        )
+                    )
 
             playhead += item_duration
 
@@ -1181,7 +1226,9 @@ def send_message_to_go(message_type: str, payload: Any, task_id: Optional[str] =
         go_message = {"Type": message_type, "Payload": payload}
         json_payload = json.dumps(go_message, default=fallback_serializer)
 
-        path = f"/msg?task_id={task_id}" if task_id else "/msg"
+        path = f"{GO_ENDPOINT_PREFIX}/msg"
+        if task_id:
+            path += f"?task_id={task_id}"
         conn.request("POST", path, body=json_payload, headers=headers)
         response = conn.getresponse()
 
@@ -1512,6 +1559,32 @@ def _verify_timeline_state(
         return False
 
 
+def _nested_clips_signature(nested_clips: list[NestedAudioTimelineItem]) -> str:
+    """
+    Returns a canonical string of the nested clips' content. A nested compound clip is
+    represented by its processed file name, which is derived from its own content.
+    """
+    # We must sort the clips to ensure the order is always the same,
+    # otherwise the same content could produce different UUIDs.
+    # We sort by the clip's start time within the container.
+    sorted_nested_clips = sorted(nested_clips, key=lambda x: x["start_frame"])
+
+    nested_strings = []
+    for clip in sorted_nested_clips:
+        # Create a unique signature for each nested clip
+        clip_signature = (
+            f"path:{clip['source_file_path']},"
+            f"start:{clip['start_frame']},"
+            f"end:{clip['end_frame']},"
+            f"s_start:{clip['source_start_frame']},"
+            f"s_end:{clip['source_end_frame']}"
+        )
+        if clip.get("nested_items"):
+            clip_signature += f",compound:{clip['processed_file_name']}"
+        nested_strings.append(clip_signature)
+    return "||".join(nested_strings)
+
+
 def generate_uuid_from_nested_clips(
     top_level_item: TimelineItem, nested_clips: list[NestedAudioTimelineItem]
 ) -> str:
@@ -1538,25 +1611,7 @@ def generate_uuid_from_nested_clips(
     )
 
     # 2. Add properties from all nested clips.
-    # We must sort the clips to ensure the order is always the same,
-    # otherwise the same content could produce different UUIDs.
-    # We sort by the clip's start time within the container.
-    sorted_nested_clips = sorted(nested_clips, key=lambda x: x["start_frame"])
-
-    nested_strings = []
-    for clip in sorted_nested_clips:
-        # Create a unique signature for each nested clip
-        clip_signature = (
-            f"path:{clip['source_file_path']},"
-            f"start:{clip['start_frame']},"
-            f"end:{clip['end_frame']},"
-            f"s_start:{clip['source_start_frame']},"
-            f"s_end:{clip['source_end_frame']}"
-        )
-        nested_strings.append(clip_signature)
-
-    # Combine all nested signatures into the main seed string
-    seed_string += "nested_clips[" + "||".join(nested_strings) + "]"
+    seed_string += "nested_clips[" + _nested_clips_signature(nested_clips) + "]"
 
     # 3. Generate a UUIDv5 hash from the canonical seed string.
     # UUIDv5 is perfect for this as it's designed to create a deterministic
@@ -1850,7 +1905,81 @@ def set_timecode(timecode: str, task_id: str = "") -> bool:
     return True
 
 
-def main(sync: bool = False, task_id: str = "") -> Optional[bool]:
+def add_markers(markers: List[Dict[str, Any]]) -> int:
+    """Adds markers to the current timeline. Frames are absolute timeline frames, as in
+    TimelineItem.start_frame; Resolve counts marker frames from the timeline's start."""
+    global TIMELINE
+    if not RESOLVE or not TIMELINE:
+        return 0
+
+    timeline_start = TIMELINE.GetStartFrame()
+    added = 0
+    for marker in markers:
+        frame = int(round(marker.get("frame", 0))) - timeline_start
+        duration = max(1, int(round(marker.get("duration", 1))))
+        if frame < 0:
+            continue
+        if TIMELINE.AddMarker(
+            frame,
+            marker.get("color", "Yellow"),
+            marker.get("name", ""),
+            marker.get("note", ""),
+            duration,
+            marker.get("customData", ""),
+        ):
+            added += 1
+    return added
+
+
+def find_timeline(project, name: str):
+    for index in range(1, project.GetTimelineCount() + 1):
+        timeline = project.GetTimelineByIndex(index)
+        if timeline and timeline.GetName() == name:
+            return timeline
+    return None
+
+
+def list_timelines(task_id: str = "") -> None:
+    """Sends the names of the current project's timelines, in Resolve's order."""
+    global PROJECT
+    if not RESOLVE:
+        get_resolve(task_id)
+    if not RESOLVE or not RESOLVE.GetProjectManager():
+        send_result_with_alert(
+            "DaVinci Resolve Error",
+            "Could not connect to DaVinci Resolve. Is it running?",
+            task_id,
+            "warning",
+        )
+        return
+    PROJECT = RESOLVE.GetProjectManager().GetCurrentProject()
+    if not PROJECT:
+        send_result_with_alert("No open project", "Please open a project.", task_id)
+        return
+
+    names = []
+    for index in range(1, PROJECT.GetTimelineCount() + 1):
+        timeline = PROJECT.GetTimelineByIndex(index)
+        if timeline:
+            names.append(timeline.GetName())
+    current = PROJECT.GetCurrentTimeline()
+    send_message_to_go(
+        "taskResult",
+        {
+            "status": "success",
+            "message": f"{len(names)} timelines.",
+            "data": {
+                "timelines": names,
+                "current": current.GetName() if current else "",
+            },
+        },
+        task_id=task_id,
+    )
+
+
+def main(
+    sync: bool = False, task_id: str = "", timeline_name: Optional[str] = None
+) -> Optional[bool]:
     global RESOLVE
     global TEMP_DIR
     global PROJECT
@@ -1908,6 +2037,17 @@ def main(sync: bool = False, task_id: str = "") -> Optional[bool]:
         )
         return False
 
+    if timeline_name:
+        requested = find_timeline(PROJECT, timeline_name)
+        if not requested or not PROJECT.SetCurrentTimeline(requested):
+            PROJECT_DATA = None
+            send_result_with_alert(
+                "Timeline not found",
+                f"There is no timeline named '{timeline_name}' in this project.",
+                task_id,
+            )
+            return False
+
     TIMELINE = PROJECT.GetCurrentTimeline()
     if not TIMELINE:
         PROJECT_DATA = None
@@ -2536,7 +2676,7 @@ def signal_go_ready(go_server_port: int):
     Sends an HTTP GET request to the Go server to signal readiness.
     Retries a few times in case the Go server isn't immediately available.
     """
-    ready_url = f"http://localhost:{go_server_port}/ready"
+    ready_url = f"http://localhost:{go_server_port}{GO_ENDPOINT_PREFIX}/ready"
     parsed_url = urllib.parse.urlparse(ready_url)
 
     # Ensure hostname and port are not None
@@ -2551,7 +2691,11 @@ def signal_go_ready(go_server_port: int):
     for attempt in range(max_retries):
         try:
             conn = http.client.HTTPConnection(host, port, timeout=10)
-            conn.request("GET", parsed_url.path)
+            conn.request(
+                "GET",
+                parsed_url.path,
+                headers={"Authorization": f"Bearer {AUTH_TOKEN}"},
+            )
             response = conn.getresponse()
             status = response.status
             body = response.read().decode()
@@ -2591,14 +2735,13 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
         """Sends a JSON response with the given status code and data."""
         self.send_response(status_code)
         self.send_header("Content-type", "application/json")
-        self.send_header("Authorization", value=AUTH_TOKEN)
         self.end_headers()
         self.wfile.write(json.dumps(data_dict).encode("utf-8"))
 
     def do_POST(self):
         """Routes POST requests to the appropriate handler based on the URL path."""
         global PROJECT_DATA
-        global AUTH_TOKEN
+        global AUTH_TOKEN, PREVIOUS_AUTH_TOKEN, PREVIOUS_AUTH_TOKEN_UNTIL
         # --- Route 1: /register ---
         # Handles the initial registration from the Go application.
 
@@ -2608,6 +2751,11 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
             req_token = auth_header.split("Bearer")[1].strip()
 
         auth_passed = req_token == AUTH_TOKEN
+        if auth_passed and PREVIOUS_AUTH_TOKEN:
+            # Go uses the new token, so it has switched.
+            PREVIOUS_AUTH_TOKEN = ""
+        elif PREVIOUS_AUTH_TOKEN and req_token == PREVIOUS_AUTH_TOKEN:
+            auth_passed = time() < PREVIOUS_AUTH_TOKEN_UNTIL
         if not auth_passed:
             print("unauthorized request received.")
             self._send_json_response(
@@ -2622,8 +2770,9 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
                 data = json.loads(post_data.decode("utf-8"))
                 port = data.get("go_server_port")
                 if port:
-                    global GO_SERVER_PORT
+                    global GO_SERVER_PORT, GO_ENDPOINT_PREFIX
                     GO_SERVER_PORT = port
+                    GO_ENDPOINT_PREFIX = data.get("endpoint_prefix", GO_ENDPOINT_PREFIX)
                     print(f"Python Command Server: Registered Go server on port {port}")
                     self._send_json_response(
                         200, {"status": "success", "message": "Go server registered."}
@@ -2638,6 +2787,27 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
                 )
             return
 
+        # Go replaces the token it authenticates with; the one this request
+        # carried stays valid until Go uses the new one or the grace period ends.
+        elif self.path == "/rotate_token":
+            try:
+                content_length = int(self.headers["Content-Length"])
+                data = json.loads(self.rfile.read(content_length).decode("utf-8"))
+                new_token = data.get("token") if isinstance(data, dict) else None
+            except (json.JSONDecodeError, ValueError, TypeError):
+                new_token = None
+            if not isinstance(new_token, str) or not new_token.strip():
+                self._send_json_response(
+                    400, {"status": "error", "message": "Missing 'token'."}
+                )
+                return
+            PREVIOUS_AUTH_TOKEN = req_token
+            PREVIOUS_AUTH_TOKEN_UNTIL = time() + TOKEN_ROTATION_GRACE_SECONDS
+            AUTH_TOKEN = new_token.strip()
+            print("Python Command Server: Auth token rotated.")
+            self._send_json_response(200, {"status": "success", "rotated": True})
+            return
+
         # --- Route 2: /shutdown ---
         # Handles the shutdown signal from the Go application. No request body is expected.
         elif self.path == "/shutdown":
@@ -2669,9 +2839,21 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
                     self._send_json_response(
                         200, {"status": "success", "message": "Sync command received."}
                     )
-                    main(sync=True, task_id=task_id)
+                    main(
+                        sync=True,
+                        task_id=task_id,
+                        timeline_name=params.get("timelineName"),
+                    )
                     return  # Important: return after handling a command
 
+                elif command == "listTimelines":
+                    self._send_json_response(
+                        200,
+                        {"status": "success", "message": "Listing timelines."},
+                    )
+                    list_timelines(task_id)
+                    return
+
                 elif command == "makeFinalTimeline":
                     project_data_from_go_raw = params.get("projectData")
                     global MAKE_NEW_TIMELINE
@@ -2729,6 +2911,25 @@ class PythonCommandHandler(BaseHTTPRequestHandler):
                         )
                     return
 
+                elif command == "addMarkers":
+                    markers = params.get("markers") or []
+                    if not TIMELINE:
+                        self._send_json_response(
+                            400,
+                            {"status": "error", "message": "No timeline is open."},
+                        )
+                        return
+                    added = add_markers(markers)
+                    self._send_json_response(
+                        200,
+                        {
+                            "status": "success",
+                            "message": f"Added {added} of {len(markers)} markers.",
+                            "data": {"added": added},
+                        },
+                    )
+                    return
+
                 # IMPORTANT: The shutdown command is now handled by the /shutdown endpoint, not here.
                 # It has been removed from this section.
 
@@ -2793,8 +2994,13 @@ def init():
     global PYTHON_LISTEN_PORT
     global SERVER_INSTANCE_HOLDER
     global AUTH_TOKEN
+    global GO_ENDPOINT_PREFIX
 
-    AUTH_TOKEN = read_stdin_nonblocking()
+    # Go writes a new token for every launch to stdin, followed by the prefix of
+    # its internal endpoints; neither is taken from the command line or the environment.
+    stdin_lines = read_stdin_nonblocking().splitlines()
+    AUTH_TOKEN = stdin_lines[0].strip() if stdin_lines else ""
+    GO_ENDPOINT_PREFIX = stdin_lines[1].strip() if len(stdin_lines) > 1 else ""
     if AUTH_TOKEN == "":
         print("No token specified, exiting.")
         return
@@ -2806,7 +3012,6 @@ def init():
     parser.add_argument(
         "-lp", "--listen-on-port", type=int, default=0
     )  # port to receive commands from go
-    parser.add_argument("--auth-token", type=str)  # authorization token
     parser.add_argument("--ffmpeg", default="ffmpeg")
     parser.add_argument("-s", "--sync", action="store_true")
     parser.add_argument("--standalone", action="store_true")
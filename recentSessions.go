@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	recentSessionsFileName     = "recent_sessions.json"
+	defaultRecentSessionsLimit = 10
+)
+
+// RecentSession is one synced project+timeline combination with the parameters used on it.
+type RecentSession struct {
+	ProjectName  string          `json:"projectName"`
+	TimelineName string          `json:"timelineName"`
+	TimelineFPS  float64         `json:"timelineFps"`
+	ClipCount    int             `json:"clipCount"`
+	LastSynced   time.Time       `json:"lastSynced"`
+	LastFinished *time.Time      `json:"lastFinished,omitempty"`
+	Params       DetectionParams `json:"params"`
+}
+
+func (s RecentSession) key() string {
+	return s.ProjectName + "\x00" + s.TimelineName
+}
+
+func (a *App) getRecentSessionsPath() string {
+	return filepath.Join(a.userResourcesPath, recentSessionsFileName)
+}
+
+func (a *App) readRecentSessions() []RecentSession {
+	sessions := []RecentSession{}
+	if err := readJSONWithRecovery(a.getRecentSessionsPath(), &sessions); err != nil {
+		if !os.IsNotExist(err) {
+			appLog.Warn("Error reading recent sessions", "err", err)
+		}
+		return []RecentSession{}
+	}
+	return sessions
+}
+
+func (a *App) writeRecentSessions(sessions []RecentSession) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent sessions: %w", err)
+	}
+	if err := writeFileAtomic(a.getRecentSessionsPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write recent sessions: %w", err)
+	}
+	return nil
+}
+
+// recordRecentSession moves the session to the top of the history, merging it with
+// any previous entry for the same project and timeline.
+func (a *App) recordRecentSession(update func(*RecentSession), projectName string, timelineName string) {
+	a.recentMu.Lock()
+	defer a.recentMu.Unlock()
+
+	sessions := a.readRecentSessions()
+	entry := RecentSession{ProjectName: projectName, TimelineName: timelineName}
+	remaining := make([]RecentSession, 0, len(sessions))
+	for _, s := range sessions {
+		if s.key() == entry.key() {
+			entry = s
+			continue
+		}
+		remaining = append(remaining, s)
+	}
+	update(&entry)
+
+	limit := defaultRecentSessionsLimit
+	if settings, err := a.GetSettings(); err == nil {
+		limit = settingInt(settings, "recentSessionsLimit", defaultRecentSessionsLimit)
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	sessions = append([]RecentSession{entry}, remaining...)
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	if err := a.writeRecentSessions(sessions); err != nil {
+		appLog.Warn("Could not save recent sessions", "err", err)
+		return
+	}
+	a.emit("recentSessions:changed", sessions)
+}
+
+// recordSyncedSession is called whenever a project's audio is (re)processed after a sync.
+func (a *App) recordSyncedSession(projectData *ProjectDataPayload) {
+	if projectData.ProjectName == "" && projectData.Timeline.Name == "" {
+		return
+	}
+	params := a.GetCurrentParams()
+	a.recordRecentSession(func(s *RecentSession) {
+		s.TimelineFPS = projectData.Timeline.FPS
+		s.ClipCount = len(projectData.Timeline.AudioTrackItems)
+		s.LastSynced = time.Now()
+		if s.Params == (DetectionParams{}) {
+			s.Params = params
+		}
+	}, projectData.ProjectName, projectData.Timeline.Name)
+}
+
+// recordFinishedSession snapshots the parameters that were actually used to build the timeline.
+func (a *App) recordFinishedSession(projectData *ProjectDataPayload) {
+	params := a.GetCurrentParams()
+	now := time.Now()
+	a.recordRecentSession(func(s *RecentSession) {
+		s.TimelineFPS = projectData.Timeline.FPS
+		s.ClipCount = len(projectData.Timeline.AudioTrackItems)
+		s.LastFinished = &now
+		s.Params = params
+	}, projectData.ProjectName, projectData.Timeline.Name)
+}
+
+// GetRecentSessions returns the most recently synced project/timeline combinations, newest first.
+func (a *App) GetRecentSessions() []RecentSession {
+	a.recentMu.Lock()
+	defer a.recentMu.Unlock()
+	return a.readRecentSessions()
+}
+
+// ClearRecentSessions forgets the session history.
+func (a *App) ClearRecentSessions() error {
+	a.recentMu.Lock()
+	defer a.recentMu.Unlock()
+	if err := os.Remove(a.getRecentSessionsPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear recent sessions: %w", err)
+	}
+	os.Remove(a.getRecentSessionsPath() + backupFileSuffix)
+	a.emit("recentSessions:changed", []RecentSession{})
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRecentSessionsFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(path string) // runs after two sessions were recorded
+		wantKeys []string
+	}{
+		{"intact file", func(string) {}, []string{"P\x00B", "P\x00A"}},
+		// The backup holds the state before the last write.
+		{"corrupt file falls back to the backup", func(path string) { os.WriteFile(path, []byte(`[{"projectName":`), 0644) }, []string{"P\x00A"}},
+		{"missing file", func(path string) { os.Remove(path) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{userResourcesPath: t.TempDir()}
+			a.recordRecentSession(func(*RecentSession) {}, "P", "A")
+			a.recordRecentSession(func(*RecentSession) {}, "P", "B")
+			tt.setup(a.getRecentSessionsPath())
+
+			sessions := a.GetRecentSessions()
+			if len(sessions) != len(tt.wantKeys) {
+				t.Fatalf("got %d sessions, want %d", len(sessions), len(tt.wantKeys))
+			}
+			for i, s := range sessions {
+				if s.key() != tt.wantKeys[i] {
+					t.Errorf("session %d = %q, want %q", i, s.key(), tt.wantKeys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClearRecentSessionsRemovesBackup(t *testing.T) {
+	a := &App{userResourcesPath: t.TempDir()}
+	a.recordRecentSession(func(*RecentSession) {}, "P", "A")
+	a.recordRecentSession(func(*RecentSession) {}, "P", "B")
+	if err := a.ClearRecentSessions(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(a.getRecentSessionsPath() + backupFileSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup still exists after clearing: %v", err)
+	}
+}
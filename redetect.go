@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// ClipRedetection is the result of RedetectClip, also sent as "silences:redetected".
+type ClipRedetection struct {
+	ClipID   string          `json:"clipId"`
+	FileName string          `json:"fileName"`
+	Params   DetectionParams `json:"params"`
+	// Restandardized tells whether the clip's WAV was converted again because its source
+	// media changed since.
+	Restandardized bool            `json:"restandardized"`
+	Silences       []SilencePeriod `json:"silences"`
+}
+
+// RedetectClip detects the silences of one clip of the synced project afresh, ignoring
+// cached results. If the clip's source media was modified after its WAV was converted, the
+// WAV is converted again first, and the cached waveforms, detections and transcripts of the
+// old audio are dropped.
+func (a *App) RedetectClip(clipID string, params DetectionParams) (*ClipRedetection, error) {
+	item, fps, ok := a.findClipByID(clipID)
+	if !ok {
+		return nil, fmt.Errorf("unknown clip %q", clipID)
+	}
+	if item.ProcessedFileName == nil || *item.ProcessedFileName == "" || fps <= floatEpsilon {
+		return nil, fmt.Errorf("clip %q has no processed audio", clipID)
+	}
+	fileName := *item.ProcessedFileName
+	wavPath := filepath.Join(a.tmpPath, fileName)
+	release := a.acquireFileRefs(wavPath)
+	defer release()
+
+	restandardized := false
+	if item.Type == "" && sourceNewerThan(item.SourceFilePath, wavPath) {
+		ffmpegLog.Info("RedetectClip: source changed since it was converted; converting it again", "path", item.SourceFilePath)
+		if err := os.Remove(wavPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not replace %s: %w", fileName, err)
+		}
+		a.dropCacheEntriesForFiles(map[string]bool{fileName: true})
+		a.dropTranscripts(map[string]bool{fileName: true})
+		if err := a.StandardizeAudioToWav(item.SourceFilePath, wavPath, item.SourceChannel); err != nil {
+			return nil, err
+		}
+		restandardized = true
+	}
+
+	startSeconds := item.SourceStartFrame / fps
+	endSeconds := item.SourceEndFrame / fps
+	a.dropClipDetections(fileName, startSeconds, endSeconds)
+
+	silences, err := a.GetOrDetectSilencesWithCache(fileName,
+		params.LoudnessThreshold, params.MinSilenceDurationSeconds,
+		params.PaddingLeftSeconds, params.PaddingRightSeconds, params.MinContent,
+		startSeconds, endSeconds, fps)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ClipRedetection{
+		ClipID:         clipID,
+		FileName:       fileName,
+		Params:         params,
+		Restandardized: restandardized,
+		Silences:       silences,
+	}
+	a.emit("silences:redetected", result)
+	return result, nil
+}
+
+// dropClipDetections removes the cached detections of one clip range, whatever the parameters.
+func (a *App) dropClipDetections(fileName string, startSeconds, endSeconds float64) {
+	const rangeEpsilon = 1e-6
+	a.cacheMutex.Lock()
+	defer a.cacheMutex.Unlock()
+	for key := range a.silenceCache {
+		if key.FilePath == fileName &&
+			math.Abs(key.ClipStartSeconds-startSeconds) < rangeEpsilon &&
+			math.Abs(key.ClipEndSeconds-endSeconds) < rangeEpsilon {
+			delete(a.silenceCache, key)
+			cacheUsage.remove(key)
+		}
+	}
+}
+
+// sourceNewerThan reports whether source was modified after the converted file was written.
+func sourceNewerThan(source, converted string) bool {
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+	convertedInfo, err := os.Stat(converted)
+	if err != nil {
+		return true
+	}
+	return sourceInfo.ModTime().After(convertedInfo.ModTime())
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/i18n"
+)
+
+const reportsFolderName = "reports"
+
+// ClipReport summarizes what was cut from a single timeline item.
+type ClipReport struct {
+	ClipID               string    `json:"clipId"`
+	Name                 string    `json:"name"`
+	SourceFilePath       string    `json:"sourceFilePath"`
+	ProcessedFileName    string    `json:"processedFileName,omitempty"`
+	Thresholds           *CacheKey `json:"thresholds,omitempty"` // detection parameters last used for this clip
+	CutCount             int       `json:"cutCount"`
+	OriginalDurationSecs float64   `json:"originalDurationSeconds"`
+	KeptDurationSecs     float64   `json:"keptDurationSeconds"`
+	RemovedDurationSecs  float64   `json:"removedDurationSeconds"`
+	Warnings             []string  `json:"warnings,omitempty"`
+}
+
+// ProcessingReport documents the automated edits made by MakeFinalTimeline.
+type ProcessingReport struct {
+	GeneratedAt        time.Time    `json:"generatedAt"`
+	AppVersion         string       `json:"appVersion"`
+	ProjectName        string       `json:"projectName"`
+	TimelineName       string       `json:"timelineName"`
+	TimelineFPS        float64      `json:"timelineFps"`
+	MadeNewTimeline    bool         `json:"madeNewTimeline"`
+	Status             string       `json:"status"`
+	Clips              []ClipReport `json:"clips"`
+	TotalRemovedSecs   float64      `json:"totalRemovedSeconds"`
+	ProcessingTimeSecs float64      `json:"processingTimeSeconds"`
+	Warnings           []string     `json:"warnings,omitempty"`
+	JSONPath           string       `json:"jsonPath,omitempty"`
+	HTMLPath           string       `json:"htmlPath,omitempty"`
+}
+
+// recordDetectionParams remembers which parameters were last used to analyze a clip range,
+// so the processing report can list the thresholds behind each cut. Clips that share a
+// file keep their own entry.
+func (a *App) recordDetectionParams(key CacheKey) {
+	a.cacheMutex.Lock()
+	defer a.cacheMutex.Unlock()
+	keys := a.detectionParams[key.FilePath]
+	for i, k := range keys {
+		if sameClipRange(k, key.ClipStartSeconds, key.ClipEndSeconds) {
+			keys[i] = key
+			return
+		}
+	}
+	a.detectionParams[key.FilePath] = append(keys, key)
+}
+
+// detectionParamsFor returns the parameters last recorded for a clip range of a file.
+func (a *App) detectionParamsFor(fileName string, startSeconds, endSeconds float64) (CacheKey, bool) {
+	a.cacheMutex.RLock()
+	defer a.cacheMutex.RUnlock()
+	for _, k := range a.detectionParams[fileName] {
+		if sameClipRange(k, startSeconds, endSeconds) {
+			return k, true
+		}
+	}
+	return CacheKey{}, false
+}
+
+// sameClipRange reports whether key covers the clip range, allowing for the rounding of
+// frame-to-seconds conversions done on either side.
+func sameClipRange(key CacheKey, startSeconds, endSeconds float64) bool {
+	const rangeEpsilon = 1e-6
+	return math.Abs(key.ClipStartSeconds-startSeconds) < rangeEpsilon &&
+		math.Abs(key.ClipEndSeconds-endSeconds) < rangeEpsilon
+}
+
+// hasClippingInCache checks the cached peaks of a processed file for samples at full scale.
+func (a *App) hasClippingInCache(processedFileName string) bool {
+	a.cacheMutex.RLock()
+	defer a.cacheMutex.RUnlock()
+
+	for key, data := range a.waveformCache {
+		if key.FilePath != processedFileName || data == nil {
+			continue
+		}
+		if key.PeakType == "logarithmic" && key.MaxDb != 0 {
+			continue // a peak of 1.0 only means 0 dBFS when the display range tops out at 0
+		}
+		for _, p := range data.Peaks {
+			if p >= 0.999 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *App) buildProcessingReport(projectData *ProjectDataPayload, makeNewTimeline bool, status string, elapsed time.Duration) *ProcessingReport {
+	report := &ProcessingReport{
+		GeneratedAt:        time.Now(),
+		AppVersion:         a.appVersion,
+		ProjectName:        projectData.ProjectName,
+		TimelineName:       projectData.Timeline.Name,
+		TimelineFPS:        projectData.Timeline.FPS,
+		MadeNewTimeline:    makeNewTimeline,
+		Status:             status,
+		Clips:              []ClipReport{},
+		ProcessingTimeSecs: elapsed.Seconds(),
+	}
+
+	fps := projectData.Timeline.FPS
+	if fps <= floatEpsilon {
+		report.Warnings = append(report.Warnings, "timeline FPS is unknown; durations are reported in frames")
+		fps = 1
+	}
+
+	for _, item := range projectData.Timeline.AudioTrackItems {
+		clip := ClipReport{
+			ClipID:               item.ID,
+			Name:                 item.Name,
+			SourceFilePath:       item.SourceFilePath,
+			OriginalDurationSecs: (item.EndFrame - item.StartFrame) / fps,
+		}
+		if item.ProcessedFileName != nil {
+			clip.ProcessedFileName = *item.ProcessedFileName
+		}
+
+		var keptFrames float64
+		for _, edit := range item.EditInstructions {
+			if !edit.Enabled {
+				continue
+			}
+			keptFrames += edit.EndFrame - edit.StartFrame
+		}
+		if len(item.EditInstructions) == 0 {
+			keptFrames = item.EndFrame - item.StartFrame
+		}
+		clip.CutCount = removedRangeCount(&item, projectData.Timeline.FPS)
+		clip.KeptDurationSecs = keptFrames / fps
+		clip.RemovedDurationSecs = clip.OriginalDurationSecs - clip.KeptDurationSecs
+		if clip.RemovedDurationSecs < 0 {
+			clip.RemovedDurationSecs = 0
+		}
+
+		if clip.ProcessedFileName != "" {
+			// The same range prepGraph analyzed the clip with.
+			params, ok := a.detectionParamsFor(clip.ProcessedFileName, item.SourceStartFrame/fps, item.SourceEndFrame/fps)
+			if ok {
+				clip.Thresholds = &params
+			}
+			if a.hasClippingInCache(clip.ProcessedFileName) {
+				clip.Warnings = append(clip.Warnings, "clipping detected in source audio")
+			}
+		}
+		if clip.Thresholds == nil && len(item.EditInstructions) > 1 {
+			clip.Warnings = append(clip.Warnings, "no detection parameters recorded for this clip")
+		}
+
+		report.TotalRemovedSecs += clip.RemovedDurationSecs
+		report.Clips = append(report.Clips, clip)
+	}
+
+	return report
+}
+
+// removedRangeCount counts the silences cut from item. A cut is a disabled instruction (silence
+// kept but muted) or a gap in the source around or between instructions (silence removed);
+// a disabled instruction followed by a gap is still one cut.
+func removedRangeCount(item *TimelineItem, timelineFPS float64) int {
+	if len(item.EditInstructions) == 0 {
+		return 0
+	}
+	// Instructions hold source frames in the source's frame rate, the item in the timeline's.
+	cursor, end := item.SourceStartFrame, item.SourceEndFrame
+	if timelineFPS > floatEpsilon && item.SourceFPS > floatEpsilon {
+		cursor *= item.SourceFPS / timelineFPS
+		end *= item.SourceFPS / timelineFPS
+	}
+
+	count, inCut := 0, false
+	for _, edit := range item.EditInstructions {
+		gap := edit.SourceStartFrame-cursor >= 1 // less than a frame is rounding
+		if (gap || !edit.Enabled) && !inCut {
+			count++
+		}
+		inCut = !edit.Enabled
+		cursor = edit.SourceEndFrame
+	}
+	if end-cursor >= 1 && !inCut {
+		count++
+	}
+	return count
+}
+
+// fileURL turns path into a file:// URL, escaping spaces and the like and giving Windows
+// drive paths the leading slash they need ("file:///C:/...").
+func fileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// reportFuncs formats the report's numbers and times for locale.
+func reportFuncs(locale i18n.Locale) template.FuncMap {
+	return template.FuncMap{
+		"secs":     func(f float64) string { return locale.Number(f, 2) + "s" },
+		"num":      func(f float64) string { return locale.Number(f, -1) },
+		"datetime": func(t time.Time) string { return locale.DateTime(t) },
+		"lang":     func() string { return locale.Tag },
+	}
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs(i18n.LookupLocale("en"))).Parse(`<!DOCTYPE html>
+<html lang="{{lang}}">
+<head>
+<meta charset="utf-8">
+<title>HushCut Report – {{.TimelineName}}</title>
+<style>
+body { font-family: sans-serif; background: #28282e; color: #e4e4e7; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #3f3f46; padding: 6px 8px; text-align: left; }
+.warn { color: #facc15; }
+</style>
+</head>
+<body>
+<h1>HushCut Processing Report</h1>
+<p>Project: <b>{{.ProjectName}}</b> · Timeline: <b>{{.TimelineName}}</b> ({{num .TimelineFPS}} fps)</p>
+<p>Generated {{datetime .GeneratedAt}} by HushCut v{{.AppVersion}} · Status: {{.Status}}</p>
+<p>Total removed: {{secs .TotalRemovedSecs}} · Processing time: {{secs .ProcessingTimeSecs}}</p>
+{{range .Warnings}}<p class="warn">⚠ {{.}}</p>{{end}}
+<table>
+<tr><th>Clip</th><th>Threshold</th><th>Min. silence</th><th>Padding L/R</th><th>Cuts</th><th>Original</th><th>Kept</th><th>Removed</th><th>Warnings</th></tr>
+{{range .Clips}}<tr>
+<td>{{.Name}}</td>
+{{if .Thresholds}}<td>{{num .Thresholds.LoudnessThreshold}} dB</td><td>{{secs .Thresholds.MinSilenceDurationSeconds}}</td><td>{{secs .Thresholds.PaddingLeftSeconds}} / {{secs .Thresholds.PaddingRightSeconds}}</td>{{else}}<td>–</td><td>–</td><td>–</td>{{end}}
+<td>{{.CutCount}}</td><td>{{secs .OriginalDurationSecs}}</td><td>{{secs .KeptDurationSecs}}</td><td>{{secs .RemovedDurationSecs}}</td>
+<td class="warn">{{range .Warnings}}{{.}}<br>{{end}}</td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
+
+// writeProcessingReport saves the report as JSON and HTML into the user's reports folder.
+func (a *App) writeProcessingReport(report *ProcessingReport) error {
+	dir := filepath.Join(a.userResourcesPath, reportsFolderName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory %s: %w", dir, err)
+	}
+
+	// Milliseconds keep runs in the same second apart; O_EXCL catches what they don't.
+	baseName := "report_" + report.GeneratedAt.Format("2006-01-02_15-04-05.000")
+	var htmlFile *os.File
+	for n := 1; ; n++ {
+		name := baseName
+		if n > 1 {
+			name = fmt.Sprintf("%s_%d", baseName, n)
+		}
+		report.JSONPath = filepath.Join(dir, name+".json")
+		report.HTMLPath = filepath.Join(dir, name+".html")
+		f, err := os.OpenFile(report.HTMLPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			htmlFile = f
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create report %s: %w", report.HTMLPath, err)
+		}
+	}
+	defer htmlFile.Close()
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal processing report: %w", err)
+	}
+	if err := os.WriteFile(report.JSONPath, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", report.JSONPath, err)
+	}
+
+	tmpl := template.Must(reportTemplate.Clone()).Funcs(reportFuncs(a.reportLocale()))
+	if err := tmpl.Execute(htmlFile, report); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
+
+// generateProcessingReport builds, saves and announces the report for a finished timeline.
+func (a *App) generateProcessingReport(projectData *ProjectDataPayload, makeNewTimeline bool, status string, elapsed time.Duration) {
+	report := a.buildProcessingReport(projectData, makeNewTimeline, status, elapsed)
+	if err := a.writeProcessingReport(report); err != nil {
+		appLog.Warn("Failed to write processing report", "err", err)
+	} else {
+		appLog.Info("Processing report written", "path", report.HTMLPath)
+	}
+
+	a.mu.Lock()
+	a.lastReport = report
+	a.mu.Unlock()
+
+	a.emit("report:ready", report)
+}
+
+// GetLastProcessingReport returns the report of the most recent MakeFinalTimeline run, or nil.
+func (a *App) GetLastProcessingReport() *ProcessingReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastReport
+}
+
+// OpenLastProcessingReport opens the HTML version of the most recent report in the browser.
+func (a *App) OpenLastProcessingReport() error {
+	report := a.GetLastProcessingReport()
+	if report == nil || report.HTMLPath == "" {
+		return fmt.Errorf("no processing report available")
+	}
+	openURL(a.ctx, fileURL(report.HTMLPath))
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportThresholdsPerClip(t *testing.T) {
+	a := NewApp()
+	const fps = 25.0
+	file := "interview.wav"
+	// Two clips cut from the same file, analyzed with different thresholds, and one range
+	// that was never analyzed. Recording the second clip must not replace the first.
+	a.recordDetectionParams(CacheKey{FilePath: file, LoudnessThreshold: -40, ClipStartSeconds: 0, ClipEndSeconds: 10})
+	a.recordDetectionParams(CacheKey{FilePath: file, LoudnessThreshold: -30, ClipStartSeconds: 20, ClipEndSeconds: 30})
+	a.recordDetectionParams(CacheKey{FilePath: file, LoudnessThreshold: -35, ClipStartSeconds: 0, ClipEndSeconds: 10})
+
+	item := func(id string, startFrame, endFrame float64) TimelineItem {
+		name := file
+		return TimelineItem{ID: id, ProcessedFileName: &name, SourceStartFrame: startFrame, SourceEndFrame: endFrame, StartFrame: 0, EndFrame: endFrame - startFrame}
+	}
+	project := &ProjectDataPayload{Timeline: Timeline{FPS: fps, AudioTrackItems: []TimelineItem{
+		item("first", 0, 10*fps),
+		item("second", 20*fps, 30*fps),
+		item("unanalyzed", 40*fps, 50*fps),
+	}}}
+	report := a.buildProcessingReport(project, false, "ok", time.Second)
+
+	want := map[string]float64{"first": -35, "second": -30}
+	for _, clip := range report.Clips {
+		threshold, analyzed := want[clip.ClipID]
+		switch {
+		case !analyzed && clip.Thresholds != nil:
+			t.Errorf("clip %s: thresholds = %+v, want none", clip.ClipID, clip.Thresholds)
+		case analyzed && clip.Thresholds == nil:
+			t.Errorf("clip %s: no thresholds, want %v", clip.ClipID, threshold)
+		case analyzed && clip.Thresholds.LoudnessThreshold != threshold:
+			t.Errorf("clip %s: threshold = %v, want %v", clip.ClipID, clip.Thresholds.LoudnessThreshold, threshold)
+		}
+	}
+}
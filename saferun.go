@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// InternalError is the payload of "app:internalError", emitted when a background task panics.
+type InternalError struct {
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message"`
+	CrashLog string    `json:"crashLog,omitempty"`
+}
+
+var internalErrors struct {
+	mu   sync.Mutex
+	emit func(InternalError)
+}
+
+// startInternalErrorEvents forwards recovered panics to the frontend.
+func (a *App) startInternalErrorEvents() {
+	internalErrors.mu.Lock()
+	internalErrors.emit = func(e InternalError) {
+		a.emit("app:internalError", e)
+	}
+	internalErrors.mu.Unlock()
+}
+
+// saferun runs fn and recovers from a panic in it, so a failing background task writes a
+// crash log and reports "app:internalError" instead of taking the whole app down.
+// Spawn goroutines as `go saferun(func() { ... })`.
+func saferun(fn func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		crashLog := writeCrashLog(fmt.Sprintf("panic in background task: %v\n\n%s", r, debug.Stack()))
+		appLog.Error("Recovered from panic in background task", "panic", r, "crashLog", crashLog)
+
+		internalErrors.mu.Lock()
+		emit := internalErrors.emit
+		internalErrors.mu.Unlock()
+		if emit != nil {
+			emit(InternalError{Time: time.Now(), Message: fmt.Sprint(r), CrashLog: crashLog})
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"time"
+)
+
+const (
+	updatesFolderName     = "updates"
+	pendingUpdateFileName = "pending_update.json"
+	// How long the watchdog waits for a freshly installed version to report a successful start.
+	updateConfirmTimeout = 90 * time.Second
+)
+
+// UpdateProgress is emitted as "update:progress" while an update is downloaded and installed.
+type UpdateProgress struct {
+	Phase      string  `json:"phase"` // "download", "verify", "install", "restart"
+	Percentage float64 `json:"percentage"`
+	Message    string  `json:"message,omitempty"`
+}
+
+// PendingUpdate records a replaced installation until the new version has started once.
+// The watchdog restores BackupPath if that never happens.
+type PendingUpdate struct {
+	Version     string    `json:"version"`
+	TargetPath  string    `json:"targetPath"` // AppImage file or .app bundle that was replaced
+	BackupPath  string    `json:"backupPath"`
+	Executable  string    `json:"executable"` // binary to start for TargetPath
+	Confirmed   bool      `json:"confirmed"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+func (a *App) emitUpdateProgress(phase string, pct float64, message string) {
+	a.emit("update:progress", UpdateProgress{Phase: phase, Percentage: pct, Message: message})
+}
+
+// updateArchNames lists the spellings of an architecture used in release asset names.
+func updateArchNames(goarch string) []string {
+	return map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+	}[goarch]
+}
+
+// selectUpdateAsset picks the installer for this platform: an MSI on Windows, a DMG on macOS
+// and an AppImage on Linux, preferring assets named after the current architecture.
+func selectUpdateAsset(assets []GithubAsset, goos string, goarch string) (*GithubAsset, error) {
+	var ext string
+	switch goos {
+	case "windows":
+		ext = ".msi"
+	case "darwin":
+		ext = ".dmg"
+	case "linux":
+		ext = ".appimage"
+	default:
+		return nil, fmt.Errorf("self-update is not supported on %s", goos)
+	}
+	archNames := updateArchNames(goarch)
+
+	var fallback *GithubAsset
+	for i := range assets {
+		name := strings.ToLower(assets[i].Name)
+		if !strings.HasSuffix(name, ext) {
+			continue
+		}
+		for _, arch := range archNames {
+			if strings.Contains(name, arch) {
+				return &assets[i], nil
+			}
+		}
+		if fallback == nil || strings.Contains(name, "universal") {
+			fallback = &assets[i]
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("the release has no %s installer for this platform", ext)
+	}
+	return fallback, nil
+}
+
+// IntegrityError means a downloaded asset doesn't match the digest published with the release.
+type IntegrityError struct {
+	Asset    string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: expected %s, got %s. The download was discarded and nothing was installed", e.Asset, e.Expected, e.Actual)
+}
+
+// newDigestHasher parses a GitHub asset digest ("sha256:<hex>") and returns a matching hasher
+// and the expected hex value. Assets without a digest get a hasher and an empty expectation.
+func newDigestHasher(digest string) (hash.Hash, string, error) {
+	if digest == "" {
+		return sha256.New(), "", nil
+	}
+	algo, value, ok := strings.Cut(digest, ":")
+	if !ok || value == "" {
+		return nil, "", fmt.Errorf("malformed asset digest '%s'", digest)
+	}
+	switch strings.ToLower(algo) {
+	case "sha256":
+		return sha256.New(), strings.ToLower(value), nil
+	case "sha512":
+		return sha512.New(), strings.ToLower(value), nil
+	default:
+		return nil, "", fmt.Errorf("unsupported digest algorithm '%s'", algo)
+	}
+}
+
+// downloadUpdateAsset streams the asset into dir, reporting progress, and verifies its
+// size and published digest before it is used.
+func (a *App) downloadUpdateAsset(asset *GithubAsset, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create update folder: %w", err)
+	}
+	dest := filepath.Join(dir, filepath.Base(asset.Name))
+	if int64(asset.Size) > maxUpdateDownloadBytes {
+		return "", limitError(asset.Name, maxUpdateDownloadBytes)
+	}
+
+	resp, err := a.externalHTTPClient(0).Get(asset.BrowserDownloadUrl)
+	if err != nil {
+		return "", fmt.Errorf("could not download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("update download failed: %s", resp.Status)
+	}
+	if err := checkDownloadSize(resp, maxUpdateDownloadBytes, asset.Name); err != nil {
+		return "", err
+	}
+	// A download can't grow past what the release lists, or the limit if it lists nothing.
+	limit := int64(maxUpdateDownloadBytes)
+	if asset.Size > 0 {
+		limit = int64(asset.Size)
+	}
+	body := capReader(resp.Body, limit, asset.Name)
+
+	total := resp.ContentLength
+	if total <= 0 {
+		total = int64(asset.Size)
+	}
+
+	out, err := os.Create(dest + ".part")
+	if err != nil {
+		return "", fmt.Errorf("could not create %s: %w", dest, err)
+	}
+	hasher, expectedDigest, err := newDigestHasher(asset.Digest)
+	if err != nil {
+		out.Close()
+		return "", err
+	}
+	var written int64
+	lastEmit := time.Time{}
+	buf := make([]byte, 256*1024)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			hasher.Write(buf[:n])
+			if _, err := out.Write(buf[:n]); err != nil {
+				out.Close()
+				return "", fmt.Errorf("could not write update: %w", err)
+			}
+			written += int64(n)
+			if total > 0 && time.Since(lastEmit) > 200*time.Millisecond {
+				a.emitUpdateProgress("download", float64(written)/float64(total)*100, "")
+				lastEmit = time.Now()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			out.Close()
+			if errors.Is(readErr, errLimitExceeded) {
+				os.Remove(dest + ".part")
+			}
+			return "", fmt.Errorf("update download interrupted: %w", readErr)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+
+	a.emitUpdateProgress("verify", 0, "")
+	if asset.Size > 0 && written != int64(asset.Size) {
+		os.Remove(dest + ".part")
+		return "", fmt.Errorf("downloaded %d bytes but the release lists %d", written, asset.Size)
+	}
+	if expectedDigest != "" {
+		if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expectedDigest {
+			os.Remove(dest + ".part")
+			algo, _, _ := strings.Cut(asset.Digest, ":")
+			return "", &IntegrityError{Asset: asset.Name, Expected: asset.Digest, Actual: algo + ":" + actual}
+		}
+		updateLog.Info("Verified update against its published digest", "asset", asset.Name)
+	} else {
+		updateLog.Warn("Release lists no digest; only the size was checked", "asset", asset.Name)
+	}
+	if err := os.Rename(dest+".part", dest); err != nil {
+		return "", err
+	}
+	a.emitUpdateProgress("download", 100, "")
+	return dest, nil
+}
+
+// DownloadAndInstallUpdate downloads the installer of the available update, installs it
+// and restarts HushCut. If the new version fails to start, the previous one is restored.
+func (a *App) DownloadAndInstallUpdate() error {
+	if a.updateInfo == nil {
+		return errors.New("no update is available")
+	}
+	asset, err := selectUpdateAsset(a.updateInfo.GithubData.Assets, goruntime.GOOS, goruntime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	stagingDir := filepath.Join(a.userResourcesPath, updatesFolderName, a.updateInfo.LatestVersion)
+
+	// Prefer a small binary patch when one exists for this exact version step.
+	if applied, err := a.tryDeltaUpdate(stagingDir); applied {
+		a.emitUpdateProgress("restart", 100, "")
+		updateLog.Info("Update applied from patch; restarting", "version", a.updateInfo.LatestVersion)
+		a.quit()
+		return nil
+	} else if err != nil {
+		updateLog.Warn("Delta update failed; falling back to the full installer", "err", err)
+	}
+
+	updateLog.Info("Downloading update", "version", a.updateInfo.LatestVersion, "asset", asset.Name)
+	installer, err := a.downloadUpdateAsset(asset, stagingDir)
+	if err != nil {
+		a.emit("update:error", err.Error())
+		return err
+	}
+
+	a.emitUpdateProgress("install", 0, "")
+	switch goruntime.GOOS {
+	case "windows":
+		err = a.installMSI(installer)
+	case "darwin":
+		err = a.installDMG(installer)
+	case "linux":
+		err = a.installAppImage(installer)
+	}
+	if err != nil {
+		a.emit("update:error", err.Error())
+		return err
+	}
+
+	a.emitUpdateProgress("restart", 100, "")
+	updateLog.Info("Update installed; restarting", "version", a.updateInfo.LatestVersion)
+	a.quit()
+	return nil
+}
+
+// installMSI hands over to Windows Installer, which rolls back on its own if the
+// installation fails, and starts the new version afterwards.
+func (a *App) installMSI(msiPath string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`msiexec /i "%s" /passive /norestart && start "" "%s"`, msiPath, exe)
+	cmd := ExecCommand("cmd", "/C", script)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start the installer: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// installAppImage swaps the running AppImage for the new one.
+func (a *App) installAppImage(newImage string) error {
+	target := os.Getenv("APPIMAGE")
+	if target == "" {
+		return errors.New("HushCut is not running from an AppImage; please update it with your package manager")
+	}
+	if err := os.Chmod(newImage, 0755); err != nil {
+		return err
+	}
+	backup := target + ".previous"
+	if err := os.Rename(target, backup); err != nil {
+		return fmt.Errorf("could not back up the current version: %w", err)
+	}
+	if err := moveFile(newImage, target); err != nil {
+		os.Rename(backup, target)
+		return fmt.Errorf("could not install the new version: %w", err)
+	}
+	if err := os.Chmod(target, 0755); err != nil {
+		return err
+	}
+	return a.handOverToWatchdog(PendingUpdate{TargetPath: target, BackupPath: backup, Executable: target}, backup)
+}
+
+// installDMG mounts the image and replaces the current .app bundle with the one inside.
+func (a *App) installDMG(dmgPath string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	bundle := filepath.Dir(filepath.Dir(filepath.Dir(exe))) // .../HushCut.app/Contents/MacOS/HushCut
+	if !strings.HasSuffix(bundle, ".app") {
+		return errors.New("HushCut is not running from an app bundle")
+	}
+
+	mountPoint, err := os.MkdirTemp("", "hushcut-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(mountPoint)
+	if out, err := ExecCommand("hdiutil", "attach", "-nobrowse", "-readonly", "-mountpoint", mountPoint, dmgPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not mount update image: %v: %s", err, out)
+	}
+	defer ExecCommand("hdiutil", "detach", mountPoint, "-quiet").Run()
+
+	apps, _ := filepath.Glob(filepath.Join(mountPoint, "*.app"))
+	if len(apps) == 0 {
+		return errors.New("the update image contains no application")
+	}
+
+	backup := strings.TrimSuffix(bundle, ".app") + ".previous.app"
+	os.RemoveAll(backup)
+	if err := os.Rename(bundle, backup); err != nil {
+		return fmt.Errorf("could not back up the current version: %w", err)
+	}
+	if out, err := ExecCommand("ditto", apps[0], bundle).CombinedOutput(); err != nil {
+		os.RemoveAll(bundle)
+		os.Rename(backup, bundle)
+		return fmt.Errorf("could not install the new version: %v: %s", err, out)
+	}
+
+	backupExe := filepath.Join(backup, "Contents", "MacOS", filepath.Base(exe))
+	return a.handOverToWatchdog(PendingUpdate{TargetPath: bundle, BackupPath: backup, Executable: exe}, backupExe)
+}
+
+func (a *App) pendingUpdatePath() string {
+	return filepath.Join(a.userResourcesPath, updatesFolderName, pendingUpdateFileName)
+}
+
+// handOverToWatchdog records the pending update and starts the previous version's binary
+// in watchdog mode; it launches the new version and rolls back if it doesn't come up.
+func (a *App) handOverToWatchdog(pending PendingUpdate, watchdogExe string) error {
+	pending.Version = a.updateInfo.LatestVersion
+	pending.InstalledAt = time.Now()
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(a.pendingUpdatePath(), data, 0644); err != nil {
+		return fmt.Errorf("could not record pending update: %w", err)
+	}
+	cmd := exec.Command(watchdogExe, "--update-watchdog", a.pendingUpdatePath())
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start update watchdog: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// confirmPendingUpdate is called once the UI is up and tells the watchdog the update worked.
+func (a *App) confirmPendingUpdate() {
+	var pending PendingUpdate
+	if err := readJSONWithRecovery(a.pendingUpdatePath(), &pending); err != nil {
+		return
+	}
+	if strings.TrimPrefix(pending.Version, "v") != strings.TrimPrefix(a.appVersion, "v") || pending.Confirmed {
+		return
+	}
+	pending.Confirmed = true
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(a.pendingUpdatePath(), data, 0644)
+	}
+	if err != nil {
+		updateLog.Warn("Could not confirm update", "err", err)
+		return
+	}
+	updateLog.Info("Update confirmed", "version", pending.Version)
+	os.RemoveAll(filepath.Join(a.userResourcesPath, updatesFolderName, pending.Version))
+}
+
+// runUpdateWatchdog runs headless (--update-watchdog <pending file>) from the previous
+// installation. It starts the new version and waits for it to confirm a successful start;
+// if the process exits or times out first, the previous version is put back and started.
+func runUpdateWatchdog(pendingPath string) {
+	readPending := func() (PendingUpdate, error) {
+		var p PendingUpdate
+		err := readJSONWithRecovery(pendingPath, &p)
+		return p, err
+	}
+	pending, err := readPending()
+	if err != nil {
+		updateLog.Warn("Update watchdog failed", "err", err)
+		return
+	}
+
+	cmd := exec.Command(pending.Executable)
+	if err := cmd.Start(); err != nil {
+		updateLog.Error("Update watchdog: new version failed to launch", "err", err)
+		rollbackUpdate(pending, pendingPath)
+		return
+	}
+	exited := make(chan struct{})
+	go saferun(func() {
+		cmd.Wait()
+		close(exited)
+	})
+
+	deadline := time.After(updateConfirmTimeout)
+	for {
+		select {
+		case <-exited:
+			if p, err := readPending(); err == nil && p.Confirmed {
+				finishUpdate(pending, pendingPath)
+				return
+			}
+			updateLog.Warn("Update watchdog: new version exited before confirming its start")
+			rollbackUpdate(pending, pendingPath)
+			return
+		case <-deadline:
+			updateLog.Warn("Update watchdog: new version did not confirm its start in time")
+			cmd.Process.Kill()
+			rollbackUpdate(pending, pendingPath)
+			return
+		case <-time.After(time.Second):
+			if p, err := readPending(); err == nil && p.Confirmed {
+				finishUpdate(pending, pendingPath)
+				return
+			}
+		}
+	}
+}
+
+func finishUpdate(pending PendingUpdate, pendingPath string) {
+	os.RemoveAll(pending.BackupPath)
+	os.Remove(pendingPath)
+}
+
+func rollbackUpdate(pending PendingUpdate, pendingPath string) {
+	os.RemoveAll(pending.TargetPath)
+	if err := os.Rename(pending.BackupPath, pending.TargetPath); err != nil {
+		updateLog.Error("Update watchdog: could not restore previous version", "err", err)
+		return
+	}
+	os.Remove(pendingPath)
+	if err := exec.Command(pending.Executable).Start(); err != nil {
+		updateLog.Error("Update watchdog: could not restart previous version", "err", err)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// `HushCut --serve` runs the app without a window: the local HTTP server, the ffmpeg job queue
+// and the Python bridge to Resolve start as usual, and the /api/ endpoints below give a
+// controller other than the webview (a script, a render node's job runner) what the frontend
+// gets from its Wails bindings. Every endpoint needs the auth token. On startup one JSON line
+// with the port and token is printed to stdout; the discovery file has them too.
+
+// maxAPIRequestBytes bounds request bodies; project payloads of long timelines are the largest.
+const maxAPIRequestBytes = 32 << 20
+
+// ServeInfo is the line `--serve` prints to stdout once the server listens.
+type ServeInfo struct {
+	Port    int    `json:"port"`
+	Token   string `json:"token"`
+	PID     int    `json:"pid"`
+	Version string `json:"version"`
+	// The TLS listener, if enabled; see tlsServer.go
+	TLSPort        int    `json:"tlsPort,omitempty"`
+	TLSFingerprint string `json:"tlsFingerprint,omitempty"`
+}
+
+// ServeStatus is the response of GET /api/status.
+type ServeStatus struct {
+	Version      string       `json:"version"`
+	FfmpegStatus FfmpegStatus `json:"ffmpegStatus"`
+	PythonReady  bool         `json:"pythonReady"`
+	LicenseValid bool         `json:"licenseValid"`
+}
+
+// DetectRequest is the body of POST /api/detect. FilePath is a WAV in the app's tmp folder, as
+// created by /api/timeline/process; the other fields are GetOrDetectSilencesWithCache's.
+type DetectRequest struct {
+	CacheKey
+	Framerate float64 `json:"framerate"`
+}
+
+// WaveformRequest is the body of POST /api/waveform, with GetWaveform's arguments.
+type WaveformRequest struct {
+	FilePath         string  `json:"filePath"`
+	SamplesPerPixel  int     `json:"samplesPerPixel"`
+	PeakType         string  `json:"peakType"`
+	MinDb            float64 `json:"minDb"`
+	ClipStartSeconds float64 `json:"clipStartSeconds"`
+	ClipEndSeconds   float64 `json:"clipEndSeconds"`
+}
+
+// MakeTimelineRequest is the body of POST /api/timeline/make.
+type MakeTimelineRequest struct {
+	ProjectData     *ProjectDataPayload `json:"projectData"`
+	MakeNewTimeline bool                `json:"makeNewTimeline"`
+}
+
+// runServe starts a in serve mode and blocks until SIGINT or SIGTERM. It returns the exit code,
+// using the CLI's codes when ffmpeg or the license is missing.
+func runServe(a *App) int {
+	a.headless = true
+	a.serve = true
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a.startup(ctx)
+	code := exitOK
+	switch {
+	case !a.licenseValid:
+		fmt.Fprintln(os.Stderr, "no valid license: activate HushCut in the app first")
+		code = exitLicense
+	case a.GetFFmpegStatus() != StatusReady:
+		fmt.Fprintln(os.Stderr, "ffmpeg not found: install it, or start HushCut once to download it")
+		code = exitFfmpegMissing
+	default:
+		<-ctx.Done()
+		ipcLog.Info("Serve mode: shutting down")
+	}
+	a.shutdown(context.Background())
+	return code
+}
+
+// serveRequested reports whether --serve is on the command line. Logging is set up before the
+// flags are parsed and needs to know.
+func serveRequested() bool {
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "-serve", "--serve", "-serve=true", "--serve=true":
+			return true
+		}
+	}
+	return false
+}
+
+// announceServe prints the ServeInfo line.
+func (a *App) announceServe() {
+	tls := a.GetTLSInfo()
+	json.NewEncoder(os.Stdout).Encode(ServeInfo{
+		Port:           actualPort,
+		Token:          a.authToken,
+		PID:            os.Getpid(),
+		Version:        a.appVersion,
+		TLSPort:        tls.Port,
+		TLSFingerprint: tls.Fingerprint,
+	})
+}
+
+// registerServeEndpoints adds the /api/ endpoints in serve mode.
+func (a *App) registerServeEndpoints(mux *http.ServeMux) {
+	if !a.serve {
+		return
+	}
+	protect := func(method string, h http.HandlerFunc) http.HandlerFunc {
+		return a.commonMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBytes)
+			h(w, r)
+		}, true)
+	}
+	mux.HandleFunc("/api/status", protect(http.MethodGet, a.handleAPIStatus))
+	mux.HandleFunc("/api/detect", protect(http.MethodPost, a.handleAPIDetect))
+	mux.HandleFunc("/api/waveform", protect(http.MethodPost, a.handleAPIWaveform))
+	mux.HandleFunc("/api/timeline/sync", protect(http.MethodPost, a.handleAPITimelineSync))
+	mux.HandleFunc("/api/timeline/process", protect(http.MethodPost, a.handleAPITimelineProcess))
+	mux.HandleFunc("/api/timeline/make", protect(http.MethodPost, a.handleAPITimelineMake))
+	ipcLog.Info("Serve endpoints enabled", "paths", "/api/status, /api/detect, /api/waveform, /api/timeline/{sync,process,make}")
+}
+
+func (a *App) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, ServeStatus{
+		Version:      a.appVersion,
+		FfmpegStatus: a.GetFFmpegStatus(),
+		PythonReady:  a.pythonReady,
+		LicenseValid: a.licenseValid,
+	})
+}
+
+func (a *App) handleAPIDetect(w http.ResponseWriter, r *http.Request) {
+	var req DetectRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.FilePath == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("filePath is required"))
+		return
+	}
+	silences, err := a.GetOrDetectSilencesWithCache(req.FilePath, req.LoudnessThreshold, req.MinSilenceDurationSeconds,
+		req.PaddingLeftSeconds, req.PaddingRightSeconds, req.MinContentDuration, req.ClipStartSeconds, req.ClipEndSeconds, req.Framerate)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]any{"silences": silences})
+}
+
+func (a *App) handleAPIWaveform(w http.ResponseWriter, r *http.Request) {
+	var req WaveformRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.FilePath == "" || req.SamplesPerPixel < 1 {
+		writeAPIError(w, http.StatusBadRequest, errors.New("filePath and a positive samplesPerPixel are required"))
+		return
+	}
+	if req.PeakType == "" {
+		req.PeakType = "logarithmic"
+	}
+	data, err := a.GetWaveform(req.FilePath, req.SamplesPerPixel, req.PeakType, req.MinDb, req.ClipStartSeconds, req.ClipEndSeconds)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, data)
+}
+
+func (a *App) handleAPITimelineSync(w http.ResponseWriter, r *http.Request) {
+	resp, err := a.SyncWithDavinci()
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, resp)
+}
+
+func (a *App) handleAPITimelineProcess(w http.ResponseWriter, r *http.Request) {
+	var projectData ProjectDataPayload
+	if !decodeAPIRequest(w, r, &projectData) {
+		return
+	}
+	if err := a.ProcessProjectAudio(projectData); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (a *App) handleAPITimelineMake(w http.ResponseWriter, r *http.Request) {
+	var req MakeTimelineRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+	if req.ProjectData == nil {
+		writeAPIError(w, http.StatusBadRequest, errors.New("projectData is required"))
+		return
+	}
+	resp, err := a.MakeFinalTimeline(req.ProjectData, req.MakeNewTimeline)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, resp)
+}
+
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	ipcLog.Warn("API request failed", "status", status, "err", err)
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The server hands out files from one place only: the audio folder (tmpPath, which the
+// "cachePath" policy may move). Every endpoint that serves a file resolves it through
+// resolveServedFile, which follows symlinks before it checks, so a link in the folder can't
+// point the server at the rest of the disk. Each file served is appended to
+// file_access_audit.log (JSON lines, next to log.txt, rotated like ffmpeg_audit.log) with who
+// asked for it.
+
+const (
+	fileAccessAuditFileName = "file_access_audit.log"
+	fileAccessAuditMaxBytes = 5 * 1024 * 1024
+)
+
+var (
+	errServedFileMissing   = errors.New("file not found")
+	errServedFileForbidden = errors.New("file may not be served")
+)
+
+// servedRoot is the audio folder with its symlinks resolved.
+func (a *App) servedRoot() (string, error) {
+	abs, err := filepath.Abs(a.tmpPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// inServedRoot reports whether path, with its symlinks resolved, lies inside the audio folder.
+// It doesn't require the file to exist.
+func (a *App) inServedRoot(path string) bool {
+	root, err := a.servedRoot()
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveServedFile maps name, relative to the audio folder, to the WAV file the server may
+// serve for it. It fails with errServedFileMissing or errServedFileForbidden.
+func (a *App) resolveServedFile(name string) (string, error) {
+	if !strings.EqualFold(filepath.Ext(name), ".wav") {
+		return "", errServedFileForbidden
+	}
+	root, err := a.servedRoot()
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Clean("/"+name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", errServedFileMissing
+	}
+	if err != nil {
+		return "", err
+	}
+	if !a.inServedRoot(resolved) {
+		return "", errServedFileForbidden
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", errServedFileMissing
+	}
+	if !info.Mode().IsRegular() {
+		return "", errServedFileForbidden
+	}
+	return resolved, nil
+}
+
+// serveFileError answers a request resolveServedFile turned down.
+func serveFileError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errServedFileMissing):
+		http.NotFound(w, r)
+	case errors.Is(err, errServedFileForbidden):
+		ipcLog.Warn("File server: access blocked", "path", r.URL.Path, "query", r.URL.RawQuery, "client", r.RemoteAddr)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	default:
+		ipcLog.Error("File server: resolving file", "path", r.URL.Path, "err", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// FileAccessEntry is one file the server handed out.
+type FileAccessEntry struct {
+	Time      time.Time `json:"time"`
+	Endpoint  string    `json:"endpoint"`
+	File      string    `json:"file"`
+	Method    string    `json:"method"`
+	Range     string    `json:"range,omitempty"`
+	Client    string    `json:"client"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Origin    string    `json:"origin,omitempty"`
+	Referer   string    `json:"referer,omitempty"`
+}
+
+var fileAccessAuditMu sync.Mutex
+
+// auditFileAccess records that the server is serving file for r.
+func auditFileAccess(r *http.Request, endpoint, file string) {
+	line, err := json.Marshal(FileAccessEntry{
+		Time:      time.Now(),
+		Endpoint:  endpoint,
+		File:      file,
+		Method:    r.Method,
+		Range:     r.Header.Get("Range"),
+		Client:    r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+		Origin:    r.Header.Get("Origin"),
+		Referer:   r.Referer(),
+	})
+	if err != nil {
+		return
+	}
+	base, err := stateDir()
+	if err != nil {
+		return
+	}
+	fileAccessAuditMu.Lock()
+	defer fileAccessAuditMu.Unlock()
+	appendAuditLine(filepath.Join(base, fileAccessAuditFileName), line, fileAccessAuditMaxBytes)
+}
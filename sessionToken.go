@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/safejson"
+)
+
+// HushCut's server knows two tokens. The session token (authToken) is the one the Resolve
+// script generates and hands over in HUSHCUT_AUTH_TOKEN, and the one the lua-helper finds in
+// the discovery file; when nobody hands one over, the server makes its own. The Python backend
+// HushCut launches itself gets a token of its own instead: new for every launch, written only
+// to its stdin, and the only token /ready and /msg accept while that backend runs. Nothing that
+// can read the environment or the discovery file can speak for it.
+//
+// The backend's token doesn't live for the whole session either. Right after registering, and
+// then every "backendTokenRotationMinutes" (0 turns it off) or whenever RotateBackendToken is
+// called, HushCut sends the backend a new token on /rotate_token, authenticated with the old
+// one. While that request runs both are accepted; once the backend confirms, only the new one
+// is. A backend that doesn't confirm, such as the lua-helper, keeps the token it has. The
+// session token the Lua side uses is not rotated.
+//
+// The endpoints only the backend and the Lua side call (/msg, /ready and /render_clip) are
+// also mounted under a random prefix for the session, so a page or another program that
+// merely guesses HushCut's port can't even find them. The frontend gets the prefix through
+// GetEndpointPrefix, the backend with its token and in /register, the lua-helper from
+// /register or the discovery file.
+
+const (
+	defaultTokenRotationMinutes = 60
+	maxTokenRotationMinutes     = 24 * 60
+	tokenRotationTimeout        = 5 * time.Second
+	maxTokenRotationReplyBytes  = 4 << 10
+)
+
+var errTokenRotationUnsupported = errors.New("the backend does not support token rotation")
+
+// newToken returns a random token in the "HushCut-..." form the Lua script uses as well.
+func newToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "HushCut-" + hex.EncodeToString(b)
+}
+
+// rotatePythonToken replaces the Python backend's token for a new launch and returns it.
+func (a *App) rotatePythonToken() string {
+	token := newToken()
+	a.pythonTokenMu.Lock()
+	a.pythonToken = token
+	a.pythonTokenMu.Unlock()
+	return token
+}
+
+// backendToken is the token requests to the Python command port carry: the launched
+// backend's own, or the session token for the lua-helper Resolve starts.
+func (a *App) backendToken() string {
+	a.pythonTokenMu.RLock()
+	defer a.pythonTokenMu.RUnlock()
+	if a.pythonToken != "" {
+		return a.pythonToken
+	}
+	return a.authToken
+}
+
+// tokenAccepted reports whether token authenticates a request. With backendOnly, as for
+// /ready and /msg, only the launched backend's token does, if there is one; the session token
+// still works there for the lua-helper Resolve starts, which gets no token of its own.
+func (a *App) tokenAccepted(token string, backendOnly bool) bool {
+	a.pythonTokenMu.RLock()
+	pythonToken, pendingToken := a.pythonToken, a.pendingPythonToken
+	a.pythonTokenMu.RUnlock()
+
+	if pythonToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(pythonToken)) == 1 {
+		return true
+	}
+	if pendingToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(pendingToken)) == 1 {
+		return true
+	}
+	if backendOnly && pythonToken != "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.authToken)) == 1
+}
+
+var tokenRotationMu sync.Mutex
+
+// RotateBackendToken gives the Python backend a new token. The backend keeps accepting the old
+// one until Go uses the new one, or for a short grace period if the reply got lost. It fails
+// with errTokenRotationUnsupported if the backend doesn't take part, which leaves its token as is.
+func (a *App) RotateBackendToken() error {
+	tokenRotationMu.Lock()
+	defer tokenRotationMu.Unlock()
+	if a.pythonCommandPort == 0 {
+		return errors.New("no Python backend is connected")
+	}
+
+	oldToken, token := a.backendToken(), newToken()
+	a.pythonTokenMu.Lock()
+	a.pendingPythonToken = token
+	a.pythonTokenMu.Unlock()
+
+	err := a.sendTokenRotation(oldToken, token)
+
+	a.pythonTokenMu.Lock()
+	a.pendingPythonToken = ""
+	if err == nil {
+		a.pythonToken = token
+	}
+	a.pythonTokenMu.Unlock()
+	if err == nil {
+		ipcLog.Info("Backend token rotated")
+	}
+	return err
+}
+
+// sendTokenRotation hands newToken to the backend and waits for it to confirm.
+func (a *App) sendTokenRotation(oldToken, newToken string) error {
+	body, err := json.Marshal(map[string]string{"token": newToken})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRotationTimeout)
+	defer cancel()
+	url := fmt.Sprintf("http://localhost:%d/rotate_token", a.pythonCommandPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+oldToken)
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("token rotation: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errTokenRotationUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token rotation: backend responded with %s", resp.Status)
+	}
+
+	// Anything but an explicit confirmation means the backend still has the old token.
+	var reply struct {
+		Rotated bool `json:"rotated"`
+	}
+	data, err := safejson.ReadAll(resp.Body, maxTokenRotationReplyBytes)
+	if err != nil || safejson.Decode(data, &reply, false) != nil || !reply.Rotated {
+		return errTokenRotationUnsupported
+	}
+	return nil
+}
+
+// runTokenRotation rotates the backend's token while the app stays open. The interval comes
+// from the "backendTokenRotationMinutes" setting and is re-read after every wait.
+func (a *App) runTokenRotation() {
+	for {
+		minutes := defaultTokenRotationMinutes
+		if settings, err := a.GetSettings(); err == nil {
+			minutes = settingInt(settings, "backendTokenRotationMinutes", defaultTokenRotationMinutes)
+		}
+		enabled := minutes > 0
+		interval := time.Duration(defaultTokenRotationMinutes) * time.Minute
+		if enabled {
+			interval = time.Duration(min(minutes, maxTokenRotationMinutes)) * time.Minute
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !enabled || !a.pythonReady {
+			continue
+		}
+		if err := a.RotateBackendToken(); err != nil && !errors.Is(err, errTokenRotationUnsupported) {
+			ipcLog.Warn("Could not rotate the backend token", "err", err)
+		}
+	}
+}
+
+// newEndpointPrefix returns a random path prefix such as "/s-1a2b...".
+func newEndpointPrefix() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "/s-" + hex.EncodeToString(b)
+}
+
+// GetEndpointPrefix returns the prefix /msg, /ready and /render_clip are mounted under.
+func (a *App) GetEndpointPrefix() string {
+	return a.endpointPrefix
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestTokenAccepted(t *testing.T) {
+	const session, backend, pending = "HushCut-session", "HushCut-backend", "HushCut-pending"
+	tests := []struct {
+		name         string
+		pythonToken  string
+		pendingToken string
+		token        string
+		backendOnly  bool
+		want         bool
+	}{
+		{"session token without a backend", "", "", session, false, true},
+		{"session token on /msg without a backend", "", "", session, true, true},
+		{"session token with a backend", backend, "", session, false, true},
+		{"session token on /msg with a backend", backend, "", session, true, false},
+		{"backend token on /msg", backend, "", backend, true, true},
+		{"pending token during rotation", backend, pending, pending, true, true},
+		{"pending token after rotation", backend, "", pending, true, false},
+		{"unknown token", backend, "", "HushCut-other", false, false},
+		{"empty token", "", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{authToken: session, pythonToken: tt.pythonToken, pendingPythonToken: tt.pendingToken}
+			if got := a.tokenAccepted(tt.token, tt.backendOnly); got != tt.want {
+				t.Errorf("tokenAccepted(%q, %v) = %v, want %v", tt.token, tt.backendOnly, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	settingsFileName           = "settings.json"
+	settingsPollInterval       = 2 * time.Second
+	defaultFfmpegConcurrency   = 8
+	defaultWaveformConcurrency = 3
+	maxFfmpegConcurrency       = 32
+	maxWaveformConcurrency     = 16
+	// Silence detection has a pool of its own, so analyzing a timeline doesn't wait behind
+	// conversions and the other way round.
+	defaultDetectionConcurrency = 4
+	maxDetectionConcurrency     = 32
+	maxCleanupThresholdDays     = 3650
+	maxCleanupIntervalHours     = 24 * 7
+)
+
+// SettingsFieldError describes a single invalid setting so the UI can highlight the field.
+type SettingsFieldError struct {
+	Field   string `json:"field"`
+	Value   any    `json:"value"`
+	Message string `json:"message"`
+}
+
+// SettingsValidationError is returned by SaveSettings when the data fails validation.
+type SettingsValidationError struct {
+	Fields []SettingsFieldError
+}
+
+func (e *SettingsValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "invalid settings: " + strings.Join(parts, "; ")
+}
+
+func (a *App) getSettingsPath() string {
+	return filepath.Join(a.userResourcesPath, settingsFileName)
+}
+
+// reads settings.json. Creates it with defaults if it doesn't exist.
+func (a *App) GetSettings() (map[string]any, error) {
+	var settingsData map[string]any
+	settingsPath := a.getSettingsPath()
+
+	err := readJSONWithRecovery(settingsPath, &settingsData)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist, create it
+			defaultSettings := make(map[string]any)
+			// Add default key-value pairs here if needed
+			defaultSettings["davinciFolderPath"] = ""
+			defaultSettings["cleanupThresholdDays"] = 30
+			defaultSettings["enableCleanup"] = true
+
+			jsonData, marshalErr := json.MarshalIndent(defaultSettings, "", "  ")
+			if marshalErr != nil {
+				return nil, fmt.Errorf("failed to marshal default settings: %w", marshalErr)
+			}
+
+			dir := filepath.Dir(settingsPath)
+			if mkDirErr := os.MkdirAll(dir, 0755); mkDirErr != nil {
+				return nil, fmt.Errorf("failed to create settings directory %s: %w", dir, mkDirErr)
+			}
+
+			if writeErr := writeFileAtomic(settingsPath, jsonData, 0644); writeErr != nil {
+				return nil, fmt.Errorf("failed to write default settings file %s: %w", settingsPath, writeErr)
+			}
+			settingsData = defaultSettings
+		} else {
+			// Unreadable, or malformed with no usable backup
+			return nil, fmt.Errorf("failed to read settings file %s: %w", settingsPath, err)
+		}
+	}
+	if settingsData == nil { // the file holds "null"
+		settingsData = map[string]any{}
+	}
+	a.policy.apply(settingsData)
+	return settingsData, nil
+}
+
+// readUserSettingsFile returns settings.json as stored, without policy values applied.
+func (a *App) readUserSettingsFile() map[string]any {
+	userSettings := map[string]any{}
+	if err := readJSONWithRecovery(a.getSettingsPath(), &userSettings); err != nil && !os.IsNotExist(err) {
+		appLog.Warn("Could not read settings file", "err", err)
+		userSettings = map[string]any{}
+	}
+	if userSettings == nil {
+		userSettings = map[string]any{}
+	}
+	return userSettings
+}
+
+// ValidateSettings checks settings data and returns one entry per invalid field.
+// An empty list means the settings are valid.
+func (a *App) ValidateSettings(settingsData map[string]interface{}) []SettingsFieldError {
+	fieldErrors := []SettingsFieldError{}
+	addErr := func(field string, message string) {
+		fieldErrors = append(fieldErrors, SettingsFieldError{Field: field, Value: settingsData[field], Message: message})
+	}
+
+	checkInt := func(field string, min int, max int) {
+		raw, present := settingsData[field]
+		if !present || raw == nil {
+			return
+		}
+		var val float64
+		switch v := raw.(type) {
+		case float64:
+			val = v
+		case int:
+			val = float64(v)
+		default:
+			addErr(field, "must be a number")
+			return
+		}
+		if val != float64(int(val)) {
+			addErr(field, "must be a whole number")
+		} else if int(val) < min || int(val) > max {
+			addErr(field, fmt.Sprintf("must be between %d and %d", min, max))
+		}
+	}
+	checkInt("cleanupThresholdDays", 0, maxCleanupThresholdDays)
+	checkInt("ffmpegConcurrency", 1, maxFfmpegConcurrency)
+	checkInt("waveformConcurrency", 1, maxWaveformConcurrency)
+	checkInt("detectionConcurrency", 1, maxDetectionConcurrency)
+	checkInt("cleanupIntervalHours", 1, maxCleanupIntervalHours)
+	checkInt("cacheMemoryBudgetMB", minCacheMemoryBudgetMB, maxCacheMemoryBudgetMB)
+	checkInt("ffmpegNiceness", 0, maxFfmpegNiceness)
+	checkInt("ffmpegMemoryLimitMB", 0, maxFfmpegMemoryLimitMB)
+	checkInt("tlsPort", 0, maxTLSPort)
+	checkInt("backendTokenRotationMinutes", 0, maxTokenRotationMinutes)
+
+	if raw, present := settingsData["maxCacheSizeGB"]; present && raw != nil {
+		switch v := raw.(type) {
+		case float64:
+			if v < 0 {
+				addErr("maxCacheSizeGB", "cannot be negative (use 0 for no limit)")
+			}
+		case int:
+			if v < 0 {
+				addErr("maxCacheSizeGB", "cannot be negative (use 0 for no limit)")
+			}
+		default:
+			addErr("maxCacheSizeGB", "must be a number")
+		}
+	}
+
+	for _, field := range []string{"enableCleanup", "offlineMode", "sendCrashReports", "runInBackground", "debugProjectDump", "debugProjectDumpRedact", "backgroundAnalysis", "tlsEnabled", "transcriptionEnabled"} {
+		if raw, present := settingsData[field]; present && raw != nil {
+			if _, ok := raw.(bool); !ok {
+				addErr(field, "must be true or false")
+			}
+		}
+	}
+
+	checkPath := func(field string, wantDir bool) {
+		raw, present := settingsData[field]
+		if !present || raw == nil {
+			return
+		}
+		path, ok := raw.(string)
+		if !ok {
+			addErr(field, "must be a path")
+			return
+		}
+		if path == "" {
+			return
+		}
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			addErr(field, "path does not exist")
+		case err != nil:
+			addErr(field, fmt.Sprintf("path is not accessible: %v", err))
+		case wantDir && !info.IsDir():
+			addErr(field, "must be a folder")
+		case !wantDir && info.IsDir():
+			addErr(field, "must be a file")
+		}
+	}
+	if raw, present := settingsData["proxyUrl"]; present && raw != nil {
+		if proxy, ok := raw.(string); !ok {
+			addErr("proxyUrl", "must be text")
+		} else if proxy != "" {
+			if _, err := parseProxyURL(proxy); err != nil {
+				addErr("proxyUrl", fmt.Sprintf("is not a valid proxy address: %v", err))
+			}
+		}
+	}
+
+	if raw, present := settingsData["updateChannel"]; present && raw != nil {
+		if channel, ok := raw.(string); !ok || (channel != updateChannelStable && channel != updateChannelBeta) {
+			addErr("updateChannel", "must be \"stable\" or \"beta\"")
+		}
+	}
+
+	checkChoice := func(field string, allowed []string) {
+		if raw, present := settingsData[field]; present && raw != nil {
+			if value, ok := raw.(string); !ok || !isOneOf(value, allowed) {
+				addErr(field, "must be one of "+strings.Join(allowed, ", "))
+			}
+		}
+	}
+	checkChoice("gpuPolicy", gpuPolicies)
+	checkChoice("displayBackend", displayBackends)
+	checkChoice("whisperModel", whisperModels)
+
+	if raw, present := settingsData["logLevel"]; present && raw != nil {
+		if name, ok := raw.(string); !ok {
+			addErr("logLevel", "must be text")
+		} else if _, err := parseLogLevel(name); err != nil {
+			addErr("logLevel", err.Error())
+		}
+	}
+	if raw, present := settingsData["logLevels"]; present && raw != nil {
+		if perSubsystem, ok := raw.(map[string]any); !ok {
+			addErr("logLevels", "must map subsystems to levels")
+		} else {
+			for subsystem, level := range perSubsystem {
+				name, _ := level.(string)
+				if !isLogSubsystem(subsystem) {
+					addErr("logLevels", fmt.Sprintf("unknown subsystem '%s'", subsystem))
+				} else if _, err := parseLogLevel(name); err != nil {
+					addErr("logLevels", fmt.Sprintf("%s: %v", subsystem, err))
+				}
+			}
+		}
+	}
+
+	checkPath("davinciFolderPath", true)
+	checkPath("ffmpegPath", false)
+	checkPath("whisperPath", false)
+
+	if a.policy != nil {
+		for field, lockedVal := range a.policy.Values {
+			if val, present := settingsData[field]; present && fmt.Sprint(val) != fmt.Sprint(lockedVal) {
+				addErr(field, "is managed by your administrator and cannot be changed")
+			}
+		}
+	}
+
+	return fieldErrors
+}
+
+// saves the given configuration data to settings.json.
+func (a *App) SaveSettings(settingsData map[string]interface{}) error {
+	if fieldErrors := a.ValidateSettings(settingsData); len(fieldErrors) > 0 {
+		return &SettingsValidationError{Fields: fieldErrors}
+	}
+
+	// Policy-controlled keys are never written; the user's own value (if any) is preserved.
+	toWrite := make(map[string]interface{}, len(settingsData))
+	for k, v := range settingsData {
+		toWrite[k] = v
+	}
+	if a.policy != nil && len(a.policy.Values) > 0 {
+		userSettings := a.readUserSettingsFile()
+		for k := range a.policy.Values {
+			if userVal, ok := userSettings[k]; ok {
+				toWrite[k] = userVal
+			} else {
+				delete(toWrite, k)
+			}
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(toWrite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings data for saving: %w", err)
+	}
+	settingsPath := a.getSettingsPath()
+
+	dir := filepath.Dir(settingsPath)
+	if mkDirErr := os.MkdirAll(dir, 0755); mkDirErr != nil {
+		return fmt.Errorf("failed to create settings directory %s for saving: %w", dir, mkDirErr)
+	}
+
+	if err := writeFileAtomic(settingsPath, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write settings file %s: %w", settingsPath, err)
+	}
+
+	// Remember our own write so the file watcher doesn't report it a second time.
+	if info, statErr := os.Stat(settingsPath); statErr == nil {
+		a.settingsMu.Lock()
+		a.settingsModTime = info.ModTime()
+		a.settingsMu.Unlock()
+	}
+
+	a.policy.apply(settingsData)
+	a.applySettings(settingsData)
+	a.emit("settings:changed", settingsData)
+	return nil
+}
+
+// settingInt reads a numeric setting. JSON numbers decode as float64, defaults may be int.
+func settingInt(settings map[string]any, key string, fallback int) int {
+	switch val := settings[key].(type) {
+	case float64:
+		return int(val)
+	case int:
+		return val
+	}
+	return fallback
+}
+
+func settingBool(settings map[string]any, key string, fallback bool) bool {
+	if val, ok := settings[key].(bool); ok {
+		return val
+	}
+	return fallback
+}
+
+func settingString(settings map[string]any, key string, fallback string) string {
+	if val, ok := settings[key].(string); ok {
+		return val
+	}
+	return fallback
+}
+
+// applySettings pushes values from the settings file into the running subsystems.
+// It is called on startup, after SaveSettings and whenever the file changes on disk.
+func (a *App) applySettings(settings map[string]any) {
+	// Invalid values are still clamped below, but never silently.
+	for _, fieldErr := range a.ValidateSettings(settings) {
+		appLog.Warn("Invalid setting; using a safe fallback", "field", fieldErr.Field, "value", fieldErr.Value, "reason", fieldErr.Message)
+	}
+
+	applyLogLevels(settings)
+
+	a.resizeSemaphores(
+		settingInt(settings, "ffmpegConcurrency", defaultFfmpegConcurrency),
+		settingInt(settings, "waveformConcurrency", defaultWaveformConcurrency),
+		settingInt(settings, "detectionConcurrency", defaultDetectionConcurrency),
+	)
+	a.setCacheMemoryBudget(settingInt(settings, "cacheMemoryBudgetMB", defaultCacheMemoryBudgetMB))
+	setFfmpegLimits(processLimits{
+		nice:     settingInt(settings, "ffmpegNiceness", defaultFfmpegNiceness),
+		memoryMB: settingInt(settings, "ffmpegMemoryLimitMB", 0),
+	})
+	a.setTLSSettings(tlsSettings{
+		enabled: settingBool(settings, "tlsEnabled", false),
+		port:    max(0, min(settingInt(settings, "tlsPort", 0), maxTLSPort)),
+	})
+	// A pending wake-up already makes the scheduler re-read the interval.
+	select {
+	case a.cleanupSettingsChanged <- struct{}{}:
+	default:
+	}
+
+	if customPath := settingString(settings, "ffmpegPath", ""); customPath != "" && customPath != a.ffmpegBinaryPath {
+		if binaryExists(customPath) {
+			ffmpegLog.Info("Using ffmpeg from settings", "path", customPath)
+			a.ffmpegMutex.Lock()
+			a.ffmpegBinaryPath = customPath
+			a.ffmpegStatus = StatusReady
+			a.ffmpegMutex.Unlock()
+			a.signalFfmpegReady()
+			a.emit("ffmpeg:status", a.ffmpegStatus)
+		} else {
+			ffmpegLog.Warn("ffmpegPath setting is not a usable ffmpeg binary", "path", customPath, "keeping", a.ffmpegBinaryPath)
+		}
+	}
+}
+
+// resizeSemaphores swaps the worker semaphores for ones of the new size.
+// Jobs holding a slot release it on the channel they acquired it from.
+func (a *App) resizeSemaphores(ffmpegSlots, waveformSlots, detectionSlots int) {
+	if ffmpegSlots < 1 {
+		ffmpegSlots = 1
+	}
+	if waveformSlots < 1 {
+		waveformSlots = 1
+	}
+	if detectionSlots < 1 {
+		detectionSlots = 1
+	}
+	ffmpegSlots = min(ffmpegSlots, maxFfmpegConcurrency)
+	waveformSlots = min(waveformSlots, maxWaveformConcurrency)
+	detectionSlots = min(detectionSlots, maxDetectionConcurrency)
+
+	a.semaphoreMu.Lock()
+	defer a.semaphoreMu.Unlock()
+	if cap(a.ffmpegSemaphore) != ffmpegSlots {
+		appLog.Info("Concurrency set", "pool", "ffmpeg", "slots", ffmpegSlots)
+		a.ffmpegSemaphore = make(chan struct{}, ffmpegSlots)
+	}
+	if cap(a.waveformSemaphore) != waveformSlots {
+		appLog.Info("Concurrency set", "pool", "waveform", "slots", waveformSlots)
+		a.waveformSemaphore = make(chan struct{}, waveformSlots)
+	}
+	if cap(a.detectSemaphore) != detectionSlots {
+		appLog.Info("Concurrency set", "pool", "detection", "slots", detectionSlots)
+		a.detectSemaphore = make(chan struct{}, detectionSlots)
+	}
+}
+
+// acquireFfmpegSlot blocks until an ffmpeg slot is free and background jobs aren't paused,
+// and returns its release func. kind and target (the file the job writes or reads) are
+// listed by ListActiveJobs. It fails with errJobCancelled if CancelAllJobs is called
+// meanwhile.
+func (a *App) acquireFfmpegSlot(kind, target string) (func(), error) {
+	return a.acquireSlot(&slotJob{kind: kind, target: target, pool: "ffmpeg"}, func() chan struct{} { return a.ffmpegSemaphore })
+}
+
+// acquireWaveformSlot is acquireFfmpegSlot for waveform jobs.
+func (a *App) acquireWaveformSlot(target string) (func(), error) {
+	return a.acquireSlot(&slotJob{kind: jobWaveform, target: target, pool: "waveform"}, func() chan struct{} { return a.waveformSemaphore })
+}
+
+// acquireDetectionSlot is acquireFfmpegSlot for silence detection.
+func (a *App) acquireDetectionSlot(target string) (func(), error) {
+	return a.acquireSlot(&slotJob{kind: jobDetection, target: target, pool: "detection"}, func() chan struct{} { return a.detectSemaphore })
+}
+
+// acquireSlot waits for a slot of the semaphore that semaphore returns, in the order of
+// slotQueue; it is called with semaphoreMu read-locked.
+func (a *App) acquireSlot(job *slotJob, semaphore func() chan struct{}) (func(), error) {
+	cancelled := activeJobs.queue(job)
+	if !a.jobsPaused.wait(cancelled) {
+		activeJobs.dequeue(job)
+		return nil, errJobCancelled
+	}
+	a.semaphoreMu.RLock()
+	sem := semaphore()
+	a.semaphoreMu.RUnlock()
+	if !waitForPrioritySlot(job.pool, job.target, sem, cancelled) {
+		activeJobs.dequeue(job)
+		return nil, errJobCancelled
+	}
+	activeJobs.acquired(job)
+	return func() {
+		activeJobs.released(job)
+		<-sem
+	}, nil
+}
+
+// watchSettingsFile polls settings.json and hot-reloads it when edited outside the app.
+func (a *App) watchSettingsFile() {
+	ticker := time.NewTicker(settingsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(a.getSettingsPath())
+		if err != nil {
+			continue
+		}
+
+		a.settingsMu.Lock()
+		changed := !info.ModTime().Equal(a.settingsModTime)
+		a.settingsModTime = info.ModTime()
+		a.settingsMu.Unlock()
+		if !changed {
+			continue
+		}
+
+		settings, err := a.GetSettings()
+		if err != nil {
+			appLog.Warn("Settings file changed but could not be read", "err", err)
+			continue
+		}
+		appLog.Info("Settings file changed on disk; reloading")
+		a.applySettings(settings)
+		a.emit("settings:changed", settings)
+	}
+}
+
+// loadInitialSettings applies the stored settings once at startup and starts the watcher.
+func (a *App) loadInitialSettings() {
+	settings, err := a.GetSettings()
+	if err != nil {
+		appLog.Warn("Could not load settings at startup", "err", err)
+	} else {
+		a.applySettings(settings)
+	}
+
+	if info, statErr := os.Stat(a.getSettingsPath()); statErr == nil {
+		a.settingsMu.Lock()
+		a.settingsModTime = info.ModTime()
+		a.settingsMu.Unlock()
+	}
+	go saferun(func() { a.watchSettingsFile() })
+}
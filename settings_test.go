@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func FuzzSettingsFile(f *testing.F) {
+	for _, seed := range []string{
+		`{"davinciFolderPath":"","cleanupThresholdDays":30,"enableCleanup":true}`,
+		`{"cleanupThresholdDays":"30","maxConcurrentJobs":1.5,"language":7}`,
+		`{"whisperModel":"base","transcriptionEnabled":"yes"}`,
+		`null`,
+		`[]`,
+		`{"a":{"b":{"c":[[[[]]]]}}}`,
+		`{`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, settingsFileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		app := &App{userResourcesPath: dir}
+		settings := app.readUserSettingsFile()
+		if settings == nil {
+			t.Fatal("readUserSettingsFile returned nil")
+		}
+		app.ValidateSettings(settings)
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// Jobs that wait for a slot are served by priority. Waveforms are precomputed in the
+// background as files finish converting, and after a sync the whole timeline is analyzed in
+// the background, so on a long timeline those queues can be far behind the clips the user is
+// looking at. The frontend hints which clips are on screen; a job for one of their files, or
+// for a file someone is waiting on right now, is urgent, and a job for a file the background
+// analysis prepares is low priority. A job doesn't take a slot while a job of a higher
+// priority waits for one in the same pool.
+
+const (
+	priorityBackground = iota
+	priorityNormal
+	priorityUrgent
+	priorityLevels
+)
+
+type slotPriority struct {
+	mu         sync.Mutex
+	visible    map[string]bool  // absolute WAV paths of the visible clips
+	wanted     map[string]int   // absolute WAV paths a caller is waiting on, and how many
+	background map[string]int   // absolute WAV paths the background analysis prepares
+	waiting    map[string][]int // by pool, the jobs waiting for a slot at each priority
+	changed    chan struct{}    // closed and replaced whenever the priorities change
+}
+
+var slotQueue = &slotPriority{
+	visible:    map[string]bool{},
+	wanted:     map[string]int{},
+	background: map[string]int{},
+	waiting:    map[string][]int{},
+	changed:    make(chan struct{}),
+}
+
+// notifyLocked wakes up the jobs that wait for a change. p.mu is held.
+func (p *slotPriority) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *slotPriority) setVisible(paths []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.visible = make(map[string]bool, len(paths))
+	for _, path := range paths {
+		p.visible[path] = true
+	}
+	p.notifyLocked()
+}
+
+// want marks path as awaited until the returned func is called.
+func (p *slotPriority) want(path string) func() {
+	return p.mark(p.wanted, []string{path})
+}
+
+// lower marks paths as prepared in the background until the returned func is called.
+func (p *slotPriority) lower(paths []string) func() {
+	return p.mark(p.background, paths)
+}
+
+func (p *slotPriority) mark(set map[string]int, paths []string) func() {
+	p.mu.Lock()
+	for _, path := range paths {
+		set[path]++
+	}
+	p.notifyLocked()
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for _, path := range paths {
+			if set[path]--; set[path] <= 0 {
+				delete(set, path)
+			}
+		}
+		p.notifyLocked()
+	}
+}
+
+func (p *slotPriority) priorityLocked(path string) int {
+	switch {
+	case p.visible[path] || p.wanted[path] > 0:
+		return priorityUrgent
+	case p.background[path] > 0:
+		return priorityBackground
+	}
+	return priorityNormal
+}
+
+// enqueue places a job of pool for path in the queue at its priority, or takes it out while a
+// job of a higher priority waits. level is where the job is, -1 if it isn't queued; the new
+// level is returned with the channel that tells when to ask again. Only a level that starts
+// or stops having jobs wakes the others up, so jobs that wait side by side don't.
+func (p *slotPriority) enqueue(pool, path string, level int) (int, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts := p.waiting[pool]
+	if counts == nil {
+		counts = make([]int, priorityLevels)
+		p.waiting[pool] = counts
+	}
+	next := p.priorityLocked(path)
+	for higher := next + 1; higher < priorityLevels; higher++ {
+		if counts[higher] > 0 {
+			next = -1
+			break
+		}
+	}
+	if next != level {
+		p.dequeueLocked(pool, level)
+		if next >= 0 {
+			if counts[next]++; counts[next] == 1 {
+				p.notifyLocked()
+			}
+		}
+	}
+	return next, p.changed
+}
+
+func (p *slotPriority) dequeue(pool string, level int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dequeueLocked(pool, level)
+}
+
+func (p *slotPriority) dequeueLocked(pool string, level int) {
+	if level < 0 {
+		return
+	}
+	counts := p.waiting[pool]
+	if counts[level]--; counts[level] == 0 {
+		p.notifyLocked()
+	}
+}
+
+// waitForPrioritySlot takes a slot of sem for a job of pool on path, urgent jobs first and
+// background jobs last. It returns false if cancelled closes first.
+func waitForPrioritySlot(pool, path string, sem chan struct{}, cancelled <-chan struct{}) bool {
+	level := -1
+	defer func() { slotQueue.dequeue(pool, level) }()
+	for {
+		var changed <-chan struct{}
+		level, changed = slotQueue.enqueue(pool, path, level)
+		if level < 0 {
+			select {
+			case <-changed:
+				continue
+			case <-cancelled:
+				return false
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+			return true
+		case <-changed:
+			// A job of a higher priority arrived, or this one changed priority: decide again.
+		case <-cancelled:
+			return false
+		}
+	}
+}
+
+// HintVisibleClips tells which clips are on screen, so their waveforms are computed before
+// those of the other clips. Waveforms of visible clips that aren't cached yet are started
+// right away.
+func (a *App) HintVisibleClips(clipIDs []string) {
+	var paths []string
+	fileNames := map[string]bool{}
+	for _, id := range clipIDs {
+		item, _, ok := a.findClipByID(id)
+		if !ok || item.ProcessedFileName == nil || *item.ProcessedFileName == "" {
+			continue
+		}
+		fileName := *item.ProcessedFileName
+		if !fileNames[fileName] {
+			fileNames[fileName] = true
+			paths = append(paths, filepath.Join(a.tmpPath, fileName))
+		}
+	}
+	slotQueue.setVisible(paths)
+
+	if a.headless {
+		return
+	}
+	for fileName := range fileNames {
+		if a.hasPrecomputedWaveform(fileName) {
+			continue
+		}
+		go saferun(func() {
+			if err := a.precomputeWaveform(fileName); err != nil {
+				waveformLog.Debug("Could not compute waveform of visible clip", "file", fileName, "err", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subtitles imported for a timeline (SRT or WebVTT) constrain where it may be cut, so that
+// captions stay on the speech they belong to: no cut falls inside a caption's display window.
+// In "shift" mode a silence reaching into a caption is trimmed to the caption's edge, and
+// dropped if what is left is shorter than the minimum silence; in "suppress" mode a silence
+// that touches a caption is not cut at all. The cues are kept in the timeline's session.
+
+const (
+	subtitleModeShift    = "shift"
+	subtitleModeSuppress = "suppress"
+)
+
+var subtitleModes = []string{subtitleModeShift, subtitleModeSuppress}
+
+// SubtitleCue is one caption, in seconds of the timeline's timecode (a cue at 01:00:00,000
+// starts on the first frame of a timeline that starts at 01:00:00:00).
+type SubtitleCue struct {
+	Index int     `json:"index"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// SubtitleTrack is the subtitle file imported for a timeline.
+type SubtitleTrack struct {
+	FileName   string    `json:"fileName"`
+	ImportedAt time.Time `json:"importedAt"`
+	Mode       string    `json:"mode"` // "shift" or "suppress"
+	// OffsetSeconds was added to the file's times; files that start at zero are moved to
+	// the timeline's start timecode.
+	OffsetSeconds float64       `json:"offsetSeconds"`
+	Cues          []SubtitleCue `json:"cues"`
+}
+
+// ImportSubtitles reads an SRT or WebVTT file for the synced timeline and stores it in the
+// timeline's session, replacing the previous one. If srcPath is empty, an open dialog is shown.
+func (a *App) ImportSubtitles(srcPath string, mode string) (*SubtitleTrack, error) {
+	if mode == "" {
+		mode = subtitleModeShift
+	}
+	if !slices.Contains(subtitleModes, mode) {
+		return nil, fmt.Errorf("unknown subtitle mode %q", mode)
+	}
+	a.mu.Lock()
+	project := a.currentProject
+	a.mu.Unlock()
+	if project == nil {
+		return nil, fmt.Errorf("no project has been synced yet")
+	}
+
+	var err error
+	if srcPath == "" {
+		srcPath, err = openFileDialog(a.ctx, "Import Subtitles", fileFilter{DisplayName: "Subtitles", Pattern: "*.srt;*.vtt"})
+		if err != nil || srcPath == "" {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subtitles: %w", err)
+	}
+	cues, err := parseSubtitles(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(srcPath), err)
+	}
+
+	track := &SubtitleTrack{FileName: filepath.Base(srcPath), ImportedAt: time.Now(), Mode: mode}
+	if start, ok := timecodeSeconds(project.Timeline.StartTimecode, project.Timeline.FPS); ok && cues[0].Start < start {
+		track.OffsetSeconds = start
+		for i := range cues {
+			cues[i].Start += start
+			cues[i].End += start
+		}
+	}
+	track.Cues = cues
+
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session = &TimelineSession{ProjectName: project.ProjectName, TimelineName: project.Timeline.Name, Params: a.GetCurrentParams()}
+	}
+	session.Subtitles = track
+	if err := a.writeTimelineSession(*session); err != nil {
+		return nil, err
+	}
+	appLog.Info("Imported subtitles", "cues", len(cues), "file", track.FileName, "timeline", project.Timeline.Name, "mode", mode)
+	return track, nil
+}
+
+// ClearSubtitles removes the subtitles of the synced timeline, so it is cut freely again.
+func (a *App) ClearSubtitles() error {
+	a.mu.Lock()
+	project := a.currentProject
+	a.mu.Unlock()
+	if project == nil {
+		return fmt.Errorf("no project has been synced yet")
+	}
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	session, err := a.GetTimelineSession(project.ProjectName, project.Timeline.Name)
+	if err != nil || session == nil || session.Subtitles == nil {
+		return err
+	}
+	session.Subtitles = nil
+	return a.writeTimelineSession(*session)
+}
+
+// parseSubtitles reads the cues of an SRT or WebVTT file, sorted by start.
+func parseSubtitles(data []byte) ([]SubtitleCue, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\r", "\n")
+
+	var cues []SubtitleCue
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		// Blocks without timing are the WebVTT header, NOTE, STYLE and REGION blocks.
+		if timing < 0 {
+			continue
+		}
+		fields := strings.Fields(lines[timing])
+		if len(fields) < 3 || fields[1] != "-->" {
+			return nil, fmt.Errorf("invalid cue timing %q", lines[timing])
+		}
+		start, err := parseSubtitleTime(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseSubtitleTime(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			continue
+		}
+		cues = append(cues, SubtitleCue{Start: start, End: end, Text: strings.Join(lines[timing+1:], "\n")})
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("no subtitle cues found")
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	for i := range cues {
+		cues[i].Index = i + 1
+	}
+	return cues, nil
+}
+
+// parseSubtitleTime parses "hh:mm:ss,mmm" (SRT) or "hh:mm:ss.mmm" and "mm:ss.mmm" (WebVTT).
+func parseSubtitleTime(s string) (float64, error) {
+	parts := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid subtitle time %q", s)
+	}
+	seconds := 0.0
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, fmt.Errorf("invalid subtitle time %q", s)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
+
+// timecodeSeconds converts a timecode like "01:00:00:00" at fps to seconds, counting frames
+// at the nominal rate like Resolve's frame numbers.
+func timecodeSeconds(timecode string, fps float64) (float64, bool) {
+	parts := strings.FieldsFunc(timecode, func(r rune) bool { return r == ':' || r == ';' })
+	if len(parts) != 4 || fps <= floatEpsilon {
+		return 0, false
+	}
+	var values [4]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return 0, false
+		}
+		values[i] = value
+	}
+	frames := ((values[0]*60+values[1])*60+values[2])*int(math.Round(fps)) + values[3]
+	return float64(frames) / fps, true
+}
+
+// constrain keeps the cuts of item out of the subtitle windows. silences and the result are
+// in seconds of the clip's source, like the detection's; minSilence is the shortest silence
+// that is still cut. It also returns how many silences were trimmed and dropped.
+func (t *SubtitleTrack) constrain(item *TimelineItem, silences []SilencePeriod, fps float64, minSilence float64) (kept []SilencePeriod, shifted int, suppressed int) {
+	if t == nil || len(t.Cues) == 0 || len(silences) == 0 || fps <= floatEpsilon {
+		return silences, 0, 0
+	}
+	// A timeline second t is source second t - (StartFrame - SourceStartFrame)/fps in the clip.
+	offset := (item.StartFrame - item.SourceStartFrame) / fps
+	clipStart, clipEnd := item.StartFrame/fps, item.EndFrame/fps
+	var windows []SilencePeriod
+	for _, cue := range t.Cues {
+		if cue.End > clipStart && cue.Start < clipEnd {
+			windows = append(windows, SilencePeriod{Start: cue.Start - offset, End: cue.End - offset})
+		}
+	}
+	if len(windows) == 0 {
+		return silences, 0, 0
+	}
+
+	kept = make([]SilencePeriod, 0, len(silences))
+	for _, silence := range silences {
+		pieces := []SilencePeriod{silence}
+		for _, window := range windows {
+			if window.End <= silence.Start || window.Start >= silence.End {
+				continue
+			}
+			if t.Mode == subtitleModeSuppress {
+				pieces = nil
+				break
+			}
+			var next []SilencePeriod
+			for _, piece := range pieces {
+				if window.Start > piece.Start {
+					next = append(next, SilencePeriod{Start: piece.Start, End: math.Min(piece.End, window.Start)})
+				}
+				if window.End < piece.End {
+					next = append(next, SilencePeriod{Start: math.Max(piece.Start, window.End), End: piece.End})
+				}
+			}
+			pieces = next
+		}
+
+		changed := len(pieces) != 1 || pieces[0] != silence
+		survived := 0
+		for _, piece := range pieces {
+			if piece.End-piece.Start >= minSilence-floatEpsilon && piece.End > piece.Start {
+				kept = append(kept, piece)
+				survived++
+			}
+		}
+		switch {
+		case survived == 0:
+			suppressed++
+		case changed:
+			shifted++
+		}
+	}
+	return kept, shifted, suppressed
+}
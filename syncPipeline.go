@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SyncAndAnalyze runs everything a sync needs in one go: fetch the timeline from Resolve,
+// then prepare its audio as one graph: convert, mix down compound clips, precompute the
+// waveforms and detect the silences of every clip. Each stage is announced as
+// "pipeline:stage"; the preparation reports its tasks as "prep:progress" and the clips
+// analyzed so far as "analysis:progress".
+
+const (
+	pipelineSync    = "sync"
+	pipelinePrepare = "prepare"
+)
+
+var pipelineStages = []string{pipelineSync, pipelinePrepare}
+
+// PipelineProgress is sent as "pipeline:stage" when a stage starts.
+type PipelineProgress struct {
+	Stage      string `json:"stage"`
+	StageIndex int    `json:"stageIndex"` // 1-based
+	StageCount int    `json:"stageCount"`
+}
+
+// SyncAnalysis is the result of SyncAndAnalyze. Project is nil if the sync brought no
+// timeline; Response says why.
+type SyncAnalysis struct {
+	Response *PythonCommandResponse     `json:"response"`
+	Project  *ProjectDataPayload        `json:"project,omitempty"`
+	Silences map[string][]SilencePeriod `json:"silences"`         // by clip ID
+	Failed   map[string]string          `json:"failed,omitempty"` // detection errors by clip ID
+}
+
+// SyncAndAnalyze syncs the timeline and analyzes it with params, or the clip's own
+// parameters where the timeline session has some. A clip whose detection fails is listed in
+// Failed and does not stop the others; a failed waveform is only logged, the clip view
+// generates it again when it is opened.
+func (a *App) SyncAndAnalyze(params DetectionParams) (*SyncAnalysis, error) {
+	return a.syncAndAnalyze("", params)
+}
+
+// syncAndAnalyze is SyncAndAnalyze for the timeline named timelineName (see syncTimeline).
+func (a *App) syncAndAnalyze(timelineName string, params DetectionParams) (*SyncAnalysis, error) {
+	a.pipelineStage(pipelineSync)
+	response, err := a.syncTimeline(timelineName)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncAnalysis{Response: response, Silences: map[string][]SilencePeriod{}}
+	// An alert turns the response into an error, but the timeline it came with is still the
+	// one the user looks at.
+	if response.Data == nil || (response.Status != "success" && !response.AlertIssued) {
+		return result, nil
+	}
+
+	project, err := decodeProjectData(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	result.Project = project
+
+	a.pipelineStage(pipelinePrepare)
+	a.setCurrentProject(project)
+	prep := a.buildProjectPrep(project, true, params)
+	// Audio that failed to convert is reported as "conversionError" and leaves its clips
+	// in Failed; only cancelling stops the pipeline.
+	if err := a.prepareProjectAudio(prep); errors.Is(err, errJobCancelled) {
+		return nil, err
+	}
+	for _, detection := range prep.detections {
+		for _, clipID := range detection.clipIDs {
+			if detection.task.state == prepDone {
+				result.Silences[clipID] = detection.silences
+				continue
+			}
+			if result.Failed == nil {
+				result.Failed = map[string]string{}
+			}
+			result.Failed[clipID] = detection.task.err.Error()
+		}
+	}
+
+	appLog.Info("SyncAndAnalyze done", "analyzed", len(result.Silences), "failed", len(result.Failed))
+	return result, nil
+}
+
+// decodeProjectData turns the data of a sync response into the project.
+func decodeProjectData(data interface{}) (*ProjectDataPayload, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the synced project: %w", err)
+	}
+	var project ProjectDataPayload
+	if err := json.Unmarshal(raw, &project); err != nil {
+		return nil, fmt.Errorf("failed to read the synced project: %w", err)
+	}
+	return &project, nil
+}
+
+func (a *App) pipelineStage(stage string) {
+	progress := PipelineProgress{Stage: stage, StageCount: len(pipelineStages)}
+	for i, s := range pipelineStages {
+		if s == stage {
+			progress.StageIndex = i + 1
+		}
+	}
+	a.emit("pipeline:stage", progress)
+}
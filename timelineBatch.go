@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A timeline batch processes several timelines of the open project one after the other with
+// the same parameters, e.g. a week's episodes: each is synced (made Resolve's current
+// timeline first), analyzed and turned into a final timeline of its own, without anyone
+// starting each step. A timeline that fails is reported and the batch goes on with the next;
+// cancelling the jobs (CancelAllJobs) stops the batch.
+
+// TimelineList is the result of ListTimelines.
+type TimelineList struct {
+	Timelines []string `json:"timelines"` // in Resolve's order
+	Current   string   `json:"current"`
+}
+
+// TimelineBatchRequest selects the timelines of a batch and how they are cut.
+type TimelineBatchRequest struct {
+	TimelineNames []string `json:"timelineNames,omitempty"`
+	// Pattern selects timelines by name in addition to TimelineNames, with * and ? as
+	// wildcards, e.g. "Episode *".
+	Pattern string `json:"pattern,omitempty"`
+	// PresetName names the preset to use; without one the current parameters are used.
+	PresetName string `json:"presetName,omitempty"`
+}
+
+// TimelineBatchEntry is the outcome of one timeline of a batch.
+type TimelineBatchEntry struct {
+	Timeline      string `json:"timeline"`
+	Status        string `json:"status"` // "success", "error" or "skipped"
+	Message       string `json:"message,omitempty"`
+	ClipsAnalyzed int    `json:"clipsAnalyzed"`
+	ClipsFailed   int    `json:"clipsFailed"`
+	ElapsedMs     int64  `json:"elapsedMs"`
+}
+
+// TimelineBatchResult is the result of ProcessTimelines, also sent as "batch:finished".
+type TimelineBatchResult struct {
+	Params    DetectionParams      `json:"params"`
+	StartedAt time.Time            `json:"startedAt"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Cancelled bool                 `json:"cancelled"`
+	Timelines []TimelineBatchEntry `json:"timelines"`
+}
+
+// TimelineBatchProgress is sent as "batch:progress" when a timeline of a batch starts.
+type TimelineBatchProgress struct {
+	Timeline string `json:"timeline"`
+	Index    int    `json:"index"` // 1-based
+	Total    int    `json:"total"`
+}
+
+// timelineBatchRunning refuses a second batch while one runs.
+var timelineBatchRunning atomic.Bool
+
+// runPythonTask sends command with a task ID and waits for the task's result, like
+// SyncWithDavinci.
+func (a *App) runPythonTask(command string, params map[string]interface{}) (*PythonCommandResponse, error) {
+	if !a.pythonReady {
+		return nil, fmt.Errorf("python backend not ready")
+	}
+	taskID := uuid.NewString()
+	respCh := make(chan PythonCommandResponse, 1)
+
+	a.pendingMu.Lock()
+	a.pendingTasks[taskID] = respCh
+	a.pendingMu.Unlock()
+	a.checkpoint.taskStarted(taskID, command)
+	defer func() {
+		a.pendingMu.Lock()
+		delete(a.pendingTasks, taskID)
+		a.pendingMu.Unlock()
+		a.checkpoint.taskFinished(taskID)
+	}()
+
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	params["taskId"] = taskID
+	ack, err := a.SendCommandToPython(command, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send '%s' command: %w", command, err)
+	}
+	if ack.Status != "success" {
+		return nil, fmt.Errorf("python '%s' ack error: %s", command, ack.Message)
+	}
+
+	response := <-respCh
+	if response.ShouldShowAlert {
+		a.emit("showAlert", map[string]interface{}{
+			"title": response.AlertTitle, "message": response.AlertMessage, "severity": response.AlertSeverity,
+		})
+		response.AlertIssued = true
+		response.Status = "error"
+		if response.Message == "" {
+			response.Message = response.AlertMessage
+		}
+	}
+	return &response, nil
+}
+
+// ListTimelines returns the names of the timelines in the project open in Resolve.
+func (a *App) ListTimelines() (*TimelineList, error) {
+	response, err := a.runPythonTask("listTimelines", nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("could not list the timelines: %s", response.Message)
+	}
+	data, _ := response.Data.(map[string]interface{})
+	list := &TimelineList{Timelines: []string{}}
+	list.Current, _ = data["current"].(string)
+	if names, ok := data["timelines"].([]interface{}); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				list.Timelines = append(list.Timelines, s)
+			}
+		}
+	}
+	return list, nil
+}
+
+// selectBatchTimelines returns the timelines of available that req names or matches, in
+// Resolve's order. Names that don't exist are returned separately.
+func selectBatchTimelines(available []string, req TimelineBatchRequest) (selected []string, missing []string, err error) {
+	if req.Pattern != "" {
+		if _, err := path.Match(req.Pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid timeline pattern %q: %w", req.Pattern, err)
+		}
+	}
+	named := make(map[string]bool, len(req.TimelineNames))
+	for _, name := range req.TimelineNames {
+		named[name] = true
+	}
+	exists := make(map[string]bool, len(available))
+	for _, name := range available {
+		exists[name] = true
+		matched := false
+		if req.Pattern != "" {
+			matched, _ = path.Match(req.Pattern, name)
+		}
+		if named[name] || matched {
+			selected = append(selected, name)
+		}
+	}
+	for _, name := range req.TimelineNames {
+		if !exists[name] {
+			missing = append(missing, name)
+		}
+	}
+	return selected, missing, nil
+}
+
+// ProcessTimelines syncs, analyzes and cuts the selected timelines one after the other,
+// creating one final timeline per timeline. Progress is sent as "batch:progress" and each
+// timeline's own events as in a manual run.
+func (a *App) ProcessTimelines(req TimelineBatchRequest) (*TimelineBatchResult, error) {
+	if !a.licenseValid {
+		return nil, fmt.Errorf("invalid license. Action not permitted")
+	}
+	if len(req.TimelineNames) == 0 && req.Pattern == "" {
+		return nil, fmt.Errorf("select timelines by name or pattern")
+	}
+	if !timelineBatchRunning.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("a timeline batch is already running")
+	}
+	defer timelineBatchRunning.Store(false)
+
+	a.mu.Lock()
+	params := a.currentParams
+	a.mu.Unlock()
+	if req.PresetName != "" {
+		preset, err := readPresetFile(a.getPresetPath(req.PresetName))
+		if err != nil {
+			return nil, err
+		}
+		params = preset.Params
+	}
+
+	list, err := a.ListTimelines()
+	if err != nil {
+		return nil, err
+	}
+	names, missing, err := selectBatchTimelines(list.Timelines, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no timeline matches the selection")
+	}
+
+	result := &TimelineBatchResult{Params: params, StartedAt: time.Now(), Timelines: []TimelineBatchEntry{}}
+	for _, name := range missing {
+		result.Timelines = append(result.Timelines, TimelineBatchEntry{Timeline: name, Status: "skipped", Message: "no such timeline"})
+	}
+	appLog.Info("ProcessTimelines starting", "timelines", len(names), "preset", req.PresetName)
+
+	for i, name := range names {
+		a.emit("batch:progress", TimelineBatchProgress{Timeline: name, Index: i + 1, Total: len(names)})
+		entry, err := a.processBatchTimeline(name, params)
+		result.Timelines = append(result.Timelines, entry)
+		if entry.Status == "success" {
+			result.Succeeded++
+		} else {
+			result.Failed++
+			appLog.Warn("ProcessTimelines: timeline failed", "timeline", name, "err", entry.Message)
+		}
+		if errors.Is(err, errJobCancelled) {
+			result.Cancelled = true
+			for _, rest := range names[i+1:] {
+				result.Timelines = append(result.Timelines, TimelineBatchEntry{Timeline: rest, Status: "skipped", Message: "the batch was cancelled"})
+			}
+			break
+		}
+	}
+
+	appLog.Info("ProcessTimelines done", "succeeded", result.Succeeded, "failed", result.Failed)
+	a.emit("batch:finished", result)
+	return result, nil
+}
+
+// processBatchTimeline runs one timeline of a batch. The error is only returned to tell a
+// cancelled batch apart; the entry describes it either way.
+func (a *App) processBatchTimeline(name string, params DetectionParams) (TimelineBatchEntry, error) {
+	started := time.Now()
+	entry := TimelineBatchEntry{Timeline: name, Status: "error"}
+	finish := func(err error) (TimelineBatchEntry, error) {
+		if err != nil {
+			entry.Message = err.Error()
+		}
+		entry.ElapsedMs = time.Since(started).Milliseconds()
+		return entry, err
+	}
+
+	analysis, err := a.syncAndAnalyze(name, params)
+	if err != nil {
+		return finish(err)
+	}
+	if analysis.Project == nil {
+		entry.Message = "the timeline could not be synced"
+		if analysis.Response != nil && analysis.Response.Message != "" {
+			entry.Message = analysis.Response.Message
+		}
+		return finish(nil)
+	}
+	entry.ClipsAnalyzed = len(analysis.Silences)
+	entry.ClipsFailed = len(analysis.Failed)
+
+	project, err := a.CalculateAndStoreEditsForTimeline(*analysis.Project, params.KeepSilenceSegments, analysis.Silences)
+	if err != nil {
+		return finish(err)
+	}
+	response, err := a.MakeFinalTimeline(&project, true)
+	if err != nil {
+		return finish(err)
+	}
+	if response.Status != "success" {
+		entry.Message = response.Message
+		return finish(nil)
+	}
+	entry.Status = "success"
+	entry.Message = response.Message
+	return finish(nil)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const sessionsFolderName = "sessions"
+
+// TimelineSession holds everything needed to pick up editing a timeline where it was left.
+type TimelineSession struct {
+	ProjectName  string          `json:"projectName"`
+	TimelineName string          `json:"timelineName"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+	Params       DetectionParams `json:"params"`
+	// Per-clip parameter overrides, keyed by timeline item ID.
+	ClipOverrides map[string]DetectionParams `json:"clipOverrides,omitempty"`
+	// Manually added or adjusted silence regions, keyed by timeline item ID.
+	SilenceAdjustments map[string][]SilencePeriod `json:"silenceAdjustments,omitempty"`
+	// Timeline item IDs excluded from processing.
+	BypassedClips []string `json:"bypassedClips,omitempty"`
+	// Subtitles keep cuts out of their cues; see ImportSubtitles.
+	Subtitles *SubtitleTrack `json:"subtitles,omitempty"`
+}
+
+// timelineSessionID derives a stable file name from the timeline's identity.
+func timelineSessionID(projectName string, timelineName string) string {
+	sum := sha1.Sum([]byte(projectName + "\x00" + timelineName))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *App) getTimelineSessionPath(projectName string, timelineName string) string {
+	return filepath.Join(a.userResourcesPath, sessionsFolderName, timelineSessionID(projectName, timelineName)+".json")
+}
+
+// GetTimelineSession returns the stored session for a timeline, or nil if there is none.
+func (a *App) GetTimelineSession(projectName string, timelineName string) (*TimelineSession, error) {
+	var session TimelineSession
+	if err := readJSONWithRecovery(a.getTimelineSessionPath(projectName, timelineName), &session); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read timeline session: %w", err)
+	}
+	return &session, nil
+}
+
+// SaveTimelineSession persists the UI's per-timeline state (parameters, per-clip overrides
+// and manual silence adjustments) so it can be restored on the next sync. Subtitles are kept
+// as stored unless session has its own; ImportSubtitles and ClearSubtitles change them.
+func (a *App) SaveTimelineSession(session TimelineSession) error {
+	if session.ProjectName == "" && session.TimelineName == "" {
+		return fmt.Errorf("a timeline session needs a project or timeline name")
+	}
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if session.Subtitles == nil {
+		if stored, err := a.GetTimelineSession(session.ProjectName, session.TimelineName); err == nil && stored != nil {
+			session.Subtitles = stored.Subtitles
+		}
+	}
+	return a.writeTimelineSession(session)
+}
+
+// writeTimelineSession stores session as is. Callers that merge it with the stored session
+// hold sessionMu.
+func (a *App) writeTimelineSession(session TimelineSession) error {
+	session.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal timeline session: %w", err)
+	}
+	sessionPath := a.getTimelineSessionPath(session.ProjectName, session.TimelineName)
+	if err := os.MkdirAll(filepath.Dir(sessionPath), 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	if err := writeFileAtomic(sessionPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write timeline session: %w", err)
+	}
+	return nil
+}
+
+// DeleteTimelineSession discards the stored state of a timeline so the next sync starts fresh.
+func (a *App) DeleteTimelineSession(projectName string, timelineName string) error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	sessionPath := a.getTimelineSessionPath(projectName, timelineName)
+	err := os.Remove(sessionPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete timeline session: %w", err)
+	}
+	os.Remove(sessionPath + backupFileSuffix)
+	return nil
+}
+
+// restoreTimelineSession is called after a sync and hands any stored state back to the UI.
+func (a *App) restoreTimelineSession(projectData *ProjectDataPayload) {
+	session, err := a.GetTimelineSession(projectData.ProjectName, projectData.Timeline.Name)
+	if err != nil {
+		appLog.Warn("Could not restore timeline session", "err", err)
+		return
+	}
+	if session == nil {
+		return
+	}
+
+	// Drop state for clips that no longer exist on the timeline.
+	present := make(map[string]bool, len(projectData.Timeline.AudioTrackItems))
+	for _, item := range projectData.Timeline.AudioTrackItems {
+		present[item.ID] = true
+	}
+	for id := range session.ClipOverrides {
+		if !present[id] {
+			delete(session.ClipOverrides, id)
+		}
+	}
+	for id := range session.SilenceAdjustments {
+		if !present[id] {
+			delete(session.SilenceAdjustments, id)
+		}
+	}
+	bypassed := session.BypassedClips[:0]
+	for _, id := range session.BypassedClips {
+		if present[id] {
+			bypassed = append(bypassed, id)
+		}
+	}
+	session.BypassedClips = bypassed
+
+	a.SetCurrentParams(session.Params)
+	appLog.Info("Restored timeline session", "timeline", session.TimelineName, "saved", session.UpdatedAt.Format(time.RFC3339))
+	a.emit("session:restored", session)
+}
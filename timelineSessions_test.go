@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestTimelineSessionFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(path string) // runs after two saves
+		wantLoud   float64
+		wantStored bool
+	}{
+		{"intact file", func(string) {}, -30, true},
+		// The backup holds the state before the last write.
+		{"corrupt file falls back to the backup", func(path string) { os.WriteFile(path, []byte(`{"projectName":`), 0644) }, -40, true},
+		{"missing file", func(path string) { os.Remove(path) }, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{userResourcesPath: t.TempDir()}
+			for _, loudness := range []float64{-40, -30} {
+				session := TimelineSession{ProjectName: "P", TimelineName: "T", Params: DetectionParams{LoudnessThreshold: loudness}}
+				if err := a.SaveTimelineSession(session); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.setup(a.getTimelineSessionPath("P", "T"))
+
+			session, err := a.GetTimelineSession("P", "T")
+			if err != nil {
+				t.Fatalf("GetTimelineSession() error = %v", err)
+			}
+			if (session != nil) != tt.wantStored {
+				t.Fatalf("session = %+v, want stored %v", session, tt.wantStored)
+			}
+			if session != nil && session.Params.LoudnessThreshold != tt.wantLoud {
+				t.Errorf("loudness = %v, want %v", session.Params.LoudnessThreshold, tt.wantLoud)
+			}
+		})
+	}
+}
+
+func TestSaveTimelineSessionKeepsImportedSubtitles(t *testing.T) {
+	a := &App{userResourcesPath: t.TempDir()}
+	a.currentProject = &ProjectDataPayload{ProjectName: "P", Timeline: Timeline{Name: "T", FPS: 25}}
+	srt := filepath.Join(t.TempDir(), "dialog.srt")
+	if err := os.WriteFile(srt, []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The UI keeps saving its state, without subtitles, while they are imported.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.SaveTimelineSession(TimelineSession{ProjectName: "P", TimelineName: "T"})
+		}()
+	}
+	if _, err := a.ImportSubtitles(srt, ""); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	session, err := a.GetTimelineSession("P", "T")
+	if err != nil || session == nil || session.Subtitles == nil {
+		t.Fatalf("session = %+v (%v), want the imported subtitles", session, err)
+	}
+
+	if err := a.DeleteTimelineSession("P", "T"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(a.getTimelineSessionPath("P", "T") + backupFileSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup still exists after deleting: %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The HTTP server listens on loopback only and in plain HTTP, so nothing it serves leaves the
+// machine. For LAN mode, and for networks whose policy forbids plain HTTP, "tlsEnabled" adds
+// a second listener on every interface that serves the same endpoints over TLS, on "tlsPort"
+// (0 picks a free port). Its certificate is self-signed, generated on first use and kept in
+// the config folder; a client pairs by comparing its SHA-256 fingerprint with the one HushCut
+// shows (GetTLSInfo, the log and the --serve startup line). The loopback server is unchanged.
+// The profiling endpoints are not served over TLS.
+
+const (
+	tlsFolderName      = "tls"
+	tlsCertFileName    = "cert.pem"
+	tlsKeyFileName     = "key.pem"
+	tlsCertValidity    = 2 * 365 * 24 * time.Hour
+	tlsCertRenewBefore = 30 * 24 * time.Hour
+	maxTLSPort         = 65535
+)
+
+// TLSInfo describes the TLS listener. It is returned by GetTLSInfo and sent as "tls:status"
+// whenever the listener starts or stops.
+type TLSInfo struct {
+	Enabled     bool     `json:"enabled"`
+	Port        int      `json:"port"`
+	Fingerprint string   `json:"fingerprint"` // SHA-256 of the certificate, for pairing
+	Addresses   []string `json:"addresses"`
+	Error       string   `json:"error,omitempty"`
+}
+
+type tlsSettings struct {
+	enabled bool
+	port    int
+}
+
+var tlsServer struct {
+	sync.Mutex
+	want    tlsSettings
+	running tlsSettings
+	handler http.Handler // nil until the loopback server is set up
+	server  *http.Server
+	info    TLSInfo
+}
+
+// setTLSSettings records the TLS settings and, once the server runs, applies them.
+func (a *App) setTLSSettings(settings tlsSettings) {
+	tlsServer.Lock()
+	defer tlsServer.Unlock()
+	tlsServer.want = settings
+	if tlsServer.handler != nil && tlsServer.want != tlsServer.running {
+		a.applyTLSLocked()
+	}
+}
+
+// startTLSServer serves handler over TLS as well if the settings ask for it.
+func (a *App) startTLSServer(handler http.Handler) {
+	tlsServer.Lock()
+	defer tlsServer.Unlock()
+	tlsServer.handler = lanHandler(handler)
+	a.applyTLSLocked()
+}
+
+// lanHandler serves the endpoints of handler to the network, except the profiling endpoints
+// (debugServer.go), which stay on loopback.
+func lanHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := path.Clean("/" + r.URL.Path); p == "/debug" || strings.HasPrefix(p, "/debug/") {
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// stopTLSServer closes the TLS listener on shutdown.
+func stopTLSServer() {
+	tlsServer.Lock()
+	defer tlsServer.Unlock()
+	tlsServer.handler = nil
+	if tlsServer.server != nil {
+		tlsServer.server.Close()
+		tlsServer.server = nil
+	}
+}
+
+// applyTLSLocked (re)starts or stops the TLS listener to match tlsServer.want.
+func (a *App) applyTLSLocked() {
+	if tlsServer.server != nil {
+		tlsServer.server.Close()
+		tlsServer.server = nil
+		ipcLog.Info("TLS server stopped", "port", tlsServer.info.Port)
+	}
+	want := tlsServer.want
+	tlsServer.running = want
+	tlsServer.info = TLSInfo{}
+	if !want.enabled {
+		a.emit("tls:status", tlsServer.info)
+		return
+	}
+
+	server, info, err := a.listenTLS(want.port, tlsServer.handler)
+	if err != nil {
+		ipcLog.Error("TLS server could not start", "port", want.port, "err", err)
+		tlsServer.info = TLSInfo{Error: err.Error()}
+		a.emit("tls:status", tlsServer.info)
+		return
+	}
+	tlsServer.server, tlsServer.info = server, info
+	ipcLog.Info("TLS server listening", "port", info.Port, "fingerprint", info.Fingerprint, "addresses", strings.Join(info.Addresses, ", "))
+	a.emit("tls:status", info)
+}
+
+func (a *App) listenTLS(port int, handler http.Handler) (*http.Server, TLSInfo, error) {
+	cert, err := loadOrCreateTLSCert(filepath.Join(a.userResourcesPath, tlsFolderName))
+	if err != nil {
+		return nil, TLSInfo{}, fmt.Errorf("certificate: %w", err)
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, TLSInfo{}, err
+	}
+	port = listener.Addr().(*net.TCPAddr).Port
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+	go saferun(func() {
+		if err := server.Serve(tls.NewListener(listener, server.TLSConfig)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ipcLog.Error("TLS server failed", "port", port, "err", err)
+		}
+	})
+	return server, TLSInfo{
+		Enabled:     true,
+		Port:        port,
+		Fingerprint: certFingerprint(cert.Certificate[0]),
+		Addresses:   lanURLs(port),
+	}, nil
+}
+
+// GetTLSInfo returns the state of the TLS listener and the fingerprint to pair with.
+func (a *App) GetTLSInfo() TLSInfo {
+	tlsServer.Lock()
+	defer tlsServer.Unlock()
+	return tlsServer.info
+}
+
+// loadOrCreateTLSCert loads the certificate kept in dir, or generates a new one if there is
+// none or it is about to expire. A new certificate has a new fingerprint: clients pair again.
+func loadOrCreateTLSCert(dir string) (tls.Certificate, error) {
+	certPath, keyPath := filepath.Join(dir, tlsCertFileName), filepath.Join(dir, tlsKeyFileName)
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && time.Until(leaf.NotAfter) > tlsCertRenewBefore {
+			return cert, nil
+		}
+		ipcLog.Info("TLS certificate expires soon; generating a new one")
+	}
+
+	certPEM, keyPEM, err := generateTLSCert()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFileAtomic(keyPath, keyPEM, 0o600); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFileAtomic(certPath, certPEM, 0o644); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// generateTLSCert creates a self-signed ECDSA certificate for this machine's names and
+// addresses. Clients pin its fingerprint, so it stays valid when the addresses change.
+func generateTLSCert() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "HushCut " + hostname, Organization: []string{"HushCut"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(tlsCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           append([]net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}, lanIPs()...),
+	}
+	if hostname != "" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// certFingerprint formats the SHA-256 of a DER certificate as "AB:CD:...".
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// lanIPs returns the machine's non-loopback unicast addresses.
+func lanIPs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips
+}
+
+// lanURLs lists the addresses the TLS listener can be reached at from the network.
+func lanURLs(port int) []string {
+	urls := []string{}
+	for _, ip := range lanIPs() {
+		urls = append(urls, "https://"+net.JoinHostPort(ip.String(), fmt.Sprint(port)))
+	}
+	return urls
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transcription is optional ("transcriptionEnabled", off by default). GetTranscript runs
+// whisper.cpp over a clip and keeps the words it heard with their timestamps next to the
+// clip's silences: in memory, keyed by the clip's WAV and range like the silence cache, and in
+// <tmp>/transcripts so a clip is transcribed only once. Times are seconds in the WAV, the same
+// as SilencePeriod, so the words line up with the detected silences.
+//
+// whisper.cpp is managed like ffmpeg: a binary set in "whisperPath", HushCut's own copy in the
+// resources folder, or one on the PATH. DownloadWhisper fetches the model ("whisperModel")
+// and, where whisper.cpp publishes a build for the platform, the binary.
+
+const (
+	whisperVersion        = "1.7.6"
+	defaultWhisperModel   = "base"
+	transcriptsFolderName = "transcripts"
+	whisperFolderName     = "whisper"
+	// whisper.cpp expects 16 kHz mono audio.
+	transcriptionSampleRate = 16000
+	maxWhisperDownloadBytes = 64 << 20
+	maxWhisperModelBytes    = 2 << 30 // large-v3-turbo, the largest offered, is about 1.6 GB
+)
+
+var whisperModels = []string{"tiny", "tiny.en", "base", "base.en", "small", "small.en", "medium", "medium.en", "large-v3-turbo"}
+
+var whisperArchiveLimits = archiveLimits{maxEntries: 64, maxTotalBytes: 256 << 20, maxEntryBytes: 128 << 20}
+
+var (
+	errTranscriptionDisabled = errors.New("transcription is turned off in the settings")
+	errWhisperMissing        = errors.New("whisper.cpp is not installed")
+)
+
+// TranscriptWord is one word with the time it is spoken.
+type TranscriptWord struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Transcript is what GetTranscript returns, also sent as "transcript:ready".
+type Transcript struct {
+	ClipID           string           `json:"clipId"`
+	FileName         string           `json:"fileName"`
+	ClipStartSeconds float64          `json:"clipStartSeconds"`
+	ClipEndSeconds   float64          `json:"clipEndSeconds"`
+	Model            string           `json:"model"`
+	Language         string           `json:"language,omitempty"` // as detected by whisper.cpp
+	CreatedAt        time.Time        `json:"createdAt"`
+	Words            []TranscriptWord `json:"words"`
+}
+
+// WhisperInfo describes the whisper.cpp installation transcription would use.
+type WhisperInfo struct {
+	Enabled bool         `json:"enabled"`
+	Status  FfmpegStatus `json:"status"` // ready once both the binary and the model are there
+	Path    string       `json:"path,omitempty"`
+	// Managed is true for the copy HushCut downloaded itself.
+	Managed        bool   `json:"managed"`
+	Model          string `json:"model"`
+	ModelPath      string `json:"modelPath"`
+	ModelInstalled bool   `json:"modelInstalled"`
+	// Downloadable tells whether DownloadWhisper can install the binary on this platform.
+	Downloadable bool `json:"downloadable"`
+}
+
+// transcriptRun is a transcription in progress; callers asking for the same clip wait for it.
+type transcriptRun struct {
+	done       chan struct{}
+	transcript *Transcript
+	err        error
+}
+
+// transcripts holds the transcripts of this session by transcriptName.
+var transcripts = struct {
+	sync.Mutex
+	byName  map[string]*Transcript
+	running map[string]*transcriptRun
+}{byName: map[string]*Transcript{}, running: map[string]*transcriptRun{}}
+
+func transcriptName(fileName string, startSeconds, endSeconds float64) string {
+	return fmt.Sprintf("%s_%.3f_%.3f.json", fileName, startSeconds, endSeconds)
+}
+
+func (a *App) getTranscriptsDir() string {
+	return filepath.Join(a.tmpPath, transcriptsFolderName)
+}
+
+func (a *App) getWhisperDir() string {
+	return filepath.Join(a.userResourcesPath, whisperFolderName)
+}
+
+func whisperBinaryName() string {
+	if goruntime.GOOS == "windows" {
+		return "whisper-cli.exe"
+	}
+	return "whisper-cli"
+}
+
+// whisperRunnable reports whether path is a whisper.cpp command line tool. It has no
+// -version flag; -h exits cleanly.
+func whisperRunnable(path string) bool {
+	if path == "" {
+		return false
+	}
+	cmd := ExecCommand(path, "-h")
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	return cmd.Run() == nil
+}
+
+// whisperBinary finds the whisper.cpp binary: the one from the settings, HushCut's own copy,
+// then the PATH (Homebrew and most packages install whisper-cli, some whisper-cpp).
+func (a *App) whisperBinary(settings map[string]any) (path string, managed bool) {
+	if custom := settingString(settings, "whisperPath", ""); custom != "" {
+		if whisperRunnable(custom) {
+			return custom, false
+		}
+		appLog.Warn("whisperPath setting is not a usable whisper.cpp binary; ignoring it", "path", custom)
+	}
+	own := filepath.Join(a.getWhisperDir(), whisperBinaryName())
+	if whisperRunnable(own) {
+		return own, true
+	}
+	for _, name := range []string{"whisper-cli", "whisper-cpp"} {
+		if found, err := exec.LookPath(name); err == nil && whisperRunnable(found) {
+			return found, false
+		}
+	}
+	return "", false
+}
+
+// whisperModel returns the configured model and where it is stored.
+func (a *App) whisperModel(settings map[string]any) (model string, path string) {
+	model = settingString(settings, "whisperModel", defaultWhisperModel)
+	if !isOneOf(model, whisperModels) {
+		model = defaultWhisperModel
+	}
+	return model, filepath.Join(a.getWhisperDir(), "ggml-"+model+".bin")
+}
+
+// whisperReleaseAsset is the whisper.cpp release archive for this platform, if it has one.
+// whisper.cpp publishes command line builds for Windows only.
+func whisperReleaseAsset() (string, bool) {
+	if goruntime.GOOS == "windows" && goruntime.GOARCH == "amd64" {
+		return fmt.Sprintf("https://github.com/ggml-org/whisper.cpp/releases/download/v%s/whisper-bin-x64.zip", whisperVersion), true
+	}
+	return "", false
+}
+
+// GetWhisperInfo reports whether transcription is turned on and can run.
+func (a *App) GetWhisperInfo() WhisperInfo {
+	settings, err := a.GetSettings()
+	if err != nil {
+		settings = map[string]any{}
+	}
+	info := WhisperInfo{Enabled: settingBool(settings, "transcriptionEnabled", false), Status: StatusMissing}
+	info.Path, info.Managed = a.whisperBinary(settings)
+	info.Model, info.ModelPath = a.whisperModel(settings)
+	if stat, err := os.Stat(info.ModelPath); err == nil && stat.Size() > 0 {
+		info.ModelInstalled = true
+	}
+	_, info.Downloadable = whisperReleaseAsset()
+	if info.Path != "" && info.ModelInstalled {
+		info.Status = StatusReady
+	}
+	return info
+}
+
+// DownloadWhisper installs the configured model and, if none is found and whisper.cpp has a
+// build for this platform, the binary. Elsewhere whisper.cpp has to be installed separately;
+// the model is downloaded regardless.
+func (a *App) DownloadWhisper() (WhisperInfo, error) {
+	if err := a.requireOnline("whisperDownload"); err != nil {
+		return a.GetWhisperInfo(), fmt.Errorf("cannot download whisper.cpp while offline: %w", err)
+	}
+	settings, err := a.GetSettings()
+	if err != nil {
+		settings = map[string]any{}
+	}
+	if err := os.MkdirAll(a.getWhisperDir(), 0755); err != nil {
+		return a.GetWhisperInfo(), fmt.Errorf("could not create %s: %w", a.getWhisperDir(), err)
+	}
+
+	model, modelPath := a.whisperModel(settings)
+	if _, err := os.Stat(modelPath); err != nil {
+		url := fmt.Sprintf("https://huggingface.co/ggerganov/whisper.cpp/resolve/main/ggml-%s.bin", model)
+		appLog.Info("Downloading whisper model", "model", model, "url", url)
+		if err := a.downloadWithProgress(url, modelPath, maxWhisperModelBytes, "the whisper model"); err != nil {
+			return a.GetWhisperInfo(), err
+		}
+	}
+
+	var missingBinary error
+	if path, _ := a.whisperBinary(settings); path == "" {
+		if url, ok := whisperReleaseAsset(); ok {
+			if err := a.installWhisperBinary(url); err != nil {
+				return a.GetWhisperInfo(), err
+			}
+		} else {
+			missingBinary = fmt.Errorf("%w: whisper.cpp has no build for %s/%s; install whisper-cli and set its path in the settings", errWhisperMissing, goruntime.GOOS, goruntime.GOARCH)
+		}
+	}
+
+	info := a.GetWhisperInfo()
+	a.emit("whisper:installed", info)
+	return info, missingBinary
+}
+
+// installWhisperBinary downloads a whisper.cpp release archive and copies whisper-cli with
+// the libraries next to it into the whisper folder.
+func (a *App) installWhisperBinary(url string) error {
+	tempDir, err := os.MkdirTemp("", "whisper-download-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	archive := filepath.Join(tempDir, "whisper.zip")
+	appLog.Info("Downloading whisper.cpp", "version", whisperVersion, "url", url)
+	if err := a.downloadWithProgress(url, archive, maxWhisperDownloadBytes, "the whisper.cpp download"); err != nil {
+		return err
+	}
+	extracted := filepath.Join(tempDir, "extracted")
+	if err := unzip(archive, extracted, whisperArchiveLimits); err != nil {
+		return fmt.Errorf("could not extract the whisper.cpp download: %w", err)
+	}
+
+	var binary string
+	filepath.WalkDir(extracted, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && d.Name() == whisperBinaryName() {
+			binary = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if binary == "" {
+		return fmt.Errorf("could not find '%s' in the extracted archive", whisperBinaryName())
+	}
+	entries, err := os.ReadDir(filepath.Dir(binary))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := moveFile(filepath.Join(filepath.Dir(binary), entry.Name()), filepath.Join(a.getWhisperDir(), entry.Name())); err != nil {
+			return fmt.Errorf("failed to install %s: %w", entry.Name(), err)
+		}
+	}
+	return os.Chmod(filepath.Join(a.getWhisperDir(), whisperBinaryName()), 0755)
+}
+
+// downloadWithProgress downloads url to dest within limit bytes, reporting progress like
+// DownloadFFmpeg. dest only appears once the download is complete.
+func (a *App) downloadWithProgress(url, dest string, limit int64, what string) error {
+	partial := dest + ".part"
+	fail := func(err error) error {
+		os.Remove(partial)
+		a.emit("download:error", progressFailed(dest, "download", "progress.downloadFailed", err))
+		return err
+	}
+
+	resp, err := a.externalHTTPClient(0).Get(url)
+	if err != nil {
+		return fail(fmt.Errorf("could not download %s: %w", what, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fail(fmt.Errorf("downloading %s failed: %s", what, resp.Status))
+	}
+	if err := checkDownloadSize(resp, limit, what); err != nil {
+		return fail(err)
+	}
+
+	tracker := &ProgressTracker{Done: make(chan error, 1), TaskType: "download", StartedAt: time.Now()}
+	a.progressTracker.Store(dest, tracker)
+	defer a.progressTracker.Delete(dest)
+
+	out, err := os.Create(partial)
+	if err != nil {
+		return fail(fmt.Errorf("could not create download file: %w", err))
+	}
+	pw := &downloadProgressWriter{tracker: tracker, totalBytes: resp.ContentLength, filePath: dest, app: a}
+	_, err = io.Copy(io.MultiWriter(out, pw), capReader(resp.Body, limit, what))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partial, dest)
+	}
+	tracker.Done <- err
+	if err != nil {
+		return fail(fmt.Errorf("could not download %s: %w", what, err))
+	}
+	a.emit("progress:done", ProgressStatus{FilePath: dest, Percentage: 100, TaskType: "download"})
+	return nil
+}
+
+// GetTranscript returns the words spoken in a clip of the synced project, transcribing it
+// first unless that was done before. It fails if transcription is turned off or whisper.cpp
+// or its model is missing (see DownloadWhisper).
+func (a *App) GetTranscript(clipID string) (*Transcript, error) {
+	item, fps, ok := a.findClipByID(clipID)
+	if !ok {
+		return nil, fmt.Errorf("unknown clip %q", clipID)
+	}
+	if item.ProcessedFileName == nil || *item.ProcessedFileName == "" || fps <= floatEpsilon {
+		return nil, fmt.Errorf("clip %q has no processed audio", clipID)
+	}
+	fileName := filepath.Base(*item.ProcessedFileName)
+	startSeconds := item.SourceStartFrame / fps
+	endSeconds := item.SourceEndFrame / fps
+	name := transcriptName(fileName, startSeconds, endSeconds)
+
+	if stored := a.storedTranscript(name); stored != nil {
+		return withClipID(stored, clipID), nil
+	}
+
+	settings, err := a.GetSettings()
+	if err != nil {
+		settings = map[string]any{}
+	}
+	if !settingBool(settings, "transcriptionEnabled", false) {
+		return nil, errTranscriptionDisabled
+	}
+
+	transcripts.Lock()
+	run, running := transcripts.running[name]
+	if !running {
+		run = &transcriptRun{done: make(chan struct{})}
+		transcripts.running[name] = run
+	}
+	transcripts.Unlock()
+
+	if !running {
+		run.transcript, run.err = a.transcribe(settings, fileName, startSeconds, endSeconds)
+		transcripts.Lock()
+		delete(transcripts.running, name)
+		if run.err == nil {
+			transcripts.byName[name] = run.transcript
+		}
+		transcripts.Unlock()
+		close(run.done)
+		if run.err == nil {
+			a.saveTranscript(name, run.transcript)
+			a.emit("transcript:ready", withClipID(run.transcript, clipID))
+		}
+	} else {
+		<-run.done
+	}
+	if run.err != nil {
+		return nil, run.err
+	}
+	return withClipID(run.transcript, clipID), nil
+}
+
+// withClipID returns a copy of t for clipID; clips sharing a WAV and range share a transcript.
+func withClipID(t *Transcript, clipID string) *Transcript {
+	copied := *t
+	copied.ClipID = clipID
+	return &copied
+}
+
+// storedTranscript returns the transcript of this session or the one saved earlier.
+func (a *App) storedTranscript(name string) *Transcript {
+	transcripts.Lock()
+	defer transcripts.Unlock()
+	if t, ok := transcripts.byName[name]; ok {
+		return t
+	}
+	var t Transcript
+	if err := readJSONFile(filepath.Join(a.getTranscriptsDir(), name), &t); err != nil {
+		if !os.IsNotExist(err) {
+			appLog.Warn("Transcript is unreadable; transcribing again", "file", name, "err", err)
+		}
+		return nil
+	}
+	transcripts.byName[name] = &t
+	return &t
+}
+
+func (a *App) saveTranscript(name string, t *Transcript) {
+	if err := os.MkdirAll(a.getTranscriptsDir(), 0755); err != nil {
+		appLog.Warn("Could not create transcripts folder", "dir", a.getTranscriptsDir(), "err", err)
+		return
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(a.getTranscriptsDir(), name), data, 0644)
+	}
+	if err != nil {
+		appLog.Warn("Could not save transcript", "file", name, "err", err)
+	}
+}
+
+// dropTranscripts forgets and deletes the transcripts of the given WAVs (by base name), or of
+// all of them if fileNames is nil, and returns how many there were.
+func (a *App) dropTranscripts(fileNames map[string]bool) int {
+	matches := func(name string) bool {
+		if fileNames == nil {
+			return true
+		}
+		for fileName := range fileNames {
+			if strings.HasPrefix(name, fileName+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	transcripts.Lock()
+	defer transcripts.Unlock()
+	dropped := map[string]bool{}
+	for name := range transcripts.byName {
+		if matches(name) {
+			delete(transcripts.byName, name)
+			dropped[name] = true
+		}
+	}
+	entries, _ := os.ReadDir(a.getTranscriptsDir())
+	for _, entry := range entries {
+		if entry.IsDir() || !matches(entry.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(a.getTranscriptsDir(), entry.Name())); err != nil && !os.IsNotExist(err) {
+			appLog.Warn("Could not delete transcript", "file", entry.Name(), "err", err)
+			continue
+		}
+		dropped[entry.Name()] = true
+	}
+	return len(dropped)
+}
+
+// whisperOutput is the part of whisper.cpp's JSON output (-oj) transcription reads. With
+// -ml 1 every segment is a single word.
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			From int64 `json:"from"` // milliseconds
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+// transcribe cuts a clip's range out of its WAV at the rate whisper.cpp expects and runs
+// whisper.cpp over it. Both run as jobs CancelAllJobs can stop.
+func (a *App) transcribe(settings map[string]any, fileName string, startSeconds, endSeconds float64) (*Transcript, error) {
+	binary, _ := a.whisperBinary(settings)
+	if binary == "" {
+		return nil, errWhisperMissing
+	}
+	model, modelPath := a.whisperModel(settings)
+	if _, err := os.Stat(modelPath); err != nil {
+		return nil, fmt.Errorf("the whisper model %q is not downloaded", model)
+	}
+	if err := a.waitForFfmpeg(); err != nil {
+		return nil, err
+	}
+
+	wavPath := filepath.Join(a.tmpPath, fileName)
+	release := a.acquireFileRefs(wavPath)
+	defer release()
+
+	tempDir, err := os.MkdirTemp("", "hushcut-transcribe-*")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	clipPath := filepath.Join(tempDir, "clip.wav")
+	cmd := a.ffmpegCommand("-y",
+		"-ss", fmt.Sprintf("%.6f", startSeconds), "-to", fmt.Sprintf("%.6f", endSeconds),
+		"-i", wavPath, "-ac", "1", "-ar", fmt.Sprint(transcriptionSampleRate), "-c:a", "pcm_s16le",
+		"-hide_banner", "-loglevel", "error", clipPath)
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+	started := time.Now()
+	err = runJob(jobConversion, cmd)
+	auditFFmpeg("transcription", cmd, started, err, stderr.String())
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed to prepare %s for transcription: %w: %s", fileName, err, strings.TrimSpace(stderr.String()))
+	}
+
+	language := settingString(settings, "transcriptionLanguage", "auto")
+	outputBase := filepath.Join(tempDir, "transcript")
+	whisper := ExecCommand(binary,
+		"-m", modelPath, "-f", clipPath, "-l", language,
+		"-ml", "1", "-sow", "-oj", "-of", outputBase, "-np")
+	whisper.Dir = tempDir
+	whisperStderr := &stderrTail{}
+	whisper.Stderr = whisperStderr
+	appLog.Info("Transcribing with whisper.cpp", "file", fileName, "start", startSeconds, "end", endSeconds, "model", model)
+	started = time.Now()
+	if err := runJob(jobTranscription, whisper); err != nil {
+		return nil, fmt.Errorf("whisper.cpp failed to transcribe %s: %w: %s", fileName, err, strings.TrimSpace(whisperStderr.String()))
+	}
+	appLog.Info("Transcribed", "file", fileName, "took", time.Since(started).Round(time.Millisecond))
+
+	var output whisperOutput
+	if err := readJSONFile(outputBase+".json", &output); err != nil {
+		return nil, fmt.Errorf("could not read the whisper.cpp output: %w", err)
+	}
+	return &Transcript{
+		FileName:         fileName,
+		ClipStartSeconds: startSeconds,
+		ClipEndSeconds:   endSeconds,
+		Model:            model,
+		Language:         output.Result.Language,
+		CreatedAt:        time.Now(),
+		Words:            transcriptWords(output, startSeconds, endSeconds),
+	}, nil
+}
+
+// transcriptWords turns whisper.cpp's segments into words timed in the WAV. Markers such as
+// [BLANK_AUDIO] are not words and are left out.
+func transcriptWords(output whisperOutput, startSeconds, endSeconds float64) []TranscriptWord {
+	words := []TranscriptWord{}
+	for _, segment := range output.Transcription {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" || (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) {
+			continue
+		}
+		start := startSeconds + float64(segment.Offsets.From)/1000
+		end := min(startSeconds+float64(segment.Offsets.To)/1000, endSeconds)
+		if end < start {
+			end = start
+		}
+		words = append(words, TranscriptWord{Text: text, Start: start, End: end})
+	}
+	return words
+}
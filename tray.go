@@ -0,0 +1,45 @@
+package main
+
+// trayItem is an entry of the tray icon's menu; label is read again on every refresh.
+type trayItem struct {
+	label     func() string
+	separator bool
+	onClick   func()
+}
+
+// startTray shows the tray icon with HushCut's quick actions. Where there is no tray (desktops
+// without a StatusNotifierItem host, and for now Windows and macOS) it only logs, and closing
+// the window quits as before: without the icon a hidden window could not be quit.
+func (a *App) startTray(icon []byte) {
+	items := []trayItem{
+		{label: func() string { return "Sync with Resolve" }, onClick: a.traySync},
+		{label: func() string { return "Open window" }, onClick: func() { showWindow(a.ctx) }},
+		{label: a.trayPauseLabel, onClick: func() { a.PauseBackgroundJobs(!a.GetBackgroundJobsPaused()) }},
+		{label: func() string { return "Cancel all jobs" }, onClick: func() { a.CancelAllJobs() }},
+		{separator: true},
+		{label: func() string { return "Quit HushCut" }, onClick: a.quit},
+	}
+	t, err := newTrayIcon("HushCut", icon, items, func() { showWindow(a.ctx) })
+	if err != nil {
+		appLog.Info("No tray icon", "err", err)
+		return
+	}
+	a.tray = t
+	go saferun(func() {
+		<-a.ctx.Done()
+		t.close()
+	})
+}
+
+// traySync brings the window up and has the frontend sync, as its Sync button would.
+func (a *App) traySync() {
+	showWindow(a.ctx)
+	a.emit("tray:sync")
+}
+
+func (a *App) trayPauseLabel() string {
+	if a.GetBackgroundJobsPaused() {
+		return "Resume background jobs"
+	}
+	return "Pause background jobs"
+}
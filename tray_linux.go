@@ -0,0 +1,317 @@
+//go:build linux && !headless
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"os"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// On Linux the tray icon is a StatusNotifierItem (KDE, most other desktops, GNOME with the
+// AppIndicator extension) with a com.canonical.dbusmenu menu, both served over the session bus.
+
+const (
+	sniInterface    = "org.kde.StatusNotifierItem"
+	sniPath         = dbus.ObjectPath("/StatusNotifierItem")
+	sniWatcherName  = "org.kde.StatusNotifierWatcher"
+	sniWatcherPath  = dbus.ObjectPath("/StatusNotifierWatcher")
+	dbusMenuIface   = "com.canonical.dbusmenu"
+	dbusMenuPath    = dbus.ObjectPath("/MenuBar")
+	trayIconSize    = 64
+	dbusMenuRootID  = 0
+	dbusMenuVersion = 3
+	dbusMenuClicked = "clicked"
+)
+
+type trayIcon struct {
+	conn       *dbus.Conn
+	items      []trayItem
+	onActivate func()
+
+	mu       sync.Mutex
+	revision uint32
+}
+
+// sniPixmap is one size of the icon: ARGB32 in network byte order.
+type sniPixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+type sniToolTip struct {
+	IconName    string
+	Icon        []sniPixmap
+	Title       string
+	Description string
+}
+
+// dbusMenuLayout is a menu item and its children, each a variant holding a dbusMenuLayout.
+type dbusMenuLayout struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+	Children   []dbus.Variant
+}
+
+type dbusMenuItemProperties struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+}
+
+type dbusMenuEvent struct {
+	ID        int32
+	EventID   string
+	Data      dbus.Variant
+	Timestamp uint32
+}
+
+func newTrayIcon(title string, icon []byte, items []trayItem, onActivate func()) (*trayIcon, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("no session bus: %w", err)
+	}
+	t := &trayIcon{conn: conn, items: items, onActivate: onActivate, revision: 1}
+	if err := t.export(title, icon); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	name := fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid())
+	if reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("could not own %s: %v", name, err)
+	}
+	watcher := conn.Object(sniWatcherName, sniWatcherPath)
+	if call := watcher.Call(sniWatcherName+".RegisterStatusNotifierItem", 0, name); call.Err != nil {
+		conn.Close()
+		var dbusErr dbus.Error
+		if errors.As(call.Err, &dbusErr) && dbusErr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" {
+			return nil, errors.New("the desktop has no StatusNotifierItem host")
+		}
+		return nil, fmt.Errorf("could not register the tray icon: %w", call.Err)
+	}
+	return t, nil
+}
+
+func (t *trayIcon) export(title string, icon []byte) error {
+	pixmaps := []sniPixmap{}
+	if p, err := sniPixmapFromPNG(icon, trayIconSize); err == nil {
+		pixmaps = append(pixmaps, p)
+	}
+	constant := func(v interface{}) *prop.Prop {
+		return &prop.Prop{Value: v, Emit: prop.EmitConst}
+	}
+	props, err := prop.Export(t.conn, sniPath, prop.Map{
+		sniInterface: {
+			"Category":   constant("ApplicationStatus"),
+			"Id":         constant("hushcut"),
+			"Title":      constant(title),
+			"Status":     constant("Active"),
+			"WindowId":   constant(int32(0)),
+			"IconName":   constant(""),
+			"IconPixmap": constant(pixmaps),
+			"ToolTip":    constant(sniToolTip{Icon: []sniPixmap{}, Title: title}),
+			"ItemIsMenu": constant(false),
+			"Menu":       constant(dbusMenuPath),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	item := &sniItem{t}
+	if err := t.conn.Export(item, sniPath, sniInterface); err != nil {
+		return err
+	}
+	err = t.conn.Export(introspect.NewIntrospectable(&introspect.Node{
+		Name: string(sniPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: sniInterface, Methods: introspect.Methods(item), Properties: props.Introspection(sniInterface)},
+		},
+	}), sniPath, "org.freedesktop.DBus.Introspectable")
+	if err != nil {
+		return err
+	}
+
+	menuProps, err := prop.Export(t.conn, dbusMenuPath, prop.Map{
+		dbusMenuIface: {
+			"Version":       constant(uint32(dbusMenuVersion)),
+			"TextDirection": constant("ltr"),
+			"Status":        constant("normal"),
+			"IconThemePath": constant([]string{}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	menu := &dbusMenu{t}
+	if err := t.conn.Export(menu, dbusMenuPath, dbusMenuIface); err != nil {
+		return err
+	}
+	return t.conn.Export(introspect.NewIntrospectable(&introspect.Node{
+		Name: string(dbusMenuPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: dbusMenuIface, Methods: introspect.Methods(menu), Properties: menuProps.Introspection(dbusMenuIface)},
+		},
+	}), dbusMenuPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// refresh tells the host to fetch the menu again, e.g. after "Pause" became "Resume".
+func (t *trayIcon) refresh() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.revision++
+	revision := t.revision
+	t.mu.Unlock()
+	t.conn.Emit(dbusMenuPath, dbusMenuIface+".LayoutUpdated", revision, int32(dbusMenuRootID))
+}
+
+func (t *trayIcon) close() {
+	if t == nil {
+		return
+	}
+	t.conn.Close()
+}
+
+// layout builds the menu: the root (id 0) with one child per item, ids counting from 1.
+func (t *trayIcon) layout() dbusMenuLayout {
+	root := dbusMenuLayout{
+		ID:         dbusMenuRootID,
+		Properties: map[string]dbus.Variant{"children-display": dbus.MakeVariant("submenu")},
+		Children:   []dbus.Variant{},
+	}
+	for i := range t.items {
+		root.Children = append(root.Children, dbus.MakeVariant(dbusMenuLayout{
+			ID:         int32(i + 1),
+			Properties: t.itemProperties(i),
+			Children:   []dbus.Variant{},
+		}))
+	}
+	return root
+}
+
+func (t *trayIcon) itemProperties(i int) map[string]dbus.Variant {
+	if t.items[i].separator {
+		return map[string]dbus.Variant{"type": dbus.MakeVariant("separator")}
+	}
+	return map[string]dbus.Variant{
+		"label":   dbus.MakeVariant(t.items[i].label()),
+		"enabled": dbus.MakeVariant(true),
+		"visible": dbus.MakeVariant(true),
+	}
+}
+
+func (t *trayIcon) click(id int32) {
+	i := int(id) - 1
+	if i < 0 || i >= len(t.items) || t.items[i].onClick == nil {
+		return
+	}
+	go saferun(t.items[i].onClick)
+}
+
+// sniItem implements org.kde.StatusNotifierItem.
+type sniItem struct{ t *trayIcon }
+
+func (s *sniItem) Activate(x, y int32) *dbus.Error {
+	if s.t.onActivate != nil {
+		go saferun(s.t.onActivate)
+	}
+	return nil
+}
+
+func (s *sniItem) SecondaryActivate(x, y int32) *dbus.Error { return s.Activate(x, y) }
+
+func (s *sniItem) ContextMenu(x, y int32) *dbus.Error { return nil }
+
+func (s *sniItem) Scroll(delta int32, orientation string) *dbus.Error { return nil }
+
+// dbusMenu implements com.canonical.dbusmenu for the tray icon's flat menu.
+type dbusMenu struct{ t *trayIcon }
+
+func (m *dbusMenu) GetLayout(parentID int32, recursionDepth int32, propertyNames []string) (uint32, dbusMenuLayout, *dbus.Error) {
+	m.t.mu.Lock()
+	revision := m.t.revision
+	m.t.mu.Unlock()
+	layout := m.t.layout()
+	if parentID != dbusMenuRootID {
+		for _, child := range layout.Children {
+			if c := child.Value().(dbusMenuLayout); c.ID == parentID {
+				return revision, c, nil
+			}
+		}
+	}
+	return revision, layout, nil
+}
+
+func (m *dbusMenu) GetGroupProperties(ids []int32, propertyNames []string) ([]dbusMenuItemProperties, *dbus.Error) {
+	result := []dbusMenuItemProperties{}
+	for _, id := range ids {
+		if i := int(id) - 1; i >= 0 && i < len(m.t.items) {
+			result = append(result, dbusMenuItemProperties{ID: id, Properties: m.t.itemProperties(i)})
+		}
+	}
+	return result, nil
+}
+
+func (m *dbusMenu) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	if i := int(id) - 1; i >= 0 && i < len(m.t.items) {
+		if v, ok := m.t.itemProperties(i)[name]; ok {
+			return v, nil
+		}
+	}
+	return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("no property %s on item %d", name, id))
+}
+
+func (m *dbusMenu) Event(id int32, eventID string, data dbus.Variant, timestamp uint32) *dbus.Error {
+	if eventID == dbusMenuClicked {
+		m.t.click(id)
+	}
+	return nil
+}
+
+func (m *dbusMenu) EventGroup(events []dbusMenuEvent) ([]int32, *dbus.Error) {
+	for _, e := range events {
+		m.Event(e.ID, e.EventID, e.Data, e.Timestamp)
+	}
+	return []int32{}, nil
+}
+
+func (m *dbusMenu) AboutToShow(id int32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (m *dbusMenu) AboutToShowGroup(ids []int32) ([]int32, []int32, *dbus.Error) {
+	return []int32{}, []int32{}, nil
+}
+
+// sniPixmapFromPNG scales a PNG to size×size and converts it to the StatusNotifierItem format.
+func sniPixmapFromPNG(data []byte, size int) (sniPixmap, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return sniPixmap{}, err
+	}
+	b := img.Bounds()
+	pix := make([]byte, 0, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x*b.Dx()/size, b.Min.Y+y*b.Dy()/size)).(color.NRGBA)
+			pix = append(pix, c.A, c.R, c.G, c.B)
+		}
+	}
+	return sniPixmap{Width: int32(size), Height: int32(size), Data: pix}, nil
+}
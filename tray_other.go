@@ -0,0 +1,16 @@
+//go:build !linux || headless
+
+package main
+
+import "errors"
+
+// trayIcon is not implemented on this platform yet.
+type trayIcon struct{}
+
+func newTrayIcon(title string, icon []byte, items []trayItem, onActivate func()) (*trayIcon, error) {
+	return nil, errors.New("tray icons are not supported on this platform")
+}
+
+func (t *trayIcon) refresh() {}
+
+func (t *trayIcon) close() {}
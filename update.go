@@ -2,12 +2,11 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type AlertContent struct {
@@ -42,16 +41,40 @@ type UpdateResponseV1 struct {
 	AlertSeverity string       `json:"alert_severity"`
 	GithubData    GithubData   `json:"github_data"`
 	Signature     string       `json:"signature"`
+	Channel       string       `json:"channel"`
+}
+
+const (
+	updateChannelStable = "stable"
+	updateChannelBeta   = "beta"
+)
+
+// updateChannel returns the "updateChannel" setting; anything unknown means stable.
+func (a *App) updateChannel() string {
+	settings, err := a.GetSettings()
+	if err != nil {
+		return updateChannelStable
+	}
+	if settingString(settings, "updateChannel", updateChannelStable) == updateChannelBeta {
+		return updateChannelBeta
+	}
+	return updateChannelStable
 }
 
 func (a *App) checkForUpdate(currentVersion string) {
 	schemaVersion := "1"
-	updateURL := "https://api.hushcut.app/update?v=" + url.QueryEscape(currentVersion) + "&schemaVersion=" + schemaVersion
+	channel := a.updateChannel()
+	query := "v=" + url.QueryEscape(currentVersion) + "&schemaVersion=" + schemaVersion + "&channel=" + channel
+	updateURL := "https://api.hushcut.app/update?" + query
 	if a.testApi {
-		updateURL = "http://localhost:8080/update?v=" + url.QueryEscape(currentVersion) + "&schemaVersion=" + schemaVersion
+		updateURL = "http://localhost:8080/update?" + query
+	}
+
+	if err := a.requireOnline("updateCheck"); err != nil {
+		return
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := a.externalHTTPClient(10 * time.Second)
 
 	var resp *http.Response
 	var err error
@@ -62,39 +85,119 @@ func (a *App) checkForUpdate(currentVersion string) {
 		if err == nil {
 			break
 		}
-		log.Printf("Update check attempt %d failed: %v", attempt, err)
-		time.Sleep(time.Duration(attempt) * time.Second) // simple backoff
+		updateLog.Warn("Update check failed", "attempt", attempt, "err", err)
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt) * time.Second): // simple backoff
+		}
 	}
 
 	if err != nil {
-		log.Printf("Update check ultimately failed: %v", err)
+		updateLog.Warn("Update check ultimately failed", "err", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNoContent {
-		log.Println("App is up to date")
+		updateLog.Info("App is up to date")
 		return
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Unexpected update response: %d", resp.StatusCode)
+		updateLog.Warn("Unexpected update response", "status", resp.StatusCode)
 		return
 	}
 
 	var updateResp UpdateResponseV1
 	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
-		log.Printf("Error decoding update response: %v", err)
+		updateLog.Warn("Error decoding update response", "err", err)
 		return
 	}
 
+	if updateResp.Channel == "" {
+		updateResp.Channel = channel
+	}
 	a.updateInfo = &updateResp
-	log.Printf("Update available: %+v", updateResp)
-	runtime.EventsEmit(a.ctx, "updateAvailable", updateResp)
+	updateLog.Info("Update available", "version", updateResp.LatestVersion, "channel", updateResp.Channel)
+	if a.updateDismissed(updateResp.LatestVersion) {
+		updateLog.Info("Update was dismissed by the user; not prompting", "version", updateResp.LatestVersion)
+		return
+	}
+	a.emit("updateAvailable", updateResp)
 }
 
 func (a *App) GetUpdateInfo() *UpdateResponseV1 {
-	runtime.EventsEmit(a.ctx, "updateAvailable", a.updateInfo)
+	if a.updateInfo != nil && a.updateDismissed(a.updateInfo.LatestVersion) {
+		return nil
+	}
+	a.emit("updateAvailable", a.updateInfo)
 	return a.updateInfo
 
 }
+
+const (
+	updateDismissalSettingsKey = "updateDismissal"
+	DismissSkip                = "skip"  // never prompt for this version again
+	DismissLater               = "later" // prompt again after the snooze period
+	updateSnoozeDuration       = 3 * 24 * time.Hour
+)
+
+// UpdateDismissal is stored in settings under "updateDismissal".
+type UpdateDismissal struct {
+	SkippedVersions []string   `json:"skippedVersions,omitempty"`
+	SnoozedVersion  string     `json:"snoozedVersion,omitempty"`
+	SnoozedUntil    *time.Time `json:"snoozedUntil,omitempty"`
+}
+
+func (a *App) loadUpdateDismissal() UpdateDismissal {
+	var dismissal UpdateDismissal
+	settings, err := a.GetSettings()
+	if err != nil {
+		return dismissal
+	}
+	raw, ok := settings[updateDismissalSettingsKey]
+	if !ok {
+		return dismissal
+	}
+	data, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &dismissal)
+	}
+	if err != nil {
+		updateLog.Warn("Ignoring invalid update dismissal in settings", "err", err)
+		return UpdateDismissal{}
+	}
+	return dismissal
+}
+
+// updateDismissed reports whether the user skipped this version or snoozed it recently.
+func (a *App) updateDismissed(version string) bool {
+	dismissal := a.loadUpdateDismissal()
+	if slices.Contains(dismissal.SkippedVersions, version) {
+		return true
+	}
+	return dismissal.SnoozedVersion == version && dismissal.SnoozedUntil != nil && time.Now().Before(*dismissal.SnoozedUntil)
+}
+
+// DismissUpdate records the user's answer to the update prompt: "skip" ignores this
+// version for good, "later" hides the prompt for a few days.
+func (a *App) DismissUpdate(version string, mode string) error {
+	if version == "" {
+		return fmt.Errorf("no version given")
+	}
+	dismissal := a.loadUpdateDismissal()
+	switch mode {
+	case DismissSkip:
+		if !slices.Contains(dismissal.SkippedVersions, version) {
+			dismissal.SkippedVersions = append(dismissal.SkippedVersions, version)
+		}
+	case DismissLater:
+		until := time.Now().Add(updateSnoozeDuration)
+		dismissal.SnoozedVersion = version
+		dismissal.SnoozedUntil = &until
+	default:
+		return fmt.Errorf("unknown dismiss mode '%s' (expected \"skip\" or \"later\")", mode)
+	}
+	return a.updateSetting(updateDismissalSettingsKey, dismissal)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oliwoli/hushcut/internal/luahelperlogic"
+	bolt "go.etcd.io/bbolt"
+)
+
+const usageDBFileName = "file_usage.db"
+
+var usageBucket = []byte("files")
+
+// FileRecord is everything HushCut knows about one cached WAV.
+type FileRecord struct {
+	FileName    string    `json:"fileName"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Size        int64     `json:"size"`
+	SourcePath  string    `json:"sourcePath,omitempty"`
+	Project     string    `json:"project,omitempty"` // project the file was first created for
+	Mixdown     bool      `json:"mixdown,omitempty"`
+	LastUsed    time.Time `json:"lastUsed"`
+}
+
+// usageStore persists FileRecords in a bbolt database inside the cache folder.
+// Each update is its own transaction, so a crash can never leave the store half-written.
+type usageStore struct {
+	db *bolt.DB
+}
+
+func openUsageStore(dir string) (*usageStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// A second instance would otherwise block forever on the file lock.
+	db, err := bolt.Open(filepath.Join(dir, usageDBFileName), 0644, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usageBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &usageStore{db: db}, nil
+}
+
+func (s *usageStore) Close() error {
+	return s.db.Close()
+}
+
+func getRecord(b *bolt.Bucket, fileName string) FileRecord {
+	rec := FileRecord{FileName: fileName}
+	if data := b.Get([]byte(fileName)); data != nil {
+		if err := json.Unmarshal(data, &rec); err != nil {
+			cacheLog.Warn("Usage store: ignoring corrupt record", "file", fileName, "err", err)
+			rec = FileRecord{FileName: fileName}
+		}
+	}
+	return rec
+}
+
+func putRecord(b *bolt.Bucket, rec FileRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(rec.FileName), data)
+}
+
+// update applies fn to the record of fileName (a fresh one if none exists) and stores it.
+// Concurrent calls are coalesced into a single transaction.
+func (s *usageStore) update(fileName string, fn func(*FileRecord)) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usageBucket)
+		rec := getRecord(b, fileName)
+		fn(&rec)
+		return putRecord(b, rec)
+	})
+}
+
+// remove deletes the records of the given files.
+func (s *usageStore) remove(fileNames ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usageBucket)
+		for _, name := range fileNames {
+			if err := b.Delete([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// all returns every record keyed by file name.
+func (s *usageStore) all() (map[string]FileRecord, error) {
+	records := make(map[string]FileRecord)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usageBucket).ForEach(func(k, v []byte) error {
+			var rec FileRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				cacheLog.Warn("Usage store: skipping corrupt record", "file", k, "err", err)
+				return nil
+			}
+			records[string(k)] = rec
+			return nil
+		})
+	})
+	return records, err
+}
+
+// syncLastUsed makes the store match the in-memory usage map: timestamps are written,
+// and records of files that are no longer tracked are removed.
+func (s *usageStore) syncLastUsed(lastUsed map[string]time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usageBucket)
+		var stale [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			if _, ok := lastUsed[string(k)]; !ok {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		for name, t := range lastUsed {
+			rec := getRecord(b, name)
+			rec.LastUsed = t
+			if err := putRecord(b, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateLegacyUsage imports an old file_usage.json once and renames it out of the way.
+func (s *usageStore) migrateLegacyUsage(jsonPath string) {
+	var rawUsage map[string]string
+	if err := readJSONWithRecovery(jsonPath, &rawUsage); err != nil {
+		if !os.IsNotExist(err) {
+			cacheLog.Warn("Usage store: could not read legacy usage file", "file", filepath.Base(jsonPath), "err", err)
+		}
+		return
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usageBucket)
+		for fileName, v := range rawUsage {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				continue
+			}
+			rec := getRecord(b, fileName)
+			if t.After(rec.LastUsed) {
+				rec.LastUsed = t
+			}
+			if err := putRecord(b, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cacheLog.Error("Usage store: migration failed", "file", filepath.Base(jsonPath), "err", err)
+		return
+	}
+	if err := os.Rename(jsonPath, jsonPath+".migrated"); err != nil {
+		cacheLog.Warn("Usage store: could not retire legacy usage file", "file", filepath.Base(jsonPath), "err", err)
+	}
+	os.Remove(jsonPath + backupFileSuffix)
+	cacheLog.Info("Usage store: migrated legacy usage file", "entries", len(rawUsage), "file", filepath.Base(jsonPath))
+}
+
+// fileFingerprint hashes the size and the first bytes of a file. Only the head is read;
+// combined with the size this is enough to notice a WAV that was regenerated with different
+// content. The lua-helper's --hash fingerprint computes the same value.
+func fileFingerprint(path string) (string, int64, error) {
+	return luahelperlogic.FileFingerprint(path)
+}
+
+// recordFileTouch stores the new last-used time plus the size and fingerprint of a cached file.
+func (a *App) recordFileTouch(fullPath string, when time.Time) {
+	if a.usageStore == nil {
+		return
+	}
+	fileName := filepath.Base(fullPath)
+	fingerprint, size, fpErr := fileFingerprint(fullPath)
+	err := a.usageStore.update(fileName, func(rec *FileRecord) {
+		rec.LastUsed = when
+		if fpErr == nil {
+			rec.Size = size
+			rec.Fingerprint = fingerprint
+		}
+	})
+	if err != nil {
+		cacheLog.Warn("Usage store: could not update record", "file", fileName, "err", err)
+	}
+}
+
+// fileRecords returns the persisted metadata of all cached files, or nil without a store.
+func (a *App) fileRecords() map[string]FileRecord {
+	if a.usageStore == nil {
+		return nil
+	}
+	records, err := a.usageStore.all()
+	if err != nil {
+		cacheLog.Warn("Usage store: could not read records", "err", err)
+	}
+	return records
+}
+
+// GetCachedFileRecords exposes the per-file metadata behind the cache statistics.
+func (a *App) GetCachedFileRecords() []FileRecord {
+	records := a.fileRecords()
+	list := make([]FileRecord, 0, len(records))
+	for _, rec := range records {
+		list = append(list, rec)
+	}
+	return list
+}
@@ -3,7 +3,15 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"os"
 	"os/exec"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // ExecCommand is a drop-in replacement for exec.Command with hidden windows on Windows.
@@ -11,3 +19,48 @@ func ExecCommand(name string, arg ...string) *exec.Cmd {
 	cmd := exec.Command(name, arg...)
 	return cmd
 }
+
+// enableVirtualTerminal makes f interpret ANSI escape sequences; Unix terminals always do.
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
+
+// processIdentity identifies a running process by its start time: the clock ticks since boot
+// from /proc on Linux, the start date from ps on macOS. It fails if pid is not running.
+func processIdentity(pid int) (string, error) {
+	if goruntime.GOOS == "linux" {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return "", err
+		}
+		// The command name in parentheses may contain spaces; starttime is the 20th field after it.
+		fields := strings.Fields(string(data[bytes.LastIndexByte(data, ')')+1:]))
+		if len(fields) < 20 {
+			return "", fmt.Errorf("unexpected /proc/%d/stat", pid)
+		}
+		if fields[0] == "Z" {
+			return "", fmt.Errorf("process %d has exited", pid)
+		}
+		return fields[19], nil
+	}
+	out, err := ExecCommand("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	identity := strings.TrimSpace(string(out))
+	if err != nil || identity == "" {
+		return "", fmt.Errorf("process %d is not running", pid)
+	}
+	return identity, nil
+}
+
+// terminateProcess asks pid to exit and kills it if it is still running after a few seconds.
+func terminateProcess(pid int) error {
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return err
+	}
+	for i := 0; i < 30; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if syscall.Kill(pid, 0) != nil {
+			return nil
+		}
+	}
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
@@ -3,8 +3,12 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"syscall"
+	"unsafe"
 )
 
 func detachConsole() {
@@ -24,3 +28,46 @@ func ExecCommand(name string, arg ...string) *exec.Cmd {
 
 	return cmd
 }
+
+// enableVirtualTerminal turns on ANSI escape sequence processing for the console behind f. It
+// returns false on consoles that don't support it (before Windows 10).
+func enableVirtualTerminal(f *os.File) bool {
+	const enableVirtualTerminalProcessing = 0x0004
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	var mode uint32
+	if ok, _, _ := kernel32.NewProc("GetConsoleMode").Call(f.Fd(), uintptr(unsafe.Pointer(&mode))); ok == 0 {
+		return false
+	}
+	ok, _, _ := kernel32.NewProc("SetConsoleMode").Call(f.Fd(), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
+
+// processIdentity identifies a running process by its creation time. It fails if pid is not
+// running.
+func processIdentity(pid int) (string, error) {
+	const processQueryLimitedInformation = 0x1000
+	const stillActive = 259
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(h)
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(h, &exitCode); err != nil {
+		return "", err
+	}
+	if exitCode != stillActive {
+		return "", fmt.Errorf("process %d has exited", pid)
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(creation.Nanoseconds(), 10), nil
+}
+
+// terminateProcess kills pid together with its children, like the shutdown of the Python
+// backend does.
+func terminateProcess(pid int) error {
+	return ExecCommand("taskkill", "/PID", strconv.Itoa(pid), "/T", "/F").Run()
+}
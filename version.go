@@ -3,7 +3,7 @@ package main
 import (
 	"embed"
 	"encoding/json"
-	"log"
+	"os"
 )
 
 //go:embed package.json
@@ -20,13 +20,15 @@ var FfmpegVersion string
 func init() {
 	file, err := content.ReadFile("package.json")
 	if err != nil {
-		log.Fatalf("Error reading embedded package.json: %v", err)
+		appLog.Error("Error reading embedded package.json", "err", err)
+		os.Exit(1)
 	}
 
 	var pkg PackageJSON
 	err = json.Unmarshal(file, &pkg)
 	if err != nil {
-		log.Fatalf("Error unmarshalling package.json: %v", err)
+		appLog.Error("Error unmarshalling package.json", "err", err)
+		os.Exit(1)
 	}
 
 	AppVersion = pkg.Version
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"net/url"
 	"os"
@@ -11,9 +11,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.org/x/sync/singleflight"
 )
 
@@ -48,11 +46,11 @@ func (a *App) GetWaveform(filePath string, samplesPerPixel int, peakType string,
 	if err := a.WaitForFile(filePath); err != nil {
 		return nil, fmt.Errorf("error waiting for file to be ready for silence detection: %w", err)
 	}
-	log.Printf("WaitForFile took: %s (file: %s)", time.Since(start), filePath)
+	waveformLog.Debug("WaitForFile finished", "file", filePath, "took", time.Since(start))
 
 	data, err := a.GetOrGenerateWaveformWithCache(filePath, samplesPerPixel, peakType, minDb, maxDb, clipStartSeconds, clipEndSeconds)
 	if err != nil {
-		runtime.LogError(a.ctx, fmt.Sprintf("Error getting or generating waveform data for %s: %v", filePath, err))
+		waveformLog.Error("Could not get or generate waveform data", "file", filePath, "err", err)
 		return nil, fmt.Errorf("failed to get/generate waveform for '%s': %v", filePath, err)
 	}
 	return data, nil
@@ -91,6 +89,14 @@ func (k WaveformCacheKey) String() string {
 
 var waveformGroup singleflight.Group
 
+// The waveform the clip view shows. Files are precomputed with it, so opening a clip is a
+// cache hit.
+const (
+	precomputedSamplesPerPixel = 128
+	precomputedPeakType        = "logarithmic"
+	precomputedMinDb           = -60.0
+)
+
 func (a *App) GetOrGenerateWaveformWithCache(
 	webInputPath string,
 	samplesPerPixel int,
@@ -100,12 +106,46 @@ func (a *App) GetOrGenerateWaveformWithCache(
 	clipStartSeconds float64,
 	clipEndSeconds float64,
 ) (*PrecomputedWaveformData, error) {
+	return a.getOrGenerateWaveform(true, webInputPath, samplesPerPixel, peakType, minDb, maxDb, clipStartSeconds, clipEndSeconds)
+}
+
+// precomputeWaveform generates the clip view's waveform of a WAV in the tmp folder in the
+// background: it yields to the waveforms of visible clips and of files a caller waits on.
+func (a *App) precomputeWaveform(fileName string) error {
+	_, err := a.getOrGenerateWaveform(false, fileName, precomputedSamplesPerPixel, precomputedPeakType, precomputedMinDb, 0, 0, -1)
+	return err
+}
+
+// hasPrecomputedWaveform reports whether the clip view's waveform of fileName is cached.
+func (a *App) hasPrecomputedWaveform(fileName string) bool {
+	key := WaveformCacheKey{FilePath: fileName, SamplesPerPixel: precomputedSamplesPerPixel, PeakType: precomputedPeakType, MinDb: precomputedMinDb}
+	a.cacheMutex.RLock()
+	defer a.cacheMutex.RUnlock()
+	_, found := a.waveformCache[key]
+	return found
+}
+
+// getOrGenerateWaveform returns the cached waveform or generates it. awaited says a caller is
+// waiting for it, which puts it ahead of background jobs.
+func (a *App) getOrGenerateWaveform(
+	awaited bool,
+	webInputPath string,
+	samplesPerPixel int,
+	peakType string,
+	minDb float64,
+	maxDb float64,
+	clipStartSeconds float64,
+	clipEndSeconds float64,
+) (*PrecomputedWaveformData, error) {
 
 	localFSPath, err := a.resolvePublicAudioPath(webInputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve web input path '%s' for pre-check: %w", webInputPath, err)
 	}
 	a.updateFileUsage(localFSPath)
+	if awaited {
+		defer slotQueue.want(localFSPath)()
+	}
 
 	if err := a.WaitForFile(localFSPath); err != nil {
 		return nil, fmt.Errorf("error waiting for file '%s' to be ready: %w", webInputPath, err)
@@ -131,14 +171,19 @@ func (a *App) GetOrGenerateWaveformWithCache(
 		cachedData, found := a.waveformCache[key]
 		a.cacheMutex.RUnlock()
 		if found {
-			//log.Println("CACHE HIT for key", key)
+			cacheUsage.touch(key)
+			waveformLog.Debug("Waveform cache hit", "key", key.String())
 			return cachedData, nil
 		}
 
-		//log.Println("CACHE MISS for key", key)
+		waveformLog.Debug("Waveform cache miss", "key", key.String())
+		release, err := a.acquireWaveformSlot(localFSPath)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
 
 		var waveformData *PrecomputedWaveformData
-		var err error
 		switch peakType {
 		case "linear":
 			waveformData, err = a.ProcessWavToLinearPeaks(webInputPath, samplesPerPixel)
@@ -152,7 +197,7 @@ func (a *App) GetOrGenerateWaveformWithCache(
 		}
 
 		a.cacheMutex.Lock()
-		a.waveformCache[key] = waveformData
+		a.storeWaveformLocked(key, waveformData)
 		a.cacheMutex.Unlock()
 		return waveformData, nil
 	})
@@ -222,107 +267,10 @@ func (a *App) ProcessWavToLogarithmicPeaks(
 	}
 
 	peaks := make([]float64, 0, expectedNumPeaks)
-
-	chunkSize := 8192
-	if chunkSize%inputChannels != 0 {
-		chunkSize = (chunkSize/inputChannels + 1) * inputChannels
-	}
-	pcmBuffer := &audio.IntBuffer{
-		Format: format,
-		Data:   make([]int, chunkSize),
-	}
-
-	var (
-		currentMaxAbs   int32
-		samplesInBlock  int
-		totalFrames     int
-		lastReportedPct float64 = -10.0
-	)
-
-	fileInfo, err := file.Stat() // Get stats ONCE here
-	if err != nil {
-		return nil, fmt.Errorf("could not get file info for '%s': %w", absPath, err)
-	}
-	totalBytes := fileInfo.Size()
-
-	for {
-		numSamples, readErr := decoder.PCMBuffer(pcmBuffer)
-		if numSamples == 0 {
-			if readErr != io.EOF && readErr != nil {
-				return nil, fmt.Errorf("error reading PCM chunk: %w", readErr)
-			}
-			break
-		}
-		defer file.Close()
-
-		// Optional progress
-		if totalBytes > 0 {
-			if pos, err := file.Seek(0, io.SeekCurrent); err == nil {
-				pct := (float64(pos) / float64(totalBytes)) * 100
-				if pct-lastReportedPct >= 5 {
-					runtime.EventsEmit(a.ctx, "waveform:progress", WaveformProgress{
-						FilePath:   webInputPath,
-						Percentage: pct,
-					})
-					lastReportedPct = pct
-				}
-			}
-		}
-
-		for i := 0; i < numSamples; i += inputChannels {
-			var maxFrameSample int32
-			for ch := range inputChannels {
-				val := int32(pcmBuffer.Data[i+ch])
-				if val < 0 {
-					val = -val
-				}
-				if val > maxFrameSample {
-					maxFrameSample = val
-				}
-			}
-
-			if maxFrameSample > currentMaxAbs {
-				currentMaxAbs = maxFrameSample
-			}
-			samplesInBlock++
-			totalFrames++
-
-			if samplesInBlock >= samplesPerPixel {
-				normalized := float64(currentMaxAbs) / 32767.0
-				dB := minDisplayDb
-				if normalized > 0 {
-					dB = 20 * math.Log10(normalized)
-				}
-				if dB < minDisplayDb {
-					dB = minDisplayDb
-				} else if dB > maxDisplayDb {
-					dB = maxDisplayDb
-				}
-				visual := (dB - minDisplayDb) / (maxDisplayDb - minDisplayDb)
-				if visual < 0 {
-					visual = 0
-				} else if visual > 1 {
-					visual = 1
-				}
-				peaks = append(peaks, visual)
-				currentMaxAbs = 0
-				samplesInBlock = 0
-			}
-		}
-
-		if readErr == io.EOF {
-			break
-		}
-		if readErr != nil {
-			return nil, fmt.Errorf("error reading PCM chunk: %w", readErr)
-		}
-	}
-
-	// leftover samples
-	if samplesInBlock > 0 {
-		normalized := float64(currentMaxAbs) / 32767.0
+	totalFrames, err := a.readWavBlockPeaks(decoder, webInputPath, inputChannels, samplesPerPixel, func(maxAbs int32) {
+		normalized := float64(maxAbs) / 32767.0
 		dB := minDisplayDb
-		if normalized > 0.000001 {
+		if normalized > 0 {
 			dB = 20 * math.Log10(normalized)
 		}
 		if dB < minDisplayDb {
@@ -331,17 +279,20 @@ func (a *App) ProcessWavToLogarithmicPeaks(
 			dB = maxDisplayDb
 		}
 		visual := (dB - minDisplayDb) / (maxDisplayDb - minDisplayDb)
-		if visual < 0.0 {
-			visual = 0.0
-		} else if visual > 1.0 {
-			visual = 1.0
+		if visual < 0 {
+			visual = 0
+		} else if visual > 1 {
+			visual = 1
 		}
 		peaks = append(peaks, visual)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %w", absPath, err)
 	}
 
 	finalDuration := float64(totalFrames) / float64(sampleRate)
 
-	runtime.EventsEmit(a.ctx, "waveform:done", WaveformProgress{FilePath: webInputPath})
+	a.emit("waveform:done", WaveformProgress{FilePath: webInputPath})
 
 	return &PrecomputedWaveformData{
 		Duration: finalDuration,
@@ -379,12 +330,6 @@ func (a *App) ProcessWavToLinearPeaks(
 	}
 	defer file.Close()
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("could not get file info for '%s': %w", absPath, err)
-	}
-	totalBytes := fileInfo.Size()
-
 	decoder := wav.NewDecoder(file)
 	if !decoder.IsValidFile() {
 		return nil, fmt.Errorf("'%s' is not a valid WAV file", absPath)
@@ -410,47 +355,71 @@ func (a *App) ProcessWavToLinearPeaks(
 	}
 
 	peaks := make([]float64, 0, expectedNumPeaks)
-
-	chunkSize := 8192
-	if chunkSize%inputChannels != 0 {
-		chunkSize = (chunkSize/inputChannels + 1) * inputChannels
+	totalFrames, err := a.readWavBlockPeaks(decoder, webInputPath, inputChannels, samplesPerPixel, func(maxAbs int32) {
+		peaks = append(peaks, float64(maxAbs)/32767.0)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %w", absPath, err)
 	}
-	pcmBuffer := &audio.IntBuffer{
-		Format: format,
-		Data:   make([]int, chunkSize),
+
+	finalDuration := float64(totalFrames) / float64(sampleRate)
+
+	a.emit("waveform:done", WaveformProgress{
+		FilePath: webInputPath,
+	})
+
+	return &PrecomputedWaveformData{
+		Duration: finalDuration,
+		Peaks:    peaks,
+	}, nil
+}
+
+// waveformReadBytes is how much PCM data is read per call; large reads keep the syscalls
+// and the progress bookkeeping out of the per-sample loop.
+const waveformReadBytes = 256 * 1024
+
+// readWavBlockPeaks reads the data chunk of a 16-bit PCM WAV with channels channels and calls
+// onBlock with the largest absolute sample of every samplesPerPixel frames, the last block
+// possibly shorter. The samples are decoded straight from the raw bytes into buffers that are
+// reused for the whole file, and progress is counted from the bytes read. It returns the
+// number of frames read.
+func (a *App) readWavBlockPeaks(decoder *wav.Decoder, webInputPath string, channels, samplesPerPixel int, onBlock func(maxAbs int32)) (int, error) {
+	if err := decoder.FwdToPCM(); err != nil {
+		return 0, fmt.Errorf("could not find PCM data: %w", err)
+	}
+	if decoder.PCMChunk == nil {
+		if err := decoder.Err(); err != nil {
+			return 0, fmt.Errorf("could not find PCM data: %w", err)
+		}
+		return 0, wav.ErrPCMChunkNotFound
 	}
+	pcmBytes := int64(decoder.PCMSize)
+	frameBytes := 2 * channels
 
+	raw := make([]byte, waveformReadBytes/frameBytes*frameBytes)
+	samples := make([]int16, len(raw)/2)
 	var (
 		currentMaxAbs   int32
 		samplesInBlock  int
-		lastReportedPct float64 = -10.0
 		totalFrames     int
+		carry           int // bytes of an incomplete frame kept at the start of raw
+		readBytes       int64
+		lastReportedPct float64 = -10.0
 	)
-
 	for {
-		numSamples, readErr := decoder.PCMBuffer(pcmBuffer)
-		if numSamples == 0 {
-			break
-		}
-
-		// Optional progress reporting
-		if totalBytes > 0 {
-			if pos, err := file.Seek(0, io.SeekCurrent); err == nil {
-				pct := (float64(pos) / float64(totalBytes)) * 100
-				if pct-lastReportedPct >= 5 {
-					runtime.EventsEmit(a.ctx, "waveform:progress", WaveformProgress{
-						FilePath:   webInputPath,
-						Percentage: pct,
-					})
-					lastReportedPct = pct
-				}
-			}
+		n, readErr := io.ReadFull(decoder.PCMChunk, raw[carry:])
+		readBytes += int64(n)
+		n += carry
+		usable := n - n%frameBytes
+		count := usable / 2
+		for i := 0; i < count; i++ {
+			samples[i] = int16(binary.LittleEndian.Uint16(raw[2*i:]))
 		}
 
-		for i := 0; i < numSamples; i += inputChannels {
+		for i := 0; i < count; i += channels {
 			var maxFrameSample int32
-			for ch := 0; ch < inputChannels; ch++ {
-				val := int32(pcmBuffer.Data[i+ch])
+			for _, sample := range samples[i : i+channels] {
+				val := int32(sample)
 				if val < 0 {
 					val = -val
 				}
@@ -458,41 +427,40 @@ func (a *App) ProcessWavToLinearPeaks(
 					maxFrameSample = val
 				}
 			}
-
 			if maxFrameSample > currentMaxAbs {
 				currentMaxAbs = maxFrameSample
 			}
 			samplesInBlock++
 			totalFrames++
-
 			if samplesInBlock >= samplesPerPixel {
-				peaks = append(peaks, float64(currentMaxAbs)/32767.0)
+				onBlock(currentMaxAbs)
 				currentMaxAbs = 0
 				samplesInBlock = 0
 			}
 		}
+		carry = copy(raw, raw[usable:n])
+
+		if pcmBytes > 0 {
+			pct := math.Min(float64(readBytes)/float64(pcmBytes)*100, 100)
+			if pct-lastReportedPct >= 5 {
+				a.emit("waveform:progress", WaveformProgress{
+					FilePath:   webInputPath,
+					Percentage: pct,
+				})
+				lastReportedPct = pct
+			}
+		}
 
-		if readErr == io.EOF {
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
 			break
 		}
 		if readErr != nil {
-			return nil, fmt.Errorf("error reading PCM: %w", readErr)
+			return 0, fmt.Errorf("error reading PCM data: %w", readErr)
 		}
 	}
 
-	// Handle leftover samples
 	if samplesInBlock > 0 {
-		peaks = append(peaks, float64(currentMaxAbs)/32767.0)
+		onBlock(currentMaxAbs)
 	}
-
-	finalDuration := float64(totalFrames) / float64(sampleRate)
-
-	runtime.EventsEmit(a.ctx, "waveform:done", WaveformProgress{
-		FilePath: webInputPath,
-	})
-
-	return &PrecomputedWaveformData{
-		Duration: finalDuration,
-		Peaks:    peaks,
-	}, nil
+	return totalFrames, nil
 }
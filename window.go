@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const windowStateSettingsKey = "window"
+
+// WindowState is the persisted window geometry and stacking preference.
+type WindowState struct {
+	Width       int  `json:"width"`
+	Height      int  `json:"height"`
+	X           int  `json:"x"`
+	Y           int  `json:"y"`
+	AlwaysOnTop bool `json:"alwaysOnTop"`
+	Maximised   bool `json:"maximised"`
+}
+
+func defaultWindowState() WindowState {
+	return WindowState{Width: 1024, Height: 801, X: -1, Y: -1, AlwaysOnTop: true}
+}
+
+// updateSetting writes a single app-managed key into settings.json without running
+// the user-facing validation, so e.g. a missing DaVinci folder can't block it.
+func (a *App) updateSetting(key string, value any) error {
+	userSettings := a.readUserSettingsFile()
+	userSettings[key] = value
+
+	jsonData, err := json.MarshalIndent(userSettings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := writeFileAtomic(a.getSettingsPath(), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+	if info, statErr := os.Stat(a.getSettingsPath()); statErr == nil {
+		a.settingsMu.Lock()
+		a.settingsModTime = info.ModTime()
+		a.settingsMu.Unlock()
+	}
+	return nil
+}
+
+func (a *App) loadWindowState() WindowState {
+	state := defaultWindowState()
+	settings, err := a.GetSettings()
+	if err != nil {
+		return state
+	}
+	raw, ok := settings[windowStateSettingsKey]
+	if !ok {
+		return state
+	}
+	// Round-trip through JSON to turn the generic map into the struct.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		appLog.Warn("Ignoring invalid window state in settings", "err", err)
+		return defaultWindowState()
+	}
+	return state
+}
+
+// GetWindowState returns the current window geometry and always-on-top preference.
+func (a *App) GetWindowState() WindowState {
+	state := a.loadWindowState()
+	if a.ctx == nil {
+		return state
+	}
+	windowGeometry(a.ctx, &state)
+	return state
+}
+
+// SetWindowState applies and persists window geometry and the always-on-top preference.
+func (a *App) SetWindowState(state WindowState) error {
+	a.applyWindowState(state)
+	return a.updateSetting(windowStateSettingsKey, state)
+}
+
+func (a *App) SetWindowAlwaysOnTop(alwaysOnTop bool) {
+	setWindowAlwaysOnTop(a.ctx, alwaysOnTop)
+
+	state := a.loadWindowState()
+	state.AlwaysOnTop = alwaysOnTop
+	if err := a.updateSetting(windowStateSettingsKey, state); err != nil {
+		appLog.Warn("Could not persist always-on-top preference", "err", err)
+	}
+}
+
+func (a *App) applyWindowState(state WindowState) {
+	if a.headless {
+		return
+	}
+	applyWindowGeometry(a.ctx, state)
+}
+
+// focusWindow brings the main window to the front, e.g. when HushCut is launched again from
+// Resolve while it is already running.
+func (a *App) focusWindow() {
+	if a.headless {
+		return
+	}
+	showWindow(a.ctx)
+}
+
+// restoreWindowState is called on startup to bring back the last session's window.
+func (a *App) restoreWindowState() {
+	a.applyWindowState(a.loadWindowState())
+}
+
+// beforeClose saves the window geometry while the window still exists. With runInBackground
+// it hides the window instead, unless the app is quitting.
+func (a *App) beforeClose(ctx context.Context) (prevent bool) {
+	if !a.quitting.Load() && a.runsInBackground() {
+		a.hideToBackground()
+		return true
+	}
+	state := a.GetWindowState()
+	if err := a.updateSetting(windowStateSettingsKey, state); err != nil {
+		appLog.Warn("Could not save window state", "err", err)
+	}
+	return false
+}